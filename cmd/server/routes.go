@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lsat-prep/backend/internal/auth"
+	"github.com/lsat-prep/backend/internal/gamification"
+	"github.com/lsat-prep/backend/internal/middleware"
+	"github.com/lsat-prep/backend/internal/models"
+	"github.com/lsat-prep/backend/internal/questions"
+)
+
+// newRouter registers every API route.
+func newRouter(authHandler *auth.Handler, questionHandler *questions.Handler, gamHandler *gamification.Handler) *mux.Router {
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api/v1").Subrouter()
+
+	// Public routes
+	api.HandleFunc("/manifest", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(routeManifest(r))
+	}).Methods("GET")
+	api.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
+	api.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+
+	// Protected routes
+	protected := api.PathPrefix("").Subrouter()
+	protected.Use(middleware.AuthMiddleware)
+	protected.HandleFunc("/auth/me", authHandler.GetCurrentUser).Methods("GET")
+
+	// Home screen
+	protected.HandleFunc("/dashboard", questionHandler.GetDashboard).Methods("GET")
+
+	// User adaptive endpoints
+	protected.HandleFunc("/users/ability", questionHandler.GetAbility).Methods("GET")
+	protected.HandleFunc("/users/ability/history", questionHandler.GetAbilityHistory).Methods("GET")
+	protected.HandleFunc("/users/difficulty-slider", questionHandler.SetDifficultySlider).Methods("PUT")
+	protected.HandleFunc("/users/study-time", questionHandler.GetStudyTime).Methods("GET")
+	protected.HandleFunc("/users/diagnostic/start", questionHandler.StartDiagnostic).Methods("POST")
+	protected.HandleFunc("/users/diagnostic/submit", questionHandler.SubmitDiagnostic).Methods("POST")
+
+	// Question endpoints (fixed paths before parameterized)
+	protected.HandleFunc("/questions/generate", questionHandler.GenerateBatch).Methods("POST")
+	protected.HandleFunc("/questions/batches", questionHandler.ListBatches).Methods("GET")
+	protected.HandleFunc("/questions/batches/{id}", questionHandler.GetBatch).Methods("GET")
+	protected.HandleFunc("/questions/batches/{id}/questions", questionHandler.GetBatchQuestions).Methods("GET")
+	protected.HandleFunc("/questions/next", questionHandler.NextQuestion).Methods("GET")
+	protected.HandleFunc("/questions/quick-drill", questionHandler.QuickDrill).Methods("POST")
+	protected.HandleFunc("/questions/subtype-drill", questionHandler.SubtypeDrill).Methods("POST")
+	protected.HandleFunc("/questions/rc-drill", questionHandler.RCDrill).Methods("POST")
+	protected.HandleFunc("/questions/batch-get", questionHandler.BatchGetQuestions).Methods("POST")
+	protected.HandleFunc("/questions/{id}", questionHandler.GetQuestion).Methods("GET")
+	protected.HandleFunc("/questions/{id}/answer", questionHandler.SubmitAnswer).Methods("POST")
+	protected.HandleFunc("/questions/{id}/answer-and-next", questionHandler.AnswerAndNext).Methods("POST")
+	protected.HandleFunc("/questions/{id}/flag", questionHandler.FlagQuestion).Methods("POST")
+	protected.HandleFunc("/questions/{id}/traps", questionHandler.GetQuestionTraps).Methods("GET")
+	protected.HandleFunc("/questions/{id}/hint", questionHandler.GetQuestionHint).Methods("GET")
+
+	// Passage endpoints
+	protected.HandleFunc("/passages", questionHandler.ListPassages).Methods("GET")
+	protected.HandleFunc("/passages/{id}", questionHandler.GetPassage).Methods("GET")
+	protected.HandleFunc("/passages/{id}/text", questionHandler.GetPassageText).Methods("GET")
+
+	// Gamification endpoints
+	protected.HandleFunc("/users/gamification", gamHandler.GetGamification).Methods("GET")
+	protected.HandleFunc("/users/status", gamHandler.GetStatus).Methods("GET")
+	protected.HandleFunc("/users/gamification/streak-freeze", gamHandler.BuyStreakFreeze).Methods("POST")
+	protected.HandleFunc("/users/gamification/auto-freeze", gamHandler.SetAutoFreeze).Methods("PUT")
+	protected.HandleFunc("/users/gems/history", gamHandler.GetGemHistory).Methods("GET")
+	protected.HandleFunc("/users/daily-goal", gamHandler.SetDailyGoal).Methods("PUT")
+	protected.HandleFunc("/users/daily-goal/skip", gamHandler.UseGoalSkip).Methods("POST")
+	protected.HandleFunc("/users/privacy", gamHandler.SetPrivacy).Methods("PUT")
+	protected.HandleFunc("/users/notifications", gamHandler.GetNotificationPrefs).Methods("GET")
+	protected.HandleFunc("/users/notifications", gamHandler.UpdateNotificationPrefs).Methods("PUT")
+	protected.HandleFunc("/drills/complete", gamHandler.CompleteDrill).Methods("POST")
+	protected.HandleFunc("/drills/history", gamHandler.GetDrillHistory).Methods("GET")
+	protected.HandleFunc("/drills/retry-mistakes", questionHandler.RetryMistakes).Methods("POST")
+	protected.HandleFunc("/drills/review", questionHandler.GetDrillReview).Methods("POST")
+	protected.HandleFunc("/quests", gamHandler.GetQuests).Methods("GET")
+
+	// Daily challenge
+	protected.HandleFunc("/daily-challenge", questionHandler.GetDailyChallenge).Methods("GET")
+	protected.HandleFunc("/daily-challenge/answer", questionHandler.SubmitDailyChallenge).Methods("POST")
+	protected.HandleFunc("/daily-challenge/leaderboard", questionHandler.GetDailyChallengeLeaderboard).Methods("GET")
+
+	// Shop
+	protected.HandleFunc("/shop", gamHandler.GetShop).Methods("GET")
+	protected.HandleFunc("/shop/purchase", gamHandler.PurchaseItem).Methods("POST")
+	protected.HandleFunc("/users/boosts/activate", gamHandler.ActivateBoost).Methods("POST")
+
+	// Leaderboard
+	protected.HandleFunc("/leaderboard/global", gamHandler.GlobalLeaderboard).Methods("GET")
+	protected.HandleFunc("/leaderboard/friends", gamHandler.FriendsLeaderboard).Methods("GET")
+	protected.HandleFunc("/leaderboard/league", gamHandler.LeagueLeaderboard).Methods("GET")
+
+	// Friends (fixed paths before parameterized)
+	protected.HandleFunc("/friends/request", gamHandler.SendFriendRequest).Methods("POST")
+	protected.HandleFunc("/friends/respond", gamHandler.RespondFriendRequest).Methods("POST")
+	protected.HandleFunc("/friends/search", gamHandler.SearchUsers).Methods("GET")
+	protected.HandleFunc("/friends", gamHandler.ListFriends).Methods("GET")
+	protected.HandleFunc("/friends/{id}", gamHandler.RemoveFriend).Methods("DELETE")
+
+	// Study groups
+	protected.HandleFunc("/groups", gamHandler.ListGroups).Methods("GET")
+	protected.HandleFunc("/groups", gamHandler.CreateGroup).Methods("POST")
+	protected.HandleFunc("/groups/{id}/join", gamHandler.JoinGroup).Methods("POST")
+	protected.HandleFunc("/groups/{id}/leave", gamHandler.LeaveGroup).Methods("POST")
+	protected.HandleFunc("/groups/{id}/leaderboard", gamHandler.GroupLeaderboard).Methods("GET")
+
+	// Nudges
+	protected.HandleFunc("/nudges", gamHandler.ListNudges).Methods("GET")
+	protected.HandleFunc("/nudges", gamHandler.SendNudge).Methods("POST")
+	protected.HandleFunc("/nudges/{id}/read", gamHandler.MarkNudgeRead).Methods("POST")
+
+	// Admin endpoints
+	protected.HandleFunc("/admin/quality-stats", questionHandler.GetQualityStats).Methods("GET")
+	protected.HandleFunc("/admin/generation-stats", questionHandler.GetGenerationStats).Methods("GET")
+	protected.HandleFunc("/admin/generation-queue", questionHandler.ListGenerationQueue).Methods("GET")
+	protected.HandleFunc("/admin/generation-queue/{id}/reset", questionHandler.ResetGenerationQueueItem).Methods("POST")
+	protected.HandleFunc("/admin/structural-stats", questionHandler.GetStructuralStats).Methods("GET")
+	protected.HandleFunc("/admin/inventory/{subtype}/histogram", questionHandler.GetDifficultyHistogram).Methods("GET")
+	protected.HandleFunc("/admin/revalidate", questionHandler.StartRevalidation).Methods("POST")
+	protected.HandleFunc("/admin/revalidate/{id}", questionHandler.GetRevalidationJob).Methods("GET")
+	protected.HandleFunc("/admin/recalibrate", questionHandler.Recalibrate).Methods("POST")
+	protected.HandleFunc("/admin/flagged", questionHandler.GetFlaggedQuestions).Methods("GET")
+	protected.HandleFunc("/admin/export", questionHandler.ExportQuestions).Methods("GET")
+	protected.HandleFunc("/admin/import", questionHandler.ImportQuestions).Methods("POST")
+	protected.HandleFunc("/admin/questions/search", questionHandler.SearchQuestions).Methods("GET")
+	protected.HandleFunc("/admin/questions/{id}/full", questionHandler.GetQuestionProvenance).Methods("GET")
+	protected.HandleFunc("/admin/questions/{id}/featured", questionHandler.SetFeatured).Methods("PUT")
+	protected.HandleFunc("/admin/questions/{id}/difficulty", questionHandler.SetQuestionDifficulty).Methods("PATCH")
+	protected.HandleFunc("/admin/questions/{id}/regenerate-explanations", questionHandler.RegenerateExplanations).Methods("POST")
+	protected.HandleFunc("/admin/batches/cleanup", questionHandler.PurgeFailedBatches).Methods("DELETE")
+	protected.HandleFunc("/admin/batches/{id}", questionHandler.UpdateBatchAnnotation).Methods("PATCH")
+	protected.HandleFunc("/admin/batches/{id}/answer-positions", questionHandler.GetBatchAnswerPositions).Methods("GET")
+	protected.HandleFunc("/admin/generate/preview", questionHandler.PreviewBatch).Methods("POST")
+
+	// History & bookmarks
+	questionHandler.RegisterHistoryRoutes(protected)
+
+	// Health check
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"ok"}`))
+	}).Methods("GET")
+
+	return r
+}
+
+// routeManifest lists the routes registered on r, in registration order.
+// Path prefixes and subrouters, which have no methods, are skipped.
+func routeManifest(r *mux.Router) models.RouteManifest {
+	manifest := models.RouteManifest{Routes: []models.RouteInfo{}}
+	r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		manifest.Routes = append(manifest.Routes, models.RouteInfo{Methods: methods, Path: tpl})
+		return nil
+	})
+	return manifest
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lsat-prep/backend/internal/auth"
+	"github.com/lsat-prep/backend/internal/models"
+	"github.com/lsat-prep/backend/internal/questions"
+)
+
+func TestRouteManifest_ListsRegisteredRoutes(t *testing.T) {
+	r := newRouter(nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/manifest", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("manifest status = %d, want 200 without auth", rec.Code)
+	}
+	var manifest models.RouteManifest
+	if err := json.NewDecoder(rec.Body).Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"/api/v1/questions/quick-drill": "POST",
+		"/api/v1/questions/{id}":        "GET",
+		"/api/v1/drills/review":         "POST",
+		"/api/v1/manifest":              "GET",
+		"/health":                       "GET",
+	}
+	for _, route := range manifest.Routes {
+		if len(route.Methods) == 0 {
+			t.Errorf("route %s has no methods", route.Path)
+		}
+		if m, ok := want[route.Path]; ok && len(route.Methods) == 1 && route.Methods[0] == m {
+			delete(want, route.Path)
+		}
+	}
+	for path, method := range want {
+		t.Errorf("manifest is missing %s %s", method, path)
+	}
+}
+
+// reviewDB is a database/sql driver answering the queries behind a drill
+// review with one passage question the user answered C on, where B is
+// correct. It records the args of the history query.
+type reviewDB struct {
+	historyArgs []driver.Value
+}
+
+type reviewConn struct{ db *reviewDB }
+
+type reviewStmt struct {
+	db    *reviewDB
+	query string
+}
+
+type reviewRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (d *reviewDB) Open(string) (driver.Conn, error) { return reviewConn{d}, nil }
+
+func (c reviewConn) Prepare(query string) (driver.Stmt, error) {
+	return &reviewStmt{db: c.db, query: query}, nil
+}
+func (c reviewConn) Close() error              { return nil }
+func (c reviewConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("no transactions") }
+
+func (s *reviewStmt) Close() error  { return nil }
+func (s *reviewStmt) NumInput() int { return -1 }
+func (s *reviewStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("unexpected exec: %s", s.query)
+}
+
+func (s *reviewStmt) Query(args []driver.Value) (driver.Rows, error) {
+	switch {
+	case strings.Contains(s.query, "user_question_history"):
+		s.db.historyArgs = args
+		return &reviewRows{
+			cols: []string{"id", "section", "lr_subtype", "rc_subtype", "difficulty", "difficulty_score",
+				"stimulus", "question_stem", "correct_answer_id", "explanation", "passage_id",
+				"correct", "selected_choice_id", "time_spent_seconds", "attempt_count", "answered_at"},
+			rows: [][]driver.Value{{int64(4), "reading_comprehension", nil, "inference", "medium", int64(50),
+				"", "Which can be inferred?", "B", "The argument assumes the sample is representative.", int64(9),
+				false, "C", 42.0, int64(1), time.Now()}},
+		}, nil
+	case strings.Contains(s.query, "FROM answer_choices"):
+		return &reviewRows{
+			cols: []string{"id", "question_id", "choice_id", "choice_text", "explanation", "is_correct", "wrong_answer_type"},
+			rows: [][]driver.Value{
+				{int64(1), int64(4), "B", "It follows.", "Names the gap.", true, nil},
+				{int64(2), int64(4), "C", "It doesn't.", "Out of scope.", false, "out_of_scope"},
+			},
+		}, nil
+	case strings.Contains(s.query, "FROM rc_passages"):
+		return &reviewRows{
+			cols: []string{"id", "title", "subject_area", "content", "is_comparative", "passage_b", "word_count"},
+			rows: [][]driver.Value{{int64(9), "Tides", "science", "Passage text.", false, nil, int64(450)}},
+		}, nil
+	case strings.Contains(s.query, "SELECT id, passage_id FROM questions"):
+		return &reviewRows{cols: []string{"id", "passage_id"}, rows: [][]driver.Value{{int64(4), int64(9)}}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+func (r *reviewRows) Columns() []string { return r.cols }
+func (r *reviewRows) Close() error      { return nil }
+func (r *reviewRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestDrillReview_ReturnsSelectionAndAnswer(t *testing.T) {
+	fake := &reviewDB{}
+	sql.Register("drill-review-fake", fake)
+	db, err := sql.Open("drill-review-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	r := newRouter(nil, questions.NewHandler(questions.NewService(questions.NewStore(db), nil, nil)), nil)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 7}).SignedString(auth.JWTSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drills/review", strings.NewReader(`{"question_ids":[4]}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("review status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	if len(fake.historyArgs) == 0 || fake.historyArgs[0] != int64(7) {
+		t.Errorf("history queried with %v, want the token's user 7 first", fake.historyArgs)
+	}
+	var payload struct {
+		Questions []map[string]interface{} `json:"questions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Questions) != 1 {
+		t.Fatalf("got %d reviewed questions, want 1", len(payload.Questions))
+	}
+	got := payload.Questions[0]
+	if got["selected_choice_id"] != "C" || got["correct_answer_id"] != "B" || got["correct"] != false {
+		t.Errorf("review = %v, want the user's C against correct answer B", got)
+	}
+	if choices, _ := got["choices"].([]interface{}); len(choices) != 2 {
+		t.Errorf("review choices = %v, want both choices with explanations", got["choices"])
+	}
+	if passage, _ := got["passage"].(map[string]interface{}); passage == nil || passage["id"] != 9.0 {
+		t.Errorf("review passage = %v, want passage 9", got["passage"])
+	}
+	if got["explanation"] == "" {
+		t.Error("review is missing the explanation")
+	}
+}
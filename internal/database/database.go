@@ -7,6 +7,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -41,12 +42,69 @@ func Connect() (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	applyPoolConfig(db, LoadPoolConfig())
 
 	return db, nil
 }
 
+// PoolConfig sizes the connection pool. Connections are recycled after
+// ConnMaxLifetime so managed Postgres restarts and failovers don't leave
+// stale connections in the pool.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// LoadPoolConfig reads DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS,
+// DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME (durations like "30m").
+// Invalid values fall back to the defaults with a warning.
+func LoadPoolConfig() PoolConfig {
+	cfg := PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+	if v := getEnv("DB_MAX_OPEN_CONNS", ""); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxOpenConns = n
+		} else {
+			log.Printf("Ignoring DB_MAX_OPEN_CONNS=%q", v)
+		}
+	}
+	if v := getEnv("DB_MAX_IDLE_CONNS", ""); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxIdleConns = n
+		} else {
+			log.Printf("Ignoring DB_MAX_IDLE_CONNS=%q", v)
+		}
+	}
+	if v := getEnv("DB_CONN_MAX_LIFETIME", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.ConnMaxLifetime = d
+		} else {
+			log.Printf("Ignoring DB_CONN_MAX_LIFETIME=%q", v)
+		}
+	}
+	if v := getEnv("DB_CONN_MAX_IDLE_TIME", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.ConnMaxIdleTime = d
+		} else {
+			log.Printf("Ignoring DB_CONN_MAX_IDLE_TIME=%q", v)
+		}
+	}
+	return cfg
+}
+
+func applyPoolConfig(db *sql.DB, cfg PoolConfig) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
 func RunMigrations(db *sql.DB) error {
 	sourceDriver, err := iofs.New(migrationsFS, "migrations")
 	if err != nil {
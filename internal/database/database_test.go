@@ -0,0 +1,35 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestLoadPoolConfig(t *testing.T) {
+	cfg := LoadPoolConfig()
+	if cfg.MaxOpenConns != 25 || cfg.MaxIdleConns != 5 || cfg.ConnMaxLifetime <= 0 {
+		t.Errorf("defaults = %+v, want 25 open / 5 idle and a lifetime", cfg)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "40")
+	t.Setenv("DB_MAX_IDLE_CONNS", "10")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "15m")
+	t.Setenv("DB_CONN_MAX_IDLE_TIME", "bogus")
+	cfg = LoadPoolConfig()
+	want := PoolConfig{MaxOpenConns: 40, MaxIdleConns: 10, ConnMaxLifetime: 15 * time.Minute, ConnMaxIdleTime: 5 * time.Minute}
+	if cfg != want {
+		t.Errorf("config = %+v, want %+v", cfg, want)
+	}
+
+	// sql.Open doesn't connect, so the pool settings can be checked offline
+	db, err := sql.Open("postgres", "host=localhost dbname=unused sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	applyPoolConfig(db, cfg)
+	if got := db.Stats().MaxOpenConnections; got != 40 {
+		t.Errorf("MaxOpenConnections = %d, want 40", got)
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE rc_passages DROP COLUMN IF EXISTS reading_grade;
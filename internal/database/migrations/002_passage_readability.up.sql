@@ -0,0 +1,3 @@
+-- Flesch-Kincaid grade level for RC passages. Computed in Go at save time;
+-- existing rows are backfilled by the server on startup.
+ALTER TABLE rc_passages ADD COLUMN IF NOT EXISTS reading_grade DECIMAL(4,1);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_batches_experiment_tag;
+ALTER TABLE question_batches DROP COLUMN IF EXISTS experiment_tag;
+ALTER TABLE question_batches DROP COLUMN IF EXISTS notes;
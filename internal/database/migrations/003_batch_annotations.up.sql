@@ -0,0 +1,5 @@
+-- Free-form admin annotations for tracking prompt/model experiments
+ALTER TABLE question_batches ADD COLUMN IF NOT EXISTS notes TEXT;
+ALTER TABLE question_batches ADD COLUMN IF NOT EXISTS experiment_tag VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_batches_experiment_tag ON question_batches(experiment_tag) WHERE experiment_tag IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_questions_prompt_version;
+ALTER TABLE questions DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE question_batches DROP COLUMN IF EXISTS prompt_version;
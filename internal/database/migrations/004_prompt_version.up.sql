@@ -0,0 +1,5 @@
+-- Track which prompt revision produced each batch and question
+ALTER TABLE question_batches ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50);
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_questions_prompt_version ON questions(prompt_version);
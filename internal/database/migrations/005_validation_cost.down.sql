@@ -0,0 +1,2 @@
+ALTER TABLE question_batches DROP COLUMN IF EXISTS validation_cost_cents;
+ALTER TABLE question_batches DROP COLUMN IF EXISTS validation_model;
@@ -0,0 +1,4 @@
+-- Validation may run on a cheaper model than generation; track it and its cost separately.
+-- total_cost_cents remains the sum of generation and validation cost.
+ALTER TABLE question_batches ADD COLUMN IF NOT EXISTS validation_model VARCHAR(100);
+ALTER TABLE question_batches ADD COLUMN IF NOT EXISTS validation_cost_cents INT NOT NULL DEFAULT 0;
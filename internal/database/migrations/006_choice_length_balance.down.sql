@@ -0,0 +1,2 @@
+ALTER TABLE questions DROP COLUMN IF EXISTS correct_length_outlier;
+ALTER TABLE questions DROP COLUMN IF EXISTS choice_length_balance;
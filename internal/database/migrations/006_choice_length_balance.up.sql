@@ -0,0 +1,4 @@
+-- Structural sub-score: how uniform answer choice lengths are, and whether
+-- the correct answer is a length outlier
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS choice_length_balance DECIMAL(3,2);
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS correct_length_outlier BOOLEAN NOT NULL DEFAULT false;
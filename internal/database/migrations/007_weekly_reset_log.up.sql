@@ -0,0 +1,9 @@
+-- One row per ISO week so the weekly reset runs at most once and leaves an audit trail
+CREATE TABLE IF NOT EXISTS weekly_reset_log (
+    id              BIGSERIAL PRIMARY KEY,
+    iso_week        VARCHAR(10) NOT NULL UNIQUE,
+    top_user_ids    JSONB,
+    league_changes  INT NOT NULL DEFAULT 0,
+    started_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at    TIMESTAMP WITH TIME ZONE
+);
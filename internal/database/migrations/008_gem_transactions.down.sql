@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_gem_transactions_user;
+DROP TABLE IF EXISTS gem_transactions;
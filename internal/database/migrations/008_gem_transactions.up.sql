@@ -0,0 +1,17 @@
+-- Signed ledger of every gem balance change
+CREATE TABLE IF NOT EXISTS gem_transactions (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount        INT NOT NULL,
+    reason        VARCHAR(50) NOT NULL,
+    balance_after INT NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gem_transactions_user ON gem_transactions(user_id, created_at DESC);
+
+-- Seed existing balances so each user's ledger sums to their current gems
+INSERT INTO gem_transactions (user_id, amount, reason, balance_after)
+SELECT user_id, gems, 'opening_balance', gems
+FROM user_gamification
+WHERE gems <> 0;
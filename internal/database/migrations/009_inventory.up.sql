@@ -0,0 +1,10 @@
+-- Items bought from the gem shop
+CREATE TABLE IF NOT EXISTS inventory (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id     VARCHAR(50) NOT NULL,
+    quantity    INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, item_id)
+);
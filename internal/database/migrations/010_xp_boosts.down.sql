@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_xp_boosts_user_expiry;
+DROP TABLE IF EXISTS xp_boosts;
@@ -0,0 +1,11 @@
+-- Time-limited XP multipliers
+CREATE TABLE IF NOT EXISTS xp_boosts (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    multiplier  DECIMAL(4,2) NOT NULL CHECK (multiplier >= 1),
+    source      VARCHAR(50) NOT NULL,
+    started_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_xp_boosts_user_expiry ON xp_boosts(user_id, expires_at);
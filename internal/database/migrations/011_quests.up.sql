@@ -0,0 +1,14 @@
+-- Daily and weekly quests assigned to each user
+CREATE TABLE IF NOT EXISTS user_quests (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_key     VARCHAR(50) NOT NULL,
+    period_key    VARCHAR(20) NOT NULL,
+    progress      INT NOT NULL DEFAULT 0,
+    target        INT NOT NULL CHECK (target > 0),
+    completed_at  TIMESTAMP WITH TIME ZONE,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, quest_key, period_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_quests_user_period ON user_quests(user_id, period_key);
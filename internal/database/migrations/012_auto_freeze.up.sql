@@ -0,0 +1,2 @@
+-- Opt-in automatic streak freeze purchase when a streak is at risk
+ALTER TABLE user_gamification ADD COLUMN IF NOT EXISTS auto_freeze BOOLEAN NOT NULL DEFAULT FALSE;
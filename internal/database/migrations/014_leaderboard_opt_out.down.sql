@@ -0,0 +1 @@
+ALTER TABLE user_gamification DROP COLUMN IF EXISTS leaderboard_opt_out;
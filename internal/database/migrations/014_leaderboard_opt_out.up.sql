@@ -0,0 +1,2 @@
+-- Users who opt out are hidden from the global leaderboard
+ALTER TABLE user_gamification ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
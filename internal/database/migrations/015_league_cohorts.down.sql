@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS league_cohort_members;
+DROP TABLE IF EXISTS league_cohorts;
@@ -0,0 +1,18 @@
+-- Weekly league cohorts: users compete within a cohort of their tier
+CREATE TABLE IF NOT EXISTS league_cohorts (
+    id          BIGSERIAL PRIMARY KEY,
+    iso_week    VARCHAR(10) NOT NULL,
+    tier        VARCHAR(20) NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_league_cohorts_week_tier ON league_cohorts(iso_week, tier);
+
+CREATE TABLE IF NOT EXISTS league_cohort_members (
+    cohort_id   BIGINT NOT NULL REFERENCES league_cohorts(id) ON DELETE CASCADE,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    iso_week    VARCHAR(10) NOT NULL,
+    joined_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (cohort_id, user_id),
+    UNIQUE (user_id, iso_week)
+);
@@ -0,0 +1,2 @@
+-- When the user was last promoted, for the post-promotion demotion grace week
+ALTER TABLE user_gamification ADD COLUMN IF NOT EXISTS promoted_at TIMESTAMP WITH TIME ZONE;
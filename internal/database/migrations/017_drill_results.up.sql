@@ -0,0 +1,12 @@
+-- One row per completed drill, for the drill history
+CREATE TABLE IF NOT EXISTS drill_results (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    correct       INT NOT NULL,
+    total         INT NOT NULL,
+    perfect       BOOLEAN NOT NULL DEFAULT FALSE,
+    xp_earned     INT NOT NULL DEFAULT 0,
+    completed_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_drill_results_user ON drill_results(user_id, completed_at DESC);
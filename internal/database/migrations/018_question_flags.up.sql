@@ -0,0 +1,11 @@
+-- User-originated flags disputing a question, reviewed in the admin flagged queue
+CREATE TABLE IF NOT EXISTS question_flags (
+    id           BIGSERIAL PRIMARY KEY,
+    question_id  BIGINT NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason       TEXT NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (question_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_flags_question ON question_flags(question_id);
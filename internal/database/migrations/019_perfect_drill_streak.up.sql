@@ -0,0 +1,2 @@
+-- Consecutive perfect drills, for the escalating perfect-drill gem bonus
+ALTER TABLE user_gamification ADD COLUMN IF NOT EXISTS perfect_drill_streak INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Admin-curated questions that adaptive serving ranks ahead of equal candidates
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS featured BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Language the question's explanations are written in
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en';
@@ -0,0 +1,14 @@
+-- Background re-validation runs over unvalidated (and optionally flagged) questions
+CREATE TABLE IF NOT EXISTS revalidation_jobs (
+    id              BIGSERIAL PRIMARY KEY,
+    status          VARCHAR(20) NOT NULL DEFAULT 'running',
+    include_flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    total           INT NOT NULL DEFAULT 0,
+    processed       INT NOT NULL DEFAULT 0,
+    passed          INT NOT NULL DEFAULT 0,
+    flagged         INT NOT NULL DEFAULT 0,
+    rejected        INT NOT NULL DEFAULT 0,
+    failed          INT NOT NULL DEFAULT 0,
+    started_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at     TIMESTAMP WITH TIME ZONE
+);
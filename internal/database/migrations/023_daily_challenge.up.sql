@@ -0,0 +1,21 @@
+-- One shared question per UTC day, fixed the first time the day is requested
+CREATE TABLE IF NOT EXISTS daily_challenges (
+    challenge_date DATE PRIMARY KEY,
+    question_id    BIGINT NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Each user's single attempt at a day's challenge
+CREATE TABLE IF NOT EXISTS daily_challenge_attempts (
+    id                 BIGSERIAL PRIMARY KEY,
+    challenge_date     DATE NOT NULL,
+    user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_id        BIGINT NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    correct            BOOLEAN NOT NULL,
+    selected_choice_id VARCHAR(5) NOT NULL,
+    time_spent_seconds REAL,
+    attempted_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(challenge_date, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_challenge_attempts_rank ON daily_challenge_attempts (challenge_date, correct DESC, time_spent_seconds);
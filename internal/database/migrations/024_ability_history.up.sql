@@ -0,0 +1,11 @@
+-- Daily ability snapshots, one row per user, scope and UTC day holding the
+-- day's latest score. scope_value is '' for the overall scope.
+CREATE TABLE IF NOT EXISTS ability_history (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scope          VARCHAR(20) NOT NULL,
+    scope_value    VARCHAR(50) NOT NULL DEFAULT '',
+    snapshot_date  DATE NOT NULL,
+    ability_score  INT NOT NULL,
+    UNIQUE(user_id, scope, scope_value, snapshot_date)
+);
@@ -0,0 +1,3 @@
+-- The original fixed scores can't be told apart from spread ones, so the
+-- spread is kept.
+SELECT 1;
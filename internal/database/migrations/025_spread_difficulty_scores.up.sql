@@ -0,0 +1,20 @@
+-- The baseline backfill gave every pre-existing question a fixed score per
+-- difficulty (25/50/75). Spread those across the full band so adaptive
+-- windows at the ends of the scale find questions. Bands match
+-- generator.DifficultyBand.
+--
+-- The generator could also land on 25, 50 or 75, so only questions created
+-- before the first one scored off those values are touched: those predate
+-- generated scores and can only hold the backfill.
+UPDATE questions SET difficulty_score = CASE difficulty
+    WHEN 'easy'   THEN FLOOR(RANDOM() * 36)::INT
+    WHEN 'medium' THEN 36 + FLOOR(RANDOM() * 30)::INT
+    WHEN 'hard'   THEN 66 + FLOOR(RANDOM() * 35)::INT
+END
+WHERE ((difficulty = 'easy' AND difficulty_score = 25)
+    OR (difficulty = 'medium' AND difficulty_score = 50)
+    OR (difficulty = 'hard' AND difficulty_score = 75))
+  AND created_at < COALESCE(
+      (SELECT MIN(created_at) FROM questions WHERE difficulty_score NOT IN (25, 50, 75)),
+      'infinity'::timestamptz
+  );
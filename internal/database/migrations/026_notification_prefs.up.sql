@@ -0,0 +1,8 @@
+-- Per-category notification toggles; users without a row get everything
+CREATE TABLE IF NOT EXISTS notification_prefs (
+    user_id       BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    nudges        BOOLEAN NOT NULL DEFAULT TRUE,
+    achievements  BOOLEAN NOT NULL DEFAULT TRUE,
+    streaks       BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at    TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE generation_queue DROP COLUMN IF EXISTS high_priority;
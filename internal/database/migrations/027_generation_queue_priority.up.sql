@@ -0,0 +1,3 @@
+-- User-driven top-ups generate immediately; everything else may wait for the
+-- off-peak window
+ALTER TABLE generation_queue ADD COLUMN IF NOT EXISTS high_priority BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- When an item entered 'generating', so items a crashed worker left behind
+-- can be told apart from ones still running
+ALTER TABLE generation_queue ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_questions_content_unique;
+ALTER TABLE questions DROP COLUMN IF EXISTS duplicate_of;
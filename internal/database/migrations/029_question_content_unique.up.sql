@@ -0,0 +1,21 @@
+-- Questions saved before this index may repeat a stimulus+stem pair. Keep the
+-- oldest copy and retire later ones, rather than deleting them, so answer
+-- history that points at them survives.
+--
+-- The passage is part of the key: RC questions have an empty stimulus and
+-- standard stems ("What is the main point of the passage?") recur across
+-- passages, so only a repeat within one passage is a duplicate.
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS duplicate_of BIGINT REFERENCES questions(id);
+
+UPDATE questions q
+SET duplicate_of = d.keep_id, validation_status = 'rejected'
+FROM (
+    SELECT id, MIN(id) OVER (PARTITION BY COALESCE(passage_id, 0), md5(stimulus), md5(question_stem)) AS keep_id
+    FROM questions
+) d
+WHERE q.id = d.id AND d.keep_id <> q.id;
+
+-- Hashed because stimuli can exceed the btree row size limit
+CREATE UNIQUE INDEX IF NOT EXISTS idx_questions_content_unique
+    ON questions (COALESCE(passage_id, 0), md5(stimulus), md5(question_stem))
+    WHERE duplicate_of IS NULL;
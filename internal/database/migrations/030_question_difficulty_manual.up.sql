@@ -0,0 +1,3 @@
+-- Set when an admin overrides a question's difficulty by hand, so
+-- recalibration leaves it alone
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS difficulty_manual BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE user_question_history DROP COLUMN IF EXISTS source;
@@ -0,0 +1,3 @@
+-- The practice mode the latest attempt was answered in; NULL for answers
+-- recorded before sources were tracked or submitted without one
+ALTER TABLE user_question_history ADD COLUMN IF NOT EXISTS source VARCHAR(20);
@@ -0,0 +1,12 @@
+-- Each drill as served: its questions, and when it was completed. A drill
+-- can be completed once, and only after all of its questions are answered.
+CREATE TABLE IF NOT EXISTS drill_sessions (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_ids BIGINT[] NOT NULL,
+    source       VARCHAR(20),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_drill_sessions_user ON drill_sessions (user_id, created_at);
@@ -0,0 +1,11 @@
+-- Each diagnostic as served, so a submission is scored only against the
+-- questions it was given, and only once
+CREATE TABLE IF NOT EXISTS diagnostic_sessions (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_ids BIGINT[] NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    submitted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_diagnostic_sessions_user ON diagnostic_sessions (user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_daily_challenge_attempts_rank;
+CREATE INDEX IF NOT EXISTS idx_daily_challenge_attempts_rank ON daily_challenge_attempts (challenge_date, correct DESC, time_spent_seconds);
+
+ALTER TABLE daily_challenge_attempts DROP COLUMN IF EXISTS ranked;
+
+DROP TABLE IF EXISTS daily_challenge_serves;
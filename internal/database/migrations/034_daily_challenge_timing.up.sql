@@ -0,0 +1,14 @@
+-- When each user first fetched a day's challenge, so attempts are timed by
+-- the server rather than the client
+CREATE TABLE IF NOT EXISTS daily_challenge_serves (
+    challenge_date DATE NOT NULL,
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    served_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (challenge_date, user_id)
+);
+
+-- Attempts at a question the user had already answered don't rank
+ALTER TABLE daily_challenge_attempts ADD COLUMN IF NOT EXISTS ranked BOOLEAN NOT NULL DEFAULT TRUE;
+
+DROP INDEX IF EXISTS idx_daily_challenge_attempts_rank;
+CREATE INDEX IF NOT EXISTS idx_daily_challenge_attempts_rank ON daily_challenge_attempts (challenge_date, ranked, correct DESC, time_spent_seconds);
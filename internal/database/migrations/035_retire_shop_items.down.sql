@@ -0,0 +1,2 @@
+-- Refunds aren't reversed; the items stay retired
+SELECT 1;
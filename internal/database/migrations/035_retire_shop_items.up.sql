@@ -0,0 +1,22 @@
+-- The difficulty unlock and Logician title never had an effect. Refund what
+-- users paid for them (100 and 150 gems) through the ledger and drop them.
+WITH removed AS (
+    DELETE FROM inventory
+    WHERE item_id IN ('difficulty_unlock', 'title_logician')
+    RETURNING user_id, item_id, quantity
+), refunds AS (
+    SELECT user_id,
+           SUM(quantity * CASE item_id WHEN 'difficulty_unlock' THEN 100 ELSE 150 END) AS gems
+    FROM removed
+    GROUP BY user_id
+    HAVING SUM(quantity) > 0
+), credited AS (
+    UPDATE user_gamification g
+    SET gems = g.gems + r.gems, updated_at = NOW()
+    FROM refunds r
+    WHERE g.user_id = r.user_id
+    RETURNING g.user_id, r.gems AS amount, g.gems AS balance_after
+)
+INSERT INTO gem_transactions (user_id, amount, reason, balance_after)
+SELECT user_id, amount, 'shop_refund', balance_after
+FROM credited;
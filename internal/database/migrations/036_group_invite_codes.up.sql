@@ -0,0 +1,7 @@
+-- Joining a group takes its invite code, shared by members
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS invite_code VARCHAR(16);
+
+UPDATE groups SET invite_code = UPPER(SUBSTR(MD5(RANDOM()::text || id::text), 1, 8))
+WHERE invite_code IS NULL;
+
+ALTER TABLE groups ALTER COLUMN invite_code SET NOT NULL;
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Migrations are applied by golang-migrate, which tracks the applied version
+// in schema_migrations and runs only newer files. These checks keep the
+// embedded set well-formed: numbered 1..N with no gaps and an up and a down
+// file for each version.
+func TestMigrationsAreSequential(t *testing.T) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := map[int]string{}
+	down := map[int]string{}
+	for _, e := range entries {
+		name := e.Name()
+		var version int
+		if _, err := fmt.Sscanf(name, "%d_", &version); err != nil {
+			t.Errorf("migration %s has no version prefix", name)
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			if prev, ok := up[version]; ok {
+				t.Errorf("version %d has two up migrations: %s and %s", version, prev, name)
+			}
+			up[version] = name
+		case strings.HasSuffix(name, ".down.sql"):
+			down[version] = name
+		default:
+			t.Errorf("migration %s is neither .up.sql nor .down.sql", name)
+		}
+	}
+
+	if len(up) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for v := 1; v <= len(up); v++ {
+		if up[v] == "" {
+			t.Errorf("missing up migration for version %d", v)
+		}
+		if down[v] == "" {
+			t.Errorf("missing down migration for version %d", v)
+		}
+	}
+}
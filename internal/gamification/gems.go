@@ -0,0 +1,57 @@
+package gamification
+
+import "log/slog"
+
+// GemConfig holds the drill gem rewards. A perfect drill pays PerfectDrill
+// plus PerfectStreakStep for each consecutive perfect drill before it, with
+// the streak bonus capped at PerfectStreakMax.
+type GemConfig struct {
+	FirstDrill        int
+	PerfectDrill      int
+	PerfectStreakStep int
+	PerfectStreakMax  int
+}
+
+// DefaultGemConfig returns the standard drill gem rewards.
+func DefaultGemConfig() GemConfig {
+	return GemConfig{
+		FirstDrill:        50,
+		PerfectDrill:      10,
+		PerfectStreakStep: 5,
+		PerfectStreakMax:  25,
+	}
+}
+
+// LoadGemConfig returns DefaultGemConfig with overrides from GEMS_FIRST_DRILL,
+// GEMS_PERFECT_DRILL, GEMS_PERFECT_STREAK_STEP and GEMS_PERFECT_STREAK_MAX.
+// Negative values are ignored with a warning.
+func LoadGemConfig() GemConfig {
+	cfg := DefaultGemConfig()
+	for key, field := range map[string]*int{
+		"GEMS_FIRST_DRILL":         &cfg.FirstDrill,
+		"GEMS_PERFECT_DRILL":       &cfg.PerfectDrill,
+		"GEMS_PERFECT_STREAK_STEP": &cfg.PerfectStreakStep,
+		"GEMS_PERFECT_STREAK_MAX":  &cfg.PerfectStreakMax,
+	} {
+		v, ok := envInts(key)
+		if !ok {
+			continue
+		}
+		if len(v) != 1 || v[0] < 0 {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", key, "want", "one non-negative number")
+			continue
+		}
+		*field = v[0]
+	}
+	return cfg
+}
+
+// PerfectDrillGems returns the gems for a perfect drill that makes
+// perfectStreak consecutive perfect drills.
+func (c GemConfig) PerfectDrillGems(perfectStreak int) int {
+	bonus := 0
+	if perfectStreak > 1 {
+		bonus = min(c.PerfectStreakStep*(perfectStreak-1), c.PerfectStreakMax)
+	}
+	return c.PerfectDrill + bonus
+}
@@ -0,0 +1,57 @@
+package gamification
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxGroupSize       = 8
+	maxGroupNameLength = 50
+
+	// Invite codes skip letters and digits that are easy to confuse
+	inviteCodeLength   = 8
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// validateGroupName trims name and checks it is non-empty and short enough.
+func validateGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("group name is required")
+	}
+	if utf8.RuneCountInString(name) > maxGroupNameLength {
+		return "", fmt.Errorf("group name must be at most %d characters", maxGroupNameLength)
+	}
+	return name, nil
+}
+
+// newInviteCode returns a random code for joining a group.
+func newInviteCode() (string, error) {
+	b := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate invite code: %w", err)
+	}
+	for i := range b {
+		b[i] = inviteCodeAlphabet[int(b[i])%len(inviteCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// checkGroupJoin reports why a user offering code can't join a group with
+// inviteCode and memberCount members, or nil if they can. Codes match
+// ignoring case and surrounding space.
+func checkGroupJoin(inviteCode, code string, memberCount int, alreadyMember bool) error {
+	if !strings.EqualFold(strings.TrimSpace(code), inviteCode) {
+		return fmt.Errorf("invalid invite code")
+	}
+	if alreadyMember {
+		return fmt.Errorf("already a member of this group")
+	}
+	if memberCount >= maxGroupSize {
+		return fmt.Errorf("group is full (max %d members)", maxGroupSize)
+	}
+	return nil
+}
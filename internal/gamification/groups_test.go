@@ -0,0 +1,93 @@
+package gamification
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestCheckGroupJoin(t *testing.T) {
+	const code = "K7QM2XPA"
+	if err := checkGroupJoin(code, code, 1, false); err != nil {
+		t.Errorf("joining a 1-member group: %v", err)
+	}
+	if err := checkGroupJoin(code, " k7qm2xpa ", 1, false); err != nil {
+		t.Errorf("code typed in lower case with spaces: %v", err)
+	}
+	if err := checkGroupJoin(code, code, maxGroupSize-1, false); err != nil {
+		t.Errorf("taking the last seat: %v", err)
+	}
+	if err := checkGroupJoin(code, code, maxGroupSize, false); err == nil || !strings.HasPrefix(err.Error(), "group is full") {
+		t.Errorf("full group: got %v, want group is full", err)
+	}
+	if err := checkGroupJoin(code, code, 2, true); err == nil || err.Error() != "already a member of this group" {
+		t.Errorf("existing member: got %v, want already a member", err)
+	}
+	for _, wrong := range []string{"", "K7QM2XPB"} {
+		if err := checkGroupJoin(code, wrong, 1, false); err == nil || err.Error() != "invalid invite code" {
+			t.Errorf("code %q: got %v, want invalid invite code", wrong, err)
+		}
+	}
+}
+
+func TestNewInviteCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		code, err := newInviteCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != inviteCodeLength || strings.Trim(code, inviteCodeAlphabet) != "" {
+			t.Fatalf("invite code %q is not %d characters from the alphabet", code, inviteCodeLength)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("%d distinct codes in 100, want no repeats", len(seen))
+	}
+}
+
+func TestValidateGroupName(t *testing.T) {
+	if name, err := validateGroupName("  Logic Games Crew "); err != nil || name != "Logic Games Crew" {
+		t.Errorf("validateGroupName = %q, %v", name, err)
+	}
+	if _, err := validateGroupName("   "); err == nil {
+		t.Error("blank name should be rejected")
+	}
+	if _, err := validateGroupName(strings.Repeat("x", maxGroupNameLength+1)); err == nil {
+		t.Error("overlong name should be rejected")
+	}
+}
+
+func TestRankGroupLeaderboard(t *testing.T) {
+	entries := []models.LeaderboardEntry{
+		{UserID: 4, WeeklyXP: 120},
+		{UserID: 2, WeeklyXP: 300},
+		{UserID: 9, WeeklyXP: 0},
+		{UserID: 3, WeeklyXP: 120},
+	}
+
+	ranked := rankByWeeklyXP(entries, 3)
+
+	wantOrder := []int64{2, 3, 4, 9}
+	for i, e := range ranked {
+		if e.UserID != wantOrder[i] {
+			t.Fatalf("order = %v, want %v", userIDs(ranked), wantOrder)
+		}
+		if e.Rank != i+1 {
+			t.Errorf("user %d rank = %d, want %d", e.UserID, e.Rank, i+1)
+		}
+		if e.IsCurrentUser != (e.UserID == 3) {
+			t.Errorf("user %d IsCurrentUser = %v", e.UserID, e.IsCurrentUser)
+		}
+	}
+}
+
+func userIDs(entries []models.LeaderboardEntry) []int64 {
+	ids := make([]int64, len(entries))
+	for i, e := range entries {
+		ids[i] = e.UserID
+	}
+	return ids
+}
@@ -2,9 +2,11 @@ package gamification
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/lsat-prep/backend/internal/models"
@@ -32,7 +34,8 @@ func (h *Handler) GetGamification(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.GetGamification(userID)
+	includeNudges := r.URL.Query().Get("include_nudges") == "true"
+	resp, err := h.service.GetGamification(userID, includeNudges)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get gamification state"})
 		return
@@ -41,6 +44,22 @@ func (h *Handler) GetGamification(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.GetStatus(userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get status"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) BuyStreakFreeze(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
@@ -57,6 +76,128 @@ func (h *Handler) BuyStreakFreeze(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// ── Shop ────────────────────────────────────────────────
+
+func (h *Handler) GetQuests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.GetQuests(userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get quests"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetShop(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.GetShop(userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get shop"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) PurchaseItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.PurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if req.ItemID == "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "item_id is required"})
+		return
+	}
+
+	resp, err := h.service.PurchaseItem(userID, req.ItemID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) ActivateBoost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.PurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	boost, err := h.service.ActivateBoost(userID, req.ItemID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, boost)
+}
+
+func (h *Handler) GetGemHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	query := r.URL.Query()
+	page := intQueryParam(query, "page", 1)
+	pageSize := intQueryParam(query, "page_size", 20)
+
+	resp, err := h.service.GetGemHistory(userID, page, pageSize)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get gem history"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetDrillHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	query := r.URL.Query()
+	page := intQueryParam(query, "page", 1)
+	pageSize := intQueryParam(query, "page_size", 20)
+
+	resp, err := h.service.GetDrillHistory(userID, page, pageSize)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get drill history"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) SetDailyGoal(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
@@ -78,6 +219,102 @@ func (h *Handler) SetDailyGoal(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]int{"daily_goal_target": req.Target})
 }
 
+func (h *Handler) UseGoalSkip(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	goal, err := h.service.UseGoalSkip(userID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, goal)
+}
+
+func (h *Handler) SetAutoFreeze(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.SetAutoFreezeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if err := h.service.SetAutoFreeze(userID, req.Enabled); err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update auto-freeze"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"auto_freeze": req.Enabled})
+}
+
+func (h *Handler) SetPrivacy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.PrivacySettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if err := h.service.SetPrivacy(userID, req); err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update privacy settings"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
+
+func (h *Handler) GetNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	prefs, err := h.service.GetNotificationPrefs(userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get notification preferences"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+func (h *Handler) UpdateNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.UpdateNotificationPrefsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	prefs, err := h.service.UpdateNotificationPrefs(userID, req)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update notification preferences"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prefs)
+}
+
 func (h *Handler) CompleteDrill(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
@@ -91,14 +328,23 @@ func (h *Handler) CompleteDrill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.QuestionIDs) == 0 {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "question_ids is required"})
+	if req.DrillID <= 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "drill_id is required"})
 		return
 	}
 
 	resp, err := h.service.CompleteDrill(userID, req)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to complete drill"})
+		switch {
+		case errors.Is(err, ErrDrillNotFound):
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, ErrDrillIncomplete):
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, ErrDrillCompleted):
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: err.Error()})
+		default:
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to complete drill"})
+		}
 		return
 	}
 
@@ -141,6 +387,22 @@ func (h *Handler) FriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (h *Handler) LeagueLeaderboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.GetLeagueLeaderboard(userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get league leaderboard"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // ── Friends ─────────────────────────────────────────────
 
 func (h *Handler) ListFriends(w http.ResponseWriter, r *http.Request) {
@@ -263,6 +525,138 @@ func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
 }
 
+// ── Study Groups ────────────────────────────────────────
+
+func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.ListGroups(userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list groups"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	group, err := h.service.CreateGroup(userID, req.Name)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "group name") {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create group"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, group)
+}
+
+func (h *Handler) JoinGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	groupID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid group ID"})
+		return
+	}
+
+	var req models.JoinGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if err := h.service.JoinGroup(userID, groupID, req.InviteCode); err != nil {
+		status := http.StatusInternalServerError
+		msg := "Failed to join group"
+		switch {
+		case err.Error() == "group not found":
+			status, msg = http.StatusNotFound, err.Error()
+		case err.Error() == "invalid invite code":
+			status, msg = http.StatusForbidden, err.Error()
+		case err.Error() == "already a member of this group", strings.HasPrefix(err.Error(), "group is full"):
+			status, msg = http.StatusConflict, err.Error()
+		}
+		writeJSON(w, status, models.ErrorResponse{Error: msg})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "joined"})
+}
+
+func (h *Handler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	groupID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid group ID"})
+		return
+	}
+
+	if err := h.service.LeaveGroup(userID, groupID); err != nil {
+		if err.Error() == "not a member of this group" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to leave group"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "left"})
+}
+
+func (h *Handler) GroupLeaderboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	groupID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid group ID"})
+		return
+	}
+
+	resp, err := h.service.GetGroupLeaderboard(userID, groupID)
+	if err != nil {
+		if err.Error() == "not a member of this group" {
+			writeJSON(w, http.StatusForbidden, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get group leaderboard"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // ── Nudges ──────────────────────────────────────────────
 
 func (h *Handler) ListNudges(w http.ResponseWriter, r *http.Request) {
@@ -303,7 +697,7 @@ func (h *Handler) SendNudge(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		status := http.StatusBadRequest
 		switch err.Error() {
-		case "you can only nudge friends":
+		case "you can only nudge friends", "this friend has turned off nudges":
 			status = http.StatusForbidden
 		case "already nudged this person today":
 			status = http.StatusTooManyRequests
@@ -0,0 +1,133 @@
+package gamification
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// Each week users in a tier are split into cohorts of about cohortSize and
+// compete only within their cohort. The top of each cohort is promoted and
+// the bottom demoted; zone sizes scale with the cohort.
+const (
+	cohortSize        = 30
+	cohortPromoteZone = 7 // per cohortSize members
+	cohortDemoteZone  = 5 // per cohortSize members
+)
+
+// leagueTiers lists the tiers from lowest to highest.
+var leagueTiers = []string{
+	models.LeagueBronze,
+	models.LeagueSilver,
+	models.LeagueGold,
+	models.LeagueDiamond,
+	models.LeagueObsidian,
+}
+
+// adjacentTier returns the tier step levels above (or below, if negative)
+// tier, or tier itself at the ends of the ladder.
+func adjacentTier(tier string, step int) string {
+	for i, t := range leagueTiers {
+		if t == tier {
+			j := i + step
+			if j < 0 || j >= len(leagueTiers) {
+				return tier
+			}
+			return leagueTiers[j]
+		}
+	}
+	return tier
+}
+
+// assignCohorts splits userIDs into the fewest cohorts of at most size
+// members, keeping cohort sizes within one of each other.
+func assignCohorts(userIDs []int64, size int) [][]int64 {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	n := (len(userIDs) + size - 1) / size
+	cohorts := make([][]int64, n)
+	base, extra := len(userIDs)/n, len(userIDs)%n
+	start := 0
+	for i := range cohorts {
+		end := start + base
+		if i < extra {
+			end++
+		}
+		cohorts[i] = userIDs[start:end]
+		start = end
+	}
+	return cohorts
+}
+
+// cohortZones returns how many members of a cohort of n in tier are promoted
+// and demoted. Bronze can't demote and Obsidian can't promote.
+func cohortZones(tier string, n int) (promote, demote int) {
+	promote = (n*cohortPromoteZone + cohortSize/2) / cohortSize
+	demote = (n*cohortDemoteZone + cohortSize/2) / cohortSize
+	if tier == models.LeagueObsidian {
+		promote = 0
+	}
+	if tier == models.LeagueBronze {
+		demote = 0
+	}
+	// Small cohorts: never let the zones overlap
+	if promote+demote > n {
+		demote = n - promote
+	}
+	return promote, demote
+}
+
+// cohortLeagueChanges ranks a cohort by weekly XP and returns the promotions
+// and demotions. Members with no weekly XP are never promoted.
+func cohortLeagueChanges(tier string, members []models.LeaderboardEntry) []LeagueChange {
+	ranked := rankByWeeklyXP(members, 0)
+	promote, demote := cohortZones(tier, len(ranked))
+
+	var changes []LeagueChange
+	for i, m := range ranked {
+		switch {
+		case i < promote && m.WeeklyXP > 0:
+			changes = append(changes, LeagueChange{UserID: m.UserID, OldTier: tier, NewTier: adjacentTier(tier, 1)})
+		case i >= len(ranked)-demote:
+			changes = append(changes, LeagueChange{UserID: m.UserID, OldTier: tier, NewTier: adjacentTier(tier, -1)})
+		}
+	}
+	return changes
+}
+
+// rankByWeeklyXP orders members by weekly XP (ties by user ID), assigns
+// ranks from 1, and flags the current user.
+func rankByWeeklyXP(entries []models.LeaderboardEntry, userID int64) []models.LeaderboardEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].WeeklyXP != entries[j].WeeklyXP {
+			return entries[i].WeeklyXP > entries[j].WeeklyXP
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+		entries[i].IsCurrentUser = entries[i].UserID == userID
+	}
+	return entries
+}
+
+// applyDemotionGrace drops demotions for users promoted at or after
+// weekStart, giving a newly promoted user one full week in their new tier.
+func applyDemotionGrace(changes []LeagueChange, promotedAt map[int64]time.Time, weekStart time.Time) []LeagueChange {
+	kept := changes[:0:0]
+	for _, c := range changes {
+		if at, ok := promotedAt[c.UserID]; ok && !isPromotion(c.OldTier, c.NewTier) && !at.Before(weekStart) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// isoWeekStart returns Monday 00:00 UTC of t's ISO week.
+func isoWeekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -int(day.Weekday()+6)%7)
+}
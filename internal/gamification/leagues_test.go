@@ -0,0 +1,149 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestAssignCohorts(t *testing.T) {
+	var ids []int64
+	for i := int64(1); i <= 65; i++ {
+		ids = append(ids, i)
+	}
+
+	cohorts := assignCohorts(ids, cohortSize)
+	if len(cohorts) != 3 {
+		t.Fatalf("65 users made %d cohorts, want 3", len(cohorts))
+	}
+	seen := map[int64]bool{}
+	for i, c := range cohorts {
+		if len(c) < 21 || len(c) > 22 {
+			t.Errorf("cohort %d has %d members, want 21-22", i, len(c))
+		}
+		for _, id := range c {
+			if seen[id] {
+				t.Errorf("user %d assigned twice", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != 65 {
+		t.Errorf("assigned %d users, want 65", len(seen))
+	}
+
+	if got := assignCohorts(ids[:30], cohortSize); len(got) != 1 {
+		t.Errorf("30 users made %d cohorts, want 1", len(got))
+	}
+	if got := assignCohorts(nil, cohortSize); got != nil {
+		t.Errorf("no users made %d cohorts, want none", len(got))
+	}
+}
+
+func TestCohortLeagueChanges_RankWithinCohort(t *testing.T) {
+	// 30-member silver cohort: XP 3000 down to 100 by user ID, user 30 idle
+	var members []models.LeaderboardEntry
+	for i := int64(1); i <= 30; i++ {
+		xp := int64(3100 - 100*i)
+		if i == 30 {
+			xp = 0
+		}
+		members = append(members, models.LeaderboardEntry{UserID: i, WeeklyXP: xp})
+	}
+	// Shuffle input order; ranking must not depend on it
+	members[0], members[29] = members[29], members[0]
+
+	changes := cohortLeagueChanges(models.LeagueSilver, members)
+	promoted, demoted := map[int64]bool{}, map[int64]bool{}
+	for _, c := range changes {
+		switch c.NewTier {
+		case models.LeagueGold:
+			promoted[c.UserID] = true
+		case models.LeagueBronze:
+			demoted[c.UserID] = true
+		default:
+			t.Errorf("unexpected change %+v", c)
+		}
+	}
+
+	if len(promoted) != cohortPromoteZone || len(demoted) != cohortDemoteZone {
+		t.Errorf("promoted %d / demoted %d, want %d / %d", len(promoted), len(demoted), cohortPromoteZone, cohortDemoteZone)
+	}
+	for id := int64(1); id <= 7; id++ {
+		if !promoted[id] {
+			t.Errorf("user %d (rank %d) should be promoted", id, id)
+		}
+	}
+	for id := int64(26); id <= 30; id++ {
+		if !demoted[id] {
+			t.Errorf("user %d (rank %d) should be demoted", id, id)
+		}
+	}
+}
+
+func TestCohortLeagueChanges_LadderEnds(t *testing.T) {
+	members := []models.LeaderboardEntry{
+		{UserID: 1, WeeklyXP: 900}, {UserID: 2, WeeklyXP: 500}, {UserID: 3, WeeklyXP: 0},
+		{UserID: 4, WeeklyXP: 0}, {UserID: 5, WeeklyXP: 0}, {UserID: 6, WeeklyXP: 0},
+	}
+
+	for _, c := range cohortLeagueChanges(models.LeagueBronze, members) {
+		if c.NewTier != models.LeagueSilver {
+			t.Errorf("bronze cohort produced %+v; bronze can't demote", c)
+		}
+	}
+	for _, c := range cohortLeagueChanges(models.LeagueObsidian, members) {
+		if c.NewTier != models.LeagueDiamond {
+			t.Errorf("obsidian cohort produced %+v; obsidian can't promote", c)
+		}
+	}
+
+	// Idle members aren't promoted even inside the zone
+	idle := []models.LeaderboardEntry{{UserID: 1}, {UserID: 2}, {UserID: 3}, {UserID: 4}, {UserID: 5}}
+	for _, c := range cohortLeagueChanges(models.LeagueBronze, idle) {
+		t.Errorf("idle bronze cohort produced %+v", c)
+	}
+}
+
+func TestApplyDemotionGrace_FirstWeekAfterPromotion(t *testing.T) {
+	// User 1 was promoted to Silver at the reset opening week W42, then had a
+	// quiet week at the bottom of their new cohort
+	weekStart := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	promotedAt := map[int64]time.Time{1: weekStart.Add(5 * time.Minute)}
+	members := []models.LeaderboardEntry{
+		{UserID: 1, WeeklyXP: 10}, {UserID: 2, WeeklyXP: 400}, {UserID: 3, WeeklyXP: 300},
+		{UserID: 4, WeeklyXP: 200}, {UserID: 5, WeeklyXP: 100}, {UserID: 6, WeeklyXP: 50},
+	}
+
+	if got := isoWeekStart(weekStart.AddDate(0, 0, 6)); !got.Equal(weekStart) {
+		t.Fatalf("isoWeekStart(Sunday) = %v, want %v", got, weekStart)
+	}
+
+	changes := cohortLeagueChanges(models.LeagueSilver, members)
+	if !hasChange(changes, 1, models.LeagueBronze) {
+		t.Fatalf("user 1 should be in the demotion zone; changes %+v", changes)
+	}
+
+	for _, c := range applyDemotionGrace(changes, promotedAt, weekStart) {
+		if c.UserID == 1 {
+			t.Errorf("user 1 demoted in their first week after promotion: %+v", c)
+		}
+	}
+
+	// Still at the bottom the following week: the grace period is over
+	nextWeek := weekStart.AddDate(0, 0, 7)
+	changes = cohortLeagueChanges(models.LeagueSilver, members)
+	if !hasChange(applyDemotionGrace(changes, promotedAt, nextWeek), 1, models.LeagueBronze) {
+		t.Error("user 1 should be demoted the week after their grace period")
+	}
+}
+
+func hasChange(changes []LeagueChange, userID int64, newTier string) bool {
+	for _, c := range changes {
+		if c.UserID == userID && c.NewTier == newTier {
+			return true
+		}
+	}
+	return false
+}
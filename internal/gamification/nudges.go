@@ -0,0 +1,119 @@
+package gamification
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// NudgeTypeDef defines a nudge type. DailyLimit is how many nudges of this
+// type one user may send the same friend per UTC day.
+type NudgeTypeDef struct {
+	Description string
+	DailyLimit  int
+}
+
+// NudgeTypes maps nudge type keys to their definitions.
+var NudgeTypes = map[string]NudgeTypeDef{
+	"comeback":    {Description: "Come back and keep your streak alive", DailyLimit: 1},
+	"challenge":   {Description: "Challenge a friend to beat your XP", DailyLimit: 1},
+	"cheer":       {Description: "Cheer a friend on", DailyLimit: 3},
+	"congrats":    {Description: "Congratulate a friend on a league promotion", DailyLimit: 1},
+	"study_buddy": {Description: "Invite a friend to study together", DailyLimit: 2},
+}
+
+// LoadNudgeTypes returns the enabled nudge types. NUDGE_TYPES, if set, is a
+// comma-separated list of keys from NudgeTypes to allow; unknown keys are
+// ignored. Unset or empty enables every type.
+func LoadNudgeTypes() map[string]NudgeTypeDef {
+	v := os.Getenv("NUDGE_TYPES")
+	if strings.TrimSpace(v) == "" {
+		return NudgeTypes
+	}
+
+	enabled := make(map[string]NudgeTypeDef)
+	for _, key := range strings.Split(v, ",") {
+		key = strings.TrimSpace(key)
+		def, ok := NudgeTypes[key]
+		if !ok {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "NUDGE_TYPES", "value", key)
+			continue
+		}
+		enabled[key] = def
+	}
+	if len(enabled) == 0 {
+		slog.Warn("NUDGE_TYPES has no known types, enabling all", "component", "gamification")
+		return NudgeTypes
+	}
+	return enabled
+}
+
+// checkNudge reports why a nudge of nudgeType can't be sent when sentToday
+// nudges of that type already went to the same friend today, or nil.
+func checkNudge(types map[string]NudgeTypeDef, nudgeType string, sentToday int) error {
+	def, ok := types[nudgeType]
+	if !ok {
+		return fmt.Errorf("invalid nudge type")
+	}
+	if sentToday >= def.DailyLimit {
+		return fmt.Errorf("already nudged this person today")
+	}
+	return nil
+}
+
+// nudgeStore is the subset of Store used to send a nudge.
+type nudgeStore interface {
+	AreFriends(userID, otherID int64) (bool, error)
+	GetNotificationPrefs(userID int64) (*models.NotificationPrefs, error)
+	CountNudgesToday(senderID, receiverID int64, nudgeType string) (int, error)
+	SendNudge(senderID, receiverID int64, nudgeType, message string) (int64, error)
+}
+
+// sendNudge checks that userID may nudge the receiver, then stores the
+// nudge and returns its ID.
+func sendNudge(st nudgeStore, types map[string]NudgeTypeDef, userID int64, req models.SendNudgeRequest) (int64, error) {
+	friends, err := st.AreFriends(userID, req.ReceiverID)
+	if err != nil || !friends {
+		return 0, fmt.Errorf("you can only nudge friends")
+	}
+
+	prefs, err := st.GetNotificationPrefs(req.ReceiverID)
+	if err != nil {
+		return 0, err
+	}
+	if !prefs.Nudges {
+		return 0, fmt.Errorf("this friend has turned off nudges")
+	}
+
+	// Validate nudge type and its daily limit
+	sentToday, err := st.CountNudgesToday(userID, req.ReceiverID, req.NudgeType)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkNudge(types, req.NudgeType, sentToday); err != nil {
+		return 0, err
+	}
+
+	id, err := st.SendNudge(userID, req.ReceiverID, req.NudgeType, req.Message)
+	if err != nil {
+		return 0, fmt.Errorf("already nudged this person today")
+	}
+	return id, nil
+}
+
+// applyNotificationPrefs returns prefs with the categories req sets changed.
+func applyNotificationPrefs(prefs models.NotificationPrefs, req models.UpdateNotificationPrefsRequest) models.NotificationPrefs {
+	if req.Nudges != nil {
+		prefs.Nudges = *req.Nudges
+	}
+	if req.Achievements != nil {
+		prefs.Achievements = *req.Achievements
+	}
+	if req.Streaks != nil {
+		prefs.Streaks = *req.Streaks
+	}
+	return prefs
+}
@@ -0,0 +1,105 @@
+package gamification
+
+import (
+	"testing"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestCheckNudge(t *testing.T) {
+	tests := []struct {
+		nudgeType string
+		sentToday int
+		wantErr   string
+	}{
+		{"congrats", 0, ""},
+		{"study_buddy", 1, ""},
+		{"study_buddy", 2, "already nudged this person today"},
+		{"cheer", 2, ""},
+		{"comeback", 1, "already nudged this person today"},
+		{"poke", 0, "invalid nudge type"},
+		{"", 0, "invalid nudge type"},
+	}
+
+	for _, tt := range tests {
+		err := checkNudge(NudgeTypes, tt.nudgeType, tt.sentToday)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("checkNudge(%q, %d) = %q, want %q", tt.nudgeType, tt.sentToday, got, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadNudgeTypes(t *testing.T) {
+	t.Setenv("NUDGE_TYPES", "cheer, congrats,bogus")
+	types := LoadNudgeTypes()
+	if len(types) != 2 {
+		t.Fatalf("enabled %d types, want 2: %v", len(types), types)
+	}
+	if err := checkNudge(types, "congrats", 0); err != nil {
+		t.Errorf("congrats should be enabled: %v", err)
+	}
+	if err := checkNudge(types, "comeback", 0); err == nil {
+		t.Error("comeback should be disabled when not listed")
+	}
+
+	t.Setenv("NUDGE_TYPES", "")
+	if got := LoadNudgeTypes(); len(got) != len(NudgeTypes) {
+		t.Errorf("unset NUDGE_TYPES enabled %d types, want all %d", len(got), len(NudgeTypes))
+	}
+}
+
+// fakeNudgeStore is a friendship between users 1 and 2 with in-memory
+// notification prefs and nudges.
+type fakeNudgeStore struct {
+	prefs map[int64]models.NotificationPrefs
+	sent  int
+}
+
+func (f *fakeNudgeStore) AreFriends(userID, otherID int64) (bool, error) {
+	return userID+otherID == 3, nil
+}
+
+func (f *fakeNudgeStore) GetNotificationPrefs(userID int64) (*models.NotificationPrefs, error) {
+	prefs, ok := f.prefs[userID]
+	if !ok {
+		prefs = models.NotificationPrefs{Nudges: true, Achievements: true, Streaks: true}
+	}
+	return &prefs, nil
+}
+
+func (f *fakeNudgeStore) CountNudgesToday(senderID, receiverID int64, nudgeType string) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeNudgeStore) SendNudge(senderID, receiverID int64, nudgeType, message string) (int64, error) {
+	f.sent++
+	return int64(f.sent), nil
+}
+
+func TestSendNudge_RespectsNotificationPrefs(t *testing.T) {
+	st := &fakeNudgeStore{prefs: map[int64]models.NotificationPrefs{}}
+	req := models.SendNudgeRequest{ReceiverID: 2, NudgeType: "cheer"}
+
+	if _, err := sendNudge(st, NudgeTypes, 1, req); err != nil {
+		t.Fatalf("nudge with default prefs: %v", err)
+	}
+
+	off := false
+	st.prefs[2] = applyNotificationPrefs(models.NotificationPrefs{Nudges: true, Achievements: true, Streaks: true},
+		models.UpdateNotificationPrefsRequest{Nudges: &off})
+	if !st.prefs[2].Achievements || !st.prefs[2].Streaks {
+		t.Errorf("prefs = %+v, want only nudges turned off", st.prefs[2])
+	}
+
+	_, err := sendNudge(st, NudgeTypes, 1, req)
+	if err == nil || err.Error() != "this friend has turned off nudges" {
+		t.Errorf("nudge to opted-out user: err = %v, want \"this friend has turned off nudges\"", err)
+	}
+	if st.sent != 1 {
+		t.Errorf("stored %d nudges, want 1", st.sent)
+	}
+}
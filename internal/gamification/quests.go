@@ -0,0 +1,204 @@
+package gamification
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// QuestEventKind identifies the activity a quest counts.
+type QuestEventKind string
+
+const (
+	QuestAnswer QuestEventKind = "answer"
+	QuestDrill  QuestEventKind = "drill"
+)
+
+// QuestEvent is one unit of quest progress: an answered question or a
+// completed drill.
+type QuestEvent struct {
+	Kind       QuestEventKind
+	Section    models.Section
+	Difficulty models.Difficulty
+	Correct    bool
+	Perfect    bool
+}
+
+// QuestDef defines a quest. Empty Section/Difficulty match any value;
+// CorrectOnly and PerfectOnly restrict which events count.
+type QuestDef struct {
+	Name        string
+	Description string
+	Weekly      bool
+	Kind        QuestEventKind
+	Section     models.Section
+	Difficulty  models.Difficulty
+	CorrectOnly bool
+	PerfectOnly bool
+	Target      int
+	Gems        int
+}
+
+// Quests maps quest keys to their definitions. Each period a user is
+// assigned dailyQuestCount daily and weeklyQuestCount weekly quests.
+var Quests = map[string]QuestDef{
+	"daily_answer_15":        {Name: "Warm Up", Description: "Answer 15 questions", Kind: QuestAnswer, Target: 15, Gems: 5},
+	"daily_correct_10":       {Name: "Sharp Mind", Description: "Get 10 questions right", Kind: QuestAnswer, CorrectOnly: true, Target: 10, Gems: 10},
+	"daily_hard_lr_10":       {Name: "Heavy Lifting", Description: "Answer 10 hard LR questions", Kind: QuestAnswer, Section: models.SectionLR, Difficulty: models.DifficultyHard, Target: 10, Gems: 15},
+	"daily_rc_correct_5":     {Name: "Close Reader", Description: "Get 5 RC questions right", Kind: QuestAnswer, Section: models.SectionRC, CorrectOnly: true, Target: 5, Gems: 10},
+	"daily_drills_2":         {Name: "Double Down", Description: "Complete 2 drills", Kind: QuestDrill, Target: 2, Gems: 10},
+	"daily_perfect_1":        {Name: "Clean Sheet", Description: "Complete a perfect drill", Kind: QuestDrill, PerfectOnly: true, Target: 1, Gems: 15},
+	"weekly_answer_100":      {Name: "Century Week", Description: "Answer 100 questions", Weekly: true, Kind: QuestAnswer, Target: 100, Gems: 40},
+	"weekly_hard_correct_25": {Name: "Summit", Description: "Get 25 hard questions right", Weekly: true, Kind: QuestAnswer, Difficulty: models.DifficultyHard, CorrectOnly: true, Target: 25, Gems: 50},
+	"weekly_drills_10":       {Name: "Drill Sergeant", Description: "Complete 10 drills", Weekly: true, Kind: QuestDrill, Target: 10, Gems: 40},
+	"weekly_perfect_5":       {Name: "Flawless Five", Description: "Complete 5 perfect drills", Weekly: true, Kind: QuestDrill, PerfectOnly: true, Target: 5, Gems: 75},
+}
+
+const (
+	dailyQuestCount  = 3
+	weeklyQuestCount = 2
+)
+
+// matches reports whether ev counts toward the quest.
+func (q QuestDef) matches(ev QuestEvent) bool {
+	if ev.Kind != q.Kind {
+		return false
+	}
+	if q.Section != "" && ev.Section != q.Section {
+		return false
+	}
+	if q.Difficulty != "" && ev.Difficulty != q.Difficulty {
+		return false
+	}
+	if q.CorrectOnly && !ev.Correct {
+		return false
+	}
+	if q.PerfectOnly && !ev.Perfect {
+		return false
+	}
+	return true
+}
+
+// dayKey returns the quest period key for t's UTC day, e.g. "2026-10-15".
+func dayKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// questExpiry returns when a quest period containing now ends: the next UTC
+// midnight for daily quests, the next ISO week start for weekly ones.
+func questExpiry(weekly bool, now time.Time) time.Time {
+	midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	if !weekly {
+		return midnight
+	}
+	daysToMonday := (8 - int(midnight.Weekday())) % 7
+	return midnight.AddDate(0, 0, daysToMonday)
+}
+
+// pickQuests deterministically chooses n quests of one period type for a
+// user, so repeated calls within a period agree without a DB read.
+func pickQuests(userID int64, weekly bool, periodKey string, n int) []string {
+	var pool []string
+	for key, def := range Quests {
+		if def.Weekly == weekly {
+			pool = append(pool, key)
+		}
+	}
+	sort.Strings(pool)
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s", userID, periodKey)
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+
+	if n > len(pool) {
+		n = len(pool)
+	}
+	picked := pool[:n]
+	sort.Strings(picked)
+	return picked
+}
+
+// questStore is the subset of Store used to assign and progress quests.
+type questStore interface {
+	AssignQuests(userID int64, periodKey string, questKeys []string) error
+	AddQuestProgress(userID int64, questKey, periodKey string, delta int) (bool, error)
+	AwardGems(userID int64, amount int, reason string) error
+}
+
+// assignQuests makes sure the user has this day's and week's quests and
+// returns the assigned quest keys by period key.
+func assignQuests(st questStore, userID int64, now time.Time) map[string][]string {
+	periods := map[string][]string{
+		dayKey(now):     pickQuests(userID, false, dayKey(now), dailyQuestCount),
+		isoWeekKey(now): pickQuests(userID, true, isoWeekKey(now), weeklyQuestCount),
+	}
+	for periodKey, keys := range periods {
+		if err := st.AssignQuests(userID, periodKey, keys); err != nil {
+			slog.Warn("assign quests failed", "component", "gamification", "user_id", userID, "period", periodKey, "err", err)
+		}
+	}
+	return periods
+}
+
+// recordQuestProgress advances the user's current quests that ev counts
+// toward and awards gems for any it completes. It returns the keys of the
+// quests completed by this event.
+func recordQuestProgress(st questStore, userID int64, now time.Time, ev QuestEvent) []string {
+	var completed []string
+	for periodKey, keys := range assignQuests(st, userID, now) {
+		for _, key := range keys {
+			def := Quests[key]
+			if !def.matches(ev) {
+				continue
+			}
+			done, err := st.AddQuestProgress(userID, key, periodKey, 1)
+			if err != nil {
+				slog.Warn("progress quest failed", "component", "gamification", "user_id", userID, "quest", key, "err", err)
+				continue
+			}
+			if done {
+				st.AwardGems(userID, def.Gems, "quest_"+key)
+				completed = append(completed, key)
+			}
+		}
+	}
+	sort.Strings(completed)
+	return completed
+}
+
+// questGems sums the gem rewards for the given quest keys.
+func questGems(keys []string) int {
+	total := 0
+	for _, key := range keys {
+		total += Quests[key].Gems
+	}
+	return total
+}
+
+// buildQuestsResponse fills stored quest rows with their definitions and
+// splits them into daily and weekly lists.
+func buildQuestsResponse(rows []models.Quest, now time.Time) *models.QuestsResponse {
+	resp := &models.QuestsResponse{Daily: []models.Quest{}, Weekly: []models.Quest{}}
+	for _, q := range rows {
+		def, ok := Quests[q.Key]
+		if !ok {
+			continue
+		}
+		q.Name = def.Name
+		q.Description = def.Description
+		q.Gems = def.Gems
+		q.ExpiresAt = questExpiry(def.Weekly, now)
+		if def.Weekly {
+			resp.Weekly = append(resp.Weekly, q)
+		} else {
+			resp.Daily = append(resp.Daily, q)
+		}
+	}
+	return resp
+}
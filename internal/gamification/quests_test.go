@@ -0,0 +1,161 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+type fakeQuest struct {
+	progress, target int
+	completed        bool
+}
+
+// fakeQuestStore keeps quest progress and gem awards in memory.
+type fakeQuestStore struct {
+	quests map[string]*fakeQuest // "period/key"
+	gems   map[string]int        // reason -> gems
+}
+
+func newFakeQuestStore() *fakeQuestStore {
+	return &fakeQuestStore{quests: map[string]*fakeQuest{}, gems: map[string]int{}}
+}
+
+func (f *fakeQuestStore) AssignQuests(userID int64, periodKey string, questKeys []string) error {
+	for _, key := range questKeys {
+		if _, ok := f.quests[periodKey+"/"+key]; !ok {
+			f.quests[periodKey+"/"+key] = &fakeQuest{target: Quests[key].Target}
+		}
+	}
+	return nil
+}
+
+func (f *fakeQuestStore) AddQuestProgress(userID int64, questKey, periodKey string, delta int) (bool, error) {
+	q, ok := f.quests[periodKey+"/"+questKey]
+	if !ok || q.completed {
+		return false, nil
+	}
+	q.progress = min(q.progress+delta, q.target)
+	q.completed = q.progress >= q.target
+	return q.completed, nil
+}
+
+func (f *fakeQuestStore) AwardGems(userID int64, amount int, reason string) error {
+	f.gems[reason] += amount
+	return nil
+}
+
+// eventFor builds an event that counts toward def.
+func eventFor(def QuestDef) QuestEvent {
+	return QuestEvent{Kind: def.Kind, Section: def.Section, Difficulty: def.Difficulty, Correct: true, Perfect: true}
+}
+
+func TestQuestDefMatches(t *testing.T) {
+	hardLR := Quests["daily_hard_lr_10"]
+	if !hardLR.matches(QuestEvent{Kind: QuestAnswer, Section: models.SectionLR, Difficulty: models.DifficultyHard}) {
+		t.Error("hard LR answer should count toward daily_hard_lr_10")
+	}
+	if hardLR.matches(QuestEvent{Kind: QuestAnswer, Section: models.SectionRC, Difficulty: models.DifficultyHard}) {
+		t.Error("RC answer should not count toward daily_hard_lr_10")
+	}
+	if hardLR.matches(QuestEvent{Kind: QuestAnswer, Section: models.SectionLR, Difficulty: models.DifficultyMedium}) {
+		t.Error("medium LR answer should not count toward daily_hard_lr_10")
+	}
+
+	perfect := Quests["weekly_perfect_5"]
+	if perfect.matches(QuestEvent{Kind: QuestDrill, Perfect: false}) {
+		t.Error("imperfect drill should not count toward weekly_perfect_5")
+	}
+	if perfect.matches(QuestEvent{Kind: QuestAnswer, Correct: true, Perfect: true}) {
+		t.Error("answer should not count toward a drill quest")
+	}
+}
+
+func TestPickQuests_StablePerPeriod(t *testing.T) {
+	a := pickQuests(7, false, "2026-10-15", dailyQuestCount)
+	b := pickQuests(7, false, "2026-10-15", dailyQuestCount)
+	if len(a) != dailyQuestCount {
+		t.Fatalf("picked %d daily quests, want %d", len(a), dailyQuestCount)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("picks differ within a period: %v vs %v", a, b)
+		}
+		if Quests[a[i]].Weekly {
+			t.Errorf("daily pick %s is a weekly quest", a[i])
+		}
+	}
+
+	for _, key := range pickQuests(7, true, "2026-W42", weeklyQuestCount) {
+		if !Quests[key].Weekly {
+			t.Errorf("weekly pick %s is a daily quest", key)
+		}
+	}
+}
+
+func TestRecordQuestProgress_AccruesAndRewardsOnce(t *testing.T) {
+	st := newFakeQuestStore()
+	now := time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC)
+	day := dayKey(now)
+	key := pickQuests(1, false, day, dailyQuestCount)[0]
+	def := Quests[key]
+
+	for i := 1; i < def.Target; i++ {
+		if done := recordQuestProgress(st, 1, now, eventFor(def)); containsKey(done, key) {
+			t.Fatalf("quest %s completed after %d of %d events", key, i, def.Target)
+		}
+	}
+	if got := st.quests[day+"/"+key].progress; got != def.Target-1 {
+		t.Errorf("progress = %d, want %d", got, def.Target-1)
+	}
+	if st.gems["quest_"+key] != 0 {
+		t.Error("gems awarded before the quest was complete")
+	}
+
+	done := recordQuestProgress(st, 1, now, eventFor(def))
+	if !containsKey(done, key) {
+		t.Fatalf("quest %s not reported complete at target; got %v", key, done)
+	}
+	if st.gems["quest_"+key] != def.Gems {
+		t.Errorf("gems for %s = %d, want %d", key, st.gems["quest_"+key], def.Gems)
+	}
+
+	// Further events don't pay out again
+	recordQuestProgress(st, 1, now, eventFor(def))
+	if st.gems["quest_"+key] != def.Gems {
+		t.Errorf("quest %s paid out twice: %d gems", key, st.gems["quest_"+key])
+	}
+
+	// The next day starts fresh daily quests
+	tomorrow := now.Add(24 * time.Hour)
+	assignQuests(st, 1, tomorrow)
+	for _, k := range pickQuests(1, false, dayKey(tomorrow), dailyQuestCount) {
+		if q := st.quests[dayKey(tomorrow)+"/"+k]; q == nil || q.progress != 0 {
+			t.Errorf("tomorrow's quest %s should start at 0 progress", k)
+		}
+	}
+}
+
+func TestQuestExpiry(t *testing.T) {
+	thu := time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC)
+	if got := questExpiry(false, thu); !got.Equal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily expiry = %v, want 2026-10-16", got)
+	}
+	if got := questExpiry(true, thu); !got.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("weekly expiry = %v, want Monday 2026-10-19", got)
+	}
+	sun := time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)
+	if got := questExpiry(true, sun); !got.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Sunday weekly expiry = %v, want 2026-10-19", got)
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
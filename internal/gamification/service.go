@@ -2,41 +2,91 @@ package gamification
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/lsat-prep/backend/internal/models"
 )
 
 type Service struct {
-	store *Store
+	store            *Store
+	xp               XPConfig
+	gems             GemConfig
+	nudgeTypes       map[string]NudgeTypeDef
+	streakMilestones map[int]StreakMilestone
+	friendRequestTTL time.Duration
+	workerInterval   time.Duration
 }
 
-func NewService(store *Store) *Service {
-	return &Service{store: store}
+func NewService(store *Store, xp XPConfig, gems GemConfig) *Service {
+	// Pending friend requests expire after this many days
+	friendRequestTTL := 30 * 24 * time.Hour
+	if v := os.Getenv("FRIEND_REQUEST_TTL_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			friendRequestTTL = time.Duration(n) * 24 * time.Hour
+		}
+	}
+
+	// How often the weekly reset and daily streak workers wake up. The jobs
+	// themselves still only run during hour 0 UTC.
+	workerInterval := time.Hour
+	if v := os.Getenv("GAMIFICATION_WORKER_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			workerInterval = d
+		}
+	}
+
+	return &Service{
+		store:            store,
+		xp:               xp,
+		gems:             gems,
+		nudgeTypes:       LoadNudgeTypes(),
+		streakMilestones: LoadStreakMilestones(),
+		friendRequestTTL: friendRequestTTL,
+		workerInterval:   workerInterval,
+	}
 }
 
 // ── Per-Question XP (called from SubmitAnswer) ──────────
 
-// AwardQuestionXP calculates and awards XP for a correct answer.
-// Returns the XP awarded (0 if incorrect — caller should only call on correct answers).
-func (s *Service) AwardQuestionXP(userID int64, difficultyScore, userAbility int) int {
-	base := BaseXP(difficultyScore)
-	challenge := ChallengeBonus(userAbility, difficultyScore)
-	xpAwarded := base + challenge
+// AwardQuestionXP calculates and awards XP for a correct answer, including the
+// user's streak multiplier. Callers should update the streak first so today's
+// activity counts. Returns the XP awarded (0 if incorrect — caller should only
+// call on correct answers).
+func (s *Service) AwardQuestionXP(userID, questionID int64, difficultyScore, userAbility int) int {
+	base := s.xp.BaseXP(difficultyScore)
+	challenge := s.xp.ChallengeBonus(userAbility, difficultyScore)
 
 	// Ensure gamification row exists
-	s.store.GetOrCreateGamification(userID)
+	currentStreak := 0
+	if gam, err := s.store.GetOrCreateGamification(userID); err == nil {
+		currentStreak = gam.CurrentStreak
+	}
+	multiplier := s.xp.StreakMultiplier(currentStreak)
+
+	boost, err := s.store.GetActiveBoost(userID)
+	if err != nil {
+		slog.Warn("get boost failed", "component", "gamification", "user_id", userID, "err", err)
+	}
+	boostMultiplier := BoostMultiplier(boost, time.Now())
+	xpAwarded := ApplyStreakMultiplier(s.xp.QuestionXP(difficultyScore, userAbility, currentStreak), boostMultiplier)
 
 	if err := s.store.AddXP(userID, xpAwarded); err != nil {
-		log.Printf("[gamification] failed to add XP for user %d: %v", userID, err)
+		slog.Warn("add XP failed", "component", "gamification", "user_id", userID, "err", err)
 	}
 
 	s.store.LogXPEvent(userID, "question_correct", xpAwarded, map[string]interface{}{
+		"question_id":      questionID,
 		"difficulty_score": difficultyScore,
 		"base_xp":          base,
 		"challenge_bonus":  challenge,
+		"multiplier":       multiplier,
+		"boost_multiplier": boostMultiplier,
 	})
 
 	return xpAwarded
@@ -53,6 +103,9 @@ func (s *Service) UpdateStreak(userID int64) error {
 	today := time.Now().UTC().Truncate(24 * time.Hour)
 
 	// Already active today — no change
+	streak := gam.CurrentStreak
+	freezeActive := gam.StreakFreezeActive
+	freezesUsed := 0
 	if gam.LastActiveDate != nil {
 		lastActive := gam.LastActiveDate.Truncate(24 * time.Hour)
 		if lastActive.Equal(today) {
@@ -64,74 +117,102 @@ func (s *Service) UpdateStreak(userID int64) error {
 		switch {
 		case daysSinceLast == 1:
 			// Consecutive day — increment streak
-			gam.CurrentStreak++
+			streak++
 		case daysSinceLast == 2 && gam.StreakFreezesOwned > 0:
 			// Missed yesterday but had a freeze — streak preserved
-			gam.CurrentStreak++
-			gam.StreakFreezeActive = false
-			gam.StreakFreezesOwned--
+			streak++
+			freezeActive = false
+			freezesUsed = 1
 		default:
 			// Streak broken
-			gam.CurrentStreak = 1
-			gam.StreakFreezeActive = false
+			streak = 1
+			freezeActive = false
 		}
 	} else {
 		// First ever activity
-		gam.CurrentStreak = 1
+		streak = 1
 	}
 
-	if gam.CurrentStreak > gam.LongestStreak {
-		gam.LongestStreak = gam.CurrentStreak
+	// Only the first request of the day applies, so a milestone pays once
+	applied, err := s.store.RecordStreakActivity(userID, today, streak, freezeActive, freezesUsed)
+	if err != nil || !applied {
+		return err
 	}
 
-	gam.LastActiveDate = &today
-
-	// Check streak milestones and award gems
-	streakMilestones := map[int]int{
-		3: 10, 7: 25, 14: 50, 30: 100, 60: 200, 100: 500, 365: 1000,
-	}
-	if gems, ok := streakMilestones[gam.CurrentStreak]; ok {
-		gam.Gems += gems
-		s.store.LogXPEvent(userID, "streak_milestone", 0, map[string]interface{}{
-			"streak":      gam.CurrentStreak,
-			"gems_awarded": gems,
-		})
-	}
+	// Check streak milestones and award gems (and XP at the big ones)
+	awardStreakMilestone(s.store, s.streakMilestones, userID, streak)
 
-	return s.store.UpdateGamification(userID, gam)
+	return nil
 }
 
 // ── Daily Goal ──────────────────────────────────────────
 
+// dailyGoalGems is the reward for reaching the daily goal.
+const dailyGoalGems = 5
+
+// dailyGoalStore is the subset of Store used to advance the daily goal.
+type dailyGoalStore interface {
+	AddDailyGoalProgress(userID int64, n int, today time.Time) (progress, target int, err error)
+	AwardGems(userID int64, amount int, reason string) error
+	LogXPEvent(userID int64, eventType string, xpAmount int, metadata map[string]interface{}) error
+}
+
 func (s *Service) UpdateDailyGoal(userID int64, questionsAnswered int) error {
-	gam, err := s.store.GetOrCreateGamification(userID)
-	if err != nil {
+	if _, err := s.store.GetOrCreateGamification(userID); err != nil {
 		return fmt.Errorf("get gamification: %w", err)
 	}
+	return advanceDailyGoal(s.store, userID, questionsAnswered, time.Now())
+}
 
-	today := time.Now().UTC().Format("2006-01-02")
-	goalDate := gam.DailyGoalDate.Format("2006-01-02")
+// advanceDailyGoal adds progress toward today's goal and pays the reward if
+// this update is the one that crossed the target.
+func advanceDailyGoal(st dailyGoalStore, userID int64, questionsAnswered int, now time.Time) error {
+	progress, target, err := st.AddDailyGoalProgress(userID, questionsAnswered, now.UTC())
+	if err != nil {
+		return fmt.Errorf("add daily goal progress: %w", err)
+	}
 
-	// Reset if new day
-	if today != goalDate {
-		gam.DailyGoalProgress = 0
-		gam.DailyGoalDate = time.Now().UTC()
+	// Award gems if just completed
+	wasCompleted := progress-questionsAnswered >= target
+	if !wasCompleted && progress >= target {
+		payDailyGoal(st, userID, target)
 	}
 
-	wasCompleted := gam.DailyGoalProgress >= gam.DailyGoalTarget
-	gam.DailyGoalProgress += questionsAnswered
-	nowCompleted := gam.DailyGoalProgress >= gam.DailyGoalTarget
+	return nil
+}
 
-	// Award gems if just completed
-	if !wasCompleted && nowCompleted {
-		gam.Gems += 5
-		s.store.LogXPEvent(userID, "daily_goal", 0, map[string]interface{}{
-			"gems_awarded": 5,
-			"target":       gam.DailyGoalTarget,
-		})
+// payDailyGoal awards the daily goal reward.
+func payDailyGoal(st dailyGoalStore, userID int64, target int) {
+	st.AwardGems(userID, dailyGoalGems, "daily_goal")
+	st.LogXPEvent(userID, "daily_goal", 0, map[string]interface{}{
+		"gems_awarded": dailyGoalGems,
+		"target":       target,
+	})
+}
+
+// goalSkipStore is the subset of Store used to skip the daily goal.
+type goalSkipStore interface {
+	dailyGoalStore
+	UseGoalSkip(userID int64, today time.Time) (target int, err error)
+}
+
+// UseGoalSkip spends a goal skip from the shop to complete today's goal.
+func (s *Service) UseGoalSkip(userID int64) (*models.DailyGoalInfo, error) {
+	if _, err := s.store.GetOrCreateGamification(userID); err != nil {
+		return nil, fmt.Errorf("get gamification: %w", err)
 	}
+	return skipDailyGoal(s.store, userID, time.Now())
+}
 
-	return s.store.UpdateGamification(userID, gam)
+// skipDailyGoal completes today's goal with a goal skip and pays the reward
+// as if the user had reached it.
+func skipDailyGoal(st goalSkipStore, userID int64, now time.Time) (*models.DailyGoalInfo, error) {
+	target, err := st.UseGoalSkip(userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	payDailyGoal(st, userID, target)
+	return &models.DailyGoalInfo{Progress: target, Target: target, Completed: true}, nil
 }
 
 // ── Counter Increment (delegates to store) ──────────────
@@ -143,74 +224,134 @@ func (s *Service) IncrementCounters(userID int64, correct bool) error {
 
 // ── Drill Completion ────────────────────────────────────
 
+// drillSessionTTL is how long after a drill is served it can be completed.
+const drillSessionTTL = 3 * time.Hour
+
+var (
+	// ErrDrillNotFound is returned for a drill the user wasn't served, or
+	// was served longer ago than drillSessionTTL.
+	ErrDrillNotFound = errors.New("drill not found")
+	// ErrDrillIncomplete is returned until every served question is answered.
+	ErrDrillIncomplete = errors.New("drill has unanswered questions")
+	// ErrDrillCompleted is returned for a drill already completed.
+	ErrDrillCompleted = errors.New("drill already completed")
+)
+
+// drillSessionStore is the subset of Store used to complete a drill.
+type drillSessionStore interface {
+	GetDrillSession(userID, drillID int64) (*DrillSession, error)
+	GetDrillAnswers(userID int64, questionIDs []int64, since time.Time) ([]DrillAnswer, error)
+	CompleteDrillSession(userID, drillID int64) (bool, error)
+}
+
+// claimDrill checks that every question served in the user's drill has been
+// answered since it was served, then marks the drill completed so it pays
+// out once. It returns the drill, its answers (oldest first) and the IDs
+// answered correctly.
+func claimDrill(st drillSessionStore, userID, drillID int64, now time.Time) (*DrillSession, []DrillAnswer, []int64, error) {
+	drill, err := st.GetDrillSession(userID, drillID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil, ErrDrillNotFound
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("get drill session: %w", err)
+	}
+	if drill.CompletedAt != nil {
+		return nil, nil, nil, ErrDrillCompleted
+	}
+	if now.Sub(drill.CreatedAt) > drillSessionTTL {
+		return nil, nil, nil, ErrDrillNotFound
+	}
+
+	recorded, err := st.GetDrillAnswers(userID, drill.QuestionIDs, drill.CreatedAt)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("get drill answers: %w", err)
+	}
+	answers, correctIDs := VerifyDrillAnswers(drill.QuestionIDs, recorded)
+	served := make(map[int64]bool, len(drill.QuestionIDs))
+	for _, id := range drill.QuestionIDs {
+		served[id] = true
+	}
+	if len(answers) < len(served) {
+		return nil, nil, nil, ErrDrillIncomplete
+	}
+
+	claimed, err := st.CompleteDrillSession(userID, drillID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !claimed {
+		return nil, nil, nil, ErrDrillCompleted
+	}
+	return drill, answers, correctIDs, nil
+}
+
+// CompleteDrill pays out a drill the user was served and has fully
+// answered. Correctness, totals and combos come from the recorded answers;
+// the client's CorrectIDs and ComboMax are not trusted.
 func (s *Service) CompleteDrill(userID int64, req models.CompleteDrillRequest) (*models.DrillCompleteResponse, error) {
 	gam, err := s.store.GetOrCreateGamification(userID)
 	if err != nil {
 		return nil, fmt.Errorf("get gamification: %w", err)
 	}
 
-	total := len(req.QuestionIDs)
-	correct := len(req.CorrectIDs)
-	isPerfect := correct == total && total > 0
-
-	// The per-question XP was already awarded during SubmitAnswer.
-	// Here we calculate the drill-level bonuses.
-
-	// Combo XP
-	comboXP := CalculateComboXPTotal(req.ComboMax)
+	drill, answers, correctIDs, err := claimDrill(s.store, userID, req.DrillID, time.Now())
+	if err != nil {
+		return nil, err
+	}
 
-	// Time bonus
-	timeBonus := TimeBonus(req.AvgTimeSeconds)
+	total := len(answers)
+	correct := len(correctIDs)
+	isPerfect := correct == total
+	comboMax := MaxCombo(answers)
 
-	// Drill completion bonus
-	drillXP := DrillCompletionXP(correct, total)
+	// The per-question XP (already streak-multiplied) was awarded during
+	// SubmitAnswer; look it up so the breakdown shows the whole drill.
+	questionXP, err := s.store.SumQuestionXP(userID, correctIDs, drill.CreatedAt)
+	if err != nil {
+		slog.Warn("sum question XP failed", "component", "gamification", "user_id", userID, "err", err)
+	}
 
-	// Subtotal of drill-level bonuses
-	subtotal := comboXP + timeBonus + drillXP
+	boost, err := s.store.GetActiveBoost(userID)
+	if err != nil {
+		slog.Warn("get boost failed", "component", "gamification", "user_id", userID, "err", err)
+	}
+	boostMultiplier := BoostMultiplier(boost, time.Now())
 
-	// Apply streak multiplier
-	multiplier := StreakMultiplier(gam.CurrentStreak)
-	totalDrillXP := ApplyStreakMultiplier(subtotal, multiplier)
+	breakdown := s.xp.DrillBreakdown(questionXP, comboMax, req.AvgTimeSeconds, correct, total, gam.CurrentStreak, boostMultiplier)
+	comboXP := breakdown.ComboBonuses
+	timeBonus := breakdown.TimeBonus
+	drillXP := breakdown.DrillCompletion
+	multiplier := breakdown.StreakMultiplier
+	totalDrillXP := breakdown.TotalXP - breakdown.Questions
 
 	// Award drill-level XP
 	if totalDrillXP > 0 {
 		if err := s.store.AddXP(userID, totalDrillXP); err != nil {
-			log.Printf("[gamification] failed to add drill XP: %v", err)
+			slog.Warn("add drill XP failed", "component", "gamification", "user_id", userID, "err", err)
 		}
 		s.store.LogXPEvent(userID, "drill_complete", totalDrillXP, map[string]interface{}{
 			"combo_xp":     comboXP,
 			"time_bonus":    timeBonus,
 			"drill_xp":      drillXP,
 			"multiplier":    multiplier,
+			"boost_multiplier": boostMultiplier,
 			"correct":       correct,
 			"total":         total,
 		})
 	}
 
-	// Update drill counters
-	gam.DrillsCompletedTotal++
-	if isPerfect {
-		gam.PerfectDrillsTotal++
+	if err := s.store.RecordDrillResult(userID, drillResultFor(answers, correctIDs, breakdown)); err != nil {
+		slog.Warn("record drill result failed", "component", "gamification", "user_id", userID, "err", err)
 	}
 
-	// Gem awards
-	gemsEarned := 0
-	if isPerfect {
-		gemsEarned += 10
-	}
-
-	// First drill bonus
-	if gam.DrillsCompletedTotal == 1 {
-		gemsEarned += 50
-	}
+	// Update drill counters and award drill gems
+	gemsEarned := recordDrillCompletion(s.store, s.gems, userID, isPerfect)
 
-	if gemsEarned > 0 {
-		gam.Gems += gemsEarned
-	}
-
-	// Refresh totals from DB before achievement check
-	if err := s.store.UpdateGamification(userID, gam); err != nil {
-		log.Printf("[gamification] failed to update gamification: %v", err)
+	questsCompleted := s.RecordQuestEvent(userID, QuestEvent{Kind: QuestDrill, Perfect: isPerfect})
+	gemsEarned += questGems(questsCompleted)
+	if questsCompleted == nil {
+		questsCompleted = []string{}
 	}
 
 	// Re-read to get accurate total_xp after AddXP
@@ -232,7 +373,7 @@ func (s *Service) CompleteDrill(userID int64, req models.CompleteDrillRequest) (
 				newAchievements = append(newAchievements, a)
 				// Award gems for achievement
 				if def, ok := Achievements[a]; ok {
-					s.store.AwardGems(userID, def.Gems)
+					s.store.AwardGems(userID, def.Gems, "achievement")
 					gemsEarned += def.Gems
 				}
 			}
@@ -244,15 +385,7 @@ func (s *Service) CompleteDrill(userID int64, req models.CompleteDrillRequest) (
 	}
 
 	return &models.DrillCompleteResponse{
-		XPBreakdown: models.XPBreakdown{
-			Questions:       0, // Already awarded per-question
-			ComboBonuses:    comboXP,
-			TimeBonus:       timeBonus,
-			DrillCompletion: drillXP,
-			Subtotal:        subtotal,
-			StreakMultiplier: multiplier,
-			TotalXP:         totalDrillXP,
-		},
+		XPBreakdown: breakdown,
 		GemsEarned: gemsEarned,
 		Streak: models.StreakInfo{
 			Current:    gam.CurrentStreak,
@@ -264,33 +397,61 @@ func (s *Service) CompleteDrill(userID int64, req models.CompleteDrillRequest) (
 			Completed: gam.DailyGoalProgress >= gam.DailyGoalTarget,
 		},
 		AchievementsUnlocked: newAchievements,
+		QuestsCompleted:      questsCompleted,
 		LeagueTier:           gam.LeagueTier,
 	}, nil
 }
 
 // ── Get Gamification State ──────────────────────────────
 
-func (s *Service) GetGamification(userID int64) (*models.GamificationResponse, error) {
-	gam, err := s.store.GetOrCreateGamification(userID)
+// recentNudgeLimit caps the unread nudges embedded in the gamification
+// response; the full list is at GET /nudges.
+const recentNudgeLimit = 5
+
+// GetGamification returns the user's gamification state. With includeNudges,
+// the most recent unread nudges are embedded so the home screen needs only
+// one request.
+func (s *Service) GetGamification(userID int64, includeNudges bool) (*models.GamificationResponse, error) {
+	return loadGamification(s.store, userID, includeNudges, time.Now())
+}
+
+// gamificationStateStore is the subset of Store used to build the
+// gamification response.
+type gamificationStateStore interface {
+	GetOrCreateGamification(userID int64) (*models.UserGamification, error)
+	GetUserAchievements(userID int64) ([]string, error)
+	CountUnreadNudges(userID int64) (int, error)
+	GetUnreadNudges(userID int64) ([]models.NudgeEntry, error)
+	GetActiveBoost(userID int64) (*models.XPBoost, error)
+}
+
+func loadGamification(st gamificationStateStore, userID int64, includeNudges bool, now time.Time) (*models.GamificationResponse, error) {
+	gam, err := st.GetOrCreateGamification(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	achievements, err := s.store.GetUserAchievements(userID)
+	achievements, err := st.GetUserAchievements(userID)
 	if err != nil {
 		achievements = []string{}
 	}
 
-	unreadNudges, _ := s.store.CountUnreadNudges(userID)
+	unreadNudges, _ := st.CountUnreadNudges(userID)
+	activeBoost, _ := st.GetActiveBoost(userID)
 
-	// Reset daily progress if day changed
-	today := time.Now().UTC().Format("2006-01-02")
-	goalDate := gam.DailyGoalDate.Format("2006-01-02")
-	dailyProgress := gam.DailyGoalProgress
-	if today != goalDate {
-		dailyProgress = 0
+	var nudges []models.NudgeEntry
+	if includeNudges && unreadNudges > 0 {
+		nudges, err = st.GetUnreadNudges(userID)
+		if err != nil {
+			slog.Warn("get nudges failed", "component", "gamification", "user_id", userID, "err", err)
+		}
+		if len(nudges) > recentNudgeLimit {
+			nudges = nudges[:recentNudgeLimit]
+		}
 	}
 
+	dailyProgress := dailyGoalProgressOn(gam.DailyGoalDate, gam.DailyGoalProgress, now)
+
 	return &models.GamificationResponse{
 		TotalXP:                gam.TotalXP,
 		WeeklyXP:              gam.WeeklyXP,
@@ -298,6 +459,8 @@ func (s *Service) GetGamification(userID int64) (*models.GamificationResponse, e
 		LongestStreak:         gam.LongestStreak,
 		StreakFreezeActive:    gam.StreakFreezeActive,
 		StreakFreezesOwned:    gam.StreakFreezesOwned,
+		AutoFreeze:            gam.AutoFreeze,
+		LeaderboardOptOut:     gam.LeaderboardOptOut,
 		Gems:                  gam.Gems,
 		DailyGoalTarget:       gam.DailyGoalTarget,
 		DailyGoalProgress:     dailyProgress,
@@ -308,9 +471,43 @@ func (s *Service) GetGamification(userID int64) (*models.GamificationResponse, e
 		PerfectDrillsTotal:     gam.PerfectDrillsTotal,
 		Achievements:          achievements,
 		UnreadNudges:          unreadNudges,
+		Nudges:                nudges,
+		ActiveBoost:           activeBoost,
 	}, nil
 }
 
+// dailyGoalProgressOn returns the goal progress for now's UTC day: the
+// stored progress if it's from today, else 0.
+func dailyGoalProgressOn(goalDate time.Time, progress int, now time.Time) int {
+	if goalDate.Format("2006-01-02") != now.UTC().Format("2006-01-02") {
+		return 0
+	}
+	return progress
+}
+
+// GetStatus returns the streak and daily-goal summary for the home screen.
+func (s *Service) GetStatus(userID int64) (*models.StatusResponse, error) {
+	sum, err := s.store.GetStatusSummary(userID)
+	if err != nil {
+		return nil, err
+	}
+	return buildStatus(sum, time.Now()), nil
+}
+
+func buildStatus(sum *StatusSummary, now time.Time) *models.StatusResponse {
+	progress := dailyGoalProgressOn(sum.DailyGoalDate, sum.DailyGoalProgress, now)
+	activeToday := sum.LastActiveDate != nil &&
+		sum.LastActiveDate.Format("2006-01-02") == now.UTC().Format("2006-01-02")
+	return &models.StatusResponse{
+		CurrentStreak:      sum.CurrentStreak,
+		StreakFreezeActive: sum.StreakFreezeActive,
+		ActiveToday:        activeToday,
+		DailyGoalProgress:  progress,
+		DailyGoalTarget:    sum.DailyGoalTarget,
+		DailyGoalCompleted: progress >= sum.DailyGoalTarget,
+	}
+}
+
 // ── Purchases ───────────────────────────────────────────
 
 func (s *Service) BuyStreakFreeze(userID int64) (*models.StreakFreezeResponse, error) {
@@ -319,11 +516,11 @@ func (s *Service) BuyStreakFreeze(userID int64) (*models.StreakFreezeResponse, e
 		return nil, err
 	}
 
-	if gam.StreakFreezesOwned >= 3 {
-		return nil, fmt.Errorf("already have maximum freezes (3)")
+	if gam.StreakFreezesOwned >= maxStreakFreezes {
+		return nil, fmt.Errorf("already have maximum freezes (%d)", maxStreakFreezes)
 	}
-	if gam.Gems < 50 {
-		return nil, fmt.Errorf("not enough gems (need 50, have %d)", gam.Gems)
+	if gam.Gems < streakFreezeCost {
+		return nil, fmt.Errorf("not enough gems (need %d, have %d)", streakFreezeCost, gam.Gems)
 	}
 
 	if err := s.store.BuyStreakFreeze(userID); err != nil {
@@ -331,11 +528,202 @@ func (s *Service) BuyStreakFreeze(userID int64) (*models.StreakFreezeResponse, e
 	}
 
 	return &models.StreakFreezeResponse{
-		GemsRemaining:    gam.Gems - 50,
+		GemsRemaining:    gam.Gems - streakFreezeCost,
 		StreakFreezesOwned: gam.StreakFreezesOwned + 1,
 	}, nil
 }
 
+func (s *Service) SetAutoFreeze(userID int64, enabled bool) error {
+	if _, err := s.store.GetOrCreateGamification(userID); err != nil {
+		return err
+	}
+	return s.store.SetAutoFreeze(userID, enabled)
+}
+
+func (s *Service) SetPrivacy(userID int64, settings models.PrivacySettings) error {
+	if _, err := s.store.GetOrCreateGamification(userID); err != nil {
+		return err
+	}
+	return s.store.SetLeaderboardOptOut(userID, settings.LeaderboardOptOut)
+}
+
+func (s *Service) GetNotificationPrefs(userID int64) (*models.NotificationPrefs, error) {
+	return s.store.GetNotificationPrefs(userID)
+}
+
+// UpdateNotificationPrefs changes the categories req sets and returns the
+// resulting preferences. Any future push delivery should check these too.
+func (s *Service) UpdateNotificationPrefs(userID int64, req models.UpdateNotificationPrefsRequest) (*models.NotificationPrefs, error) {
+	prefs, err := s.store.GetNotificationPrefs(userID)
+	if err != nil {
+		return nil, err
+	}
+	updated := applyNotificationPrefs(*prefs, req)
+	if err := s.store.SetNotificationPrefs(userID, updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *Service) GetShop(userID int64) (*models.ShopResponse, error) {
+	gam, err := s.store.GetOrCreateGamification(userID)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := s.store.GetInventory(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ShopResponse{
+		Gems:  gam.Gems,
+		Items: shopCatalog(inventory),
+	}, nil
+}
+
+func (s *Service) PurchaseItem(userID int64, itemID string) (*models.PurchaseResponse, error) {
+	item, ok := ShopItems[itemID]
+	if !ok {
+		return nil, fmt.Errorf("unknown shop item %q", itemID)
+	}
+
+	gam, err := s.store.GetOrCreateGamification(userID)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := s.store.GetInventory(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPurchase(item, gam.Gems, inventory[itemID]); err != nil {
+		return nil, err
+	}
+
+	// The store re-checks both limits inside the transaction
+	balance, quantity, err := s.store.PurchaseItem(userID, itemID, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PurchaseResponse{
+		ItemID:        itemID,
+		Quantity:      quantity,
+		GemsRemaining: balance,
+	}, nil
+}
+
+// ActivateBoost starts an XP boost bought from the shop.
+func (s *Service) ActivateBoost(userID int64, itemID string) (*models.XPBoost, error) {
+	item, ok := ShopItems[itemID]
+	if !ok || item.BoostMultiplier == 0 {
+		return nil, fmt.Errorf("%q is not an XP boost", itemID)
+	}
+	return s.store.ActivateBoost(userID, itemID, item.BoostMultiplier, time.Duration(item.BoostMinutes)*time.Minute)
+}
+
+func (s *Service) GetGemHistory(userID int64, page, pageSize int) (*models.GemHistoryResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 50 {
+		pageSize = 50
+	}
+
+	txns, total, err := s.store.GetGemHistory(userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
+	return &models.GemHistoryResponse{
+		Transactions: txns,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+		TotalPages:   totalPages,
+		HasMore:      hasMore,
+	}, nil
+}
+
+// drillCounterStore is the subset of Store used to count a completed drill.
+type drillCounterStore interface {
+	IncrementDrillCounters(userID int64, perfect bool) (drillsTotal, perfectStreak int, err error)
+	AwardGems(userID int64, amount int, reason string) error
+}
+
+// recordDrillCompletion counts a completed drill and awards the perfect-drill
+// and first-drill gems, returning the gems awarded. The first-drill bonus
+// goes to whichever completion the counter numbers 1, so it pays once even
+// when drills finish concurrently.
+func recordDrillCompletion(st drillCounterStore, cfg GemConfig, userID int64, perfect bool) int {
+	drillsTotal, perfectStreak, err := st.IncrementDrillCounters(userID, perfect)
+	if err != nil {
+		slog.Warn("update drill counters failed", "component", "gamification", "user_id", userID, "err", err)
+		perfectStreak = 1
+	}
+
+	gems := 0
+	if perfect {
+		amount := cfg.PerfectDrillGems(perfectStreak)
+		st.AwardGems(userID, amount, "perfect_drill")
+		gems += amount
+	}
+	if err == nil && drillsTotal == 1 && cfg.FirstDrill > 0 {
+		st.AwardGems(userID, cfg.FirstDrill, "first_drill")
+		gems += cfg.FirstDrill
+	}
+	return gems
+}
+
+// drillResultFor summarizes a completed drill from its verified answers.
+func drillResultFor(answers []DrillAnswer, correctIDs []int64, breakdown models.XPBreakdown) models.DrillResult {
+	return models.DrillResult{
+		Correct:  len(correctIDs),
+		Total:    len(answers),
+		Perfect:  len(correctIDs) == len(answers),
+		XPEarned: breakdown.TotalXP,
+	}
+}
+
+// drillHistoryStore is the subset of Store used to list drill history.
+type drillHistoryStore interface {
+	GetDrillResults(userID int64, limit, offset int) ([]models.DrillResult, int, error)
+}
+
+func (s *Service) GetDrillHistory(userID int64, page, pageSize int) (*models.DrillHistoryResponse, error) {
+	return buildDrillHistory(s.store, userID, page, pageSize)
+}
+
+func buildDrillHistory(st drillHistoryStore, userID int64, page, pageSize int) (*models.DrillHistoryResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 50 {
+		pageSize = 50
+	}
+
+	drills, total, err := st.GetDrillResults(userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if drills == nil {
+		drills = []models.DrillResult{}
+	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
+	return &models.DrillHistoryResponse{
+		Drills:     drills,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
+	}, nil
+}
+
 func (s *Service) SetDailyGoal(userID int64, target int) error {
 	validTargets := map[int]bool{3: true, 6: true, 12: true, 18: true}
 	if !validTargets[target] {
@@ -345,6 +733,25 @@ func (s *Service) SetDailyGoal(userID int64, target int) error {
 	return s.store.SetDailyGoalTarget(userID, target)
 }
 
+// ── Quests ──────────────────────────────────────────────
+
+func (s *Service) GetQuests(userID int64) (*models.QuestsResponse, error) {
+	now := time.Now()
+	assignQuests(s.store, userID, now)
+
+	rows, err := s.store.GetQuests(userID, []string{dayKey(now), isoWeekKey(now)})
+	if err != nil {
+		return nil, err
+	}
+	return buildQuestsResponse(rows, now), nil
+}
+
+// RecordQuestEvent advances the user's quests and returns the keys of any
+// completed by the event. Called from SubmitAnswer and CompleteDrill.
+func (s *Service) RecordQuestEvent(userID int64, ev QuestEvent) []string {
+	return recordQuestProgress(s.store, userID, time.Now(), ev)
+}
+
 // ── Friends ─────────────────────────────────────────────
 
 func (s *Service) SendFriendRequest(userID int64, friendID int64) (*models.FriendRequestResponse, error) {
@@ -362,6 +769,14 @@ func (s *Service) SendFriendRequest(userID int64, friendID int64) (*models.Frien
 	if err != nil {
 		return nil, err
 	}
+	if existing != nil && existing.Status == "pending" &&
+		friendRequestExpired(existing.CreatedAt, s.friendRequestTTL, time.Now()) {
+		// An expired request the cleanup hasn't removed yet doesn't block a new one
+		if err := s.store.RespondFriendRequest(existing.ID, false); err != nil {
+			return nil, fmt.Errorf("remove expired request: %w", err)
+		}
+		existing = nil
+	}
 	if existing != nil {
 		return nil, fmt.Errorf("friend request already exists")
 	}
@@ -396,13 +811,61 @@ func (s *Service) RespondFriendRequest(userID int64, friendshipID int64, action
 	if friendship.Status != "pending" {
 		return fmt.Errorf("request already processed")
 	}
+	if friendRequestExpired(friendship.CreatedAt, s.friendRequestTTL, time.Now()) {
+		return fmt.Errorf("friend request expired")
+	}
 
 	accept := action == "accept"
 	return s.store.RespondFriendRequest(friendshipID, accept)
 }
 
 func (s *Service) ListFriends(userID int64) (*models.FriendsResponse, error) {
-	return s.store.GetFriends(userID)
+	resp, err := s.store.GetFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+	// The daily cleanup deletes expired requests; hide any it hasn't reached yet
+	now := time.Now()
+	resp.PendingReceived = dropExpiredRequests(resp.PendingReceived, s.friendRequestTTL, now)
+	resp.PendingSent = dropExpiredRequests(resp.PendingSent, s.friendRequestTTL, now)
+
+	// Mutual friends help decide on pending requests
+	ids := []int64{userID}
+	for _, p := range resp.PendingReceived {
+		ids = append(ids, p.UserID)
+	}
+	for _, p := range resp.PendingSent {
+		ids = append(ids, p.UserID)
+	}
+	if len(ids) > 1 {
+		friendsOf, err := s.store.GetFriendIDs(ids)
+		if err != nil {
+			slog.Warn("get mutual friends failed", "component", "gamification", "user_id", userID, "err", err)
+			return resp, nil
+		}
+		for i := range resp.PendingReceived {
+			resp.PendingReceived[i].MutualFriends = countMutualFriends(friendsOf, userID, resp.PendingReceived[i].UserID)
+		}
+		for i := range resp.PendingSent {
+			resp.PendingSent[i].MutualFriends = countMutualFriends(friendsOf, userID, resp.PendingSent[i].UserID)
+		}
+	}
+	return resp, nil
+}
+
+func friendRequestExpired(createdAt time.Time, ttl time.Duration, now time.Time) bool {
+	return now.Sub(createdAt) > ttl
+}
+
+// dropExpiredRequests filters out pending requests older than ttl.
+func dropExpiredRequests(pending []models.PendingFriendEntry, ttl time.Duration, now time.Time) []models.PendingFriendEntry {
+	kept := make([]models.PendingFriendEntry, 0, len(pending))
+	for _, p := range pending {
+		if !friendRequestExpired(p.CreatedAt, ttl, now) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
 }
 
 func (s *Service) RemoveFriend(userID int64, friendshipID int64) error {
@@ -413,27 +876,47 @@ func (s *Service) SearchUsers(userID int64, query string) ([]models.UserSearchRe
 	if len(query) < 2 {
 		return []models.UserSearchResult{}, nil
 	}
-	return s.store.SearchUsers(query, userID)
-}
-
-// ── Nudges ──────────────────────────────────────────────
+	results, err := s.store.SearchUsers(query, userID)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *Service) SendNudge(userID int64, req models.SendNudgeRequest) (int64, error) {
-	// Verify friendship
-	friends, err := s.store.AreFriends(userID, req.ReceiverID)
-	if err != nil || !friends {
-		return 0, fmt.Errorf("you can only nudge friends")
+	ids := []int64{userID}
+	for _, r := range results {
+		ids = append(ids, r.UserID)
+	}
+	friendsOf, err := s.store.GetFriendIDs(ids)
+	if err != nil {
+		slog.Warn("get mutual friends failed", "component", "gamification", "user_id", userID, "err", err)
+		return results, nil
+	}
+	for i := range results {
+		results[i].MutualFriends = countMutualFriends(friendsOf, userID, results[i].UserID)
 	}
+	return results, nil
+}
 
-	// Validate nudge type
-	validTypes := map[string]bool{"comeback": true, "challenge": true, "cheer": true}
-	if !validTypes[req.NudgeType] {
-		return 0, fmt.Errorf("invalid nudge type")
+// countMutualFriends counts users who are friends with both a and b.
+func countMutualFriends(friendsOf map[int64][]int64, a, b int64) int {
+	ofA := make(map[int64]bool, len(friendsOf[a]))
+	for _, id := range friendsOf[a] {
+		ofA[id] = true
+	}
+	count := 0
+	for _, id := range friendsOf[b] {
+		if ofA[id] && id != a && id != b {
+			count++
+		}
 	}
+	return count
+}
+
+// ── Nudges ──────────────────────────────────────────────
 
-	id, err := s.store.SendNudge(userID, req.ReceiverID, req.NudgeType, req.Message)
+func (s *Service) SendNudge(userID int64, req models.SendNudgeRequest) (int64, error) {
+	id, err := sendNudge(s.store, s.nudgeTypes, userID, req)
 	if err != nil {
-		return 0, fmt.Errorf("already nudged this person today")
+		return 0, err
 	}
 
 	// Check nudge_first achievement
@@ -442,7 +925,7 @@ func (s *Service) SendNudge(userID int64, req models.SendNudgeRequest) (int64, e
 	if def, ok := Achievements["nudge_first"]; ok {
 		// Award gems only if this is the first time (AwardAchievement is idempotent)
 		// We check by trying the insert — if it was a no-op, no gems
-		s.store.AwardGems(userID, def.Gems)
+		s.store.AwardGems(userID, def.Gems, "achievement")
 	}
 
 	return id, nil
@@ -465,12 +948,25 @@ func (s *Service) MarkNudgeRead(userID int64, nudgeID int64) error {
 
 // ── Leaderboard ─────────────────────────────────────────
 
+// GetGlobalLeaderboard returns the top users by weekly XP. Users who opted
+// out are left off, but still see their own rank as CurrentUser.
 func (s *Service) GetGlobalLeaderboard(userID int64, limit int) (*models.LeaderboardResponse, error) {
+	return buildGlobalLeaderboard(s.store, userID, limit, time.Now())
+}
+
+// globalLeaderboardStore is the subset of Store used by the global leaderboard.
+type globalLeaderboardStore interface {
+	GetGlobalLeaderboard(limit int) ([]models.LeaderboardEntry, error)
+	GetUserRank(userID int64) (int, error)
+	GetOrCreateGamification(userID int64) (*models.UserGamification, error)
+}
+
+func buildGlobalLeaderboard(st globalLeaderboardStore, userID int64, limit int, now time.Time) (*models.LeaderboardResponse, error) {
 	if limit <= 0 {
 		limit = 20
 	}
 
-	entries, err := s.store.GetGlobalLeaderboard(limit)
+	entries, err := st.GetGlobalLeaderboard(limit)
 	if err != nil {
 		return nil, err
 	}
@@ -492,9 +988,9 @@ func (s *Service) GetGlobalLeaderboard(userID int64, limit int) (*models.Leaderb
 		}
 	}
 	if !found {
-		rank, _ := s.store.GetUserRank(userID)
+		rank, _ := st.GetUserRank(userID)
 		if rank > 0 {
-			gam, _ := s.store.GetOrCreateGamification(userID)
+			gam, _ := st.GetOrCreateGamification(userID)
 			currentUser = &models.LeaderboardEntry{
 				Rank:       rank,
 				UserID:     userID,
@@ -509,10 +1005,7 @@ func (s *Service) GetGlobalLeaderboard(userID int64, limit int) (*models.Leaderb
 	}
 
 	// Compute period string
-	now := time.Now().UTC()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()-time.Monday+7)%7)
-	weekEnd := weekStart.AddDate(0, 0, 6)
-	period := fmt.Sprintf("%s to %s", weekStart.Format("2006-01-02"), weekEnd.Format("2006-01-02"))
+	period := leaderboardPeriod(now)
 
 	return &models.LeaderboardResponse{
 		Period:      period,
@@ -537,10 +1030,7 @@ func (s *Service) GetFriendsLeaderboard(userID int64) (*models.LeaderboardRespon
 		entries = []models.LeaderboardEntry{}
 	}
 
-	now := time.Now().UTC()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()-time.Monday+7)%7)
-	weekEnd := weekStart.AddDate(0, 0, 6)
-	period := fmt.Sprintf("%s to %s", weekStart.Format("2006-01-02"), weekEnd.Format("2006-01-02"))
+	period := leaderboardPeriod(time.Now())
 
 	return &models.LeaderboardResponse{
 		Period:  period,
@@ -548,74 +1038,227 @@ func (s *Service) GetFriendsLeaderboard(userID int64) (*models.LeaderboardRespon
 	}, nil
 }
 
+// GetLeagueLeaderboard returns the standings in the user's league cohort
+// for the current week, joining them to a cohort on first view.
+func (s *Service) GetLeagueLeaderboard(userID int64) (*models.LeagueLeaderboardResponse, error) {
+	gam, err := s.store.GetOrCreateGamification(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	week := isoWeekKey(now)
+	cohortID, err := s.store.GetOrAssignCohort(userID, week, gam.LeagueTier)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.store.GetCohortLeaderboard(cohortID)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.LeaderboardEntry{}
+	}
+	entries = rankByWeeklyXP(entries, userID)
+	promote, demote := cohortZones(gam.LeagueTier, len(entries))
+
+	return &models.LeagueLeaderboardResponse{
+		Tier:          gam.LeagueTier,
+		CohortID:      cohortID,
+		Period:        leaderboardPeriod(now),
+		PromotionZone: promote,
+		DemotionZone:  demote,
+		Entries:       entries,
+	}, nil
+}
+
+// leaderboardPeriod describes the weekly leaderboard window containing now.
+func leaderboardPeriod(now time.Time) string {
+	now = now.UTC()
+	weekStart := now.AddDate(0, 0, -int(now.Weekday()-time.Monday+7)%7)
+	weekEnd := weekStart.AddDate(0, 0, 6)
+	return fmt.Sprintf("%s to %s", weekStart.Format("2006-01-02"), weekEnd.Format("2006-01-02"))
+}
+
+// ── Study Groups ────────────────────────────────────────
+
+func (s *Service) CreateGroup(userID int64, name string) (*models.Group, error) {
+	name, err := validateGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+	inviteCode, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	return s.store.CreateGroup(userID, name, inviteCode)
+}
+
+// JoinGroup adds the user to the group. code must be the group's invite
+// code, which members see in their group list.
+func (s *Service) JoinGroup(userID, groupID int64, code string) error {
+	return s.store.JoinGroup(groupID, userID, code)
+}
+
+func (s *Service) LeaveGroup(userID, groupID int64) error {
+	return s.store.LeaveGroup(groupID, userID)
+}
+
+func (s *Service) ListGroups(userID int64) (*models.GroupsResponse, error) {
+	groups, err := s.store.ListUserGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		groups = []models.Group{}
+	}
+	return &models.GroupsResponse{Groups: groups}, nil
+}
+
+// GetGroupLeaderboard ranks the group's members by weekly XP. Only members
+// can view it.
+func (s *Service) GetGroupLeaderboard(userID, groupID int64) (*models.LeaderboardResponse, error) {
+	isMember, err := s.store.IsGroupMember(groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, fmt.Errorf("not a member of this group")
+	}
+
+	entries, err := s.store.GetGroupLeaderboard(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.LeaderboardEntry{}
+	}
+
+	return &models.LeaderboardResponse{
+		Period:  leaderboardPeriod(time.Now()),
+		Entries: rankByWeeklyXP(entries, userID),
+	}, nil
+}
+
 // ── Background Workers ──────────────────────────────────
 
 func (s *Service) StartWeeklyResetWorker(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := time.NewTicker(s.workerInterval)
 	defer ticker.Stop()
 
-	log.Println("[gamification] Weekly reset worker started")
+	slog.Info("weekly reset worker started", "component", "gamification")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("[gamification] Weekly reset worker shutting down")
+			slog.Info("weekly reset worker shutting down", "component", "gamification")
 			return
 		case t := <-ticker.C:
 			utc := t.UTC()
 			// Run at Monday 00:xx UTC
 			if utc.Weekday() == time.Monday && utc.Hour() == 0 {
-				log.Println("[gamification] Running weekly leaderboard reset")
-				s.runWeeklyReset()
+				slog.Info("running weekly leaderboard reset", "component", "gamification")
+				runWeeklyReset(s.store, utc)
 			}
 		}
 	}
 }
 
-func (s *Service) runWeeklyReset() {
+// weeklyResetStore is the subset of Store used by the weekly reset.
+type weeklyResetStore interface {
+	ClaimWeeklyReset(isoWeek string) (bool, error)
+	CompleteWeeklyReset(isoWeek string, topUserIDs []int64, leagueChanges int) error
+	GetGlobalLeaderboard(limit int) ([]models.LeaderboardEntry, error)
+	AwardGems(userID int64, amount int, reason string) error
+	ProcessLeagueChanges(weekStart time.Time) ([]LeagueChange, error)
+	AwardAchievement(userID int64, achievement string) error
+	ResetWeeklyXP() error
+	AssignLeagueCohorts(isoWeek string) (int, error)
+}
+
+// isoWeekKey formats t's ISO week as "2006-W01".
+func isoWeekKey(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// runWeeklyReset distributes weekly rewards, processes league changes, and
+// resets weekly XP. It returns false without doing anything if a reset has
+// already run for now's ISO week.
+func runWeeklyReset(st weeklyResetStore, now time.Time) bool {
+	week := isoWeekKey(now)
+	claimed, err := st.ClaimWeeklyReset(week)
+	if err != nil {
+		slog.Error("weekly reset: claim failed", "component", "gamification", "week", week, "err", err)
+		return false
+	}
+	if !claimed {
+		slog.Info("weekly reset: already ran, skipping", "component", "gamification", "week", week)
+		return false
+	}
+
 	// 1. Award gems to top 3
-	top3, err := s.store.GetGlobalLeaderboard(3)
+	var topUserIDs []int64
+	top3, err := st.GetGlobalLeaderboard(3)
 	if err != nil {
-		log.Printf("[gamification] weekly reset: failed to get top 3: %v", err)
+		slog.Error("weekly reset: get top 3 failed", "component", "gamification", "err", err)
 	} else {
 		gemRewards := []int{50, 30, 20}
 		for i, entry := range top3 {
 			if i < len(gemRewards) {
-				s.store.AwardGems(entry.UserID, gemRewards[i])
-				log.Printf("[gamification] weekly reset: awarded %d gems to user %d (rank %d)", gemRewards[i], entry.UserID, i+1)
+				st.AwardGems(entry.UserID, gemRewards[i], "weekly_top3")
+				topUserIDs = append(topUserIDs, entry.UserID)
+				slog.Info("weekly reset: awarded gems", "component", "gamification", "user_id", entry.UserID, "gems", gemRewards[i], "rank", i+1)
 			}
 		}
 	}
 
-	// 2. Process league changes
-	changes, err := s.store.ProcessLeagueChanges()
+	// 2. Process league changes for the week that just ended
+	changes, err := st.ProcessLeagueChanges(isoWeekStart(now).AddDate(0, 0, -7))
 	if err != nil {
-		log.Printf("[gamification] weekly reset: failed to process leagues: %v", err)
+		slog.Error("weekly reset: process leagues failed", "component", "gamification", "err", err)
 	} else {
 		for _, c := range changes {
-			log.Printf("[gamification] league change: user %d %s → %s", c.UserID, c.OldTier, c.NewTier)
+			slog.Info("league change", "component", "gamification", "user_id", c.UserID, "old_tier", c.OldTier, "new_tier", c.NewTier)
 			// Award gems for promotion
 			if isPromotion(c.OldTier, c.NewTier) {
-				s.store.AwardGems(c.UserID, 25)
+				st.AwardGems(c.UserID, 25, "league_promotion")
 				// Award league achievement
 				switch c.NewTier {
 				case models.LeagueSilver:
-					s.store.AwardAchievement(c.UserID, "league_silver")
+					st.AwardAchievement(c.UserID, "league_silver")
 				case models.LeagueGold:
-					s.store.AwardAchievement(c.UserID, "league_gold")
+					st.AwardAchievement(c.UserID, "league_gold")
 				case models.LeagueDiamond:
-					s.store.AwardAchievement(c.UserID, "league_diamond")
+					st.AwardAchievement(c.UserID, "league_diamond")
 				case models.LeagueObsidian:
-					s.store.AwardAchievement(c.UserID, "league_obsidian")
+					st.AwardAchievement(c.UserID, "league_obsidian")
 				}
 			}
 		}
 	}
 
 	// 3. Reset weekly XP
-	if err := s.store.ResetWeeklyXP(); err != nil {
-		log.Printf("[gamification] weekly reset: failed to reset XP: %v", err)
+	if err := st.ResetWeeklyXP(); err != nil {
+		slog.Error("weekly reset: reset XP failed", "component", "gamification", "err", err)
 	}
+
+	// Place active users into this week's league cohorts
+	if n, err := st.AssignLeagueCohorts(week); err != nil {
+		slog.Error("weekly reset: assign cohorts failed", "component", "gamification", "err", err)
+	} else {
+		slog.Info("weekly reset: assigned cohorts", "component", "gamification", "week", week, "users", n)
+	}
+
+	// 4. Record the outcome for auditing
+	if topUserIDs == nil {
+		topUserIDs = []int64{}
+	}
+	if err := st.CompleteWeeklyReset(week, topUserIDs, len(changes)); err != nil {
+		slog.Error("weekly reset: record failed", "component", "gamification", "week", week, "err", err)
+	}
+	return true
 }
 
 func isPromotion(old, new string) bool {
@@ -630,21 +1273,21 @@ func isPromotion(old, new string) bool {
 }
 
 func (s *Service) StartDailyStreakWorker(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := time.NewTicker(s.workerInterval)
 	defer ticker.Stop()
 
-	log.Println("[gamification] Daily streak worker started")
+	slog.Info("daily streak worker started", "component", "gamification")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("[gamification] Daily streak worker shutting down")
+			slog.Info("daily streak worker shutting down", "component", "gamification")
 			return
 		case t := <-ticker.C:
 			utc := t.UTC()
 			// Run at midnight UTC
 			if utc.Hour() == 0 {
-				log.Println("[gamification] Running daily streak check")
+				slog.Info("running daily streak check", "component", "gamification")
 				s.runDailyStreakCheck()
 			}
 		}
@@ -652,13 +1295,39 @@ func (s *Service) StartDailyStreakWorker(ctx context.Context) {
 }
 
 func (s *Service) runDailyStreakCheck() {
-	users, err := s.store.GetAllGamificationForStreakCheck()
+	if n, err := s.store.DeleteExpiredBoosts(); err != nil {
+		slog.Error("streak check: delete expired boosts failed", "component", "gamification", "err", err)
+	} else if n > 0 {
+		slog.Info("streak check: deleted expired boosts", "component", "gamification", "boosts", n)
+	}
+
+	if n, err := s.store.ExpireStaleFriendRequests(s.friendRequestTTL); err != nil {
+		slog.Error("streak check: expire friend requests failed", "component", "gamification", "err", err)
+	} else if n > 0 {
+		slog.Info("streak check: expired stale friend requests", "component", "gamification", "requests", n)
+	}
+
+	runStreakCheck(s.store, time.Now())
+}
+
+// streakCheckStore is the subset of Store used by the daily streak check.
+type streakCheckStore interface {
+	GetAllGamificationForStreakCheck() ([]models.UserGamification, error)
+	UpdateStreakData(userID int64, currentStreak, longestStreak int, freezeActive bool, freezesOwned int) error
+	BuyStreakFreeze(userID int64) error
+}
+
+// runStreakCheck activates a freeze for every user whose streak is at risk
+// (no activity since before yesterday). Users with auto_freeze on who own no
+// freeze get one bought first, if their gems allow.
+func runStreakCheck(st streakCheckStore, now time.Time) {
+	users, err := st.GetAllGamificationForStreakCheck()
 	if err != nil {
-		log.Printf("[gamification] streak check: failed to get users: %v", err)
+		slog.Error("streak check: get users failed", "component", "gamification", "err", err)
 		return
 	}
 
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+	today := now.UTC().Truncate(24 * time.Hour)
 	yesterday := today.AddDate(0, 0, -1)
 
 	for _, g := range users {
@@ -667,12 +1336,25 @@ func (s *Service) runDailyStreakCheck() {
 		}
 
 		lastActive := g.LastActiveDate.Truncate(24 * time.Hour)
+		if !lastActive.Before(yesterday) || g.StreakFreezeActive {
+			continue
+		}
+
+		// Auto-buy a freeze for opted-in users who have none
+		if g.StreakFreezesOwned == 0 && g.AutoFreeze && g.Gems >= streakFreezeCost {
+			if err := st.BuyStreakFreeze(g.UserID); err != nil {
+				slog.Warn("streak check: auto-buy freeze failed", "component", "gamification", "user_id", g.UserID, "err", err)
+				continue
+			}
+			g.StreakFreezesOwned++
+			slog.Info("streak check: auto-bought freeze", "component", "gamification", "user_id", g.UserID)
+		}
 
-		// If last active was before yesterday and has freezes, activate one
-		if lastActive.Before(yesterday) && g.StreakFreezesOwned > 0 && !g.StreakFreezeActive {
+		// Activate an owned freeze to cover the missed day
+		if g.StreakFreezesOwned > 0 {
 			g.StreakFreezeActive = true
-			s.store.UpdateStreakData(g.UserID, g.CurrentStreak, g.LongestStreak, true, g.StreakFreezesOwned)
-			log.Printf("[gamification] streak check: auto-activated freeze for user %d", g.UserID)
+			st.UpdateStreakData(g.UserID, g.CurrentStreak, g.LongestStreak, true, g.StreakFreezesOwned)
+			slog.Info("streak check: auto-activated freeze", "component", "gamification", "user_id", g.UserID)
 		}
 	}
 }
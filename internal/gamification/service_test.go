@@ -0,0 +1,840 @@
+package gamification
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// fakeResetStore records weekly-reset side effects in memory.
+type fakeResetStore struct {
+	claimed       map[string]bool
+	completed     map[string][]int64
+	leagueChanges map[string]int
+	gems          map[int64]int
+	awards        []gemAward
+	resets        int
+	leaderboard   []models.LeaderboardEntry
+	changes       []LeagueChange
+
+	processedWeeks []string
+	cohortWeeks    []string
+}
+
+// gemAward is one AwardGems call.
+type gemAward struct {
+	userID int64
+	amount int
+	reason string
+}
+
+func newFakeResetStore() *fakeResetStore {
+	return &fakeResetStore{
+		claimed:       map[string]bool{},
+		completed:     map[string][]int64{},
+		leagueChanges: map[string]int{},
+		gems:          map[int64]int{},
+	}
+}
+
+func (f *fakeResetStore) ClaimWeeklyReset(week string) (bool, error) {
+	if f.claimed[week] {
+		return false, nil
+	}
+	f.claimed[week] = true
+	return true, nil
+}
+
+func (f *fakeResetStore) CompleteWeeklyReset(week string, topUserIDs []int64, leagueChanges int) error {
+	f.completed[week] = topUserIDs
+	f.leagueChanges[week] = leagueChanges
+	return nil
+}
+
+func (f *fakeResetStore) GetGlobalLeaderboard(limit int) ([]models.LeaderboardEntry, error) {
+	if len(f.leaderboard) > limit {
+		return f.leaderboard[:limit], nil
+	}
+	return f.leaderboard, nil
+}
+
+func (f *fakeResetStore) AwardGems(userID int64, amount int, reason string) error {
+	f.gems[userID] += amount
+	f.awards = append(f.awards, gemAward{userID, amount, reason})
+	return nil
+}
+
+func (f *fakeResetStore) ProcessLeagueChanges(weekStart time.Time) ([]LeagueChange, error) {
+	f.processedWeeks = append(f.processedWeeks, isoWeekKey(weekStart))
+	return f.changes, nil
+}
+
+func (f *fakeResetStore) AssignLeagueCohorts(isoWeek string) (int, error) {
+	f.cohortWeeks = append(f.cohortWeeks, isoWeek)
+	return 0, nil
+}
+
+func (f *fakeResetStore) AwardAchievement(userID int64, achievement string) error {
+	return nil
+}
+
+func (f *fakeResetStore) ResetWeeklyXP() error {
+	f.resets++
+	return nil
+}
+
+func TestIsoWeekKey(t *testing.T) {
+	// 2027-01-01 is a Friday in ISO week 53 of 2026
+	if got := isoWeekKey(time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC)); got != "2026-W53" {
+		t.Errorf("isoWeekKey = %q, want 2026-W53", got)
+	}
+	if got := isoWeekKey(time.Date(2026, 10, 12, 0, 5, 0, 0, time.UTC)); got != "2026-W42" {
+		t.Errorf("isoWeekKey = %q, want 2026-W42", got)
+	}
+}
+
+func TestRunWeeklyReset_OncePerWeek(t *testing.T) {
+	st := newFakeResetStore()
+	st.leaderboard = []models.LeaderboardEntry{{UserID: 1}, {UserID: 2}, {UserID: 3}}
+	st.changes = []LeagueChange{{UserID: 2, OldTier: models.LeagueBronze, NewTier: models.LeagueSilver}}
+
+	monday := time.Date(2026, 10, 12, 0, 5, 0, 0, time.UTC)
+	if !runWeeklyReset(st, monday) {
+		t.Fatal("expected first reset of the week to run")
+	}
+
+	top := st.completed["2026-W42"]
+	if len(top) != 3 || top[0] != 1 || top[1] != 2 || top[2] != 3 {
+		t.Errorf("expected top users [1 2 3] logged, got %v", top)
+	}
+	if st.leagueChanges["2026-W42"] != 1 {
+		t.Errorf("expected 1 league change logged, got %d", st.leagueChanges["2026-W42"])
+	}
+	if st.gems[1] != 50 || st.gems[2] != 30+25 || st.gems[3] != 20 {
+		t.Errorf("unexpected gem awards: %v", st.gems)
+	}
+	if len(st.processedWeeks) != 1 || st.processedWeeks[0] != "2026-W41" {
+		t.Errorf("league changes processed for %v, want the ending week 2026-W41", st.processedWeeks)
+	}
+	if len(st.cohortWeeks) != 1 || st.cohortWeeks[0] != "2026-W42" {
+		t.Errorf("cohorts assigned for %v, want the new week 2026-W42", st.cohortWeeks)
+	}
+
+	// A second run later the same week must not award or reset again
+	if runWeeklyReset(st, monday.Add(40*time.Minute)) {
+		t.Error("expected second reset in the same week to be skipped")
+	}
+	if st.resets != 1 {
+		t.Errorf("expected weekly XP reset once, got %d", st.resets)
+	}
+	if st.gems[1] != 50 {
+		t.Errorf("expected no additional gems on second run, got %d", st.gems[1])
+	}
+
+	// The following week runs again
+	if !runWeeklyReset(st, monday.AddDate(0, 0, 7)) {
+		t.Error("expected reset to run in the next week")
+	}
+}
+
+func TestRunWeeklyReset_AwardsGemsWithReasons(t *testing.T) {
+	st := newFakeResetStore()
+	st.leaderboard = []models.LeaderboardEntry{{UserID: 1}, {UserID: 2}, {UserID: 3}}
+	st.changes = []LeagueChange{
+		{UserID: 1, OldTier: models.LeagueSilver, NewTier: models.LeagueGold},
+		{UserID: 4, OldTier: models.LeagueGold, NewTier: models.LeagueSilver},
+	}
+
+	runWeeklyReset(st, time.Date(2026, 10, 12, 0, 5, 0, 0, time.UTC))
+
+	// Top 3 by rank, then the promotion; user 4's demotion earns nothing
+	want := []gemAward{
+		{1, 50, "weekly_top3"},
+		{2, 30, "weekly_top3"},
+		{3, 20, "weekly_top3"},
+		{1, 25, "league_promotion"},
+	}
+	if len(st.awards) != len(want) {
+		t.Fatalf("awards = %+v, want %+v", st.awards, want)
+	}
+	for i, w := range want {
+		if st.awards[i] != w {
+			t.Errorf("award %d = %+v, want %+v", i, st.awards[i], w)
+		}
+	}
+}
+
+// fakeStreakStore applies streak-check writes to an in-memory user list.
+type fakeStreakStore struct {
+	users map[int64]*models.UserGamification
+}
+
+func (f *fakeStreakStore) GetAllGamificationForStreakCheck() ([]models.UserGamification, error) {
+	var out []models.UserGamification
+	for _, g := range f.users {
+		out = append(out, *g)
+	}
+	return out, nil
+}
+
+func (f *fakeStreakStore) UpdateStreakData(userID int64, currentStreak, longestStreak int, freezeActive bool, freezesOwned int) error {
+	g := f.users[userID]
+	g.CurrentStreak, g.LongestStreak = currentStreak, longestStreak
+	g.StreakFreezeActive, g.StreakFreezesOwned = freezeActive, freezesOwned
+	return nil
+}
+
+func (f *fakeStreakStore) BuyStreakFreeze(userID int64) error {
+	g := f.users[userID]
+	if g.Gems < streakFreezeCost || g.StreakFreezesOwned >= maxStreakFreezes {
+		return fmt.Errorf("insufficient gems or max freezes reached")
+	}
+	g.Gems -= streakFreezeCost
+	g.StreakFreezesOwned++
+	return nil
+}
+
+func TestRunStreakCheck_AutoFreezePreservesStreak(t *testing.T) {
+	now := time.Date(2026, 10, 15, 0, 5, 0, 0, time.UTC)
+	twoDaysAgo := now.Truncate(24*time.Hour).AddDate(0, 0, -2)
+	at := func(id int64, autoFreeze bool, gems int) *models.UserGamification {
+		return &models.UserGamification{
+			UserID: id, CurrentStreak: 12, LongestStreak: 12,
+			LastActiveDate: &twoDaysAgo, AutoFreeze: autoFreeze, Gems: gems,
+		}
+	}
+	st := &fakeStreakStore{users: map[int64]*models.UserGamification{
+		1: at(1, true, 80),  // opted in, can afford
+		2: at(2, false, 80), // not opted in
+		3: at(3, true, 20),  // opted in, too few gems
+	}}
+
+	runStreakCheck(st, now)
+
+	u1 := st.users[1]
+	if !u1.StreakFreezeActive || u1.StreakFreezesOwned != 1 {
+		t.Errorf("user 1: freeze active=%v owned=%d, want active with 1 owned", u1.StreakFreezeActive, u1.StreakFreezesOwned)
+	}
+	if u1.Gems != 80-streakFreezeCost {
+		t.Errorf("user 1: gems = %d, want %d", u1.Gems, 80-streakFreezeCost)
+	}
+	if u1.CurrentStreak != 12 {
+		t.Errorf("user 1: streak = %d, want 12", u1.CurrentStreak)
+	}
+
+	for _, id := range []int64{2, 3} {
+		if g := st.users[id]; g.StreakFreezeActive || g.StreakFreezesOwned != 0 {
+			t.Errorf("user %d: freeze should not be bought (active=%v owned=%d)", id, g.StreakFreezeActive, g.StreakFreezesOwned)
+		}
+	}
+	if st.users[2].Gems != 80 || st.users[3].Gems != 20 {
+		t.Errorf("gems changed for users without auto-freeze: %d, %d", st.users[2].Gems, st.users[3].Gems)
+	}
+
+	// A second run the same night doesn't buy another
+	runStreakCheck(st, now)
+	if u1.StreakFreezesOwned != 1 || u1.Gems != 80-streakFreezeCost {
+		t.Errorf("user 1 after rerun: owned=%d gems=%d", u1.StreakFreezesOwned, u1.Gems)
+	}
+}
+
+// fakeStateStore serves a fixed gamification row and nudge list.
+type fakeStateStore struct {
+	gam          *models.UserGamification
+	nudges       []models.NudgeEntry
+	nudgeFetches int
+}
+
+func (f *fakeStateStore) GetOrCreateGamification(userID int64) (*models.UserGamification, error) {
+	if f.gam != nil {
+		g := *f.gam
+		return &g, nil
+	}
+	return &models.UserGamification{UserID: userID, CurrentStreak: 4, Gems: 30}, nil
+}
+
+func (f *fakeStateStore) GetUserAchievements(userID int64) ([]string, error) {
+	return []string{"first_drill"}, nil
+}
+
+func (f *fakeStateStore) CountUnreadNudges(userID int64) (int, error) {
+	return len(f.nudges), nil
+}
+
+func (f *fakeStateStore) GetUnreadNudges(userID int64) ([]models.NudgeEntry, error) {
+	f.nudgeFetches++
+	return f.nudges, nil
+}
+
+func (f *fakeStateStore) GetActiveBoost(userID int64) (*models.XPBoost, error) {
+	return nil, nil
+}
+
+func TestLoadGamification_NudgesOnlyWhenRequested(t *testing.T) {
+	st := &fakeStateStore{}
+	for i := int64(1); i <= 7; i++ {
+		st.nudges = append(st.nudges, models.NudgeEntry{ID: i, SenderName: "friend", NudgeType: "encourage"})
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	lean, err := loadGamification(st, 1, false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lean.Nudges != nil {
+		t.Errorf("default response embedded %d nudges, want none", len(lean.Nudges))
+	}
+	if lean.UnreadNudges != 7 {
+		t.Errorf("unread count = %d, want 7", lean.UnreadNudges)
+	}
+	if st.nudgeFetches != 0 {
+		t.Error("default response should not load nudges")
+	}
+
+	full, err := loadGamification(st, 1, true, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(full.Nudges) != recentNudgeLimit {
+		t.Fatalf("embedded %d nudges, want %d", len(full.Nudges), recentNudgeLimit)
+	}
+	if full.Nudges[0].ID != 1 {
+		t.Errorf("first embedded nudge = %d, want the most recent (1)", full.Nudges[0].ID)
+	}
+	if full.UnreadNudges != 7 {
+		t.Errorf("unread count = %d, want 7 even when truncated", full.UnreadNudges)
+	}
+}
+
+func TestDropExpiredRequests(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	ttl := 30 * 24 * time.Hour
+	pending := []models.PendingFriendEntry{
+		{FriendshipID: 1, CreatedAt: now.Add(-time.Hour)},
+		{FriendshipID: 2, CreatedAt: now.Add(-31 * 24 * time.Hour)},
+		{FriendshipID: 3, CreatedAt: now.Add(-29 * 24 * time.Hour)},
+		{FriendshipID: 4, CreatedAt: now.Add(-90 * 24 * time.Hour)},
+	}
+
+	kept := dropExpiredRequests(pending, ttl, now)
+	if len(kept) != 2 || kept[0].FriendshipID != 1 || kept[1].FriendshipID != 3 {
+		t.Errorf("kept %v, want requests 1 and 3", kept)
+	}
+
+	if got := dropExpiredRequests(nil, ttl, now); got == nil || len(got) != 0 {
+		t.Errorf("nil input should give an empty, non-nil list; got %v", got)
+	}
+}
+
+func TestCountMutualFriends(t *testing.T) {
+	// Accepted friendships: 1-2, 1-3, 1-4, 5-2, 5-3, 5-6, 1-5
+	edges := [][2]int64{{1, 2}, {1, 3}, {1, 4}, {5, 2}, {5, 3}, {5, 6}, {1, 5}}
+	friendsOf := map[int64][]int64{}
+	for _, e := range edges {
+		friendsOf[e[0]] = append(friendsOf[e[0]], e[1])
+		friendsOf[e[1]] = append(friendsOf[e[1]], e[0])
+	}
+
+	tests := []struct {
+		a, b int64
+		want int
+	}{
+		{1, 5, 2}, // 2 and 3
+		{5, 1, 2},
+		{1, 6, 1}, // 5
+		{2, 3, 2}, // 1 and 5
+		{4, 6, 0},
+		{1, 99, 0},
+	}
+	for _, tt := range tests {
+		if got := countMutualFriends(friendsOf, tt.a, tt.b); got != tt.want {
+			t.Errorf("countMutualFriends(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// fakeBoardStore mirrors the global leaderboard queries over an in-memory
+// user list, including the opt-out filter.
+type fakeBoardStore struct {
+	users []models.UserGamification
+}
+
+func (f *fakeBoardStore) GetGlobalLeaderboard(limit int) ([]models.LeaderboardEntry, error) {
+	var entries []models.LeaderboardEntry
+	for _, g := range f.users {
+		if g.WeeklyXP > 0 && !g.LeaderboardOptOut {
+			entries = append(entries, models.LeaderboardEntry{UserID: g.UserID, WeeklyXP: g.WeeklyXP})
+		}
+	}
+	ranked := rankByWeeklyXP(entries, 0)
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
+
+func (f *fakeBoardStore) GetUserRank(userID int64) (int, error) {
+	var me *models.UserGamification
+	for i := range f.users {
+		if f.users[i].UserID == userID {
+			me = &f.users[i]
+		}
+	}
+	if me == nil || me.WeeklyXP == 0 {
+		return 0, nil
+	}
+	rank := 1
+	for _, o := range f.users {
+		if o.WeeklyXP > me.WeeklyXP && !o.LeaderboardOptOut {
+			rank++
+		}
+	}
+	return rank, nil
+}
+
+func (f *fakeBoardStore) GetOrCreateGamification(userID int64) (*models.UserGamification, error) {
+	for i := range f.users {
+		if f.users[i].UserID == userID {
+			return &f.users[i], nil
+		}
+	}
+	return &models.UserGamification{UserID: userID}, nil
+}
+
+func TestBuildGlobalLeaderboard_OptedOutUserHidden(t *testing.T) {
+	st := &fakeBoardStore{users: []models.UserGamification{
+		{UserID: 1, WeeklyXP: 500},
+		{UserID: 2, WeeklyXP: 900, LeaderboardOptOut: true},
+		{UserID: 3, WeeklyXP: 300},
+	}}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	resp, err := buildGlobalLeaderboard(st, 3, 20, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 2 {
+		t.Fatalf("board has %d entries, want 2", len(resp.Entries))
+	}
+	for _, e := range resp.Entries {
+		if e.UserID == 2 {
+			t.Error("opted-out user 2 should not be on the global board")
+		}
+	}
+	if resp.Entries[0].UserID != 1 || resp.Entries[0].Rank != 1 {
+		t.Errorf("top entry = user %d rank %d, want user 1 rank 1", resp.Entries[0].UserID, resp.Entries[0].Rank)
+	}
+
+	// The opted-out user still sees where they'd stand
+	own, err := buildGlobalLeaderboard(st, 2, 20, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own.CurrentUser == nil || own.CurrentUser.Rank != 1 {
+		t.Errorf("opted-out user's own rank = %+v, want rank 1", own.CurrentUser)
+	}
+}
+
+// fakeDrillResultStore keeps drill results in memory, newest first.
+type fakeDrillResultStore struct {
+	results []models.DrillResult
+}
+
+func (f *fakeDrillResultStore) RecordDrillResult(userID int64, r models.DrillResult) error {
+	r.ID = int64(len(f.results) + 1)
+	f.results = append([]models.DrillResult{r}, f.results...)
+	return nil
+}
+
+func (f *fakeDrillResultStore) GetDrillResults(userID int64, limit, offset int) ([]models.DrillResult, int, error) {
+	end := min(offset+limit, len(f.results))
+	if offset >= end {
+		return nil, len(f.results), nil
+	}
+	return f.results[offset:end], len(f.results), nil
+}
+
+func TestDrillHistory_CompletedDrillAppears(t *testing.T) {
+	st := &fakeDrillResultStore{}
+	empty, err := buildDrillHistory(st, 1, 1, 20)
+	if err != nil || empty.Drills == nil || len(empty.Drills) != 0 {
+		t.Fatalf("empty history = %+v, %v; want an empty list", empty, err)
+	}
+
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	answers, correctIDs := VerifyDrillAnswers([]int64{1, 2, 3}, []DrillAnswer{
+		{QuestionID: 1, Correct: true, AnsweredAt: start},
+		{QuestionID: 2, Correct: false, AnsweredAt: start.Add(time.Minute)},
+		{QuestionID: 3, Correct: true, AnsweredAt: start.Add(2 * time.Minute)},
+	})
+	st.RecordDrillResult(1, drillResultFor(answers, correctIDs, models.XPBreakdown{TotalXP: 42}))
+
+	resp, err := buildDrillHistory(st, 1, 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || len(resp.Drills) != 1 {
+		t.Fatalf("history has %d drills (total %d), want 1", len(resp.Drills), resp.Total)
+	}
+	d := resp.Drills[0]
+	if d.Correct != 2 || d.Total != 3 || d.Perfect || d.XPEarned != 42 {
+		t.Errorf("drill = %+v, want 2/3, not perfect, 42 XP", d)
+	}
+}
+
+func TestDrillHistory_HasMoreOnlyBeforeLastPage(t *testing.T) {
+	st := &fakeDrillResultStore{}
+	for i := 0; i < 5; i++ {
+		st.RecordDrillResult(1, models.DrillResult{Correct: i, Total: 5})
+	}
+
+	first, err := buildDrillHistory(st, 1, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.HasMore || first.TotalPages != 3 {
+		t.Errorf("page 1 of 5 drills = has_more %t, total_pages %d; want true, 3", first.HasMore, first.TotalPages)
+	}
+
+	last, err := buildDrillHistory(st, 1, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.HasMore || last.TotalPages != 3 || len(last.Drills) != 1 {
+		t.Errorf("last page = has_more %t, total_pages %d, %d drills; want false, 3, 1", last.HasMore, last.TotalPages, len(last.Drills))
+	}
+
+	empty, err := buildDrillHistory(&fakeDrillResultStore{}, 1, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.HasMore || empty.TotalPages != 0 {
+		t.Errorf("empty history = has_more %t, total_pages %d; want false, 0", empty.HasMore, empty.TotalPages)
+	}
+}
+
+// fakeCounterStore applies each column update atomically, like the
+// single-statement UPDATEs in Store.
+type fakeCounterStore struct {
+	mu                    sync.Mutex
+	drills, perfectDrills int
+	perfectStreak         int
+	goalProgress          int
+	goalTarget            int
+	goalSkips             int
+	gems                  map[string]int
+}
+
+func (f *fakeCounterStore) IncrementDrillCounters(userID int64, perfect bool) (int, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drills++
+	if perfect {
+		f.perfectDrills++
+		f.perfectStreak++
+	} else {
+		f.perfectStreak = 0
+	}
+	return f.drills, f.perfectStreak, nil
+}
+
+func (f *fakeCounterStore) AddDailyGoalProgress(userID int64, n int, today time.Time) (int, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.goalProgress += n
+	return f.goalProgress, f.goalTarget, nil
+}
+
+func (f *fakeCounterStore) AwardGems(userID int64, amount int, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gems[reason] += amount
+	return nil
+}
+
+func (f *fakeCounterStore) LogXPEvent(userID int64, eventType string, xpAmount int, metadata map[string]interface{}) error {
+	return nil
+}
+
+func (f *fakeCounterStore) UseGoalSkip(userID int64, today time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.goalProgress >= f.goalTarget {
+		return 0, fmt.Errorf("daily goal already complete")
+	}
+	if f.goalSkips == 0 {
+		return 0, fmt.Errorf("no %s in inventory", goalSkipItem)
+	}
+	f.goalSkips--
+	f.goalProgress = f.goalTarget
+	return f.goalTarget, nil
+}
+
+func TestSkipDailyGoal_CompletesGoalOnce(t *testing.T) {
+	st := &fakeCounterStore{goalTarget: 6, goalProgress: 2, goalSkips: 2, gems: map[string]int{}}
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+
+	goal, err := skipDailyGoal(st, 1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !goal.Completed || goal.Progress != 6 || st.gems["daily_goal"] != dailyGoalGems {
+		t.Errorf("goal %+v, gems %v; want the goal complete and its reward paid", *goal, st.gems)
+	}
+
+	// A completed goal can't be skipped again, and keeps the skip
+	if _, err := skipDailyGoal(st, 1, now); err == nil || err.Error() != "daily goal already complete" {
+		t.Errorf("second skip: got %v, want daily goal already complete", err)
+	}
+	// Answering past the target doesn't pay twice
+	advanceDailyGoal(st, 1, 1, now)
+	if st.goalSkips != 1 || st.gems["daily_goal"] != dailyGoalGems {
+		t.Errorf("skips left %d, goal gems %d; want 1 and one reward", st.goalSkips, st.gems["daily_goal"])
+	}
+
+	st = &fakeCounterStore{goalTarget: 6, gems: map[string]int{}}
+	if _, err := skipDailyGoal(st, 1, now); err == nil || !strings.Contains(err.Error(), "no daily_goal_skip") {
+		t.Errorf("no skips owned: got %v, want an inventory error", err)
+	}
+	if st.gems["daily_goal"] != 0 {
+		t.Errorf("failed skip paid %d gems", st.gems["daily_goal"])
+	}
+}
+
+func TestConcurrentCounters_NoLostUpdates(t *testing.T) {
+	st := &fakeCounterStore{goalTarget: 6, gems: map[string]int{}}
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	// No streak bonus, so perfect-drill gems don't depend on interleaving
+	cfg := DefaultGemConfig()
+	cfg.PerfectStreakStep = 0
+
+	const drills, answers = 20, 40
+	var wg sync.WaitGroup
+	for i := 0; i < drills; i++ {
+		wg.Add(1)
+		go func(perfect bool) {
+			defer wg.Done()
+			recordDrillCompletion(st, cfg, 1, perfect)
+		}(i%2 == 0)
+	}
+	for i := 0; i < answers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			advanceDailyGoal(st, 1, 1, now)
+		}()
+	}
+	wg.Wait()
+
+	if st.drills != drills || st.perfectDrills != drills/2 {
+		t.Errorf("drills = %d (perfect %d), want %d (%d)", st.drills, st.perfectDrills, drills, drills/2)
+	}
+	if st.goalProgress != answers {
+		t.Errorf("daily goal progress = %d, want %d", st.goalProgress, answers)
+	}
+	if st.gems["first_drill"] != 50 {
+		t.Errorf("first drill gems = %d, want 50 exactly once", st.gems["first_drill"])
+	}
+	if st.gems["perfect_drill"] != 10*drills/2 {
+		t.Errorf("perfect drill gems = %d, want %d", st.gems["perfect_drill"], 10*drills/2)
+	}
+	if st.gems["daily_goal"] != dailyGoalGems {
+		t.Errorf("daily goal gems = %d, want %d exactly once", st.gems["daily_goal"], dailyGoalGems)
+	}
+}
+
+func TestBuildStatus_MatchesFullDayRollover(t *testing.T) {
+	now := time.Date(2026, 10, 15, 0, 30, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+
+	for _, goalDate := range []time.Time{yesterday, now} {
+		gam := &models.UserGamification{
+			CurrentStreak: 9, LastActiveDate: &yesterday,
+			DailyGoalTarget: 6, DailyGoalProgress: 8, DailyGoalDate: goalDate,
+		}
+		full, err := loadGamification(&fakeStateStore{gam: gam}, 1, false, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := buildStatus(&StatusSummary{
+			CurrentStreak: gam.CurrentStreak, LastActiveDate: gam.LastActiveDate,
+			DailyGoalTarget: gam.DailyGoalTarget, DailyGoalProgress: gam.DailyGoalProgress, DailyGoalDate: gam.DailyGoalDate,
+		}, now)
+
+		if status.DailyGoalProgress != full.DailyGoalProgress || status.DailyGoalTarget != full.DailyGoalTarget ||
+			status.CurrentStreak != full.CurrentStreak {
+			t.Errorf("goal date %s: status %+v disagrees with full response (progress %d, target %d, streak %d)",
+				goalDate.Format("2006-01-02"), status, full.DailyGoalProgress, full.DailyGoalTarget, full.CurrentStreak)
+		}
+		wantDone := goalDate.Equal(now)
+		if status.DailyGoalCompleted != wantDone {
+			t.Errorf("goal date %s: completed = %v, want %v", goalDate.Format("2006-01-02"), status.DailyGoalCompleted, wantDone)
+		}
+		if status.ActiveToday {
+			t.Error("last active yesterday should not count as active today")
+		}
+	}
+}
+
+func TestRecordDrillCompletion_GemBonuses(t *testing.T) {
+	cfg := GemConfig{FirstDrill: 40, PerfectDrill: 8, PerfectStreakStep: 4, PerfectStreakMax: 10}
+	st := &fakeCounterStore{gems: map[string]int{}}
+
+	// perfect, perfect, perfect, perfect, miss, perfect
+	results := []bool{true, true, true, true, false, true}
+	want := []int{40 + 8, 8 + 4, 8 + 8, 8 + 10, 0, 8}
+	for i, perfect := range results {
+		if got := recordDrillCompletion(st, cfg, 1, perfect); got != want[i] {
+			t.Errorf("drill %d (perfect=%v): gems = %d, want %d", i+1, perfect, got, want[i])
+		}
+	}
+	if st.gems["first_drill"] != 40 {
+		t.Errorf("first drill gems = %d, want 40 exactly once", st.gems["first_drill"])
+	}
+}
+
+func TestNewService_WorkerInterval(t *testing.T) {
+	t.Setenv("GAMIFICATION_WORKER_INTERVAL", "")
+	if got := NewService(nil, XPConfig{}, GemConfig{}).workerInterval; got != time.Hour {
+		t.Errorf("default interval = %s, want 1h", got)
+	}
+	t.Setenv("GAMIFICATION_WORKER_INTERVAL", "5m")
+	if got := NewService(nil, XPConfig{}, GemConfig{}).workerInterval; got != 5*time.Minute {
+		t.Errorf("interval = %s, want 5m", got)
+	}
+	t.Setenv("GAMIFICATION_WORKER_INTERVAL", "-1s")
+	if got := NewService(nil, XPConfig{}, GemConfig{}).workerInterval; got != time.Hour {
+		t.Errorf("negative interval = %s, want default 1h", got)
+	}
+}
+
+// fakeDrillSessionStore serves drills and history answers from memory.
+type fakeDrillSessionStore struct {
+	drills  map[int64]*DrillSession
+	owners  map[int64]int64
+	answers []DrillAnswer
+}
+
+func (f *fakeDrillSessionStore) GetDrillSession(userID, drillID int64) (*DrillSession, error) {
+	d, ok := f.drills[drillID]
+	if !ok || f.owners[drillID] != userID {
+		return nil, sql.ErrNoRows
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (f *fakeDrillSessionStore) GetDrillAnswers(userID int64, questionIDs []int64, since time.Time) ([]DrillAnswer, error) {
+	want := map[int64]bool{}
+	for _, id := range questionIDs {
+		want[id] = true
+	}
+	var out []DrillAnswer
+	for _, a := range f.answers {
+		if want[a.QuestionID] && !a.AnsweredAt.Before(since) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDrillSessionStore) CompleteDrillSession(userID, drillID int64) (bool, error) {
+	d := f.drills[drillID]
+	if d.CompletedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	d.CompletedAt = &now
+	return true, nil
+}
+
+func TestClaimDrill_RequiresServedDrillAnsweredInFull(t *testing.T) {
+	served := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	st := &fakeDrillSessionStore{
+		drills: map[int64]*DrillSession{5: {ID: 5, QuestionIDs: []int64{1, 2, 3}, CreatedAt: served}},
+		owners: map[int64]int64{5: 1},
+		answers: []DrillAnswer{
+			{QuestionID: 1, Correct: true, AnsweredAt: served.Add(time.Minute)},
+			{QuestionID: 2, Correct: true, AnsweredAt: served.Add(2 * time.Minute)},
+			// Answered before this drill was served
+			{QuestionID: 3, Correct: true, AnsweredAt: served.Add(-time.Hour)},
+		},
+	}
+	now := served.Add(10 * time.Minute)
+
+	if _, _, _, err := claimDrill(st, 1, 5, now); !errors.Is(err, ErrDrillIncomplete) {
+		t.Fatalf("skipping question 3: err = %v, want ErrDrillIncomplete", err)
+	}
+	if st.drills[5].CompletedAt != nil {
+		t.Fatal("an incomplete drill should not be marked completed")
+	}
+
+	st.answers = append(st.answers, DrillAnswer{QuestionID: 3, Correct: false, AnsweredAt: served.Add(3 * time.Minute)})
+	_, answers, correctIDs, err := claimDrill(st, 1, 5, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 3 || len(correctIDs) != 2 {
+		t.Errorf("got %d answers / %d correct, want 3 / 2", len(answers), len(correctIDs))
+	}
+
+	if _, _, _, err := claimDrill(st, 1, 5, now); !errors.Is(err, ErrDrillCompleted) {
+		t.Errorf("second completion: err = %v, want ErrDrillCompleted", err)
+	}
+	if _, _, _, err := claimDrill(st, 2, 5, now); !errors.Is(err, ErrDrillNotFound) {
+		t.Errorf("another user's drill: err = %v, want ErrDrillNotFound", err)
+	}
+	if _, _, _, err := claimDrill(st, 1, 99, now); !errors.Is(err, ErrDrillNotFound) {
+		t.Errorf("unserved drill: err = %v, want ErrDrillNotFound", err)
+	}
+
+	st.drills[6] = &DrillSession{ID: 6, QuestionIDs: []int64{1}, CreatedAt: served}
+	st.owners[6] = 1
+	if _, _, _, err := claimDrill(st, 1, 6, served.Add(drillSessionTTL+time.Minute)); !errors.Is(err, ErrDrillNotFound) {
+		t.Errorf("expired drill: err = %v, want ErrDrillNotFound", err)
+	}
+}
+
+func TestClaimDrill_ComboCountsOnlyTheDrillsAnswers(t *testing.T) {
+	served := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return served.Add(time.Duration(min) * time.Minute) }
+	st := &fakeDrillSessionStore{
+		drills: map[int64]*DrillSession{
+			5: {ID: 5, QuestionIDs: []int64{1, 2, 3, 4}, CreatedAt: served},
+			6: {ID: 6, QuestionIDs: []int64{10, 11}, CreatedAt: served},
+		},
+		owners: map[int64]int64{5: 1, 6: 1},
+		// Drill 5 goes ✓ ✓ ✓ ✓, interleaved with misses in drill 6 and
+		// in practice (question 20)
+		answers: []DrillAnswer{
+			{QuestionID: 1, Correct: true, AnsweredAt: at(1)},
+			{QuestionID: 10, Correct: false, AnsweredAt: at(2)},
+			{QuestionID: 2, Correct: true, AnsweredAt: at(3)},
+			{QuestionID: 20, Correct: false, AnsweredAt: at(4)},
+			{QuestionID: 3, Correct: true, AnsweredAt: at(5)},
+			{QuestionID: 11, Correct: true, AnsweredAt: at(6)},
+			{QuestionID: 4, Correct: true, AnsweredAt: at(7)},
+		},
+	}
+
+	_, answers, _, err := claimDrill(st, 1, 5, at(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := MaxCombo(answers); got != 4 {
+		t.Errorf("drill 5 combo = %d, want 4", got)
+	}
+	_, answers, _, err = claimDrill(st, 1, 6, at(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := MaxCombo(answers); got != 1 {
+		t.Errorf("drill 6 combo = %d, want 1", got)
+	}
+}
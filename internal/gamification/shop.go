@@ -0,0 +1,71 @@
+package gamification
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// Streak freezes cost streakFreezeCost gems; a user may own at most
+// maxStreakFreezes at a time.
+const (
+	streakFreezeCost = 50
+	maxStreakFreezes = 3
+)
+
+// ShopItemDef defines an item that can be bought with gems. Items with a
+// BoostMultiplier are XP boosts, activated later from the inventory; goal
+// skips are used through UseGoalSkip.
+type ShopItemDef struct {
+	Name            string
+	Description     string
+	Cost            int
+	MaxOwned        int
+	BoostMultiplier float64
+	BoostMinutes    int
+}
+
+// ShopItems maps shop item keys to their definitions. Streak freezes are sold
+// separately through BuyStreakFreeze.
+var ShopItems = map[string]ShopItemDef{
+	goalSkipItem:  {Name: "Goal Skip", Description: "Count today's daily goal as complete", Cost: 30, MaxOwned: 3},
+	"xp_boost_2x": {Name: "Double XP", Description: "2x XP for 30 minutes", Cost: 60, MaxOwned: 3, BoostMultiplier: 2.0, BoostMinutes: 30},
+}
+
+// goalSkipItem is the shop item UseGoalSkip consumes.
+const goalSkipItem = "daily_goal_skip"
+
+// checkPurchase reports why a user with gems and owned copies of the item
+// cannot buy another, or nil if they can.
+func checkPurchase(item ShopItemDef, gems, owned int) error {
+	if owned >= item.MaxOwned {
+		return fmt.Errorf("already own the maximum of %s (%d)", item.Name, item.MaxOwned)
+	}
+	if gems < item.Cost {
+		return fmt.Errorf("not enough gems (need %d, have %d)", item.Cost, gems)
+	}
+	return nil
+}
+
+// shopCatalog lists shop items with the user's owned quantities, cheapest first.
+func shopCatalog(inventory map[string]int) []models.ShopItem {
+	items := make([]models.ShopItem, 0, len(ShopItems))
+	for id, def := range ShopItems {
+		items = append(items, models.ShopItem{
+			ID:          id,
+			Name:        def.Name,
+			Description: def.Description,
+			Cost:        def.Cost,
+			MaxOwned:    def.MaxOwned,
+			Owned:       inventory[id],
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Cost != items[j].Cost {
+			return items[i].Cost < items[j].Cost
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
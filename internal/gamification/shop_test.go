@@ -0,0 +1,48 @@
+package gamification
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPurchase(t *testing.T) {
+	item := ShopItems["daily_goal_skip"]
+
+	if err := checkPurchase(item, item.Cost, 0); err != nil {
+		t.Errorf("expected purchase with exact balance to succeed, got %v", err)
+	}
+	if err := checkPurchase(item, 500, item.MaxOwned-1); err != nil {
+		t.Errorf("expected purchase below max owned to succeed, got %v", err)
+	}
+
+	err := checkPurchase(item, item.Cost-1, 0)
+	if err == nil || !strings.Contains(err.Error(), "not enough gems") {
+		t.Errorf("expected insufficient gems error, got %v", err)
+	}
+
+	err = checkPurchase(item, 500, item.MaxOwned)
+	if err == nil || !strings.Contains(err.Error(), "maximum") {
+		t.Errorf("expected max owned error, got %v", err)
+	}
+}
+
+func TestShopCatalog(t *testing.T) {
+	items := shopCatalog(map[string]int{"xp_boost_2x": 1})
+	if len(items) != len(ShopItems) {
+		t.Fatalf("expected %d items, got %d", len(ShopItems), len(items))
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i].Cost < items[i-1].Cost {
+			t.Errorf("catalog not sorted by cost: %v", items)
+		}
+	}
+	for _, item := range items {
+		want := 0
+		if item.ID == "xp_boost_2x" {
+			want = 1
+		}
+		if item.Owned != want {
+			t.Errorf("%s: owned = %d, want %d", item.ID, item.Owned, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/lsat-prep/backend/internal/models"
 )
 
@@ -34,7 +35,7 @@ func (s *Store) GetOrCreateGamification(userID int64) (*models.UserGamification,
 	err = s.db.QueryRow(
 		`SELECT user_id, total_xp, weekly_xp, weekly_xp_reset_at,
 		        current_streak, longest_streak, last_active_date,
-		        streak_freeze_active, streak_freezes_owned, gems,
+		        streak_freeze_active, streak_freezes_owned, auto_freeze, leaderboard_opt_out, gems,
 		        daily_goal_target, daily_goal_progress, daily_goal_date,
 		        league_tier, questions_answered_total, questions_correct_total,
 		        drills_completed_total, perfect_drills_total,
@@ -43,7 +44,7 @@ func (s *Store) GetOrCreateGamification(userID int64) (*models.UserGamification,
 		userID,
 	).Scan(&g.UserID, &g.TotalXP, &g.WeeklyXP, &g.WeeklyXPResetAt,
 		&g.CurrentStreak, &g.LongestStreak, &g.LastActiveDate,
-		&g.StreakFreezeActive, &g.StreakFreezesOwned, &g.Gems,
+		&g.StreakFreezeActive, &g.StreakFreezesOwned, &g.AutoFreeze, &g.LeaderboardOptOut, &g.Gems,
 		&g.DailyGoalTarget, &g.DailyGoalProgress, &g.DailyGoalDate,
 		&g.LeagueTier, &g.QuestionsAnsweredTotal, &g.QuestionsCorrectTotal,
 		&g.DrillsCompletedTotal, &g.PerfectDrillsTotal,
@@ -54,25 +55,155 @@ func (s *Store) GetOrCreateGamification(userID int64) (*models.UserGamification,
 	return &g, nil
 }
 
-func (s *Store) UpdateGamification(userID int64, g *models.UserGamification) error {
-	_, err := s.db.Exec(
+// RecordStreakActivity sets the streak state for the user's first activity
+// on day, consuming freezesUsed freezes. It reports false, changing nothing,
+// if activity on day was already recorded by a concurrent request.
+func (s *Store) RecordStreakActivity(userID int64, day time.Time, currentStreak int, freezeActive bool, freezesUsed int) (bool, error) {
+	result, err := s.db.Exec(
 		`UPDATE user_gamification SET
-		    total_xp = $2, weekly_xp = $3,
-		    current_streak = $4, longest_streak = $5, last_active_date = $6,
-		    streak_freeze_active = $7, streak_freezes_owned = $8, gems = $9,
-		    daily_goal_target = $10, daily_goal_progress = $11, daily_goal_date = $12,
-		    league_tier = $13, questions_answered_total = $14, questions_correct_total = $15,
-		    drills_completed_total = $16, perfect_drills_total = $17,
+		    current_streak = $3,
+		    longest_streak = GREATEST(longest_streak, $3),
+		    last_active_date = $2,
+		    streak_freeze_active = $4,
+		    streak_freezes_owned = GREATEST(streak_freezes_owned - $5, 0),
 		    updated_at = NOW()
-		 WHERE user_id = $1`,
-		userID, g.TotalXP, g.WeeklyXP,
-		g.CurrentStreak, g.LongestStreak, g.LastActiveDate,
-		g.StreakFreezeActive, g.StreakFreezesOwned, g.Gems,
-		g.DailyGoalTarget, g.DailyGoalProgress, g.DailyGoalDate,
-		g.LeagueTier, g.QuestionsAnsweredTotal, g.QuestionsCorrectTotal,
-		g.DrillsCompletedTotal, g.PerfectDrillsTotal,
+		 WHERE user_id = $1 AND last_active_date IS DISTINCT FROM $2`,
+		userID, day.Format("2006-01-02"), currentStreak, freezeActive, freezesUsed,
 	)
-	return err
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// AddDailyGoalProgress adds n to today's goal progress, restarting it on a
+// new day, and returns the new progress and the target.
+func (s *Store) AddDailyGoalProgress(userID int64, n int, today time.Time) (progress, target int, err error) {
+	err = s.db.QueryRow(
+		`UPDATE user_gamification SET
+		    daily_goal_progress = CASE WHEN daily_goal_date = $3
+		        THEN daily_goal_progress + $2 ELSE $2 END,
+		    daily_goal_date = $3,
+		    updated_at = NOW()
+		 WHERE user_id = $1
+		 RETURNING daily_goal_progress, daily_goal_target`,
+		userID, n, today.Format("2006-01-02"),
+	).Scan(&progress, &target)
+	return progress, target, err
+}
+
+// UseGoalSkip consumes a goal skip from the user's inventory and sets today's
+// goal progress to the target, in one transaction. It fails without using
+// the skip if today's goal is already complete.
+func (s *Store) UseGoalSkip(userID int64, today time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	day := today.Format("2006-01-02")
+	var progress, target int
+	err = tx.QueryRow(
+		`SELECT CASE WHEN daily_goal_date = $2 THEN daily_goal_progress ELSE 0 END, daily_goal_target
+		 FROM user_gamification WHERE user_id = $1
+		 FOR UPDATE`,
+		userID, day,
+	).Scan(&progress, &target)
+	if err != nil {
+		return 0, fmt.Errorf("get daily goal: %w", err)
+	}
+	if progress >= target {
+		return 0, fmt.Errorf("daily goal already complete")
+	}
+
+	result, err := tx.Exec(
+		`UPDATE inventory SET quantity = quantity - 1, updated_at = NOW()
+		 WHERE user_id = $1 AND item_id = $2 AND quantity > 0`,
+		userID, goalSkipItem,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("consume goal skip: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return 0, fmt.Errorf("no %s in inventory", goalSkipItem)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE user_gamification SET daily_goal_progress = daily_goal_target, daily_goal_date = $2, updated_at = NOW()
+		 WHERE user_id = $1`,
+		userID, day,
+	); err != nil {
+		return 0, fmt.Errorf("complete daily goal: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit goal skip: %w", err)
+	}
+	return target, nil
+}
+
+// IncrementDrillCounters counts a completed drill and returns the user's new
+// drills-completed total and consecutive-perfect-drill count.
+func (s *Store) IncrementDrillCounters(userID int64, perfect bool) (drillsTotal, perfectStreak int, err error) {
+	perfectInc := 0
+	if perfect {
+		perfectInc = 1
+	}
+	err = s.db.QueryRow(
+		`UPDATE user_gamification SET
+		    drills_completed_total = drills_completed_total + 1,
+		    perfect_drills_total = perfect_drills_total + $2,
+		    perfect_drill_streak = CASE WHEN $2 = 1 THEN perfect_drill_streak + 1 ELSE 0 END,
+		    updated_at = NOW()
+		 WHERE user_id = $1
+		 RETURNING drills_completed_total, perfect_drill_streak`,
+		userID, perfectInc,
+	).Scan(&drillsTotal, &perfectStreak)
+	return drillsTotal, perfectStreak, err
+}
+
+// StatusSummary is the streak and daily-goal state behind GET /users/status.
+// DailyGoalProgress is as stored; it belongs to DailyGoalDate.
+type StatusSummary struct {
+	CurrentStreak      int
+	StreakFreezeActive bool
+	LastActiveDate     *time.Time
+	DailyGoalTarget    int
+	DailyGoalProgress  int
+	DailyGoalDate      time.Time
+}
+
+// GetStatusSummary reads just the streak and daily-goal columns. Users
+// without a gamification row get it created with defaults.
+func (s *Store) GetStatusSummary(userID int64) (*StatusSummary, error) {
+	var sum StatusSummary
+	err := s.db.QueryRow(
+		`SELECT current_streak, streak_freeze_active, last_active_date,
+		        daily_goal_target, daily_goal_progress, daily_goal_date
+		 FROM user_gamification WHERE user_id = $1`,
+		userID,
+	).Scan(&sum.CurrentStreak, &sum.StreakFreezeActive, &sum.LastActiveDate,
+		&sum.DailyGoalTarget, &sum.DailyGoalProgress, &sum.DailyGoalDate)
+	if err == sql.ErrNoRows {
+		gam, err := s.GetOrCreateGamification(userID)
+		if err != nil {
+			return nil, err
+		}
+		return &StatusSummary{
+			CurrentStreak:      gam.CurrentStreak,
+			StreakFreezeActive: gam.StreakFreezeActive,
+			LastActiveDate:     gam.LastActiveDate,
+			DailyGoalTarget:    gam.DailyGoalTarget,
+			DailyGoalProgress:  gam.DailyGoalProgress,
+			DailyGoalDate:      gam.DailyGoalDate,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get status summary: %w", err)
+	}
+	return &sum, nil
 }
 
 func (s *Store) IncrementCounters(userID int64, correct bool) error {
@@ -122,16 +253,132 @@ func (s *Store) LogXPEvent(userID int64, eventType string, xpAmount int, metadat
 	return err
 }
 
+// SumQuestionXP totals the per-question XP the user earned for questionIDs
+// since since.
+func (s *Store) SumQuestionXP(userID int64, questionIDs []int64, since time.Time) (int, error) {
+	if len(questionIDs) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, len(questionIDs))
+	args := []interface{}{userID, since}
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
+		args = append(args, id)
+	}
+
+	var sum int
+	err := s.db.QueryRow(fmt.Sprintf(
+		`SELECT COALESCE(SUM(xp_amount), 0) FROM xp_events
+		 WHERE user_id = $1 AND event_type = 'question_correct'
+		   AND created_at >= $2
+		   AND (metadata->>'question_id')::BIGINT IN (%s)`,
+		strings.Join(placeholders, ", ")),
+		args...,
+	).Scan(&sum)
+	if err != nil {
+		return 0, fmt.Errorf("sum question xp: %w", err)
+	}
+	return sum, nil
+}
+
+// DrillAnswer is a user's recorded answer to one question in a drill.
+type DrillAnswer struct {
+	QuestionID int64
+	Correct    bool
+	AnsweredAt time.Time
+}
+
+// GetDrillAnswers returns the user's answers to questionIDs recorded since
+// since, oldest first.
+func (s *Store) GetDrillAnswers(userID int64, questionIDs []int64, since time.Time) ([]DrillAnswer, error) {
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(questionIDs))
+	args := []interface{}{userID, since}
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT question_id, correct, answered_at FROM user_question_history
+		 WHERE user_id = $1 AND answered_at >= $2
+		   AND question_id IN (%s)
+		 ORDER BY answered_at`,
+		strings.Join(placeholders, ", ")),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get drill answers: %w", err)
+	}
+	defer rows.Close()
+
+	var answers []DrillAnswer
+	for rows.Next() {
+		var a DrillAnswer
+		if err := rows.Scan(&a.QuestionID, &a.Correct, &a.AnsweredAt); err != nil {
+			return nil, err
+		}
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// DrillSession is a drill as the server served it.
+type DrillSession struct {
+	ID          int64
+	QuestionIDs []int64
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// GetDrillSession returns the user's drill drillID, or sql.ErrNoRows if the
+// user was never served it.
+func (s *Store) GetDrillSession(userID, drillID int64) (*DrillSession, error) {
+	var d DrillSession
+	var ids pq.Int64Array
+	err := s.db.QueryRow(
+		`SELECT id, question_ids, created_at, completed_at FROM drill_sessions
+		 WHERE id = $1 AND user_id = $2`,
+		drillID, userID,
+	).Scan(&d.ID, &ids, &d.CreatedAt, &d.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.QuestionIDs = ids
+	return &d, nil
+}
+
+// CompleteDrillSession marks the user's drill completed. It reports false,
+// changing nothing, if the drill was already completed.
+func (s *Store) CompleteDrillSession(userID, drillID int64) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE drill_sessions SET completed_at = NOW()
+		 WHERE id = $1 AND user_id = $2 AND completed_at IS NULL`,
+		drillID, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("complete drill session: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
 // ── Leaderboard ─────────────────────────────────────────
 
+// weeklyRank ranks leaderboard rows by weekly XP. Ties share a rank, so a
+// user's rank is the same on the board and in GetUserRank.
+const weeklyRank = `RANK() OVER (ORDER BY g.weekly_xp DESC)`
+
 func (s *Store) GetGlobalLeaderboard(limit int) ([]models.LeaderboardEntry, error) {
 	rows, err := s.db.Query(
 		`SELECT u.id, u.name, COALESCE(u.username, ''), g.weekly_xp, g.league_tier, g.current_streak,
-		        ROW_NUMBER() OVER (ORDER BY g.weekly_xp DESC) as rank
+		        `+weeklyRank+` as rank
 		 FROM user_gamification g
 		 JOIN users u ON u.id = g.user_id
-		 WHERE g.weekly_xp > 0
-		 ORDER BY g.weekly_xp DESC
+		 WHERE g.weekly_xp > 0 AND NOT g.leaderboard_opt_out
+		 ORDER BY g.weekly_xp DESC, u.id
 		 LIMIT $1`,
 		limit,
 	)
@@ -146,7 +393,7 @@ func (s *Store) GetGlobalLeaderboard(limit int) ([]models.LeaderboardEntry, erro
 func (s *Store) GetFriendsLeaderboard(userID int64) ([]models.LeaderboardEntry, error) {
 	rows, err := s.db.Query(
 		`SELECT u.id, u.name, COALESCE(u.username, ''), g.weekly_xp, g.league_tier, g.current_streak,
-		        ROW_NUMBER() OVER (ORDER BY g.weekly_xp DESC) as rank
+		        `+weeklyRank+` as rank
 		 FROM user_gamification g
 		 JOIN users u ON u.id = g.user_id
 		 WHERE g.user_id IN (
@@ -156,7 +403,7 @@ func (s *Store) GetFriendsLeaderboard(userID int64) ([]models.LeaderboardEntry,
 		     UNION
 		     SELECT $1
 		 )
-		 ORDER BY g.weekly_xp DESC`,
+		 ORDER BY g.weekly_xp DESC, u.id`,
 		userID,
 	)
 	if err != nil {
@@ -181,21 +428,62 @@ func scanLeaderboard(rows *sql.Rows) ([]models.LeaderboardEntry, error) {
 	return entries, rows.Err()
 }
 
+// GetUserRank returns the user's position on the global leaderboard, or 0 if
+// they have no weekly XP. Opted-out users get the rank they would hold among
+// visible users, so they can still see where they stand.
 func (s *Store) GetUserRank(userID int64) (int, error) {
 	var rank int
 	err := s.db.QueryRow(
-		`SELECT COALESCE(
-		    (SELECT rank FROM (
-		        SELECT user_id, ROW_NUMBER() OVER (ORDER BY weekly_xp DESC) as rank
-		        FROM user_gamification WHERE weekly_xp > 0
-		    ) r WHERE r.user_id = $1),
-		    0
-		)`,
+		`SELECT r.rank FROM (
+		    SELECT g.user_id, `+weeklyRank+` as rank
+		    FROM user_gamification g
+		    WHERE g.weekly_xp > 0 AND (NOT g.leaderboard_opt_out OR g.user_id = $1)
+		 ) r
+		 WHERE r.user_id = $1`,
 		userID,
 	).Scan(&rank)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
 	return rank, err
 }
 
+func (s *Store) SetLeaderboardOptOut(userID int64, optOut bool) error {
+	_, err := s.db.Exec(
+		`UPDATE user_gamification SET leaderboard_opt_out = $2, updated_at = NOW() WHERE user_id = $1`,
+		userID, optOut,
+	)
+	return err
+}
+
+// GetNotificationPrefs returns the user's notification toggles, all on if
+// they've never changed them.
+func (s *Store) GetNotificationPrefs(userID int64) (*models.NotificationPrefs, error) {
+	prefs := models.NotificationPrefs{Nudges: true, Achievements: true, Streaks: true}
+	err := s.db.QueryRow(
+		`SELECT nudges, achievements, streaks FROM notification_prefs WHERE user_id = $1`,
+		userID,
+	).Scan(&prefs.Nudges, &prefs.Achievements, &prefs.Streaks)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("get notification prefs: %w", err)
+	}
+	return &prefs, nil
+}
+
+func (s *Store) SetNotificationPrefs(userID int64, prefs models.NotificationPrefs) error {
+	_, err := s.db.Exec(
+		`INSERT INTO notification_prefs (user_id, nudges, achievements, streaks)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id) DO UPDATE SET
+		    nudges = $2, achievements = $3, streaks = $4, updated_at = NOW()`,
+		userID, prefs.Nudges, prefs.Achievements, prefs.Streaks,
+	)
+	if err != nil {
+		return fmt.Errorf("set notification prefs: %w", err)
+	}
+	return nil
+}
+
 func (s *Store) ResetWeeklyXP() error {
 	_, err := s.db.Exec(
 		`UPDATE user_gamification SET weekly_xp = 0, weekly_xp_reset_at = NOW()`,
@@ -203,6 +491,36 @@ func (s *Store) ResetWeeklyXP() error {
 	return err
 }
 
+// ClaimWeeklyReset records that the reset for isoWeek has started. It returns
+// false if a reset for that week was already claimed.
+func (s *Store) ClaimWeeklyReset(isoWeek string) (bool, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO weekly_reset_log (iso_week) VALUES ($1)
+		 ON CONFLICT (iso_week) DO NOTHING`,
+		isoWeek,
+	)
+	if err != nil {
+		return false, fmt.Errorf("claim weekly reset: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows == 1, nil
+}
+
+// CompleteWeeklyReset stores the outcome of a claimed weekly reset.
+func (s *Store) CompleteWeeklyReset(isoWeek string, topUserIDs []int64, leagueChanges int) error {
+	topJSON, err := json.Marshal(topUserIDs)
+	if err != nil {
+		return fmt.Errorf("marshal top users: %w", err)
+	}
+	_, err = s.db.Exec(
+		`UPDATE weekly_reset_log
+		 SET top_user_ids = $2, league_changes = $3, completed_at = NOW()
+		 WHERE iso_week = $1`,
+		isoWeek, string(topJSON), leagueChanges,
+	)
+	return err
+}
+
 // LeagueChange represents a user's league tier change.
 type LeagueChange struct {
 	UserID  int64
@@ -210,31 +528,129 @@ type LeagueChange struct {
 	NewTier string
 }
 
-func (s *Store) ProcessLeagueChanges() ([]LeagueChange, error) {
+// ProcessLeagueChanges promotes and demotes users at the end of the week
+// starting at weekStart. Users in a cohort that week move by their rank
+// within it; users without a cohort fall back to the weekly XP thresholds in
+// evaluateLeague. Users promoted at the start of the week aren't demoted.
+func (s *Store) ProcessLeagueChanges(weekStart time.Time) ([]LeagueChange, error) {
+	endingWeek := isoWeekKey(weekStart)
 	rows, err := s.db.Query(
-		`SELECT user_id, weekly_xp, league_tier FROM user_gamification`,
+		`SELECT c.id, c.tier, m.user_id, g.weekly_xp
+		 FROM league_cohorts c
+		 JOIN league_cohort_members m ON m.cohort_id = c.id
+		 JOIN user_gamification g ON g.user_id = m.user_id
+		 WHERE c.iso_week = $1
+		 ORDER BY c.id`,
+		endingWeek,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("get league data: %w", err)
+		return nil, fmt.Errorf("get cohorts: %w", err)
 	}
 	defer rows.Close()
 
-	var changes []LeagueChange
+	cohortTier := make(map[int64]string)
+	cohortMembers := make(map[int64][]models.LeaderboardEntry)
+	var cohortIDs []int64
 	for rows.Next() {
+		var cohortID int64
+		var tier string
+		var e models.LeaderboardEntry
+		if err := rows.Scan(&cohortID, &tier, &e.UserID, &e.WeeklyXP); err != nil {
+			return nil, err
+		}
+		if _, ok := cohortTier[cohortID]; !ok {
+			cohortIDs = append(cohortIDs, cohortID)
+			cohortTier[cohortID] = tier
+		}
+		cohortMembers[cohortID] = append(cohortMembers[cohortID], e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var changes []LeagueChange
+	for _, id := range cohortIDs {
+		changes = append(changes, cohortLeagueChanges(cohortTier[id], cohortMembers[id])...)
+	}
+
+	// Users who never joined a cohort this week
+	rest, err := s.db.Query(
+		`SELECT user_id, weekly_xp, league_tier FROM user_gamification
+		 WHERE user_id NOT IN (SELECT user_id FROM league_cohort_members WHERE iso_week = $1)`,
+		endingWeek,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get league data: %w", err)
+	}
+	defer rest.Close()
+
+	for rest.Next() {
 		var userID int64
 		var weeklyXP int64
 		var tier string
-		if err := rows.Scan(&userID, &weeklyXP, &tier); err != nil {
+		if err := rest.Scan(&userID, &weeklyXP, &tier); err != nil {
 			return nil, err
 		}
 
 		newTier := evaluateLeague(tier, weeklyXP)
 		if newTier != tier {
 			changes = append(changes, LeagueChange{UserID: userID, OldTier: tier, NewTier: newTier})
-			s.db.Exec(`UPDATE user_gamification SET league_tier = $1 WHERE user_id = $2`, newTier, userID)
 		}
 	}
-	return changes, rows.Err()
+	if err := rest.Err(); err != nil {
+		return nil, err
+	}
+
+	promotedAt, err := s.getPromotedAt(changes)
+	if err != nil {
+		return nil, err
+	}
+	changes = applyDemotionGrace(changes, promotedAt, weekStart)
+
+	for _, c := range changes {
+		if isPromotion(c.OldTier, c.NewTier) {
+			s.db.Exec(`UPDATE user_gamification SET league_tier = $1, promoted_at = NOW() WHERE user_id = $2`, c.NewTier, c.UserID)
+		} else {
+			s.db.Exec(`UPDATE user_gamification SET league_tier = $1 WHERE user_id = $2`, c.NewTier, c.UserID)
+		}
+	}
+	return changes, nil
+}
+
+// getPromotedAt returns when each user facing a demotion was last promoted.
+func (s *Store) getPromotedAt(changes []LeagueChange) (map[int64]time.Time, error) {
+	var ids []string
+	var args []interface{}
+	for _, c := range changes {
+		if !isPromotion(c.OldTier, c.NewTier) {
+			args = append(args, c.UserID)
+			ids = append(ids, fmt.Sprintf("$%d", len(args)))
+		}
+	}
+	promotedAt := make(map[int64]time.Time)
+	if len(ids) == 0 {
+		return promotedAt, nil
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT user_id, promoted_at FROM user_gamification
+		 WHERE promoted_at IS NOT NULL AND user_id IN (%s)`,
+		strings.Join(ids, ", ")),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get promoted_at: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID int64
+		var at time.Time
+		if err := rows.Scan(&userID, &at); err != nil {
+			return nil, err
+		}
+		promotedAt[userID] = at
+	}
+	return promotedAt, rows.Err()
 }
 
 func evaluateLeague(currentTier string, weeklyXP int64) string {
@@ -272,6 +688,159 @@ func evaluateLeague(currentTier string, weeklyXP int64) string {
 	return currentTier
 }
 
+// ── League Cohorts ──────────────────────────────────────
+
+// AssignLeagueCohorts places every user active in the last two weeks into a
+// cohort of their tier for isoWeek. Users already placed are skipped. It
+// returns the number of users assigned.
+func (s *Store) AssignLeagueCohorts(isoWeek string) (int, error) {
+	rows, err := s.db.Query(
+		`SELECT user_id, league_tier FROM user_gamification
+		 WHERE last_active_date >= CURRENT_DATE - 14
+		   AND user_id NOT IN (SELECT user_id FROM league_cohort_members WHERE iso_week = $1)
+		 ORDER BY user_id`,
+		isoWeek,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("get users for cohorts: %w", err)
+	}
+	byTier := make(map[string][]int64)
+	for rows.Next() {
+		var userID int64
+		var tier string
+		if err := rows.Scan(&userID, &tier); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		byTier[tier] = append(byTier[tier], userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	assigned := 0
+	for tier, userIDs := range byTier {
+		for _, members := range assignCohorts(userIDs, cohortSize) {
+			var cohortID int64
+			if err := tx.QueryRow(
+				`INSERT INTO league_cohorts (iso_week, tier) VALUES ($1, $2) RETURNING id`,
+				isoWeek, tier,
+			).Scan(&cohortID); err != nil {
+				return 0, fmt.Errorf("create cohort: %w", err)
+			}
+			for _, userID := range members {
+				if _, err := tx.Exec(
+					`INSERT INTO league_cohort_members (cohort_id, user_id, iso_week) VALUES ($1, $2, $3)
+					 ON CONFLICT (user_id, iso_week) DO NOTHING`,
+					cohortID, userID, isoWeek,
+				); err != nil {
+					return 0, fmt.Errorf("add cohort member: %w", err)
+				}
+				assigned++
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit cohorts: %w", err)
+	}
+	return assigned, nil
+}
+
+// GetOrAssignCohort returns the user's cohort for isoWeek, placing them in
+// the first cohort of their tier with room (or a new one) if they have none.
+func (s *Store) GetOrAssignCohort(userID int64, isoWeek, tier string) (int64, error) {
+	var cohortID int64
+	err := s.db.QueryRow(
+		`SELECT cohort_id FROM league_cohort_members WHERE user_id = $1 AND iso_week = $2`,
+		userID, isoWeek,
+	).Scan(&cohortID)
+	if err == nil {
+		return cohortID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("get cohort: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize placement per week and tier until commit. Locking the
+	// cohort rows alone would let two requests both count the same free
+	// seat, or both create a cohort when none has room.
+	if _, err := tx.Exec(
+		`SELECT pg_advisory_xact_lock(hashtext('league_cohort:' || $1::text || ':' || $2::text))`,
+		isoWeek, tier,
+	); err != nil {
+		return 0, fmt.Errorf("lock cohorts: %w", err)
+	}
+
+	err = tx.QueryRow(
+		`SELECT c.id FROM league_cohorts c
+		 WHERE c.iso_week = $1 AND c.tier = $2
+		   AND (SELECT COUNT(*) FROM league_cohort_members m WHERE m.cohort_id = c.id) < $3
+		 ORDER BY c.id
+		 LIMIT 1`,
+		isoWeek, tier, cohortSize,
+	).Scan(&cohortID)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(
+			`INSERT INTO league_cohorts (iso_week, tier) VALUES ($1, $2) RETURNING id`,
+			isoWeek, tier,
+		).Scan(&cohortID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find open cohort: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO league_cohort_members (cohort_id, user_id, iso_week) VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, iso_week) DO NOTHING`,
+		cohortID, userID, isoWeek,
+	); err != nil {
+		return 0, fmt.Errorf("join cohort: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit cohort: %w", err)
+	}
+
+	// A concurrent request may have placed the user first
+	err = s.db.QueryRow(
+		`SELECT cohort_id FROM league_cohort_members WHERE user_id = $1 AND iso_week = $2`,
+		userID, isoWeek,
+	).Scan(&cohortID)
+	return cohortID, err
+}
+
+func (s *Store) GetCohortLeaderboard(cohortID int64) ([]models.LeaderboardEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT u.id, u.name, COALESCE(u.username, ''), g.weekly_xp, g.league_tier, g.current_streak,
+		        ROW_NUMBER() OVER (ORDER BY g.weekly_xp DESC, u.id) as rank
+		 FROM league_cohort_members m
+		 JOIN users u ON u.id = m.user_id
+		 JOIN user_gamification g ON g.user_id = m.user_id
+		 WHERE m.cohort_id = $1
+		 ORDER BY g.weekly_xp DESC, u.id`,
+		cohortID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get cohort leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLeaderboard(rows)
+}
+
 // ── Friends ─────────────────────────────────────────────
 
 func (s *Store) LookupUserByID(userID int64) (int64, string, string, error) {
@@ -436,6 +1005,20 @@ func (s *Store) GetFriends(userID int64) (*models.FriendsResponse, error) {
 	return resp, nil
 }
 
+// ExpireStaleFriendRequests deletes pending friend requests created more
+// than olderThan ago and returns how many were removed.
+func (s *Store) ExpireStaleFriendRequests(olderThan time.Duration) (int64, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM friendships
+		 WHERE status = 'pending' AND created_at < NOW() - $1 * INTERVAL '1 second'`,
+		int(olderThan.Seconds()),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("expire friend requests: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 func (s *Store) RemoveFriend(friendshipID int64, userID int64) error {
 	result, err := s.db.Exec(
 		`DELETE FROM friendships WHERE id = $1 AND (user_id = $2 OR friend_id = $2)`,
@@ -498,6 +1081,50 @@ func (s *Store) SearchUsers(query string, currentUserID int64) ([]models.UserSea
 	return results, rows.Err()
 }
 
+// GetFriendIDs returns the accepted friends of each of userIDs, keyed by user.
+func (s *Store) GetFriendIDs(userIDs []int64) (map[int64][]int64, error) {
+	friendsOf := make(map[int64][]int64, len(userIDs))
+	if len(userIDs) == 0 {
+		return friendsOf, nil
+	}
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	in := strings.Join(placeholders, ", ")
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT user_id, friend_id FROM friendships
+		 WHERE status = 'accepted' AND (user_id IN (%s) OR friend_id IN (%s))`,
+		in, in),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get friend ids: %w", err)
+	}
+	defer rows.Close()
+
+	wanted := make(map[int64]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+	for rows.Next() {
+		var a, b int64
+		if err := rows.Scan(&a, &b); err != nil {
+			return nil, err
+		}
+		if wanted[a] {
+			friendsOf[a] = append(friendsOf[a], b)
+		}
+		if wanted[b] {
+			friendsOf[b] = append(friendsOf[b], a)
+		}
+	}
+	return friendsOf, rows.Err()
+}
+
 // formatDisplayName converts "John Smith" → "John S."
 func formatDisplayName(fullName string) string {
 	parts := strings.Fields(fullName)
@@ -535,6 +1162,183 @@ func (s *Store) AreFriends(userID, otherID int64) (bool, error) {
 	return exists, err
 }
 
+// ── Study Groups ────────────────────────────────────────
+
+// CreateGroup creates a group owned by ownerID and adds the owner as its
+// first member.
+func (s *Store) CreateGroup(ownerID int64, name, inviteCode string) (*models.Group, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	g := models.Group{Name: name, OwnerID: ownerID, MemberCount: 1, InviteCode: inviteCode}
+	err = tx.QueryRow(
+		`INSERT INTO groups (name, owner_id, invite_code) VALUES ($1, $2, $3) RETURNING id, created_at`,
+		name, ownerID, inviteCode,
+	).Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO group_members (group_id, user_id) VALUES ($1, $2)`,
+		g.ID, ownerID,
+	); err != nil {
+		return nil, fmt.Errorf("add group owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit group: %w", err)
+	}
+	return &g, nil
+}
+
+// JoinGroup adds userID to the group if code is its invite code. The group
+// row is locked so concurrent joins can't push it past maxGroupSize.
+func (s *Store) JoinGroup(groupID, userID int64, code string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var inviteCode string
+	err = tx.QueryRow(`SELECT invite_code FROM groups WHERE id = $1 FOR UPDATE`, groupID).Scan(&inviteCode)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("group not found")
+	}
+	if err != nil {
+		return fmt.Errorf("lock group: %w", err)
+	}
+
+	var memberCount int
+	var isMember bool
+	err = tx.QueryRow(
+		`SELECT COUNT(*), COALESCE(BOOL_OR(user_id = $2), false)
+		 FROM group_members WHERE group_id = $1`,
+		groupID, userID,
+	).Scan(&memberCount, &isMember)
+	if err != nil {
+		return fmt.Errorf("count group members: %w", err)
+	}
+	if err := checkGroupJoin(inviteCode, code, memberCount, isMember); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO group_members (group_id, user_id) VALUES ($1, $2)`,
+		groupID, userID,
+	); err != nil {
+		return fmt.Errorf("join group: %w", err)
+	}
+	return tx.Commit()
+}
+
+// LeaveGroup removes userID from the group. An empty group is deleted; if the
+// owner leaves, ownership passes to the longest-standing member.
+func (s *Store) LeaveGroup(groupID, userID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`DELETE FROM group_members WHERE group_id = $1 AND user_id = $2`,
+		groupID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("leave group: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("not a member of this group")
+	}
+
+	var nextOwner sql.NullInt64
+	err = tx.QueryRow(
+		`SELECT user_id FROM group_members WHERE group_id = $1
+		 ORDER BY joined_at, user_id LIMIT 1`,
+		groupID,
+	).Scan(&nextOwner)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("find next owner: %w", err)
+	}
+
+	if !nextOwner.Valid {
+		_, err = tx.Exec(`DELETE FROM groups WHERE id = $1`, groupID)
+	} else {
+		_, err = tx.Exec(
+			`UPDATE groups SET owner_id = $2 WHERE id = $1 AND owner_id = $3`,
+			groupID, nextOwner.Int64, userID,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("update group after leave: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Store) IsGroupMember(groupID, userID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2)`,
+		groupID, userID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ListUserGroups returns the groups userID belongs to, newest first.
+func (s *Store) ListUserGroups(userID int64) ([]models.Group, error) {
+	rows, err := s.db.Query(
+		`SELECT g.id, g.name, g.owner_id,
+		        (SELECT COUNT(*) FROM group_members m WHERE m.group_id = g.id),
+		        g.invite_code, g.created_at
+		 FROM groups g
+		 JOIN group_members gm ON gm.group_id = g.id
+		 WHERE gm.user_id = $1
+		 ORDER BY g.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []models.Group
+	for rows.Next() {
+		var g models.Group
+		if err := rows.Scan(&g.ID, &g.Name, &g.OwnerID, &g.MemberCount, &g.InviteCode, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// GetGroupLeaderboard lists every member of the group, including ones with
+// no gamification row yet, who show with no XP.
+func (s *Store) GetGroupLeaderboard(groupID int64) ([]models.LeaderboardEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT u.id, u.name, COALESCE(u.username, ''), COALESCE(g.weekly_xp, 0),
+		        COALESCE(g.league_tier, 'bronze'), COALESCE(g.current_streak, 0),
+		        ROW_NUMBER() OVER (ORDER BY COALESCE(g.weekly_xp, 0) DESC, u.id) as rank
+		 FROM group_members gm
+		 JOIN users u ON u.id = gm.user_id
+		 LEFT JOIN user_gamification g ON g.user_id = gm.user_id
+		 WHERE gm.group_id = $1
+		 ORDER BY COALESCE(g.weekly_xp, 0) DESC, u.id`,
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get group leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLeaderboard(rows)
+}
+
 // ── Nudges ──────────────────────────────────────────────
 
 func (s *Store) SendNudge(senderID, receiverID int64, nudgeType, message string) (int64, error) {
@@ -551,6 +1355,22 @@ func (s *Store) SendNudge(senderID, receiverID int64, nudgeType, message string)
 	return id, err
 }
 
+// CountNudgesToday returns how many nudges of nudgeType the sender has sent
+// the receiver since UTC midnight.
+func (s *Store) CountNudgesToday(senderID, receiverID int64, nudgeType string) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM nudges
+		 WHERE sender_id = $1 AND receiver_id = $2 AND nudge_type = $3
+		   AND created_at >= date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`,
+		senderID, receiverID, nudgeType,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count nudges today: %w", err)
+	}
+	return count, nil
+}
+
 func (s *Store) GetUnreadNudges(userID int64) ([]models.NudgeEntry, error) {
 	rows, err := s.db.Query(
 		`SELECT n.id, u.name, n.sender_id, n.nudge_type, COALESCE(n.message, ''), n.created_at
@@ -638,31 +1458,311 @@ func (s *Store) AwardAchievement(userID int64, achievement string) error {
 	return err
 }
 
-func (s *Store) AwardGems(userID int64, amount int) error {
-	_, err := s.db.Exec(
-		`UPDATE user_gamification SET gems = gems + $2, updated_at = NOW() WHERE user_id = $1`,
+// ── Gems ────────────────────────────────────────────────
+
+// AwardGems changes the user's gem balance by amount (negative to deduct) and
+// records the change in the gem ledger.
+func (s *Store) AwardGems(userID int64, amount int, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var balance int
+	err = tx.QueryRow(
+		`UPDATE user_gamification SET gems = gems + $2, updated_at = NOW()
+		 WHERE user_id = $1 RETURNING gems`,
 		userID, amount,
+	).Scan(&balance)
+	if err != nil {
+		return fmt.Errorf("update gems: %w", err)
+	}
+	if err := recordGemTransaction(tx, userID, amount, reason, balance); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func recordGemTransaction(tx *sql.Tx, userID int64, amount int, reason string, balanceAfter int) error {
+	_, err := tx.Exec(
+		`INSERT INTO gem_transactions (user_id, amount, reason, balance_after)
+		 VALUES ($1, $2, $3, $4)`,
+		userID, amount, reason, balanceAfter,
+	)
+	if err != nil {
+		return fmt.Errorf("record gem transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetGemHistory(userID int64, limit, offset int) ([]models.GemTransaction, int, error) {
+	var total int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM gem_transactions WHERE user_id = $1`, userID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count gem transactions: %w", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, amount, reason, balance_after, created_at
+		 FROM gem_transactions WHERE user_id = $1
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get gem history: %w", err)
+	}
+	defer rows.Close()
+
+	var txns []models.GemTransaction
+	for rows.Next() {
+		var t models.GemTransaction
+		if err := rows.Scan(&t.ID, &t.Amount, &t.Reason, &t.BalanceAfter, &t.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		txns = append(txns, t)
+	}
+	if txns == nil {
+		txns = []models.GemTransaction{}
+	}
+	return txns, total, rows.Err()
+}
+
+// ── Drill History ───────────────────────────────────────
+
+func (s *Store) RecordDrillResult(userID int64, r models.DrillResult) error {
+	_, err := s.db.Exec(
+		`INSERT INTO drill_results (user_id, correct, total, perfect, xp_earned)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		userID, r.Correct, r.Total, r.Perfect, r.XPEarned,
 	)
 	return err
 }
 
+func (s *Store) GetDrillResults(userID int64, limit, offset int) ([]models.DrillResult, int, error) {
+	var total int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM drill_results WHERE user_id = $1`, userID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count drill results: %w", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, correct, total, perfect, xp_earned, completed_at
+		 FROM drill_results WHERE user_id = $1
+		 ORDER BY completed_at DESC, id DESC
+		 LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get drill results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.DrillResult
+	for rows.Next() {
+		var r models.DrillResult
+		if err := rows.Scan(&r.ID, &r.Correct, &r.Total, &r.Perfect, &r.XPEarned, &r.CompletedAt); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, r)
+	}
+	return results, total, rows.Err()
+}
+
 // ── Streak Freeze ───────────────────────────────────────
 
 func (s *Store) BuyStreakFreeze(userID int64) error {
-	result, err := s.db.Exec(
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var balance int
+	err = tx.QueryRow(
 		`UPDATE user_gamification
-		 SET gems = gems - 50, streak_freezes_owned = streak_freezes_owned + 1, updated_at = NOW()
-		 WHERE user_id = $1 AND gems >= 50 AND streak_freezes_owned < 3`,
+		 SET gems = gems - $2, streak_freezes_owned = streak_freezes_owned + 1, updated_at = NOW()
+		 WHERE user_id = $1 AND gems >= $2 AND streak_freezes_owned < $3
+		 RETURNING gems`,
+		userID, streakFreezeCost, maxStreakFreezes,
+	).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("insufficient gems or max freezes reached")
+	}
+	if err != nil {
+		return err
+	}
+	if err := recordGemTransaction(tx, userID, -streakFreezeCost, "streak_freeze", balance); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) SetAutoFreeze(userID int64, enabled bool) error {
+	_, err := s.db.Exec(
+		`UPDATE user_gamification SET auto_freeze = $2, updated_at = NOW() WHERE user_id = $1`,
+		userID, enabled,
+	)
+	return err
+}
+
+// ── Shop ────────────────────────────────────────────────
+
+// GetInventory returns the user's owned shop items keyed by item ID.
+func (s *Store) GetInventory(userID int64) (map[string]int, error) {
+	rows, err := s.db.Query(
+		`SELECT item_id, quantity FROM inventory WHERE user_id = $1 AND quantity > 0`,
 		userID,
 	)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("get inventory: %w", err)
 	}
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return fmt.Errorf("insufficient gems or max freezes reached")
+	defer rows.Close()
+
+	inventory := make(map[string]int)
+	for rows.Next() {
+		var itemID string
+		var qty int
+		if err := rows.Scan(&itemID, &qty); err != nil {
+			return nil, err
+		}
+		inventory[itemID] = qty
 	}
-	return nil
+	return inventory, rows.Err()
+}
+
+// PurchaseItem deducts the item's cost and adds it to the user's inventory in
+// one transaction. It returns the remaining gem balance and owned quantity.
+func (s *Store) PurchaseItem(userID int64, itemID string, item ShopItemDef) (int, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The upsert only increments below the cap, so two first purchases can't
+	// both pass a check made before the row exists
+	var quantity int
+	err = tx.QueryRow(
+		`INSERT INTO inventory (user_id, item_id, quantity) VALUES ($1, $2, 1)
+		 ON CONFLICT (user_id, item_id)
+		 DO UPDATE SET quantity = inventory.quantity + 1, updated_at = NOW()
+		 WHERE inventory.quantity < $3
+		 RETURNING quantity`,
+		userID, itemID, item.MaxOwned,
+	).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("already own the maximum of %s (%d)", item.Name, item.MaxOwned)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("add to inventory: %w", err)
+	}
+
+	var balance int
+	err = tx.QueryRow(
+		`UPDATE user_gamification SET gems = gems - $2, updated_at = NOW()
+		 WHERE user_id = $1 AND gems >= $2
+		 RETURNING gems`,
+		userID, item.Cost,
+	).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("not enough gems (need %d)", item.Cost)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("deduct gems: %w", err)
+	}
+
+	if err := recordGemTransaction(tx, userID, -item.Cost, "shop_"+itemID, balance); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit purchase: %w", err)
+	}
+	return balance, quantity, nil
+}
+
+// ── XP Boosts ───────────────────────────────────────────
+
+// GetActiveBoost returns the user's strongest unexpired boost, or nil.
+func (s *Store) GetActiveBoost(userID int64) (*models.XPBoost, error) {
+	var b models.XPBoost
+	err := s.db.QueryRow(
+		`SELECT id, multiplier, source, started_at, expires_at
+		 FROM xp_boosts
+		 WHERE user_id = $1 AND expires_at > NOW()
+		 ORDER BY multiplier DESC, expires_at DESC
+		 LIMIT 1`,
+		userID,
+	).Scan(&b.ID, &b.Multiplier, &b.Source, &b.StartedAt, &b.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get active boost: %w", err)
+	}
+	return &b, nil
+}
+
+// ActivateBoost consumes one boost item from the user's inventory and starts
+// it. Only one boost may be active at a time.
+func (s *Store) ActivateBoost(userID int64, itemID string, multiplier float64, duration time.Duration) (*models.XPBoost, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var active bool
+	if err := tx.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM xp_boosts WHERE user_id = $1 AND expires_at > NOW())`,
+		userID,
+	).Scan(&active); err != nil {
+		return nil, fmt.Errorf("check active boost: %w", err)
+	}
+	if active {
+		return nil, fmt.Errorf("a boost is already active")
+	}
+
+	result, err := tx.Exec(
+		`UPDATE inventory SET quantity = quantity - 1, updated_at = NOW()
+		 WHERE user_id = $1 AND item_id = $2 AND quantity > 0`,
+		userID, itemID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("consume boost: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("no %s in inventory", itemID)
+	}
+
+	var b models.XPBoost
+	err = tx.QueryRow(
+		`INSERT INTO xp_boosts (user_id, multiplier, source, expires_at)
+		 VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+		 RETURNING id, multiplier, source, started_at, expires_at`,
+		userID, multiplier, itemID, int(duration.Seconds()),
+	).Scan(&b.ID, &b.Multiplier, &b.Source, &b.StartedAt, &b.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert boost: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit boost: %w", err)
+	}
+	return &b, nil
+}
+
+// DeleteExpiredBoosts removes boosts that expired more than a day ago.
+func (s *Store) DeleteExpiredBoosts() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM xp_boosts WHERE expires_at < NOW() - INTERVAL '1 day'`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 // ── Daily Goal ──────────────────────────────────────────
@@ -675,12 +1775,94 @@ func (s *Store) SetDailyGoalTarget(userID int64, target int) error {
 	return err
 }
 
+// ── Quests ──────────────────────────────────────────────
+
+// AssignQuests records the user's quests for a period. Quests already
+// assigned for the period are left untouched.
+func (s *Store) AssignQuests(userID int64, periodKey string, questKeys []string) error {
+	for _, key := range questKeys {
+		def, ok := Quests[key]
+		if !ok {
+			continue
+		}
+		_, err := s.db.Exec(
+			`INSERT INTO user_quests (user_id, quest_key, period_key, target)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (user_id, quest_key, period_key) DO NOTHING`,
+			userID, key, periodKey, def.Target,
+		)
+		if err != nil {
+			return fmt.Errorf("assign quest %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// AddQuestProgress advances an incomplete quest by delta. It returns true
+// only for the update that completes the quest, so the reward is granted once.
+func (s *Store) AddQuestProgress(userID int64, questKey, periodKey string, delta int) (bool, error) {
+	var completed bool
+	err := s.db.QueryRow(
+		`UPDATE user_quests SET
+		    progress = LEAST(progress + $4, target),
+		    completed_at = CASE WHEN progress + $4 >= target THEN NOW() END
+		 WHERE user_id = $1 AND quest_key = $2 AND period_key = $3
+		   AND completed_at IS NULL
+		 RETURNING completed_at IS NOT NULL`,
+		userID, questKey, periodKey, delta,
+	).Scan(&completed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("add quest progress: %w", err)
+	}
+	return completed, nil
+}
+
+// GetQuests returns the user's quests for the given periods. Only the
+// stored fields (key, period, progress, target, completion) are set.
+func (s *Store) GetQuests(userID int64, periodKeys []string) ([]models.Quest, error) {
+	if len(periodKeys) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(periodKeys))
+	args := []interface{}{userID}
+	for i, key := range periodKeys {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, key)
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT quest_key, period_key, progress, target, completed_at IS NOT NULL
+		 FROM user_quests
+		 WHERE user_id = $1 AND period_key IN (%s)
+		 ORDER BY quest_key`,
+		strings.Join(placeholders, ", ")),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get quests: %w", err)
+	}
+	defer rows.Close()
+
+	var quests []models.Quest
+	for rows.Next() {
+		var q models.Quest
+		if err := rows.Scan(&q.Key, &q.PeriodKey, &q.Progress, &q.Target, &q.Completed); err != nil {
+			return nil, err
+		}
+		quests = append(quests, q)
+	}
+	return quests, rows.Err()
+}
+
 // ── Weekly Reset Helpers ────────────────────────────────
 
 func (s *Store) GetAllGamificationForStreakCheck() ([]models.UserGamification, error) {
 	rows, err := s.db.Query(
 		`SELECT user_id, current_streak, longest_streak, last_active_date,
-		        streak_freeze_active, streak_freezes_owned
+		        streak_freeze_active, streak_freezes_owned, auto_freeze, gems
 		 FROM user_gamification
 		 WHERE current_streak > 0`,
 	)
@@ -693,7 +1875,8 @@ func (s *Store) GetAllGamificationForStreakCheck() ([]models.UserGamification, e
 	for rows.Next() {
 		var g models.UserGamification
 		if err := rows.Scan(&g.UserID, &g.CurrentStreak, &g.LongestStreak,
-			&g.LastActiveDate, &g.StreakFreezeActive, &g.StreakFreezesOwned); err != nil {
+			&g.LastActiveDate, &g.StreakFreezeActive, &g.StreakFreezesOwned,
+			&g.AutoFreeze, &g.Gems); err != nil {
 			return nil, err
 		}
 		users = append(users, g)
@@ -0,0 +1,100 @@
+package gamification
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StreakMilestone is the reward for reaching a streak length. XP is only set
+// for the big milestones.
+type StreakMilestone struct {
+	Gems int
+	XP   int
+}
+
+// DefaultStreakMilestones maps streak lengths in days to their rewards.
+var DefaultStreakMilestones = map[int]StreakMilestone{
+	3:   {Gems: 10},
+	7:   {Gems: 25},
+	14:  {Gems: 50},
+	30:  {Gems: 100, XP: 50},
+	60:  {Gems: 200, XP: 100},
+	100: {Gems: 500, XP: 250},
+	365: {Gems: 1000, XP: 500},
+}
+
+// LoadStreakMilestones returns the streak milestone rewards. STREAK_MILESTONES,
+// if set, replaces the defaults with a comma-separated list of
+// days:gems[:xp] entries, e.g. "7:25,30:100:50". Malformed entries are
+// ignored with a warning; if none are valid the defaults apply.
+func LoadStreakMilestones() map[int]StreakMilestone {
+	v := os.Getenv("STREAK_MILESTONES")
+	if strings.TrimSpace(v) == "" {
+		return DefaultStreakMilestones
+	}
+
+	milestones := make(map[int]StreakMilestone)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "STREAK_MILESTONES", "value", entry)
+			continue
+		}
+		nums := make([]int, len(parts))
+		valid := true
+		for i, p := range parts {
+			n, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || n < 0 {
+				valid = false
+				break
+			}
+			nums[i] = n
+		}
+		if !valid || nums[0] == 0 {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "STREAK_MILESTONES", "value", entry)
+			continue
+		}
+		m := StreakMilestone{Gems: nums[1]}
+		if len(nums) == 3 {
+			m.XP = nums[2]
+		}
+		milestones[nums[0]] = m
+	}
+	if len(milestones) == 0 {
+		slog.Warn("STREAK_MILESTONES has no valid entries, using defaults", "component", "gamification")
+		return DefaultStreakMilestones
+	}
+	return milestones
+}
+
+// streakMilestoneStore is the subset of Store used to pay streak milestones.
+type streakMilestoneStore interface {
+	AwardGems(userID int64, amount int, reason string) error
+	AddXP(userID int64, amount int) error
+	LogXPEvent(userID int64, eventType string, xpAmount int, metadata map[string]interface{}) error
+}
+
+// awardStreakMilestone pays the reward for reaching streak, if it's a
+// milestone, and reports whether it was.
+func awardStreakMilestone(st streakMilestoneStore, milestones map[int]StreakMilestone, userID int64, streak int) bool {
+	m, ok := milestones[streak]
+	if !ok {
+		return false
+	}
+	if m.Gems > 0 {
+		st.AwardGems(userID, m.Gems, "streak_milestone")
+	}
+	if m.XP > 0 {
+		if err := st.AddXP(userID, m.XP); err != nil {
+			slog.Warn("award streak milestone XP failed", "component", "gamification", "user_id", userID, "err", err)
+		}
+	}
+	st.LogXPEvent(userID, "streak_milestone", m.XP, map[string]interface{}{
+		"streak":       streak,
+		"gems_awarded": m.Gems,
+	})
+	return true
+}
@@ -0,0 +1,54 @@
+package gamification
+
+import "testing"
+
+// fakeMilestoneStore records gem and XP awards in memory.
+type fakeMilestoneStore struct {
+	gems, xp int
+	events   int
+}
+
+func (f *fakeMilestoneStore) AwardGems(userID int64, amount int, reason string) error {
+	f.gems += amount
+	return nil
+}
+
+func (f *fakeMilestoneStore) AddXP(userID int64, amount int) error {
+	f.xp += amount
+	return nil
+}
+
+func (f *fakeMilestoneStore) LogXPEvent(userID int64, eventType string, xpAmount int, metadata map[string]interface{}) error {
+	f.events++
+	return nil
+}
+
+func TestAwardStreakMilestone_Configured(t *testing.T) {
+	t.Setenv("STREAK_MILESTONES", "5:20, 50:300:150, bogus")
+	milestones := LoadStreakMilestones()
+	if len(milestones) != 2 {
+		t.Fatalf("loaded %d milestones, want 2: %v", len(milestones), milestones)
+	}
+
+	st := &fakeMilestoneStore{}
+	if !awardStreakMilestone(st, milestones, 1, 50) {
+		t.Fatal("day 50 should be a milestone")
+	}
+	if st.gems != 300 || st.xp != 150 {
+		t.Errorf("day 50 awarded %d gems / %d XP, want 300 / 150", st.gems, st.xp)
+	}
+
+	st = &fakeMilestoneStore{}
+	awardStreakMilestone(st, milestones, 1, 5)
+	if st.gems != 20 || st.xp != 0 {
+		t.Errorf("day 5 awarded %d gems / %d XP, want 20 / 0", st.gems, st.xp)
+	}
+
+	// Default milestones no longer apply, and other days grant nothing
+	for _, day := range []int{1, 4, 7, 30, 49} {
+		st = &fakeMilestoneStore{}
+		if awardStreakMilestone(st, milestones, 1, day) || st.gems != 0 || st.xp != 0 || st.events != 0 {
+			t.Errorf("day %d granted %d gems / %d XP", day, st.gems, st.xp)
+		}
+	}
+}
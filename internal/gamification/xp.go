@@ -1,107 +1,315 @@
 package gamification
 
-import "math"
+import (
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-// BaseXP returns XP for a correct answer based on difficulty score (0-100).
-func BaseXP(difficultyScore int) int {
-	if difficultyScore <= 20 {
-		return 5
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// The streak multiplier applies to all XP a user earns:
+//
+//	question XP = round((BaseXP + ChallengeBonus) × streak multiplier), per correct answer
+//	drill XP    = round((combo + time bonus + completion bonus) × streak multiplier), per drill
+//
+// An active boost multiplies each amount again after the streak multiplier.
+
+// XPConfig holds the tunable constants of the XP economy. Tiered values pair
+// with a threshold list: a value list has one more entry than its thresholds,
+// and the last value applies above the highest threshold.
+type XPConfig struct {
+	// BaseXP by difficulty score (0-100); thresholds are inclusive upper bounds.
+	BaseXPThresholds []int
+	BaseXPValues     []int
+
+	// ChallengeBonus by how far difficulty exceeds ability; inclusive upper bounds.
+	ChallengeGapThresholds []int
+	ChallengeBonusValues   []int
+
+	// ComboXP for the Nth consecutive correct answer, starting at ComboMinStreak.
+	// The last value repeats for longer combos.
+	ComboMinStreak int
+	ComboValues    []int
+
+	// TimeBonus by average seconds per question; inclusive upper bounds.
+	// Slower than the last threshold earns nothing.
+	TimeBonusThresholds []float64
+	TimeBonusValues     []int
+
+	// StreakMultiplier by current streak; thresholds are the minimum streak
+	// for each multiplier after the first.
+	StreakThresholds  []int
+	StreakMultipliers []float64
+
+	// DrillCompletionXP bonuses.
+	PerfectDrillXP     int
+	GreatDrillXP       int
+	GreatDrillAccuracy float64
+}
+
+// DefaultXPConfig returns the standard XP economy.
+func DefaultXPConfig() XPConfig {
+	return XPConfig{
+		BaseXPThresholds:       []int{20, 40, 60, 80},
+		BaseXPValues:           []int{5, 8, 10, 13, 16},
+		ChallengeGapThresholds: []int{0, 10, 20},
+		ChallengeBonusValues:   []int{0, 2, 5, 8},
+		ComboMinStreak:         3,
+		ComboValues:            []int{3, 5, 8, 10},
+		TimeBonusThresholds:    []float64{45, 75, 120},
+		TimeBonusValues:        []int{10, 5, 2},
+		StreakThresholds:       []int{3, 7, 14, 30},
+		StreakMultipliers:      []float64{1.0, 1.15, 1.25, 1.5, 2.0},
+		PerfectDrillXP:         25,
+		GreatDrillXP:           10,
+		GreatDrillAccuracy:     0.8,
+	}
+}
+
+// LoadXPConfig returns DefaultXPConfig with overrides from XP_* environment
+// variables. Lists are comma-separated; an override whose length doesn't
+// match its paired list is ignored with a warning.
+func LoadXPConfig() XPConfig {
+	cfg := DefaultXPConfig()
+
+	if v, ok := envInts("XP_BASE_VALUES"); ok {
+		if len(v) == len(cfg.BaseXPThresholds)+1 {
+			cfg.BaseXPValues = v
+		} else {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "XP_BASE_VALUES", "want_values", len(cfg.BaseXPThresholds)+1)
+		}
 	}
-	if difficultyScore <= 40 {
-		return 8
+	if v, ok := envInts("XP_CHALLENGE_BONUS_VALUES"); ok {
+		if len(v) == len(cfg.ChallengeGapThresholds)+1 {
+			cfg.ChallengeBonusValues = v
+		} else {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "XP_CHALLENGE_BONUS_VALUES", "want_values", len(cfg.ChallengeGapThresholds)+1)
+		}
 	}
-	if difficultyScore <= 60 {
-		return 10
+	if v, ok := envInts("XP_COMBO_VALUES"); ok && len(v) > 0 {
+		cfg.ComboValues = v
 	}
-	if difficultyScore <= 80 {
-		return 13
+	if v, ok := envInts("XP_TIME_BONUS_VALUES"); ok {
+		if len(v) == len(cfg.TimeBonusThresholds) {
+			cfg.TimeBonusValues = v
+		} else {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "XP_TIME_BONUS_VALUES", "want_values", len(cfg.TimeBonusThresholds))
+		}
 	}
-	return 16
+	if v, ok := envFloats("XP_STREAK_MULTIPLIERS"); ok {
+		if len(v) == len(cfg.StreakThresholds)+1 {
+			cfg.StreakMultipliers = v
+		} else {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", "XP_STREAK_MULTIPLIERS", "want_values", len(cfg.StreakThresholds)+1)
+		}
+	}
+	if v, ok := envInts("XP_PERFECT_DRILL"); ok && len(v) == 1 {
+		cfg.PerfectDrillXP = v[0]
+	}
+	if v, ok := envInts("XP_GREAT_DRILL"); ok && len(v) == 1 {
+		cfg.GreatDrillXP = v[0]
+	}
+
+	return cfg
 }
 
-// ChallengeBonus adds XP when you answer a question above your ability level.
-func ChallengeBonus(userAbility, difficultyScore int) int {
-	gap := difficultyScore - userAbility
-	if gap <= 0 {
-		return 0
+func envInts(key string) ([]int, bool) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil, false
+	}
+	var out []int
+	for _, part := range strings.Split(raw, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", key, "err", err)
+			return nil, false
+		}
+		out = append(out, n)
 	}
-	if gap <= 10 {
-		return 2
+	return out, true
+}
+
+func envFloats(key string) ([]float64, bool) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil, false
 	}
-	if gap <= 20 {
-		return 5
+	var out []float64
+	for _, part := range strings.Split(raw, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			slog.Warn("ignoring invalid config", "component", "gamification", "key", key, "err", err)
+			return nil, false
+		}
+		out = append(out, f)
 	}
-	return 8
+	return out, true
+}
+
+// upperBoundTier returns the index of the first threshold >= v, or
+// len(thresholds) if v exceeds them all.
+func upperBoundTier(v int, thresholds []int) int {
+	for i, t := range thresholds {
+		if v <= t {
+			return i
+		}
+	}
+	return len(thresholds)
+}
+
+// BaseXP returns XP for a correct answer based on difficulty score (0-100).
+func (c XPConfig) BaseXP(difficultyScore int) int {
+	return c.BaseXPValues[upperBoundTier(difficultyScore, c.BaseXPThresholds)]
+}
+
+// ChallengeBonus adds XP when you answer a question above your ability level.
+func (c XPConfig) ChallengeBonus(userAbility, difficultyScore int) int {
+	return c.ChallengeBonusValues[upperBoundTier(difficultyScore-userAbility, c.ChallengeGapThresholds)]
 }
 
 // ComboXP returns bonus XP for consecutive correct answers in a drill.
-func ComboXP(consecutiveCorrect int) int {
-	switch {
-	case consecutiveCorrect < 3:
+func (c XPConfig) ComboXP(consecutiveCorrect int) int {
+	idx := consecutiveCorrect - c.ComboMinStreak
+	if idx < 0 || len(c.ComboValues) == 0 {
 		return 0
-	case consecutiveCorrect == 3:
-		return 3
-	case consecutiveCorrect == 4:
-		return 5
-	case consecutiveCorrect == 5:
-		return 8
-	default:
-		return 10
 	}
+	if idx >= len(c.ComboValues) {
+		idx = len(c.ComboValues) - 1
+	}
+	return c.ComboValues[idx]
 }
 
 // TimeBonus rewards fast correct answers. Based on avg time per question in drill.
-func TimeBonus(avgSecondsPerQuestion float64) int {
-	if avgSecondsPerQuestion <= 45 {
-		return 10
-	}
-	if avgSecondsPerQuestion <= 75 {
-		return 5
-	}
-	if avgSecondsPerQuestion <= 120 {
-		return 2
+func (c XPConfig) TimeBonus(avgSecondsPerQuestion float64) int {
+	for i, t := range c.TimeBonusThresholds {
+		if avgSecondsPerQuestion <= t {
+			return c.TimeBonusValues[i]
+		}
 	}
 	return 0
 }
 
 // StreakMultiplier returns the XP multiplier for a daily streak.
-func StreakMultiplier(currentStreak int) float64 {
-	if currentStreak < 3 {
-		return 1.0
-	}
-	if currentStreak < 7 {
-		return 1.15
+func (c XPConfig) StreakMultiplier(currentStreak int) float64 {
+	idx := 0
+	for i, t := range c.StreakThresholds {
+		if currentStreak >= t {
+			idx = i + 1
+		}
 	}
-	if currentStreak < 14 {
-		return 1.25
-	}
-	if currentStreak < 30 {
-		return 1.5
+	return c.StreakMultipliers[idx]
+}
+
+// QuestionXP returns the XP for one correct answer with the user's streak
+// multiplier applied.
+func (c XPConfig) QuestionXP(difficultyScore, userAbility, currentStreak int) int {
+	raw := c.BaseXP(difficultyScore) + c.ChallengeBonus(userAbility, difficultyScore)
+	return ApplyStreakMultiplier(raw, c.StreakMultiplier(currentStreak))
+}
+
+// DrillBreakdown computes the XP for a completed drill. questionXP is the
+// per-question XP already awarded during the drill; the drill-level bonuses
+// are multiplied by the streak and boost multipliers.
+func (c XPConfig) DrillBreakdown(questionXP, comboMax int, avgSeconds float64, correct, total, currentStreak int, boostMultiplier float64) models.XPBreakdown {
+	b := models.XPBreakdown{
+		Questions:        questionXP,
+		ComboBonuses:     c.CalculateComboXPTotal(comboMax),
+		TimeBonus:        c.TimeBonus(avgSeconds),
+		DrillCompletion:  c.DrillCompletionXP(correct, total),
+		StreakMultiplier: c.StreakMultiplier(currentStreak),
+		BoostMultiplier:  boostMultiplier,
 	}
-	return 2.0
+	b.Subtotal = b.ComboBonuses + b.TimeBonus + b.DrillCompletion
+	bonusXP := ApplyStreakMultiplier(ApplyStreakMultiplier(b.Subtotal, b.StreakMultiplier), boostMultiplier)
+	b.TotalXP = b.Questions + bonusXP
+	return b
 }
 
 // DrillCompletionXP returns bonus XP for completing a drill.
-func DrillCompletionXP(correct, total int) int {
+func (c XPConfig) DrillCompletionXP(correct, total int) int {
 	if total == 0 {
 		return 0
 	}
 	accuracy := float64(correct) / float64(total)
 
 	if correct == total {
-		return 25 // Perfect drill bonus
+		return c.PerfectDrillXP // Perfect drill bonus
 	}
-	if accuracy >= 0.8 {
-		return 10 // Great drill bonus
+	if accuracy >= c.GreatDrillAccuracy {
+		return c.GreatDrillXP // Great drill bonus
 	}
 	return 0
 }
 
+// MaxCombo returns the longest run of consecutive correct answers, in the
+// order they were answered. answers should be one drill session's, as
+// claimDrill returns them, so answers given elsewhere meanwhile don't break
+// or extend the run.
+func MaxCombo(answers []DrillAnswer) int {
+	ordered := make([]DrillAnswer, len(answers))
+	copy(ordered, answers)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].AnsweredAt.Before(ordered[j].AnsweredAt)
+	})
+
+	best, run := 0, 0
+	for _, a := range ordered {
+		if a.Correct {
+			run++
+			if run > best {
+				best = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return best
+}
+
+// VerifyDrillAnswers matches the client's drill question IDs against the
+// recorded answers. IDs with no recorded answer are dropped; duplicates are
+// counted once, using the most recent answer. It returns the verified
+// answers (oldest first) and the IDs answered correctly, in drill order.
+func VerifyDrillAnswers(questionIDs []int64, answers []DrillAnswer) ([]DrillAnswer, []int64) {
+	latest := make(map[int64]DrillAnswer, len(answers))
+	for _, a := range answers {
+		if prev, ok := latest[a.QuestionID]; !ok || !a.AnsweredAt.Before(prev.AnsweredAt) {
+			latest[a.QuestionID] = a
+		}
+	}
+
+	seen := make(map[int64]bool, len(questionIDs))
+	var verified []DrillAnswer
+	var correctIDs []int64
+	for _, id := range questionIDs {
+		a, ok := latest[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		verified = append(verified, a)
+		if a.Correct {
+			correctIDs = append(correctIDs, id)
+		}
+	}
+
+	sort.SliceStable(verified, func(i, j int) bool {
+		return verified[i].AnsweredAt.Before(verified[j].AnsweredAt)
+	})
+	return verified, correctIDs
+}
+
 // CalculateComboXPTotal computes total combo XP from the max combo streak in a drill.
-func CalculateComboXPTotal(comboMax int) int {
+func (c XPConfig) CalculateComboXPTotal(comboMax int) int {
 	total := 0
-	for i := 3; i <= comboMax; i++ {
-		total += ComboXP(i)
+	for i := c.ComboMinStreak; i <= comboMax; i++ {
+		total += c.ComboXP(i)
 	}
 	return total
 }
@@ -110,3 +318,12 @@ func CalculateComboXPTotal(comboMax int) int {
 func ApplyStreakMultiplier(xp int, multiplier float64) int {
 	return int(math.Round(float64(xp) * multiplier))
 }
+
+// BoostMultiplier returns the multiplier of boost if it is active at now,
+// otherwise 1.0.
+func BoostMultiplier(boost *models.XPBoost, now time.Time) float64 {
+	if boost == nil || !now.Before(boost.ExpiresAt) || boost.Multiplier < 1 {
+		return 1.0
+	}
+	return boost.Multiplier
+}
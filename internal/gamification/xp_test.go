@@ -0,0 +1,265 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestBoostMultiplier(t *testing.T) {
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	boost := &models.XPBoost{Multiplier: 2.0, StartedAt: start, ExpiresAt: start.Add(30 * time.Minute)}
+	cfg := DefaultXPConfig()
+	base := cfg.BaseXP(50) + cfg.ChallengeBonus(40, 50)
+
+	during := ApplyStreakMultiplier(base, BoostMultiplier(boost, start.Add(10*time.Minute)))
+	if during != base*2 {
+		t.Errorf("expected %d XP while boosted, got %d", base*2, during)
+	}
+
+	after := ApplyStreakMultiplier(base, BoostMultiplier(boost, start.Add(30*time.Minute)))
+	if after != base {
+		t.Errorf("expected %d XP after expiry, got %d", base, after)
+	}
+
+	if m := BoostMultiplier(nil, start); m != 1.0 {
+		t.Errorf("expected 1.0 with no boost, got %f", m)
+	}
+}
+
+func TestDefaultXPConfig_MatchesStandardEconomy(t *testing.T) {
+	cfg := DefaultXPConfig()
+
+	base := map[int]int{0: 5, 20: 5, 21: 8, 40: 8, 60: 10, 80: 13, 81: 16, 100: 16}
+	for score, want := range base {
+		if got := cfg.BaseXP(score); got != want {
+			t.Errorf("BaseXP(%d) = %d, want %d", score, got, want)
+		}
+	}
+
+	challenge := map[int]int{-5: 0, 0: 0, 1: 2, 10: 2, 11: 5, 20: 5, 21: 8}
+	for gap, want := range challenge {
+		if got := cfg.ChallengeBonus(50, 50+gap); got != want {
+			t.Errorf("ChallengeBonus(gap %d) = %d, want %d", gap, got, want)
+		}
+	}
+
+	combo := map[int]int{2: 0, 3: 3, 4: 5, 5: 8, 6: 10, 9: 10}
+	for n, want := range combo {
+		if got := cfg.ComboXP(n); got != want {
+			t.Errorf("ComboXP(%d) = %d, want %d", n, got, want)
+		}
+	}
+	if got := cfg.CalculateComboXPTotal(6); got != 3+5+8+10 {
+		t.Errorf("CalculateComboXPTotal(6) = %d, want 26", got)
+	}
+
+	timeBonus := map[float64]int{30: 10, 45: 10, 60: 5, 120: 2, 121: 0}
+	for secs, want := range timeBonus {
+		if got := cfg.TimeBonus(secs); got != want {
+			t.Errorf("TimeBonus(%.0f) = %d, want %d", secs, got, want)
+		}
+	}
+
+	streak := map[int]float64{0: 1.0, 2: 1.0, 3: 1.15, 7: 1.25, 14: 1.5, 29: 1.5, 30: 2.0, 365: 2.0}
+	for days, want := range streak {
+		if got := cfg.StreakMultiplier(days); got != want {
+			t.Errorf("StreakMultiplier(%d) = %f, want %f", days, got, want)
+		}
+	}
+
+	if got := cfg.DrillCompletionXP(6, 6); got != 25 {
+		t.Errorf("perfect drill XP = %d, want 25", got)
+	}
+	if got := cfg.DrillCompletionXP(5, 6); got != 10 {
+		t.Errorf("great drill XP = %d, want 10", got)
+	}
+	if got := cfg.DrillCompletionXP(3, 6); got != 0 {
+		t.Errorf("weak drill XP = %d, want 0", got)
+	}
+}
+
+func TestLoadXPConfig_Overrides(t *testing.T) {
+	t.Setenv("XP_BASE_VALUES", "1,2,3,4,50")
+	t.Setenv("XP_STREAK_MULTIPLIERS", "1, 1.1, 1.2, 1.3, 3")
+	t.Setenv("XP_COMBO_VALUES", "7")
+	t.Setenv("XP_PERFECT_DRILL", "40")
+	t.Setenv("XP_TIME_BONUS_VALUES", "1,2") // wrong length, ignored
+
+	cfg := LoadXPConfig()
+	if got := cfg.BaseXP(95); got != 50 {
+		t.Errorf("BaseXP(95) = %d, want overridden 50", got)
+	}
+	if got := cfg.StreakMultiplier(30); got != 3 {
+		t.Errorf("StreakMultiplier(30) = %f, want overridden 3", got)
+	}
+	if got := cfg.CalculateComboXPTotal(5); got != 21 {
+		t.Errorf("CalculateComboXPTotal(5) = %d, want 21", got)
+	}
+	if got := cfg.DrillCompletionXP(4, 4); got != 40 {
+		t.Errorf("perfect drill XP = %d, want overridden 40", got)
+	}
+	if got := cfg.TimeBonus(30); got != 10 {
+		t.Errorf("TimeBonus(30) = %d, want default 10 after invalid override", got)
+	}
+}
+
+func TestDrillXP_StreakMultiplierAppliesToAllXP(t *testing.T) {
+	cfg := DefaultXPConfig()
+	streak := 30 // 2.0x
+
+	// Five correct answers at difficulty 50 for a user at ability 50: 10 XP each
+	questionXP := 0
+	for i := 0; i < 5; i++ {
+		questionXP += cfg.QuestionXP(50, 50, streak)
+	}
+	if questionXP != 5*10*2 {
+		t.Errorf("question XP = %d, want %d", questionXP, 5*10*2)
+	}
+
+	// Drill bonuses: combo of 5 (3+5+8), 40s average (10), perfect (25)
+	bonuses := cfg.CalculateComboXPTotal(5) + cfg.TimeBonus(40) + cfg.DrillCompletionXP(5, 5)
+	drillXP := ApplyStreakMultiplier(bonuses, cfg.StreakMultiplier(streak))
+	if drillXP != 51*2 {
+		t.Errorf("drill bonus XP = %d, want %d", drillXP, 51*2)
+	}
+
+	if total := questionXP + drillXP; total != 202 {
+		t.Errorf("total drill XP = %d, want 202", total)
+	}
+
+	// Without a streak the same drill earns half as much
+	if got := cfg.QuestionXP(50, 50, 0); got != 10 {
+		t.Errorf("unstreaked question XP = %d, want 10", got)
+	}
+}
+
+func TestDrillBreakdown_SumsToXPDelta(t *testing.T) {
+	cfg := DefaultXPConfig()
+	streak := 7 // 1.25x
+
+	// Per-question XP as awarded during the drill: 4 correct of 5
+	awarded := []int{
+		cfg.QuestionXP(30, 50, streak),
+		cfg.QuestionXP(55, 50, streak),
+		cfg.QuestionXP(70, 50, streak),
+		cfg.QuestionXP(90, 50, streak),
+	}
+	questionXP := 0
+	for _, xp := range awarded {
+		questionXP += xp
+	}
+
+	b := cfg.DrillBreakdown(questionXP, 3, 60, 4, 5, streak, 1.0)
+	if b.Questions != questionXP {
+		t.Errorf("Questions = %d, want %d", b.Questions, questionXP)
+	}
+	if b.Subtotal != b.ComboBonuses+b.TimeBonus+b.DrillCompletion {
+		t.Errorf("Subtotal = %d, want sum of bonuses %d", b.Subtotal, b.ComboBonuses+b.TimeBonus+b.DrillCompletion)
+	}
+
+	// XP delta: everything awarded during the drill plus the drill-level award
+	drillAward := b.TotalXP - b.Questions
+	delta := questionXP + drillAward
+	if b.TotalXP != delta {
+		t.Errorf("TotalXP = %d, actual XP delta = %d", b.TotalXP, delta)
+	}
+	// 3 combo + 5 time + 10 great drill = 18, × 1.25 = 22.5 → 23
+	if drillAward != 23 {
+		t.Errorf("drill-level award = %d, want 23", drillAward)
+	}
+
+	// The boost applies to the rounded streak amount: 23 × 2
+	boosted := cfg.DrillBreakdown(questionXP, 3, 60, 4, 5, streak, 2.0)
+	if boosted.TotalXP-boosted.Questions != 46 {
+		t.Errorf("boosted drill-level award = %d, want 46", boosted.TotalXP-boosted.Questions)
+	}
+}
+
+func TestMaxCombo_FromAnswerHistory(t *testing.T) {
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	// Answered in order: ✓ ✓ ✗ ✓ ✓ ✓ ✗ — listed out of order to check sorting
+	sequence := []bool{true, true, false, true, true, true, false}
+	var answers []DrillAnswer
+	for i := len(sequence) - 1; i >= 0; i-- {
+		answers = append(answers, DrillAnswer{
+			QuestionID: int64(i + 1),
+			Correct:    sequence[i],
+			AnsweredAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	if got := MaxCombo(answers); got != 3 {
+		t.Errorf("MaxCombo = %d, want 3", got)
+	}
+
+	// A client claiming a 7-answer combo gets combo XP for the real run only
+	cfg := DefaultXPConfig()
+	clientComboMax := 7
+	b := cfg.DrillBreakdown(0, MaxCombo(answers), 200, 5, 7, 0, 1.0)
+	if b.ComboBonuses != cfg.CalculateComboXPTotal(3) {
+		t.Errorf("combo bonus = %d, want %d", b.ComboBonuses, cfg.CalculateComboXPTotal(3))
+	}
+	if b.ComboBonuses == cfg.CalculateComboXPTotal(clientComboMax) {
+		t.Error("combo bonus should not reflect the client-supplied combo")
+	}
+
+	if got := MaxCombo(nil); got != 0 {
+		t.Errorf("MaxCombo(nil) = %d, want 0", got)
+	}
+}
+
+func TestVerifyDrillAnswers_ClientLiesAboutCorrectness(t *testing.T) {
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	recorded := []DrillAnswer{
+		{QuestionID: 1, Correct: true, AnsweredAt: start},
+		{QuestionID: 2, Correct: false, AnsweredAt: start.Add(time.Minute)},
+		{QuestionID: 3, Correct: true, AnsweredAt: start.Add(2 * time.Minute)},
+		{QuestionID: 4, Correct: false, AnsweredAt: start.Add(3 * time.Minute)},
+	}
+
+	// Client claims a perfect 5-question drill, including an unanswered
+	// question 99 and a duplicated ID
+	clientIDs := []int64{1, 2, 3, 4, 99, 3}
+	clientCorrect := clientIDs
+
+	answers, correctIDs := VerifyDrillAnswers(clientIDs, recorded)
+	if len(answers) != 4 {
+		t.Fatalf("verified %d answers, want 4", len(answers))
+	}
+	if len(correctIDs) != 2 || correctIDs[0] != 1 || correctIDs[1] != 3 {
+		t.Errorf("correctIDs = %v, want [1 3]", correctIDs)
+	}
+	if len(correctIDs) == len(answers) {
+		t.Error("drill should not be perfect")
+	}
+
+	cfg := DefaultXPConfig()
+	b := cfg.DrillBreakdown(0, MaxCombo(answers), 200, len(correctIDs), len(answers), 0, 1.0)
+	want := cfg.DrillCompletionXP(2, 4)
+	if b.DrillCompletion != want {
+		t.Errorf("drill completion = %d, want %d", b.DrillCompletion, want)
+	}
+	if b.DrillCompletion == cfg.DrillCompletionXP(len(clientCorrect), len(clientIDs)) {
+		t.Error("drill completion should not reflect the client's claim")
+	}
+}
+
+func TestVerifyDrillAnswers_RetryUsesLatestAnswer(t *testing.T) {
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	recorded := []DrillAnswer{
+		{QuestionID: 1, Correct: false, AnsweredAt: start},
+		{QuestionID: 2, Correct: true, AnsweredAt: start.Add(time.Minute)},
+		{QuestionID: 1, Correct: true, AnsweredAt: start.Add(2 * time.Minute)},
+	}
+
+	answers, correctIDs := VerifyDrillAnswers([]int64{1, 2}, recorded)
+	if len(answers) != 2 || len(correctIDs) != 2 {
+		t.Errorf("got %d answers / %d correct, want 2 / 2", len(answers), len(correctIDs))
+	}
+
+	if answers, _ := VerifyDrillAnswers([]int64{7, 8}, recorded); len(answers) != 0 {
+		t.Errorf("unanswered IDs verified %d answers, want 0", len(answers))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -32,11 +33,35 @@ type Generator struct {
 	model string
 }
 
+// Default Anthropic models. Validation runs on a cheaper model than
+// generation; override with ANTHROPIC_MODEL and VALIDATOR_MODEL.
+const (
+	defaultGenerationModel = "claude-opus-4-5-20251101"
+	defaultValidationModel = "claude-sonnet-4-5-20250929"
+)
+
+// Provider returns the configured LLM provider: "anthropic", "cli", or "mock".
+// LLM_PROVIDER takes precedence; otherwise the legacy USE_CLI_GENERATOR and
+// MOCK_GENERATOR flags are honored, defaulting to the Anthropic API.
+func Provider() string {
+	if p := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER"))); p != "" {
+		return p
+	}
+	if os.Getenv("USE_CLI_GENERATOR") == "true" {
+		return "cli"
+	}
+	if os.Getenv("MOCK_GENERATOR") == "true" {
+		return "mock"
+	}
+	return "anthropic"
+}
+
 func NewGenerator() *Generator {
 	var llm LLMClient
 	model := "mock"
 
-	if os.Getenv("USE_CLI_GENERATOR") == "true" {
+	switch provider := Provider(); provider {
+	case "cli":
 		cliPath := os.Getenv("CLAUDE_CLI_PATH")
 		if cliPath == "" {
 			cliPath = "claude"
@@ -44,13 +69,16 @@ func NewGenerator() *Generator {
 		llm = NewCLIClient(cliPath)
 		model = "claude-cli"
 		log.Println("Generator using Claude CLI (local plan)")
-	} else if os.Getenv("MOCK_GENERATOR") == "true" {
+	case "mock":
 		llm = NewMockClient()
 		log.Println("Generator using mock data")
-	} else {
+	default:
+		if provider != "anthropic" {
+			log.Printf("Unknown LLM_PROVIDER %q, falling back to anthropic", provider)
+		}
 		model = os.Getenv("ANTHROPIC_MODEL")
 		if model == "" {
-			model = "claude-opus-4-5-20251101"
+			model = defaultGenerationModel
 		}
 		llm = NewAPIClient(model)
 		log.Println("Generator using Anthropic API:", model)
@@ -63,9 +91,9 @@ func (g *Generator) ModelName() string {
 	return g.model
 }
 
-func (g *Generator) GenerateLRBatch(ctx context.Context, subtype models.LRSubtype, difficulty models.Difficulty, count int) (*GeneratedBatch, *LLMResponse, error) {
+func (g *Generator) GenerateLRBatch(ctx context.Context, subtype models.LRSubtype, difficulty models.Difficulty, count int, language string, target *models.ScoreRange) (*GeneratedBatch, *LLMResponse, error) {
 	systemPrompt := LRSystemPrompt()
-	userPrompt := BuildLRUserPrompt(subtype, difficulty, count)
+	userPrompt := withExplanationLanguage(withDifficultyTarget(BuildLRUserPrompt(subtype, difficulty, count), target), language)
 
 	resp, err := g.llm.Generate(ctx, systemPrompt, userPrompt)
 	if err != nil {
@@ -76,13 +104,14 @@ func (g *Generator) GenerateLRBatch(ctx context.Context, subtype models.LRSubtyp
 	if err != nil {
 		return nil, resp, fmt.Errorf("parse LR response: %w", err)
 	}
+	batch.PromptVersion = PromptVersion
 
 	return batch, resp, nil
 }
 
-func (g *Generator) GenerateRCBatch(ctx context.Context, difficulty models.Difficulty, questionsPerPassage int, subjectArea string, comparative bool) (*GeneratedBatch, *LLMResponse, error) {
+func (g *Generator) GenerateRCBatch(ctx context.Context, difficulty models.Difficulty, questionsPerPassage int, subjectArea string, comparative bool, language string, target *models.ScoreRange) (*GeneratedBatch, *LLMResponse, error) {
 	systemPrompt := RCSystemPrompt()
-	userPrompt := BuildRCUserPrompt(difficulty, questionsPerPassage, subjectArea, comparative)
+	userPrompt := withExplanationLanguage(withDifficultyTarget(BuildRCUserPrompt(difficulty, questionsPerPassage, subjectArea, comparative), target), language)
 
 	resp, err := g.llm.Generate(ctx, systemPrompt, userPrompt)
 	if err != nil {
@@ -93,25 +122,39 @@ func (g *Generator) GenerateRCBatch(ctx context.Context, difficulty models.Diffi
 	if err != nil {
 		return nil, resp, fmt.Errorf("parse RC response: %w", err)
 	}
+	batch.PromptVersion = PromptVersion
 
 	return batch, resp, nil
 }
 
-// AssignDifficultyScore maps a generation difficulty enum to a numeric score (0-100).
-// Each difficulty band gets a random score within its range.
-func AssignDifficultyScore(difficulty models.Difficulty) int {
+// DifficultyBand returns the difficulty_score range a difficulty enum covers.
+// The bands tile 0-100 with the same cut points adaptive serving uses to map
+// scores back to an enum.
+func DifficultyBand(difficulty models.Difficulty) models.ScoreRange {
 	switch difficulty {
 	case models.DifficultyEasy:
-		return 10 + rand.Intn(26) // 10-35
+		return models.ScoreRange{Min: 0, Max: 35}
 	case models.DifficultyMedium:
-		return 40 + rand.Intn(26) // 40-65
+		return models.ScoreRange{Min: 36, Max: 65}
 	case models.DifficultyHard:
-		return 70 + rand.Intn(26) // 70-95
+		return models.ScoreRange{Min: 66, Max: 100}
 	default:
-		return 50
+		return models.ScoreRange{Min: 50, Max: 50}
 	}
 }
 
+// AssignDifficultyScore maps a generation difficulty enum to a numeric score
+// (0-100), picked at random across the enum's band so scores fill the range.
+func AssignDifficultyScore(difficulty models.Difficulty) int {
+	return AssignDifficultyScoreInRange(DifficultyBand(difficulty))
+}
+
+// AssignDifficultyScoreInRange picks a random score within target, for
+// batches generated against a specific score range.
+func AssignDifficultyScoreInRange(target models.ScoreRange) int {
+	return target.Min + rand.Intn(target.Max-target.Min+1)
+}
+
 // ── APIClient — Anthropic SDK (Production) ─────────────────
 
 type APIClient struct {
@@ -191,6 +234,9 @@ func NewMockClient() *MockClient {
 }
 
 func (m *MockClient) Generate(ctx context.Context, systemPrompt string, userPrompt string) (*LLMResponse, error) {
+	if systemPrompt == explanationSystemPrompt {
+		return &LLMResponse{Content: buildMockExplanationJSON(), PromptTokens: 800, OutputTokens: 600}, nil
+	}
 	mockJSON := buildMockJSON()
 	return &LLMResponse{
 		Content:      mockJSON,
@@ -243,3 +289,15 @@ func buildMockJSON() string {
 
 	return fmt.Sprintf(`{"questions":%s}`, questions)
 }
+
+func buildMockExplanationJSON() string {
+	choices := "["
+	for i, id := range []string{"A", "B", "C", "D", "E"} {
+		if i > 0 {
+			choices += ","
+		}
+		choices += fmt.Sprintf(`{"id":"%s","explanation":"[Mock] Rewritten explanation for choice %s."}`, id, id)
+	}
+	choices += "]"
+	return fmt.Sprintf(`{"explanation":"[Mock] Rewritten explanation of the correct answer.","choices":%s}`, choices)
+}
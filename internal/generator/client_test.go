@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestGenerateLRBatch_RecordsPromptVersion(t *testing.T) {
+	g := &Generator{llm: NewMockClient(), model: "mock"}
+
+	batch, _, err := g.GenerateLRBatch(context.Background(), models.SubtypeStrengthen, models.DifficultyMedium, 6, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.PromptVersion != PromptVersion {
+		t.Errorf("expected prompt version %q, got %q", PromptVersion, batch.PromptVersion)
+	}
+}
+
+func TestGenerateRCBatch_RecordsPromptVersion(t *testing.T) {
+	g := &Generator{llm: NewMockClient(), model: "mock"}
+
+	batch, _, err := g.GenerateRCBatch(context.Background(), models.DifficultyHard, 6, "law", false, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.PromptVersion != PromptVersion {
+		t.Errorf("expected prompt version %q, got %q", PromptVersion, batch.PromptVersion)
+	}
+}
+
+func TestRegenerateExplanations_RejectsIncompleteRewrite(t *testing.T) {
+	g := &Generator{llm: NewMockClient(), model: "mock"}
+	q := GeneratedQuestion{QuestionStem: "Stem", CorrectAnswerID: "B"}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		q.Choices = append(q.Choices, GeneratedChoice{ID: id, Text: "Choice " + id})
+	}
+
+	rw, _, err := g.RegenerateExplanations(context.Background(), q, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rw.Explanation == "" || len(rw.Choices) != 5 {
+		t.Errorf("rewrite = %+v, want an explanation for the question and each choice", rw)
+	}
+
+	partial := &ExplanationRewrite{Explanation: "Fine.", Choices: rw.Choices[:4]}
+	if err := checkExplanationRewrite(q, partial); err == nil {
+		t.Error("rewrite missing choice E should be rejected")
+	}
+}
+
+func TestAssignDifficultyScore_SpreadsAcrossBand(t *testing.T) {
+	for _, d := range []models.Difficulty{models.DifficultyEasy, models.DifficultyMedium, models.DifficultyHard} {
+		band := DifficultyBand(d)
+		seen := map[int]bool{}
+		for i := 0; i < 2000; i++ {
+			score := AssignDifficultyScore(d)
+			if score < band.Min || score > band.Max {
+				t.Fatalf("%s score %d outside band %d-%d", d, score, band.Min, band.Max)
+			}
+			seen[score] = true
+		}
+		if !seen[band.Min] || !seen[band.Max] {
+			t.Errorf("%s scores never reached the band ends %d and %d", d, band.Min, band.Max)
+		}
+		if len(seen) < (band.Max-band.Min+1)*3/4 {
+			t.Errorf("%s scores hit only %d of %d values", d, len(seen), band.Max-band.Min+1)
+		}
+	}
+
+	hard := map[bool]int{}
+	for i := 0; i < 2000; i++ {
+		hard[AssignDifficultyScore(models.DifficultyHard) > 80]++
+	}
+	if hard[true] == 0 {
+		t.Error("no hard question scored above 80")
+	}
+}
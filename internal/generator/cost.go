@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"math"
+	"strings"
+)
+
+// ModelPrice is the list price of a model in US cents per million tokens.
+type ModelPrice struct {
+	InputCentsPerMTok  float64
+	OutputCentsPerMTok float64
+}
+
+// modelPrices is keyed by model family prefix so dated snapshots match.
+var modelPrices = map[string]ModelPrice{
+	"claude-opus":   {InputCentsPerMTok: 500, OutputCentsPerMTok: 2500},
+	"claude-sonnet": {InputCentsPerMTok: 300, OutputCentsPerMTok: 1500},
+	"claude-haiku":  {InputCentsPerMTok: 100, OutputCentsPerMTok: 500},
+}
+
+// PriceForModel returns the pricing for a model name. Unknown models, the CLI,
+// and the mock client are treated as free.
+func PriceForModel(model string) ModelPrice {
+	for prefix, price := range modelPrices {
+		if strings.HasPrefix(model, prefix) {
+			return price
+		}
+	}
+	return ModelPrice{}
+}
+
+// EstimateCostCents returns the estimated cost in whole cents (rounded up) of
+// a call or set of calls to model with the given token counts.
+func EstimateCostCents(model string, promptTokens, outputTokens int) int {
+	price := PriceForModel(model)
+	cents := float64(promptTokens)*price.InputCentsPerMTok/1_000_000 +
+		float64(outputTokens)*price.OutputCentsPerMTok/1_000_000
+	return int(math.Ceil(cents))
+}
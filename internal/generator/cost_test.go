@@ -0,0 +1,38 @@
+package generator
+
+import "testing"
+
+func TestEstimateCostCents(t *testing.T) {
+	tests := []struct {
+		model          string
+		prompt, output int
+		want           int
+	}{
+		// 1M in * $5 + 1M out * $25 = $30
+		{"claude-opus-4-5-20251101", 1_000_000, 1_000_000, 3000},
+		// 1500 * 300/1M + 3000 * 1500/1M = 0.45 + 4.5 = 4.95 → 5
+		{"claude-sonnet-4-5-20250929", 1500, 3000, 5},
+		{"claude-haiku-4-5", 1_000_000, 0, 100},
+		{"claude-cli", 1_000_000, 1_000_000, 0},
+		{"mock", 1500, 3000, 0},
+		{"claude-opus-4-5-20251101", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := EstimateCostCents(tt.model, tt.prompt, tt.output)
+		if got != tt.want {
+			t.Errorf("EstimateCostCents(%q, %d, %d) = %d, want %d", tt.model, tt.prompt, tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestValidationModelCheaperThanGeneration(t *testing.T) {
+	gen := PriceForModel(defaultGenerationModel)
+	val := PriceForModel(defaultValidationModel)
+	if defaultGenerationModel == defaultValidationModel {
+		t.Fatal("expected validation to default to a different model than generation")
+	}
+	if val.InputCentsPerMTok >= gen.InputCentsPerMTok || val.OutputCentsPerMTok >= gen.OutputCentsPerMTok {
+		t.Errorf("expected default validation model %q to be cheaper than %q", defaultValidationModel, defaultGenerationModel)
+	}
+}
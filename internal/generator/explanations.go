@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExplanationRewrite is a model rewrite of a question's explanations. The
+// question text, choices, and correct answer are never part of it.
+type ExplanationRewrite struct {
+	Explanation string                     `json:"explanation"`
+	Choices     []ExplanationRewriteChoice `json:"choices"`
+}
+
+type ExplanationRewriteChoice struct {
+	ID          string `json:"id"`
+	Explanation string `json:"explanation"`
+}
+
+const explanationSystemPrompt = `You are an expert LSAT tutor who has scored a 180 on the LSAT. You write clear, precise explanations for practice questions. The question, its answer choices, and its correct answer are final; you only rewrite the explanations. Respond with JSON only.`
+
+// RegenerateExplanations asks the model to rewrite the overall and
+// per-choice explanations of q, keeping everything else fixed. The rewrite is
+// in language, which should be the one q's explanations were generated in.
+func (g *Generator) RegenerateExplanations(ctx context.Context, q GeneratedQuestion, passage *GeneratedPassage, language string) (*ExplanationRewrite, *LLMResponse, error) {
+	resp, err := g.llm.Generate(ctx, explanationSystemPrompt, withExplanationLanguage(buildExplanationPrompt(q, passage), language))
+	if err != nil {
+		return nil, nil, fmt.Errorf("regenerate explanations: %w", err)
+	}
+
+	var rw ExplanationRewrite
+	if err := json.Unmarshal([]byte(stripCodeFences(resp.Content)), &rw); err != nil {
+		return nil, resp, fmt.Errorf("failed to parse explanation response: %w", err)
+	}
+	if err := checkExplanationRewrite(q, &rw); err != nil {
+		return nil, resp, err
+	}
+	return &rw, resp, nil
+}
+
+func buildExplanationPrompt(q GeneratedQuestion, passage *GeneratedPassage) string {
+	var sb strings.Builder
+
+	if passage != nil {
+		sb.WriteString("PASSAGE:\n")
+		sb.WriteString(passage.Content)
+		if passage.PassageB != "" {
+			sb.WriteString("\n\nPASSAGE B:\n")
+			sb.WriteString(passage.PassageB)
+		}
+		sb.WriteString("\n\n")
+	}
+
+	if q.Stimulus != "" {
+		sb.WriteString("STIMULUS:\n")
+		sb.WriteString(q.Stimulus)
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("QUESTION:\n")
+	sb.WriteString(q.QuestionStem)
+	sb.WriteString("\n\nCHOICES:\n")
+	for _, c := range q.Choices {
+		sb.WriteString(fmt.Sprintf("(%s) %s\n", c.ID, c.Text))
+	}
+	sb.WriteString(fmt.Sprintf("\nCORRECT ANSWER: %s\n", q.CorrectAnswerID))
+
+	sb.WriteString(`
+Rewrite the explanations. The overall explanation says why the correct answer is right. Each choice explanation says why that choice is right or wrong, naming the specific flaw for wrong choices. Give one explanation for every choice, using the same choice IDs. Respond with JSON only:
+{
+  "explanation": "...",
+  "choices": [
+    {"id": "A", "explanation": "..."},
+    {"id": "B", "explanation": "..."},
+    {"id": "C", "explanation": "..."},
+    {"id": "D", "explanation": "..."},
+    {"id": "E", "explanation": "..."}
+  ]
+}`)
+
+	return sb.String()
+}
+
+// checkExplanationRewrite rejects a rewrite that is blank or doesn't cover
+// exactly the question's choices.
+func checkExplanationRewrite(q GeneratedQuestion, rw *ExplanationRewrite) error {
+	var errs []string
+	rw.Explanation = strings.TrimSpace(rw.Explanation)
+	if rw.Explanation == "" {
+		errs = append(errs, "explanation is empty")
+	}
+
+	want := make(map[string]bool, len(q.Choices))
+	for _, c := range q.Choices {
+		want[c.ID] = true
+	}
+	seen := make(map[string]bool, len(rw.Choices))
+	for i := range rw.Choices {
+		c := &rw.Choices[i]
+		c.ID = strings.ToUpper(strings.TrimSpace(c.ID))
+		c.Explanation = strings.TrimSpace(c.Explanation)
+		switch {
+		case !want[c.ID]:
+			errs = append(errs, fmt.Sprintf("unknown choice %q", c.ID))
+		case seen[c.ID]:
+			errs = append(errs, fmt.Sprintf("duplicate choice %s", c.ID))
+		case c.Explanation == "":
+			errs = append(errs, fmt.Sprintf("choice %s explanation is empty", c.ID))
+		}
+		seen[c.ID] = true
+	}
+	for id := range want {
+		if !seen[id] {
+			errs = append(errs, fmt.Sprintf("choice %s explanation missing", id))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
@@ -8,8 +8,9 @@ import (
 )
 
 type GeneratedBatch struct {
-	Questions []GeneratedQuestion `json:"questions"`
-	Passage   *GeneratedPassage   `json:"passage,omitempty"`
+	Questions     []GeneratedQuestion `json:"questions"`
+	Passage       *GeneratedPassage   `json:"passage,omitempty"`
+	PromptVersion string              `json:"-"`
 }
 
 type GeneratedQuestion struct {
@@ -278,6 +278,11 @@ WRONG ANSWER CONSTRUCTION (Role of a Statement):
 4. RIGHT ROLE, WRONG RELATIONSHIP: Correctly names the role type but misstates what it supports or opposes`,
 }
 
+// PromptVersion identifies the current revision of the generation prompts.
+// Bump it whenever LRSystemPrompt, RCSystemPrompt, or the user prompt
+// builders change so questions can be attributed to the prompt that made them.
+const PromptVersion = "2025.1"
+
 func LRSystemPrompt() string {
 	return `You are an expert LSAT question writer with 20 years of experience at the Law School Admission Council (LSAC). You write questions that are indistinguishable from real LSAT Logical Reasoning questions.
 
@@ -539,6 +544,58 @@ Requirements:
 		questionsPerPassage, string(difficulty), subjectInstruction, comparativeInstruction, passageExample)
 }
 
+// DefaultLanguage is the language questions and explanations are written in
+// unless a batch requests otherwise.
+const DefaultLanguage = "en"
+
+// explanationLanguages maps the supported explanation language codes to the
+// name used in prompts.
+var explanationLanguages = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+}
+
+// SupportedLanguage reports whether explanations can be generated in lang.
+func SupportedLanguage(lang string) bool {
+	_, ok := explanationLanguages[lang]
+	return ok
+}
+
+// withExplanationLanguage appends an instruction to write the explanations
+// in language. Everything else stays in English, so an empty or English
+// language leaves the prompt unchanged.
+func withExplanationLanguage(prompt, language string) string {
+	name, ok := explanationLanguages[language]
+	if !ok || language == DefaultLanguage {
+		return prompt
+	}
+	return prompt + fmt.Sprintf(`
+
+EXPLANATION LANGUAGE:
+- Write every "explanation" field (the question explanation and each choice explanation) in %s
+- Keep the passage, stimulus, question stem, and answer choice text in English
+- Keep all JSON keys, choice IDs, and "wrong_answer_type" labels exactly as specified`, name)
+}
+
+// withDifficultyTarget appends calibration for a 0-100 difficulty_score
+// range. A nil target leaves the prompt unchanged.
+func withDifficultyTarget(prompt string, target *models.ScoreRange) string {
+	if target == nil {
+		return prompt
+	}
+	return prompt + fmt.Sprintf(`
+
+DIFFICULTY TARGET:
+- On a 0-100 scale, where 0 is the easiest real LSAT question and 100 the hardest, every question must land between %d and %d
+- Calibrate distractor strength and reasoning subtlety to that range, not just the named difficulty band`, target.Min, target.Max)
+}
+
 // GetSubtypeStems returns the question stems for a given subtype.
 func GetSubtypeStems(subtype models.LRSubtype) []string {
 	return subtypeStems[subtype]
@@ -122,3 +122,33 @@ func TestWrongAnswerRulesInjectedIntoPrompt(t *testing.T) {
 		}
 	}
 }
+
+func TestWithExplanationLanguage(t *testing.T) {
+	base := BuildLRUserPrompt(models.SubtypeStrengthen, models.DifficultyMedium, 6)
+	if got := withExplanationLanguage(base, ""); got != base {
+		t.Error("empty language should leave the prompt unchanged")
+	}
+	if got := withExplanationLanguage(base, DefaultLanguage); got != base {
+		t.Error("English should leave the prompt unchanged")
+	}
+
+	prompt := withExplanationLanguage(base, "es")
+	if !strings.Contains(prompt, "EXPLANATION LANGUAGE") || !strings.Contains(prompt, "in Spanish") {
+		t.Error("Spanish prompt should instruct explanations in Spanish")
+	}
+	if !strings.HasPrefix(prompt, base) {
+		t.Error("language instruction should be appended to the base prompt")
+	}
+}
+
+func TestWithDifficultyTarget(t *testing.T) {
+	base := BuildLRUserPrompt(models.SubtypeFlaw, models.DifficultyHard, 6)
+	if got := withDifficultyTarget(base, nil); got != base {
+		t.Error("nil target should leave the prompt unchanged")
+	}
+
+	prompt := withDifficultyTarget(base, &models.ScoreRange{Min: 81, Max: 100})
+	if !strings.HasPrefix(prompt, base) || !strings.Contains(prompt, "between 81 and 100") {
+		t.Errorf("prompt should append the 81-100 target, got:\n%s", prompt[len(base):])
+	}
+}
@@ -1,15 +1,168 @@
 package generator
 
+import (
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
 // StructuralScore holds the individual structural compliance checks.
+// The first four are compliance checks (true = OK); the remaining fields flag
+// problems (true = penalize).
 type StructuralScore struct {
 	StimulusLengthOK       bool
 	AllChoicesInRange       bool
 	AllExplanationsPresent  bool
 	CorrectAnswerDistribOK bool
+
+	// MissingRequiredTrap is set when an LR subtype requires a specific
+	// distractor archetype (see requiredTrapTypes) and no wrong answer has it.
+	MissingRequiredTrap bool
+
+	// CorrectLengthOutlier is set when the correct answer is conspicuously
+	// longer or shorter than the wrong answers — a known test-taking tell.
+	CorrectLengthOutlier bool
+
+	// HasMetaChoice is set when a choice refers to other choices ("none of
+	// the above", "both A and B"). Real LSAT questions never do this.
+	HasMetaChoice bool
+
+	// LengthBalance is informational (0.0-1.0): 1 minus the coefficient of
+	// variation of choice lengths. It is persisted but not scored directly.
+	LengthBalance float64
+}
+
+// metaChoicePatterns match answer choices that reference other choices.
+// Only explicit references count: "answer choice A", "of the above", a
+// choice that opens "Both A and B", or a parenthesized letter joined to
+// another choice ("(A) and (C)", "either (B)"). Other letters are ordinary
+// LSAT prose ("option A", "subsection (B) of the statute", "none of the
+// other council members").
+var metaChoicePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(all|none|neither|both|any) of the (above|preceding|answer choices|other answer choices)\b`),
+	regexp.MustCompile(`\b(?i:answer\s+choices?)\s+\(?[A-E]\)?(\W|$)`),
+	regexp.MustCompile(`^(?i)(both|either|neither)\s+\(?[A-E]\)?\s+(and|or|nor)\s+\(?[A-E]\)?(\W|$)`),
+	regexp.MustCompile(`\([A-E]\)\s*(?i:,|and|or|nor)\s*\(?[A-E]\)?(\W|$)`),
+	regexp.MustCompile(`(?i)\b(both|either|neither|only)\s+\([A-E]\)`),
+}
+
+// IsMetaChoice reports whether choice text refers to other answer choices.
+func IsMetaChoice(text string) bool {
+	for _, re := range metaChoicePatterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Length outlier thresholds, relative to the mean wrong-answer length.
+const (
+	correctLongRatio  = 1.5
+	correctShortRatio = 0.6
+)
+
+// ChoiceLengthBalance measures how uniform the answer choice lengths are and
+// whether the correct answer stands out. balance is 1 minus the coefficient of
+// variation of all choice lengths, clamped to [0, 1]. The correct answer is an
+// outlier when it is the longest choice and at least 1.5x the mean wrong-answer
+// length, or the shortest and at most 0.6x.
+func ChoiceLengthBalance(q GeneratedQuestion) (balance float64, correctOutlier bool) {
+	if len(q.Choices) < 2 {
+		return 1.0, false
+	}
+
+	var lengths []float64
+	var correctLen, wrongSum float64
+	longest, shortest := 0.0, math.MaxFloat64
+	wrongCount := 0
+	for _, c := range q.Choices {
+		l := float64(len(c.Text))
+		lengths = append(lengths, l)
+		longest = math.Max(longest, l)
+		shortest = math.Min(shortest, l)
+		if c.ID == q.CorrectAnswerID {
+			correctLen = l
+		} else {
+			wrongSum += l
+			wrongCount++
+		}
+	}
+
+	mean := 0.0
+	for _, l := range lengths {
+		mean += l
+	}
+	mean /= float64(len(lengths))
+	if mean == 0 {
+		return 1.0, false
+	}
+	variance := 0.0
+	for _, l := range lengths {
+		variance += (l - mean) * (l - mean)
+	}
+	cv := math.Sqrt(variance/float64(len(lengths))) / mean
+	balance = math.Max(0, math.Min(1, 1-cv))
+
+	if wrongCount > 0 && wrongCount < len(q.Choices) {
+		wrongMean := wrongSum / float64(wrongCount)
+		if correctLen == longest && correctLen >= correctLongRatio*wrongMean {
+			correctOutlier = true
+		}
+		if correctLen == shortest && correctLen <= correctShortRatio*wrongMean {
+			correctOutlier = true
+		}
+	}
+	return balance, correctOutlier
+}
+
+// requiredTrapTypes lists the wrong_answer_type labels the LR prompt requires
+// at least one distractor to carry, per subtype.
+var requiredTrapTypes = map[models.LRSubtype][]string{
+	models.SubtypeStrengthen: {"weakener"},
+	models.SubtypeWeaken:     {"strengthener"},
+}
+
+// normalizeWrongAnswerType folds label variants ("Weakener", "out of scope",
+// "out-of-scope", "premise/conclusion") into the snake_case form used in
+// prompts.
+func normalizeWrongAnswerType(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	label = strings.NewReplacer(" ", "_", "-", "_", "/", "_").Replace(label)
+	return label
+}
+
+// hasRequiredTraps reports whether every trap archetype required for the
+// subtype appears among the question's wrong answers.
+func hasRequiredTraps(q GeneratedQuestion, subtype models.LRSubtype) bool {
+	required := requiredTrapTypes[subtype]
+	if len(required) == 0 {
+		return true
+	}
+
+	present := make(map[string]bool)
+	for _, c := range q.Choices {
+		if c.ID == q.CorrectAnswerID || c.WrongAnswerType == nil {
+			continue
+		}
+		present[normalizeWrongAnswerType(*c.WrongAnswerType)] = true
+	}
+	for _, r := range required {
+		if !present[r] {
+			return false
+		}
+	}
+	return true
 }
 
 // ComputeStructuralScore evaluates structural compliance for a single question.
-func ComputeStructuralScore(q GeneratedQuestion, isRC bool) StructuralScore {
+// lrSubtype is empty for RC questions.
+func ComputeStructuralScore(q GeneratedQuestion, isRC bool, lrSubtype models.LRSubtype) StructuralScore {
 	stimOK := true
 	if !isRC {
 		stimLen := len(q.Stimulus)
@@ -28,14 +181,39 @@ func ComputeStructuralScore(q GeneratedQuestion, isRC bool) StructuralScore {
 		}
 	}
 
+	balance, lengthOutlier := ChoiceLengthBalance(q)
+
+	metaChoice := false
+	for _, c := range q.Choices {
+		if IsMetaChoice(c.Text) {
+			metaChoice = true
+			break
+		}
+	}
+
 	return StructuralScore{
 		StimulusLengthOK:       stimOK,
 		AllChoicesInRange:       choicesOK,
 		AllExplanationsPresent:  explOK,
 		CorrectAnswerDistribOK: true, // Set externally based on batch-level analysis
+		MissingRequiredTrap:    !isRC && !hasRequiredTraps(q, lrSubtype),
+		CorrectLengthOutlier:   lengthOutlier,
+		HasMetaChoice:          metaChoice,
+		LengthBalance:          balance,
 	}
 }
 
+// ShouldFlag reports whether a structural problem warrants flagging the
+// question for review regardless of its overall quality score.
+func (s StructuralScore) ShouldFlag() bool {
+	return s.MissingRequiredTrap
+}
+
+// ShouldReject reports whether a structural problem disqualifies the question.
+func (s StructuralScore) ShouldReject() bool {
+	return s.HasMetaChoice
+}
+
 // ComputeQualityScore calculates a composite quality score (0.0-1.0).
 //
 // Formula: verification_confidence * 0.40 + adversarial_cleanliness * 0.35 + structural * 0.25
@@ -89,17 +267,81 @@ func ComputeQualityScore(vr *ValidationResult, ar *AdversarialResult, structural
 		structuralScore += 0.25
 	}
 
+	// Problem flags each cost one check's worth
+	if structural.MissingRequiredTrap {
+		structuralScore -= 0.25
+	}
+	if structural.CorrectLengthOutlier {
+		structuralScore -= 0.25
+	}
+	if structural.HasMetaChoice {
+		structuralScore -= 0.25
+	}
+	if structuralScore < 0 {
+		structuralScore = 0
+	}
+
 	return verificationScore*0.40 + adversarialScore*0.35 + structuralScore*0.25
 }
 
-// ClassifyQuality returns a classification based on the quality score.
-// Returns: "reject" (< 0.50), "flagged" (0.50-0.70), "passed" (> 0.70)
-func ClassifyQuality(score float64) string {
-	if score < 0.50 {
+// QualityThresholds are the quality score boundaries between classes:
+// scores below RejectBelow are rejected, scores from RejectBelow up to and
+// including FlagBelow are flagged, and higher scores pass.
+type QualityThresholds struct {
+	RejectBelow float64
+	FlagBelow   float64
+}
+
+// DefaultQualityThresholds apply unless QUALITY_REJECT_BELOW or
+// QUALITY_FLAG_BELOW say otherwise.
+var DefaultQualityThresholds = QualityThresholds{RejectBelow: 0.50, FlagBelow: 0.70}
+
+// QualityThresholdsFromEnv returns the thresholds set by QUALITY_REJECT_BELOW
+// and QUALITY_FLAG_BELOW, each in [0, 1], falling back to the defaults for
+// unset or invalid values. A reject threshold above the flag threshold is
+// ignored.
+func QualityThresholdsFromEnv() QualityThresholds {
+	t := DefaultQualityThresholds
+	for _, c := range []struct {
+		key string
+		dst *float64
+	}{
+		{"QUALITY_REJECT_BELOW", &t.RejectBelow},
+		{"QUALITY_FLAG_BELOW", &t.FlagBelow},
+	} {
+		v := os.Getenv(c.key)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			log.Printf("WARN: ignoring %s=%q, want a number in [0, 1]", c.key, v)
+			continue
+		}
+		*c.dst = f
+	}
+	if t.RejectBelow > t.FlagBelow {
+		log.Printf("WARN: ignoring quality thresholds reject<%v flag<=%v: reject must not exceed flag", t.RejectBelow, t.FlagBelow)
+		return DefaultQualityThresholds
+	}
+	return t
+}
+
+// Classify returns "reject", "flagged" or "passed" for score. The zero
+// value rejects nothing and flags only a score of 0; use
+// DefaultQualityThresholds for the standard cut-offs.
+func (t QualityThresholds) Classify(score float64) string {
+	if score < t.RejectBelow {
 		return "reject"
 	}
-	if score <= 0.70 {
+	if score <= t.FlagBelow {
 		return "flagged"
 	}
 	return "passed"
 }
+
+// ClassifyQuality classifies score under DefaultQualityThresholds.
+// Returns: "reject" (< 0.50), "flagged" (0.50-0.70), "passed" (> 0.70)
+func ClassifyQuality(score float64) string {
+	return DefaultQualityThresholds.Classify(score)
+}
@@ -3,6 +3,8 @@ package generator
 import (
 	"math"
 	"testing"
+
+	"github.com/lsat-prep/backend/internal/models"
 )
 
 func TestComputeQualityScore_AllPerfect(t *testing.T) {
@@ -131,7 +133,7 @@ func TestComputeStructuralScore(t *testing.T) {
 		{ID: "E", Text: string(make([]byte, 30)), Explanation: "expl"},
 	}
 
-	score := ComputeStructuralScore(q, false)
+	score := ComputeStructuralScore(q, false, models.SubtypeAssumption)
 	if !score.StimulusLengthOK {
 		t.Error("expected StimulusLengthOK = true for 200-char stimulus")
 	}
@@ -155,7 +157,7 @@ func TestComputeStructuralScore_RC(t *testing.T) {
 		},
 	}
 
-	score := ComputeStructuralScore(q, true)
+	score := ComputeStructuralScore(q, true, "")
 	if !score.StimulusLengthOK {
 		t.Error("expected StimulusLengthOK = true for RC question (stimulus check skipped)")
 	}
@@ -192,3 +194,249 @@ func TestDetermineAdversarialScore(t *testing.T) {
 func almostEqual(a, b float64) bool {
 	return math.Abs(a-b) < 0.001
 }
+
+func trapChoices(correct string, labels ...string) []GeneratedChoice {
+	var choices []GeneratedChoice
+	i := 0
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		c := GeneratedChoice{ID: id, Text: string(make([]byte, 30)), Explanation: "expl"}
+		if id != correct && i < len(labels) {
+			label := labels[i]
+			c.WrongAnswerType = &label
+			i++
+		}
+		choices = append(choices, c)
+	}
+	return choices
+}
+
+func TestComputeStructuralScore_MissingRequiredTrap(t *testing.T) {
+	q := GeneratedQuestion{
+		Stimulus:        string(make([]byte, 200)),
+		CorrectAnswerID: "B",
+		Choices:         trapChoices("B", "irrelevant", "out_of_scope", "restates_premise", "irrelevant"),
+	}
+
+	score := ComputeStructuralScore(q, false, models.SubtypeStrengthen)
+	if !score.MissingRequiredTrap {
+		t.Error("expected strengthen question without a weakener to be missing its required trap")
+	}
+	if !score.ShouldFlag() {
+		t.Error("expected missing trap to flag the question")
+	}
+
+	// Label variants are normalized
+	q.Choices = trapChoices("B", "irrelevant", "Weakener", "out of scope", "irrelevant")
+	score = ComputeStructuralScore(q, false, models.SubtypeStrengthen)
+	if score.MissingRequiredTrap {
+		t.Error("expected 'Weakener' label to satisfy the strengthen trap requirement")
+	}
+
+	// The correct answer's label doesn't count toward coverage
+	weakener := "weakener"
+	q.Choices = trapChoices("B", "irrelevant", "irrelevant", "irrelevant", "irrelevant")
+	q.Choices[1].WrongAnswerType = &weakener
+	score = ComputeStructuralScore(q, false, models.SubtypeStrengthen)
+	if !score.MissingRequiredTrap {
+		t.Error("expected a weakener label on the correct answer not to count")
+	}
+
+	// Subtypes without a required archetype are never penalized
+	q.Choices = trapChoices("B", "irrelevant", "irrelevant", "irrelevant", "irrelevant")
+	score = ComputeStructuralScore(q, false, models.SubtypeFlaw)
+	if score.MissingRequiredTrap {
+		t.Error("expected no trap requirement for flaw questions")
+	}
+}
+
+func TestComputeQualityScore_MissingTrapPenalty(t *testing.T) {
+	vr := &ValidationResult{Confidence: "high", Matches: true}
+	structural := StructuralScore{
+		StimulusLengthOK:       true,
+		AllChoicesInRange:      true,
+		AllExplanationsPresent: true,
+		CorrectAnswerDistribOK: true,
+		MissingRequiredTrap:    true,
+	}
+
+	score := ComputeQualityScore(vr, nil, structural)
+	// verification: 1.0*0.40 + adversarial: 1.0*0.35 + structural: 0.75*0.25 = 0.9375
+	if !almostEqual(score, 0.9375) {
+		t.Errorf("expected score ~0.9375, got %f", score)
+	}
+}
+
+func TestChoiceLengthBalance_CorrectAnswerFarLonger(t *testing.T) {
+	q := GeneratedQuestion{
+		Stimulus:        string(make([]byte, 200)),
+		CorrectAnswerID: "C",
+		Choices: []GeneratedChoice{
+			{ID: "A", Text: string(make([]byte, 60)), Explanation: "expl"},
+			{ID: "B", Text: string(make([]byte, 55)), Explanation: "expl"},
+			{ID: "C", Text: string(make([]byte, 240)), Explanation: "expl"},
+			{ID: "D", Text: string(make([]byte, 65)), Explanation: "expl"},
+			{ID: "E", Text: string(make([]byte, 60)), Explanation: "expl"},
+		},
+	}
+
+	balance, outlier := ChoiceLengthBalance(q)
+	if !outlier {
+		t.Error("expected correct answer 4x longer than the others to be an outlier")
+	}
+	if balance > 0.5 {
+		t.Errorf("expected low length balance, got %f", balance)
+	}
+
+	structural := ComputeStructuralScore(q, false, models.SubtypeFlaw)
+	if !structural.CorrectLengthOutlier {
+		t.Error("expected CorrectLengthOutlier in structural score")
+	}
+
+	vr := &ValidationResult{Confidence: "high", Matches: true}
+	score := ComputeQualityScore(vr, nil, structural)
+	// verification: 1.0*0.40 + adversarial: 1.0*0.35 + structural: 0.75*0.25 = 0.9375
+	if !almostEqual(score, 0.9375) {
+		t.Errorf("expected length outlier penalty to give ~0.9375, got %f", score)
+	}
+}
+
+func TestChoiceLengthBalance_Balanced(t *testing.T) {
+	q := GeneratedQuestion{
+		CorrectAnswerID: "A",
+		Choices: []GeneratedChoice{
+			{ID: "A", Text: string(make([]byte, 70))},
+			{ID: "B", Text: string(make([]byte, 60))},
+			{ID: "C", Text: string(make([]byte, 65))},
+			{ID: "D", Text: string(make([]byte, 75))},
+			{ID: "E", Text: string(make([]byte, 62))},
+		},
+	}
+
+	balance, outlier := ChoiceLengthBalance(q)
+	if outlier {
+		t.Error("expected no outlier for similar-length choices")
+	}
+	if balance < 0.9 {
+		t.Errorf("expected high length balance, got %f", balance)
+	}
+}
+
+func TestChoiceLengthBalance_CorrectAnswerFarShorter(t *testing.T) {
+	q := GeneratedQuestion{
+		CorrectAnswerID: "E",
+		Choices: []GeneratedChoice{
+			{ID: "A", Text: string(make([]byte, 100))},
+			{ID: "B", Text: string(make([]byte, 110))},
+			{ID: "C", Text: string(make([]byte, 95))},
+			{ID: "D", Text: string(make([]byte, 105))},
+			{ID: "E", Text: string(make([]byte, 40))},
+		},
+	}
+
+	if _, outlier := ChoiceLengthBalance(q); !outlier {
+		t.Error("expected much shorter correct answer to be an outlier")
+	}
+}
+
+func TestIsMetaChoice(t *testing.T) {
+	offending := []string{
+		"None of the above.",
+		"All of the above are assumptions of the argument.",
+		"Both (A) and (B)",
+		"Either (B) or (D)",
+		"Answer choices B and D together weaken the argument.",
+		"The claims in answer choice C, if true.",
+		"(A) and (C) only",
+		"Any of the other answer choices would also work.",
+		"Both A and B are required for the permit.",
+		"Neither B nor D.",
+		"Either (C) or E",
+		"(B), (C), and (E)",
+	}
+	for _, text := range offending {
+		if !IsMetaChoice(text) {
+			t.Errorf("expected %q to be detected as a meta-choice", text)
+		}
+	}
+
+	clean := []string{
+		"The study's sample was not representative of the population.",
+		"Country A and country B had similar growth rates.",
+		"Both of the researchers agreed the data were incomplete.",
+		"Some of the above-ground structures were damaged.",
+		"The committee made a choice about funding.",
+		"Plan B was cheaper than plan A.",
+		"None of the other council members supported the proposal.",
+		"The mayor was more popular than any of the other candidates.",
+		"Residents preferred option A to the alternative.",
+		"All of the other studies used larger samples.",
+		"The committee considered the options B and C before voting.",
+		"The claim relies on subsection (B) of the statute.",
+		"Under clause (A), the tenant may withhold rent.",
+		"Both plan A and plan B exceeded the budget.",
+	}
+	for _, text := range clean {
+		if IsMetaChoice(text) {
+			t.Errorf("expected %q not to be detected as a meta-choice", text)
+		}
+	}
+}
+
+func TestComputeStructuralScore_MetaChoiceRejected(t *testing.T) {
+	q := GeneratedQuestion{
+		Stimulus:        string(make([]byte, 200)),
+		CorrectAnswerID: "A",
+		Choices:         trapChoices("A", "irrelevant", "irrelevant", "irrelevant", "irrelevant"),
+	}
+	q.Choices[4].Text = "None of the above choices strengthens the argument."
+
+	score := ComputeStructuralScore(q, false, models.SubtypeFlaw)
+	if !score.HasMetaChoice {
+		t.Error("expected HasMetaChoice for 'none of the above' choice")
+	}
+	if !score.ShouldReject() {
+		t.Error("expected meta-choice question to be rejected")
+	}
+}
+
+func TestQualityThresholds_ConfiguredBoundaries(t *testing.T) {
+	t.Setenv("QUALITY_REJECT_BELOW", "0.6")
+	t.Setenv("QUALITY_FLAG_BELOW", "0.8")
+	strict := QualityThresholdsFromEnv()
+	if strict != (QualityThresholds{RejectBelow: 0.6, FlagBelow: 0.8}) {
+		t.Fatalf("thresholds = %+v, want reject<0.6 flag<=0.8", strict)
+	}
+
+	cases := []struct {
+		score         float64
+		def, stricter string
+	}{
+		{0.55, "flagged", "reject"},
+		{0.60, "flagged", "flagged"},
+		{0.75, "passed", "flagged"},
+		{0.80, "passed", "flagged"},
+		{0.81, "passed", "passed"},
+	}
+	for _, c := range cases {
+		if got := DefaultQualityThresholds.Classify(c.score); got != c.def {
+			t.Errorf("default: %.2f = %q, want %q", c.score, got, c.def)
+		}
+		if got := strict.Classify(c.score); got != c.stricter {
+			t.Errorf("strict: %.2f = %q, want %q", c.score, got, c.stricter)
+		}
+	}
+
+	// Invalid or inverted settings fall back to the defaults
+	t.Setenv("QUALITY_REJECT_BELOW", "0.9")
+	if got := QualityThresholdsFromEnv(); got != DefaultQualityThresholds {
+		t.Errorf("reject above flag = %+v, want defaults", got)
+	}
+	t.Setenv("QUALITY_REJECT_BELOW", "high")
+	t.Setenv("QUALITY_FLAG_BELOW", "")
+	if got := QualityThresholdsFromEnv(); got != DefaultQualityThresholds {
+		t.Errorf("invalid reject = %+v, want defaults", got)
+	}
+	if got := (QualityThresholds{}).Classify(0.55); got != "passed" {
+		t.Errorf("zero value: 0.55 = %q, want passed without thresholds", got)
+	}
+}
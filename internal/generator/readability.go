@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// TextStats holds the raw counts used by readability formulas.
+type TextStats struct {
+	Words     int
+	Sentences int
+	Syllables int
+}
+
+// ComputeTextStats counts words, sentences, and syllables in text.
+// A sentence ends at '.', '!' or '?'; text without terminal punctuation
+// counts as one sentence.
+func ComputeTextStats(text string) TextStats {
+	var stats TextStats
+	for _, w := range strings.Fields(text) {
+		word := strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if word == "" {
+			continue
+		}
+		stats.Words++
+		stats.Syllables += CountSyllables(word)
+	}
+
+	inTerminator := false
+	for _, r := range text {
+		if r == '.' || r == '!' || r == '?' {
+			if !inTerminator {
+				stats.Sentences++
+			}
+			inTerminator = true
+			continue
+		}
+		inTerminator = false
+	}
+	if stats.Sentences == 0 && stats.Words > 0 {
+		stats.Sentences = 1
+	}
+	return stats
+}
+
+// CountSyllables estimates the syllables in a single word by counting vowel
+// groups and dropping a silent trailing 'e'. Every word has at least one.
+func CountSyllables(word string) int {
+	w := strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, r := range w {
+		v := strings.ContainsRune("aeiouy", r)
+		if v && !prevVowel {
+			count++
+		}
+		prevVowel = v
+	}
+
+	if count > 1 && strings.HasSuffix(w, "e") && !strings.HasSuffix(w, "le") {
+		count--
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+// FleschKincaidGrade returns the Flesch-Kincaid grade level of text,
+// rounded to one decimal place. Empty text scores 0.
+//
+// Formula: 0.39 * (words/sentences) + 11.8 * (syllables/words) - 15.59
+func FleschKincaidGrade(text string) float64 {
+	stats := ComputeTextStats(text)
+	if stats.Words == 0 {
+		return 0
+	}
+	grade := 0.39*float64(stats.Words)/float64(stats.Sentences) +
+		11.8*float64(stats.Syllables)/float64(stats.Words) - 15.59
+	return math.Round(grade*10) / 10
+}
@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCountSyllables(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{"cat", 1},
+		{"the", 1},
+		{"make", 1},
+		{"table", 2},
+		{"argument", 3},
+		{"readability", 5},
+		{"interpretation", 5},
+		{"rhythm", 1},
+		{"A", 1},
+	}
+
+	for _, tt := range tests {
+		if got := CountSyllables(tt.word); got != tt.want {
+			t.Errorf("CountSyllables(%q) = %d, want %d", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestComputeTextStats(t *testing.T) {
+	stats := ComputeTextStats("The cat sat on the mat. It was happy!")
+	if stats.Words != 9 {
+		t.Errorf("expected 9 words, got %d", stats.Words)
+	}
+	if stats.Sentences != 2 {
+		t.Errorf("expected 2 sentences, got %d", stats.Sentences)
+	}
+	// 6 one-syllable words + it, was, hap-py
+	if stats.Syllables != 10 {
+		t.Errorf("expected 10 syllables, got %d", stats.Syllables)
+	}
+}
+
+func TestComputeTextStats_NoTerminalPunctuation(t *testing.T) {
+	stats := ComputeTextStats("no punctuation here")
+	if stats.Sentences != 1 {
+		t.Errorf("expected 1 sentence, got %d", stats.Sentences)
+	}
+
+	// Ellipses count as a single sentence break
+	stats = ComputeTextStats("Wait... what?")
+	if stats.Sentences != 2 {
+		t.Errorf("expected 2 sentences, got %d", stats.Sentences)
+	}
+}
+
+func TestFleschKincaidGrade(t *testing.T) {
+	// 6 words, 1 sentence, 6 syllables: 0.39*6 + 11.8*1 - 15.59 = -1.45
+	got := FleschKincaidGrade("The cat sat on the mat.")
+	if math.Abs(got-(-1.45)) > 0.06 {
+		t.Errorf("simple sentence grade = %f, want ~-1.45", got)
+	}
+
+	// 6 words, 2 sentences, 22 syllables: 0.39*3 + 11.8*22/6 - 15.59 = 28.85
+	got = FleschKincaidGrade("Readability matters. Legislation requires careful interpretation.")
+	if math.Abs(got-28.85) > 0.06 {
+		t.Errorf("dense sentence grade = %f, want ~28.85", got)
+	}
+
+	if got := FleschKincaidGrade(""); got != 0 {
+		t.Errorf("empty text grade = %f, want 0", got)
+	}
+}
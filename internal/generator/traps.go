@@ -0,0 +1,65 @@
+package generator
+
+// trapArchetypes describes each wrong_answer_type label the prompts ask for,
+// condensed from the wrong answer rules, so students can learn the traps.
+var trapArchetypes = map[string]string{
+	// LR
+	"irrelevant":                       "Sounds related but doesn't touch the argument's logical gap.",
+	"weakener":                         "Undermines the argument, the opposite of what the question asks.",
+	"strengthener":                     "Supports the argument, the opposite of what the question asks.",
+	"out_of_scope":                     "Addresses a topic related to, but distinct from, what's at issue.",
+	"restates_premise":                 "Repeats information already given instead of adding anything.",
+	"restates_conclusion":              "Says what the argument concludes, not what it assumes.",
+	"too_extreme":                      "Addresses a stronger version of the claim than the one made.",
+	"helps_but_not_required":           "Strengthens the argument but isn't necessary; it fails the negation test.",
+	"wrong_flaw":                       "Names a real reasoning flaw, just not the one this argument commits.",
+	"describes_the_argument_correctly": "Accurately describes what the argument does without identifying an error.",
+	"mischaracterizes":                 "Describes the argument doing something it doesn't actually do.",
+	"could_be_true":                    "Consistent with the stimulus but not required or supported by it.",
+	"goes_beyond":                      "Needs assumptions the stimulus doesn't provide.",
+	"partial_inference":                "True of some cases mentioned but overgeneralizes from them.",
+	"reversal":                         "Gets a conditional or causal relationship backwards.",
+	"extreme_language":                 "Uses absolute words like always or never where the stimulus hedges.",
+	"wrong_method":                     "Describes a real reasoning method, but not the one used here.",
+	"partial_description":              "Captures one aspect of the argument but misses its main technique.",
+	"too_specific":                     "Describes the reasoning more narrowly than the argument warrants.",
+	"too_general":                      "Describes the reasoning so broadly it misses what's distinctive.",
+	"same_topic_wrong_structure":       "Shares the subject matter but not the logical form.",
+	"flawed_when_original_is_valid":    "Introduces a logical error the original doesn't have.",
+	"valid_when_original_is_flawed":    "Fixes the original's error, so the reasoning no longer matches.",
+	"partially_parallel":               "Matches some structural elements but not all of them.",
+	"different_flaw":                   "Contains a reasoning error, but a different one from the original.",
+	"no_flaw":                          "The reasoning is actually valid, so there's no flaw to parallel.",
+	"same_topic":                       "Mimics the subject matter with a different logical structure.",
+	"wrong_direction":                  "A principle that would justify the opposite conclusion.",
+	"irrelevant_principle":             "A valid principle that doesn't connect to this argument's reasoning.",
+	"violates_a_condition":             "The scenario doesn't meet every condition the principle sets.",
+	"wrong_outcome":                    "Meets the principle's conditions but draws the wrong conclusion.",
+	"superficially_similar":            "Shares surface features with the principle without actually applying it.",
+	"reverses_application":             "Applies the principle backwards.",
+	"one_directional":                  "Either answer would only strengthen, or only weaken, the argument.",
+	"irrelevant_question":              "No answer to the question would affect the argument.",
+	"already_answered":                 "The stimulus already provides the information asked about.",
+	"wrong_scope":                      "Asks about something adjacent to, not central to, the argument.",
+	"premise_masquerading":             "A premise from the stimulus posing as the conclusion.",
+	"intermediate_conclusion":          "A sub-conclusion that supports the main conclusion.",
+	"background_info":                  "Context that isn't argued for or against.",
+	"overstated_conclusion":            "Exaggerates what the argument actually claims.",
+	"wrong_role":                       "Notices the statement but mischaracterizes its function.",
+	"confuses_premise_conclusion":      "Calls a premise a conclusion, or a conclusion a premise.",
+	"invents_a_role":                   "Gives the statement a function it doesn't serve.",
+	"right_role_wrong_relationship":    "Names the right kind of role but misstates what it supports or opposes.",
+
+	// RC, plus labels shared with LR
+	"distortion":            "Takes an idea from the passage and subtly changes it.",
+	"too_broad":             "Overgeneralizes beyond what the text supports.",
+	"too_narrow":            "Captures one detail and misses the bigger picture.",
+	"reversed_relationship": "Gets a causal or comparative direction wrong.",
+	"wrong_paragraph":       "Attributes information to the wrong part of the passage.",
+}
+
+// TrapDescription returns a short description of the wrong answer archetype
+// label, or "" if the label isn't one the prompts use.
+func TrapDescription(label string) string {
+	return trapArchetypes[normalizeWrongAnswerType(label)]
+}
@@ -9,34 +9,75 @@ import (
 	"strings"
 )
 
+// confidenceRank orders the validator's confidence levels.
+var confidenceRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// DefaultPassConfidence is the lowest validator confidence that counts as
+// passed unless VALIDATION_PASS_CONFIDENCE says otherwise.
+const DefaultPassConfidence = "high"
+
+// PassConfidence returns the lowest validator confidence ("high", "medium" or
+// "low") that counts as passed, from VALIDATION_PASS_CONFIDENCE. Answers that
+// match with lower confidence are flagged.
+func PassConfidence() string {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("VALIDATION_PASS_CONFIDENCE")))
+	if v == "" {
+		return DefaultPassConfidence
+	}
+	if _, ok := confidenceRank[v]; !ok {
+		log.Printf("WARN: ignoring VALIDATION_PASS_CONFIDENCE=%q, want high, medium or low", v)
+		return DefaultPassConfidence
+	}
+	return v
+}
+
+// ConfidencePasses reports whether confidence is at least minimum. An
+// unrecognised minimum is treated as DefaultPassConfidence, and an
+// unrecognised confidence never passes.
+func ConfidencePasses(confidence, minimum string) bool {
+	min, ok := confidenceRank[minimum]
+	if !ok {
+		min = confidenceRank[DefaultPassConfidence]
+	}
+	return confidenceRank[strings.ToLower(confidence)] >= min
+}
+
 // Validator handles Stage 2 (self-verification) and Stage 3 (adversarial) checks.
 type Validator struct {
 	llm   LLMClient
 	model string
+
+	// passConfidence is the lowest confidence counted as passed
+	passConfidence string
 }
 
 func NewValidator() *Validator {
 	var llm LLMClient
 	model := "mock"
 
-	if os.Getenv("USE_CLI_GENERATOR") == "true" {
+	switch Provider() {
+	case "cli":
 		cliPath := os.Getenv("CLAUDE_CLI_PATH")
 		if cliPath == "" {
 			cliPath = "claude"
 		}
 		llm = NewCLIClient(cliPath)
 		model = "claude-cli"
-	} else if os.Getenv("MOCK_GENERATOR") == "true" {
+	case "mock":
 		llm = nil // Validation is skipped in mock mode
-	} else {
-		model = os.Getenv("ANTHROPIC_VALIDATION_MODEL")
+	default:
+		model = os.Getenv("VALIDATOR_MODEL")
+		if model == "" {
+			model = os.Getenv("ANTHROPIC_VALIDATION_MODEL")
+		}
 		if model == "" {
-			model = "claude-sonnet-4-5-20250929"
+			model = defaultValidationModel
 		}
 		llm = NewAPIClient(model)
+		log.Println("Validator using Anthropic API:", model)
 	}
 
-	return &Validator{llm: llm, model: model}
+	return &Validator{llm: llm, model: model, passConfidence: PassConfidence()}
 }
 
 func (v *Validator) ModelName() string {
@@ -101,7 +142,7 @@ func (v *Validator) ValidateBatch(ctx context.Context, batch *GeneratedBatch) (*
 
 		if vr.SelectedAnswer == q.CorrectAnswerID {
 			vr.Matches = true
-			if vr.Confidence == "high" {
+			if ConfidencePasses(vr.Confidence, v.passConfidence) {
 				result.PassedCount++
 			} else {
 				result.FlaggedCount++
@@ -0,0 +1,24 @@
+// Package logging configures the process-wide logger.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+// New returns a logger writing to w: JSON lines when format is "json", text
+// otherwise.
+func New(w io.Writer, format string) *slog.Logger {
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, nil))
+	}
+	return slog.New(slog.NewTextHandler(w, nil))
+}
+
+// Setup installs the logger selected by LOG_FORMAT as the slog default.
+// Packages still using the standard log package write through it too, so
+// every line comes out in one format.
+func Setup() {
+	slog.SetDefault(New(os.Stderr, os.Getenv("LOG_FORMAT")))
+}
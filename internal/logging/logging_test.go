@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestNew_JSONEmitsParseableLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json")
+	logger.Info("generation completed", "component", "gen-queue", "queue_id", 42)
+	logger.Warn("count unseen failed", "component", "user-gen", "err", errors.New("db down"))
+
+	lines := 0
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		lines++
+		var entry map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", lines, err, sc.Text())
+		}
+		for _, key := range []string{"time", "level", "msg", "component"} {
+			if _, ok := entry[key]; !ok {
+				t.Errorf("line %d missing %q: %s", lines, key, sc.Text())
+			}
+		}
+	}
+	if lines != 2 {
+		t.Errorf("got %d lines, want 2", lines)
+	}
+
+	// Anything else selects text, for local dev
+	buf.Reset()
+	New(&buf, "").Info("hello", "component", "test")
+	if json.Valid(bytes.TrimSpace(buf.Bytes())) {
+		t.Errorf("default format should be text, got %s", buf.String())
+	}
+	if _, ok := New(&buf, "text").Handler().(*slog.TextHandler); !ok {
+		t.Error("text format should use a text handler")
+	}
+}
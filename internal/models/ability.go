@@ -41,6 +41,18 @@ type AbilityResponse struct {
 	DifficultySlider int            `json:"difficulty_slider"`
 }
 
+// AbilityHistoryPoint is a scope's ability at the end of one UTC day.
+type AbilityHistoryPoint struct {
+	Date         string `json:"date"`
+	AbilityScore int    `json:"ability_score"`
+}
+
+type AbilityHistoryResponse struct {
+	Scope      AbilityScope          `json:"scope"`
+	ScopeValue *string               `json:"scope_value,omitempty"`
+	Points     []AbilityHistoryPoint `json:"points"`
+}
+
 type QuickDrillRequest struct {
 	Section          string `json:"section"`
 	DifficultySlider int    `json:"difficulty_slider"`
@@ -53,16 +65,70 @@ type SubtypeDrillRequest struct {
 	RCSubtype        *string `json:"rc_subtype,omitempty"`
 	DifficultySlider int     `json:"difficulty_slider"`
 	Count            int     `json:"count"`
+	// IncludeFlagged serves flagged questions too; honored for admins only
+	IncludeFlagged bool `json:"include_flagged,omitempty"`
+}
+
+// RetryMistakesRequest lists the questions of a finished drill; the ones
+// the user answered wrong are served again.
+type RetryMistakesRequest struct {
+	QuestionIDs []int64 `json:"question_ids"`
 }
 
 type DifficultySliderRequest struct {
 	SliderValue int `json:"slider_value"`
 }
 
+// DiagnosticStartResponse is a placement set spanning the difficulty range
+// in each section.
+// DiagnosticID is sent back with the answers.
+type DiagnosticStartResponse struct {
+	DiagnosticID int64           `json:"diagnostic_id"`
+	Questions    []DrillQuestion `json:"questions"`
+}
+
+type DiagnosticAnswer struct {
+	QuestionID       int64  `json:"question_id"`
+	SelectedChoiceID string `json:"selected_choice_id"`
+	// SelectedChoiceIDs answers a multi-correct question and takes
+	// precedence over SelectedChoiceID
+	SelectedChoiceIDs []string `json:"selected_choice_ids,omitempty"`
+}
+
+// ChoiceIDs returns the submitted selection, whichever field carried it.
+func (a DiagnosticAnswer) ChoiceIDs() []string {
+	return choiceIDs(a.SelectedChoiceID, a.SelectedChoiceIDs)
+}
+
+type DiagnosticSubmitRequest struct {
+	DiagnosticID int64              `json:"diagnostic_id"`
+	Answers      []DiagnosticAnswer `json:"answers"`
+}
+
+// DiagnosticResult holds the abilities set from a diagnostic. Sections the
+// diagnostic had no answers for are left out of SectionAbilities.
+type DiagnosticResult struct {
+	Answered         int            `json:"answered"`
+	Correct          int            `json:"correct"`
+	OverallAbility   int            `json:"overall_ability"`
+	SectionAbilities map[string]int `json:"section_abilities"`
+}
+
+// AbilitySnapshot holds the abilities after an answer. SubtypeAbility is nil
+// for questions without a subtype.
 type AbilitySnapshot struct {
-	OverallAbility int `json:"overall_ability"`
-	SectionAbility int `json:"section_ability"`
-	SubtypeAbility int `json:"subtype_ability"`
+	OverallAbility int  `json:"overall_ability"`
+	SectionAbility int  `json:"section_ability"`
+	SubtypeAbility *int `json:"subtype_ability"`
+}
+
+// XPAbility is the ability the challenge bonus is measured against: the
+// subtype ability when the question has a subtype, else the section ability.
+func (a AbilitySnapshot) XPAbility() int {
+	if a.SubtypeAbility != nil {
+		return *a.SubtypeAbility
+	}
+	return a.SectionAbility
 }
 
 type GenerationQueueItem struct {
@@ -77,7 +143,18 @@ type GenerationQueueItem struct {
 	QuestionsNeeded     int        `json:"questions_needed"`
 	SubjectArea         *string    `json:"subject_area,omitempty"`
 	IsComparative       bool       `json:"is_comparative"`
+	HighPriority        bool       `json:"high_priority"`
 	ErrorMessage        *string    `json:"error_message,omitempty"`
 	CreatedAt           time.Time  `json:"created_at"`
+	StartedAt           *time.Time `json:"started_at,omitempty"`
 	CompletedAt         *time.Time `json:"completed_at,omitempty"`
 }
+
+type GenerationQueueListResponse struct {
+	Items      []GenerationQueueItem `json:"items"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+	HasMore    bool                  `json:"has_more"`
+}
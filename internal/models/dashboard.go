@@ -0,0 +1,11 @@
+package models
+
+// DashboardResponse is the home screen in one payload. A section that failed
+// to load is omitted and named in Unavailable, so the rest still render.
+type DashboardResponse struct {
+	Gamification *GamificationResponse `json:"gamification,omitempty"`
+	Ability      *AbilityResponse      `json:"ability,omitempty"`
+	Stats        *HistoryStatsResponse `json:"stats,omitempty"`
+	Nudges       *NudgesResponse       `json:"nudges,omitempty"`
+	Unavailable  []string              `json:"unavailable,omitempty"`
+}
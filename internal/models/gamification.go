@@ -14,6 +14,8 @@ type UserGamification struct {
 	LastActiveDate        *time.Time `json:"last_active_date"`
 	StreakFreezeActive    bool       `json:"streak_freeze_active"`
 	StreakFreezesOwned    int        `json:"streak_freezes_owned"`
+	AutoFreeze            bool       `json:"auto_freeze"`
+	LeaderboardOptOut     bool       `json:"leaderboard_opt_out"`
 	Gems                  int        `json:"gems"`
 	DailyGoalTarget       int        `json:"daily_goal_target"`
 	DailyGoalProgress     int        `json:"daily_goal_progress"`
@@ -55,6 +57,24 @@ type Nudge struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// GemTransaction is one signed entry in a user's gem ledger.
+type GemTransaction struct {
+	ID           int64     `json:"id"`
+	Amount       int       `json:"amount"`
+	Reason       string    `json:"reason"`
+	BalanceAfter int       `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// XPBoost is a time-limited XP multiplier.
+type XPBoost struct {
+	ID         int64     `json:"id"`
+	Multiplier float64   `json:"multiplier"`
+	Source     string    `json:"source"`
+	StartedAt  time.Time `json:"started_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
 type Achievement struct {
 	ID          int64     `json:"id"`
 	UserID      int64     `json:"user_id"`
@@ -65,16 +85,44 @@ type Achievement struct {
 // ── Request Types ─────────────────────────────────────────
 
 type CompleteDrillRequest struct {
-	QuestionIDs    []int64 `json:"question_ids"`
-	CorrectIDs     []int64 `json:"correct_ids"`
+	DrillID        int64   `json:"drill_id"`
+	QuestionIDs    []int64 `json:"question_ids"` // Ignored; the drill's served questions are used
+	CorrectIDs     []int64 `json:"correct_ids"`  // Ignored; correctness is read from answer history
 	AvgTimeSeconds float64 `json:"avg_time_seconds"`
-	ComboMax       int     `json:"combo_max"`
+	ComboMax       int     `json:"combo_max"` // Ignored; combos are computed from the drill's recorded answers
 }
 
 type SetDailyGoalRequest struct {
 	Target int `json:"target"`
 }
 
+type SetAutoFreezeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type PrivacySettings struct {
+	LeaderboardOptOut bool `json:"leaderboard_opt_out"`
+}
+
+// NotificationPrefs are a user's per-category notification toggles. Every
+// category is on by default.
+type NotificationPrefs struct {
+	Nudges       bool `json:"nudges"`
+	Achievements bool `json:"achievements"`
+	Streaks      bool `json:"streaks"`
+}
+
+// UpdateNotificationPrefsRequest changes only the categories it sets.
+type UpdateNotificationPrefsRequest struct {
+	Nudges       *bool `json:"nudges"`
+	Achievements *bool `json:"achievements"`
+	Streaks      *bool `json:"streaks"`
+}
+
+type PurchaseRequest struct {
+	ItemID string `json:"item_id"`
+}
+
 type FriendRequestReq struct {
 	ToUserID int64 `json:"to_user_id"`
 }
@@ -99,6 +147,8 @@ type GamificationResponse struct {
 	LongestStreak         int      `json:"longest_streak"`
 	StreakFreezeActive    bool     `json:"streak_freeze_active"`
 	StreakFreezesOwned    int      `json:"streak_freezes_owned"`
+	AutoFreeze            bool     `json:"auto_freeze"`
+	LeaderboardOptOut     bool     `json:"leaderboard_opt_out"`
 	Gems                  int      `json:"gems"`
 	DailyGoalTarget       int      `json:"daily_goal_target"`
 	DailyGoalProgress     int      `json:"daily_goal_progress"`
@@ -109,6 +159,18 @@ type GamificationResponse struct {
 	PerfectDrillsTotal     int     `json:"perfect_drills_total"`
 	Achievements          []string `json:"achievements"`
 	UnreadNudges          int      `json:"unread_nudges"`
+	Nudges                []NudgeEntry `json:"nudges,omitempty"` // Only with include_nudges=true
+	ActiveBoost           *XPBoost `json:"active_boost,omitempty"`
+}
+
+// StatusResponse is the lightweight streak and daily-goal summary.
+type StatusResponse struct {
+	CurrentStreak      int  `json:"current_streak"`
+	StreakFreezeActive bool `json:"streak_freeze_active"`
+	ActiveToday        bool `json:"active_today"`
+	DailyGoalProgress  int  `json:"daily_goal_progress"`
+	DailyGoalTarget    int  `json:"daily_goal_target"`
+	DailyGoalCompleted bool `json:"daily_goal_completed"`
 }
 
 type DrillCompleteResponse struct {
@@ -117,9 +179,13 @@ type DrillCompleteResponse struct {
 	Streak               StreakInfo     `json:"streak"`
 	DailyGoal            DailyGoalInfo  `json:"daily_goal"`
 	AchievementsUnlocked []string       `json:"achievements_unlocked"`
+	QuestsCompleted      []string       `json:"quests_completed"`
 	LeagueTier           string         `json:"league_tier"`
 }
 
+// XPBreakdown explains a drill's XP. Questions is the per-question XP already
+// awarded during the drill; Subtotal is the drill-level bonuses before
+// multipliers; TotalXP is Questions plus the multiplied bonuses.
 type XPBreakdown struct {
 	Questions        int     `json:"questions"`
 	ComboBonuses     int     `json:"combo_bonuses"`
@@ -127,6 +193,7 @@ type XPBreakdown struct {
 	DrillCompletion  int     `json:"drill_completion"`
 	Subtotal         int     `json:"subtotal"`
 	StreakMultiplier float64 `json:"streak_multiplier"`
+	BoostMultiplier  float64 `json:"boost_multiplier"`
 	TotalXP          int     `json:"total_xp"`
 }
 
@@ -147,6 +214,18 @@ type LeaderboardResponse struct {
 	CurrentUser *LeaderboardEntry  `json:"current_user,omitempty"`
 }
 
+// LeagueLeaderboardResponse is a user's weekly league cohort. The top
+// PromotionZone entries move up a tier at the weekly reset and the bottom
+// DemotionZone move down.
+type LeagueLeaderboardResponse struct {
+	Tier          string             `json:"tier"`
+	CohortID      int64              `json:"cohort_id"`
+	Period        string             `json:"period"`
+	PromotionZone int                `json:"promotion_zone"`
+	DemotionZone  int                `json:"demotion_zone"`
+	Entries       []LeaderboardEntry `json:"entries"`
+}
+
 type LeaderboardEntry struct {
 	Rank          int    `json:"rank"`
 	UserID        int64  `json:"user_id"`
@@ -177,11 +256,12 @@ type FriendEntry struct {
 }
 
 type PendingFriendEntry struct {
-	FriendshipID int64     `json:"friendship_id"`
-	UserID       int64     `json:"user_id"`
-	DisplayName  string    `json:"display_name"`
-	Username     string    `json:"username"`
-	CreatedAt    time.Time `json:"created_at"`
+	FriendshipID  int64     `json:"friendship_id"`
+	UserID        int64     `json:"user_id"`
+	DisplayName   string    `json:"display_name"`
+	Username      string    `json:"username"`
+	MutualFriends int       `json:"mutual_friends"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type FriendRequestResponse struct {
@@ -200,6 +280,7 @@ type UserSearchResult struct {
 	Username           string `json:"username"`
 	LeagueTier         string `json:"league_tier"`
 	RelationshipStatus string `json:"relationship_status"`
+	MutualFriends      int    `json:"mutual_friends"`
 }
 
 type NudgesResponse struct {
@@ -221,6 +302,93 @@ type StreakFreezeResponse struct {
 	StreakFreezesOwned int `json:"streak_freezes_owned"`
 }
 
+type ShopItem struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Cost        int    `json:"cost"`
+	MaxOwned    int    `json:"max_owned"`
+	Owned       int    `json:"owned"`
+}
+
+type ShopResponse struct {
+	Gems  int        `json:"gems"`
+	Items []ShopItem `json:"items"`
+}
+
+type PurchaseResponse struct {
+	ItemID        string `json:"item_id"`
+	Quantity      int    `json:"quantity"`
+	GemsRemaining int    `json:"gems_remaining"`
+}
+
+type GemHistoryResponse struct {
+	Transactions []GemTransaction `json:"transactions"`
+	Total        int              `json:"total"`
+	Page         int              `json:"page"`
+	PageSize     int              `json:"page_size"`
+	TotalPages   int              `json:"total_pages"`
+	HasMore      bool             `json:"has_more"`
+}
+
+// DrillResult is one completed drill in a user's drill history. XPEarned is
+// the drill's total XP, including per-question XP.
+type DrillResult struct {
+	ID          int64     `json:"id"`
+	Correct     int       `json:"correct"`
+	Total       int       `json:"total"`
+	Perfect     bool      `json:"perfect"`
+	XPEarned    int       `json:"xp_earned"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type DrillHistoryResponse struct {
+	Drills     []DrillResult `json:"drills"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	PageSize   int           `json:"page_size"`
+	TotalPages int           `json:"total_pages"`
+	HasMore    bool          `json:"has_more"`
+}
+
+type Quest struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	PeriodKey   string    `json:"period_key"`
+	Progress    int       `json:"progress"`
+	Target      int       `json:"target"`
+	Gems        int       `json:"gems"`
+	Completed   bool      `json:"completed"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type QuestsResponse struct {
+	Daily  []Quest `json:"daily"`
+	Weekly []Quest `json:"weekly"`
+}
+
+type Group struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	OwnerID     int64     `json:"owner_id"`
+	MemberCount int       `json:"member_count"`
+	InviteCode  string    `json:"invite_code"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name"`
+}
+
+type JoinGroupRequest struct {
+	InviteCode string `json:"invite_code"`
+}
+
+type GroupsResponse struct {
+	Groups []Group `json:"groups"`
+}
+
 // ── League Tier Constants ─────────────────────────────────
 
 const (
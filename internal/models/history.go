@@ -4,6 +4,28 @@ import "time"
 
 // ── History Types ────────────────────────────────────────
 
+// AnswerSource is the practice mode an answer was given in.
+type AnswerSource string
+
+const (
+	SourceQuickDrill     AnswerSource = "quick_drill"
+	SourceSubtypeDrill   AnswerSource = "subtype_drill"
+	SourceRCDrill        AnswerSource = "rc_drill"
+	SourceReview         AnswerSource = "review"
+	SourcePractice       AnswerSource = "practice"
+	SourceDailyChallenge AnswerSource = "daily_challenge"
+)
+
+// ClientAnswerSources are the sources a client may send with an answer: the
+// ones drill responses hand out. Practice and daily challenge answers are
+// tagged by the server from the endpoint they arrive on.
+var ClientAnswerSources = map[AnswerSource]bool{
+	SourceQuickDrill:   true,
+	SourceSubtypeDrill: true,
+	SourceRCDrill:      true,
+	SourceReview:       true,
+}
+
 type HistoryQuestion struct {
 	QuestionID      int64          `json:"question_id"`
 	Section         Section        `json:"section"`
@@ -34,6 +56,7 @@ type HistoryListRequest struct {
 	Correct   *bool   `json:"correct"`
 	DateFrom  *string `json:"date_from"`
 	DateTo    *string `json:"date_to"`
+	Search    *string `json:"search"`
 	SortBy    string  `json:"sort_by"`
 	SortOrder string  `json:"sort_order"`
 	Page      int     `json:"page"`
@@ -48,13 +71,19 @@ type DrillReviewRequest struct {
 	QuestionIDs []int64 `json:"question_ids"`
 }
 
+type BookmarkCheckRequest struct {
+	QuestionIDs []int64 `json:"question_ids"`
+}
+
 // ── Response Types ────────────────────────────────────────
 
 type HistoryListResponse struct {
-	Questions []HistoryQuestion `json:"questions"`
-	Total     int               `json:"total"`
-	Page      int               `json:"page"`
-	PageSize  int               `json:"page_size"`
+	Questions  []HistoryQuestion `json:"questions"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+	HasMore    bool              `json:"has_more"`
 }
 
 type HistoryStatsResponse struct {
@@ -66,6 +95,40 @@ type HistoryStatsResponse struct {
 	SubtypeStats    map[string]SubtypeStat `json:"subtype_stats"`
 	DifficultyStats DifficultyBreakdown    `json:"difficulty_stats"`
 	RecentTrend     []DailyAccuracy        `json:"recent_trend"`
+	StudyTime       StudyTimeResponse      `json:"study_time"`
+	Improvement     *ImprovementStat       `json:"improvement,omitempty"`
+	// SourceStats is keyed by AnswerSource; answers without one are left out.
+	// History keeps one row per question, so each question counts once,
+	// under the source of the user's latest answer to it.
+	SourceStats map[string]SectionStat `json:"source_stats"`
+}
+
+// ImprovementStat compares accuracy over the last WindowDays with the
+// WindowDays before them. Change is the difference in accuracy, positive
+// when the user is improving.
+type ImprovementStat struct {
+	WindowDays     int     `json:"window_days"`
+	RecentAnswered int     `json:"recent_answered"`
+	RecentAccuracy float64 `json:"recent_accuracy"`
+	PriorAnswered  int     `json:"prior_answered"`
+	PriorAccuracy  float64 `json:"prior_accuracy"`
+	Change         float64 `json:"change"`
+}
+
+// StudyTime totals the recorded answer time in one period. Answers saved
+// before timing was recorded have no time and count only toward Answered.
+// A re-answered question keeps only its latest time, so Seconds is a lower
+// bound on time studied.
+type StudyTime struct {
+	Period   string  `json:"period"`
+	Seconds  float64 `json:"seconds"`
+	Answered int     `json:"answered"`
+	Timed    int     `json:"timed"`
+}
+
+type StudyTimeResponse struct {
+	Today StudyTime `json:"today"`
+	Week  StudyTime `json:"week"`
 }
 
 type SectionStat struct {
@@ -111,9 +174,17 @@ type BookmarkEntry struct {
 	Question *HistoryQuestion `json:"question,omitempty"`
 }
 
+// BookmarkCheckResponse lists which of the requested question IDs the user
+// has bookmarked, in request order.
+type BookmarkCheckResponse struct {
+	Bookmarked []int64 `json:"bookmarked"`
+}
+
 type BookmarkListResponse struct {
-	Bookmarks []BookmarkEntry `json:"bookmarks"`
-	Total     int             `json:"total"`
-	Page      int             `json:"page"`
-	PageSize  int             `json:"page_size"`
+	Bookmarks  []BookmarkEntry `json:"bookmarks"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+	HasMore    bool            `json:"has_more"`
 }
@@ -0,0 +1,13 @@
+package models
+
+// RouteInfo is one registered API route.
+type RouteInfo struct {
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+}
+
+// RouteManifest lists every registered route so clients can check which
+// endpoints the server supports.
+type RouteManifest struct {
+	Routes []RouteInfo `json:"routes"`
+}
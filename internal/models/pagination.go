@@ -0,0 +1,23 @@
+package models
+
+// PageInfo returns how many pages of pageSize hold total items, and whether
+// any items come after page (1-based).
+func PageInfo(total, page, pageSize int) (totalPages int, hasMore bool) {
+	if pageSize <= 0 {
+		return 0, false
+	}
+	totalPages = (total + pageSize - 1) / pageSize
+	return totalPages, page < totalPages
+}
+
+// OffsetPageInfo is PageInfo for lists paged by limit and offset, where
+// returned items came back from offset. page is the page offset falls on;
+// hasMore counts the rows actually returned, so an offset that isn't a
+// multiple of limit doesn't skew it.
+func OffsetPageInfo(total, limit, offset, returned int) (page, totalPages int, hasMore bool) {
+	if limit <= 0 {
+		return 0, 0, false
+	}
+	totalPages, _ = PageInfo(total, 1, limit)
+	return offset/limit + 1, totalPages, offset+returned < total
+}
@@ -118,7 +118,12 @@ type QuestionBatch struct {
 	ValidationTokens  int         `json:"validation_tokens,omitempty"`
 	GenerationTimeMs  int         `json:"generation_time_ms,omitempty"`
 	TotalCostCents    int         `json:"total_cost_cents,omitempty"`
+	ValidationModel   *string     `json:"validation_model,omitempty"`
+	ValidationCostCents int       `json:"validation_cost_cents,omitempty"`
 	ErrorMessage      *string     `json:"error_message,omitempty"`
+	Notes             *string     `json:"notes,omitempty"`
+	ExperimentTag     *string     `json:"experiment_tag,omitempty"`
+	PromptVersion     *string     `json:"prompt_version,omitempty"`
 	CreatedAt         time.Time   `json:"created_at"`
 	CompletedAt       *time.Time  `json:"completed_at,omitempty"`
 }
@@ -145,6 +150,63 @@ type Question struct {
 	TimesServed         int              `json:"times_served"`
 	TimesCorrect        int              `json:"times_correct"`
 	CreatedAt           time.Time        `json:"created_at"`
+	Language            string           `json:"language,omitempty"`
+	UserFlags           []QuestionFlag   `json:"user_flags,omitempty"`
+	// LengthBalance and LengthOutlier are the choice length checks stored
+	// when the question was scored; LengthBalance is nil if it never was.
+	LengthBalance *float64 `json:"choice_length_balance,omitempty"`
+	LengthOutlier bool     `json:"correct_length_outlier,omitempty"`
+}
+
+// QuestionFlag is a user's dispute of a question, shown to admins in the
+// flagged queue.
+type QuestionFlag struct {
+	UserID    int64     `json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FlagQuestionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ToDrillQuestion returns the question as served to users, without the
+// answer key, explanations or wrong-answer types. The passage isn't attached.
+func (q *Question) ToDrillQuestion() DrillQuestion {
+	dq := DrillQuestion{
+		ID:              q.ID,
+		Section:         q.Section,
+		LRSubtype:       q.LRSubtype,
+		RCSubtype:       q.RCSubtype,
+		Difficulty:      q.Difficulty,
+		DifficultyScore: q.DifficultyScore,
+		Stimulus:        q.Stimulus,
+		QuestionStem:    q.QuestionStem,
+		Choices:         make([]DrillChoice, len(q.Choices)),
+	}
+	for i, c := range q.Choices {
+		dq.Choices[i] = DrillChoice{ChoiceID: c.ChoiceID, ChoiceText: c.ChoiceText}
+	}
+	if n := len(q.CorrectChoiceIDs()); n > 1 {
+		dq.SelectCount = n
+	}
+	return dq
+}
+
+// CorrectChoiceIDs returns the choices that grade as correct. A question with
+// more than one choice marked is_correct is multi-correct; otherwise
+// CorrectAnswerID is the single answer.
+func (q *Question) CorrectChoiceIDs() []string {
+	var ids []string
+	for _, c := range q.Choices {
+		if c.IsCorrect {
+			ids = append(ids, c.ChoiceID)
+		}
+	}
+	if len(ids) > 1 {
+		return ids
+	}
+	return []string{q.CorrectAnswerID}
 }
 
 type AnswerChoice struct {
@@ -157,6 +219,29 @@ type AnswerChoice struct {
 	WrongAnswerType string `json:"wrong_answer_type,omitempty"`
 }
 
+// QuestionTrap is one wrong choice of an answered question with the trap
+// archetype it represents.
+type QuestionTrap struct {
+	ChoiceID             string `json:"choice_id"`
+	ChoiceText           string `json:"choice_text"`
+	WrongAnswerType      string `json:"wrong_answer_type,omitempty"`
+	Explanation          string `json:"explanation"`
+	ArchetypeDescription string `json:"archetype_description,omitempty"`
+}
+
+type QuestionTrapsResponse struct {
+	QuestionID      int64          `json:"question_id"`
+	CorrectAnswerID string         `json:"correct_answer_id"`
+	Traps           []QuestionTrap `json:"traps"`
+}
+
+// QuestionHintResponse nudges the user toward a question's answer without
+// naming the correct choice.
+type QuestionHintResponse struct {
+	QuestionID int64  `json:"question_id"`
+	Hint       string `json:"hint"`
+}
+
 type RCPassage struct {
 	ID            int64     `json:"id"`
 	BatchID       int64     `json:"batch_id"`
@@ -208,11 +293,94 @@ type GenerateBatchRequest struct {
 	Count         int        `json:"count"`
 	SubjectArea   string     `json:"subject_area,omitempty"`
 	IsComparative bool       `json:"is_comparative,omitempty"`
+	Notes         *string    `json:"notes,omitempty"`
+	ExperimentTag *string    `json:"experiment_tag,omitempty"`
+	// Language is the code explanations are written in, e.g. "es"; empty
+	// means English.
+	Language string `json:"language,omitempty"`
+	// DifficultyScore, if set, targets a 0-100 difficulty_score range more
+	// precisely than Difficulty. Difficulty may then be omitted.
+	DifficultyScore *ScoreRange `json:"difficulty_score,omitempty"`
+}
+
+// ScoreRange is an inclusive range of difficulty scores.
+type ScoreRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// GeneratePreviewRequest runs the generation pipeline without persisting anything.
+type GeneratePreviewRequest struct {
+	GenerateBatchRequest
+	Validate bool `json:"validate"`
+}
+
+type BatchListFilters struct {
+	Status        *BatchStatus `json:"status"`
+	ExperimentTag *string      `json:"experiment_tag"`
+}
+
+// UpdateBatchAnnotationRequest sets admin notes on a batch. Nil fields are left unchanged.
+type UpdateBatchAnnotationRequest struct {
+	Notes         *string `json:"notes"`
+	ExperimentTag *string `json:"experiment_tag"`
+}
+
+// BatchPurgeResult reports what a batch cleanup removed.
+type BatchPurgeResult struct {
+	OlderThan             time.Time `json:"older_than"`
+	BatchesRemoved        int       `json:"batches_removed"`
+	PassagesRemoved       int       `json:"passages_removed"`
+	ValidationLogsRemoved int       `json:"validation_logs_removed"`
+}
+
+// BatchAnswerPositions counts a batch's correct answers at each position,
+// to audit whether generation clustered them. Every position A-E is listed.
+type BatchAnswerPositions struct {
+	BatchID   int64          `json:"batch_id"`
+	Total     int            `json:"total"`
+	Positions map[string]int `json:"positions"`
+}
+
+// SetFeaturedRequest pins or unpins a question for preferential serving.
+type SetFeaturedRequest struct {
+	Featured *bool `json:"featured"`
+}
+
+// SetDifficultyRequest overrides a question's difficulty. Either field may
+// be given alone; the other is derived from it.
+type SetDifficultyRequest struct {
+	Difficulty      *Difficulty `json:"difficulty,omitempty"`
+	DifficultyScore *int        `json:"difficulty_score,omitempty"`
 }
 
 type SubmitAnswerRequest struct {
-	SelectedChoiceID string   `json:"selected_choice_id"`
-	TimeSpentSeconds *float64 `json:"time_spent_seconds,omitempty"`
+	SelectedChoiceID string `json:"selected_choice_id"`
+	// SelectedChoiceIDs answers a multi-correct question and takes
+	// precedence over SelectedChoiceID
+	SelectedChoiceIDs []string `json:"selected_choice_ids,omitempty"`
+	TimeSpentSeconds  *float64 `json:"time_spent_seconds,omitempty"`
+	// Source echoes the source of the drill response the question came from.
+	// It is client-supplied and unverified, so it only labels stats and is
+	// limited to ClientAnswerSources.
+	Source AnswerSource `json:"source,omitempty"`
+}
+
+// ChoiceIDs returns the submitted selection, whichever field carried it.
+func (r SubmitAnswerRequest) ChoiceIDs() []string {
+	return choiceIDs(r.SelectedChoiceID, r.SelectedChoiceIDs)
+}
+
+// choiceIDs returns a selection sent as either one choice or a list, the
+// list taking precedence.
+func choiceIDs(single string, multi []string) []string {
+	if len(multi) > 0 {
+		return multi
+	}
+	if single == "" {
+		return nil
+	}
+	return []string{single}
 }
 
 type RCDrillRequest struct {
@@ -223,11 +391,13 @@ type RCDrillRequest struct {
 }
 
 type RCDrillResponse struct {
+	DrillID   int64           `json:"drill_id"`
 	Passage   DrillPassage    `json:"passage"`
 	Questions []DrillQuestion `json:"questions"`
 	Total     int             `json:"total"`
 	Page      int             `json:"page"`
 	PageSize  int             `json:"page_size"`
+	Source    AnswerSource    `json:"source,omitempty"`
 }
 
 // ── Response Types ────────────────────────────────────
@@ -241,20 +411,72 @@ type GenerateBatchResponse struct {
 	Message           string      `json:"message"`
 }
 
+type GeneratePreviewResponse struct {
+	ModelUsed        string            `json:"model_used"`
+	PromptVersion    string            `json:"prompt_version,omitempty"`
+	PromptTokens     int               `json:"prompt_tokens"`
+	OutputTokens     int               `json:"output_tokens"`
+	ValidationTokens int               `json:"validation_tokens"`
+	Passage          *ExportPassage    `json:"passage,omitempty"`
+	Questions        []PreviewQuestion `json:"questions"`
+}
+
+type PreviewQuestion struct {
+	Stimulus            string         `json:"stimulus"`
+	QuestionStem        string         `json:"question_stem"`
+	CorrectAnswerID     string         `json:"correct_answer_id"`
+	Explanation         string         `json:"explanation"`
+	Choices             []ExportChoice `json:"choices"`
+	QualityScore        *float64       `json:"quality_score,omitempty"`
+	ValidationStatus    string         `json:"validation_status"`
+	ValidationReasoning *string        `json:"validation_reasoning,omitempty"`
+	AdversarialScore    *string        `json:"adversarial_score,omitempty"`
+}
+
+// SubmitAnswerResponse grades an answer. Credit is the share of the question
+// earned, 1 for a correct answer; only multi-correct questions graded with
+// partial credit earn fractions. CorrectAnswerIDs lists every correct choice
+// of a multi-correct question.
 type SubmitAnswerResponse struct {
-	Correct         bool              `json:"correct"`
-	CorrectAnswerID string            `json:"correct_answer_id"`
-	Explanation     string            `json:"explanation"`
-	Choices         []AnswerChoice    `json:"choices"`
-	AbilityUpdated  *AbilitySnapshot  `json:"ability_updated,omitempty"`
-	XPAwarded       int               `json:"xp_awarded"`
+	Correct          bool             `json:"correct"`
+	Credit           float64          `json:"credit"`
+	CorrectAnswerID  string           `json:"correct_answer_id"`
+	CorrectAnswerIDs []string         `json:"correct_answer_ids,omitempty"`
+	Explanation      string           `json:"explanation"`
+	Choices          []AnswerChoice   `json:"choices"`
+	AbilityUpdated   *AbilitySnapshot `json:"ability_updated,omitempty"`
+	XPAwarded        int              `json:"xp_awarded"`
 }
 
 type QuestionListResponse struct {
-	Questions []Question `json:"questions"`
-	Total     int        `json:"total"`
-	Page      int        `json:"page"`
-	PageSize  int        `json:"page_size"`
+	Questions  []Question `json:"questions"`
+	Total      int        `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	TotalPages int        `json:"total_pages"`
+	HasMore    bool       `json:"has_more"`
+}
+
+type BatchListResponse struct {
+	Batches    []QuestionBatch `json:"batches"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+	HasMore    bool            `json:"has_more"`
+}
+
+type BatchGetQuestionsRequest struct {
+	QuestionIDs []int64 `json:"question_ids"`
+}
+
+// BatchGetQuestionsResponse holds the requested questions in request order.
+// Passages lists each passage they reference once; NotFound lists requested
+// IDs that don't exist.
+type BatchGetQuestionsResponse struct {
+	Questions []Question     `json:"questions"`
+	Passages  []DrillPassage `json:"passages"`
+	NotFound  []int64        `json:"not_found"`
 }
 
 // ── Drill Types (strip answers for serving) ───────────
@@ -270,6 +492,24 @@ type DrillQuestion struct {
 	QuestionStem    string        `json:"question_stem"`
 	Choices         []DrillChoice `json:"choices"`
 	Passage         *DrillPassage `json:"passage,omitempty"`
+	// SelectCount is how many choices to select on a multi-correct
+	// question; it's omitted for single-answer questions.
+	SelectCount int `json:"select_count,omitempty"`
+}
+
+// NextQuestionResponse is one question of an infinite practice session.
+// SessionCount is how many questions the session has served so far.
+type NextQuestionResponse struct {
+	Question     *DrillQuestion `json:"question"`
+	SessionCount int            `json:"session_count"`
+}
+
+// AnswerAndNextResponse is an answer's result plus the next practice
+// question. Next is nil when nothing new is left to serve.
+type AnswerAndNextResponse struct {
+	Result       *SubmitAnswerResponse `json:"result"`
+	Next         *DrillQuestion        `json:"next"`
+	SessionCount int                   `json:"session_count"`
 }
 
 type DrillPassage struct {
@@ -287,11 +527,53 @@ type DrillChoice struct {
 	ChoiceText string `json:"choice_text"`
 }
 
+// DrillListResponse is a list of drill questions. Source names the practice
+// mode, for clients to send back with each answer. DrillID identifies the
+// drill when completing it; it is 0 for lists that aren't drills.
 type DrillListResponse struct {
+	DrillID   int64           `json:"drill_id,omitempty"`
 	Questions []DrillQuestion `json:"questions"`
 	Total     int             `json:"total"`
 	Page      int             `json:"page"`
 	PageSize  int             `json:"page_size"`
+	Source    AnswerSource    `json:"source,omitempty"`
+}
+
+// DrillPendingResponse is returned with 202 when a drill has no questions yet
+// but generation has been queued; clients should retry after
+// RetryAfterSeconds.
+type DrillPendingResponse struct {
+	Status            string `json:"status"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+	Message           string `json:"message"`
+}
+
+// ── Passage Library Types ─────────────────────────────
+
+type PassageListFilters struct {
+	SubjectArea *string `json:"subject_area"`
+	Comparative *bool   `json:"comparative"`
+}
+
+type PassageSummary struct {
+	ID            int64     `json:"id"`
+	BatchID       int64     `json:"batch_id"`
+	Title         string    `json:"title"`
+	SubjectArea   string    `json:"subject_area"`
+	IsComparative bool      `json:"is_comparative"`
+	WordCount     int       `json:"word_count"`
+	ReadingGrade  *float64  `json:"reading_grade,omitempty"`
+	QuestionCount int       `json:"question_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PassageListResponse struct {
+	Passages   []PassageSummary `json:"passages"`
+	Total      int              `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+	HasMore    bool             `json:"has_more"`
 }
 
 // ── Admin Types ───────────────────────────────────────
@@ -303,6 +585,61 @@ type QualityStats struct {
 	TotalRejected    int                `json:"total_rejected"`
 	PassRate         float64            `json:"pass_rate"`
 	QualityDistribution map[string]int  `json:"quality_score_distribution"`
+	ByPromptVersion  map[string]PromptVersionStats `json:"by_prompt_version"`
+}
+
+type StructuralStats struct {
+	TotalScored              int     `json:"total_scored"`
+	AvgLengthBalance         float64 `json:"avg_length_balance"`
+	CorrectLengthOutliers    int     `json:"correct_length_outliers"`
+	CorrectLengthOutlierRate float64 `json:"correct_length_outlier_rate"`
+}
+
+// RevalidateRequest starts a re-validation job. Unvalidated questions are
+// always included; IncludeFlagged adds questions awaiting review.
+type RevalidateRequest struct {
+	IncludeFlagged bool `json:"include_flagged"`
+}
+
+// RevalidationJob reports the progress of a background re-validation run.
+// Failed counts questions the validator couldn't score; they stay unvalidated.
+type RevalidationJob struct {
+	ID             int64      `json:"id"`
+	Status         string     `json:"status"`
+	IncludeFlagged bool       `json:"include_flagged"`
+	Total          int        `json:"total"`
+	Processed      int        `json:"processed"`
+	Passed         int        `json:"passed"`
+	Flagged        int        `json:"flagged"`
+	Rejected       int        `json:"rejected"`
+	Failed         int        `json:"failed"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// DifficultyBucket counts questions with Min <= difficulty_score <= Max.
+type DifficultyBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// DifficultyHistogram is the servable inventory of one subtype by 10-point
+// difficulty_score bucket.
+type DifficultyHistogram struct {
+	Section string             `json:"section"`
+	Subtype string             `json:"subtype"`
+	Total   int                `json:"total"`
+	Buckets []DifficultyBucket `json:"buckets"`
+}
+
+type PromptVersionStats struct {
+	Total      int     `json:"total"`
+	Passed     int     `json:"passed"`
+	Flagged    int     `json:"flagged"`
+	Rejected   int     `json:"rejected"`
+	PassRate   float64 `json:"pass_rate"`
+	AvgQuality float64 `json:"avg_quality_score"`
 }
 
 type GenerationStats struct {
@@ -315,6 +652,7 @@ type CostStats struct {
 	TodayCents     int `json:"today_cents"`
 	ThisWeekCents  int `json:"this_week_cents"`
 	ThisMonthCents int `json:"this_month_cents"`
+	ValidationThisMonthCents int `json:"validation_this_month_cents"`
 	DailyLimitCents int `json:"daily_limit_cents"`
 }
 
@@ -329,6 +667,32 @@ type TokenStats struct {
 	ValidationTotal  int `json:"validation_total"`
 }
 
+// QuestionProvenance bundles everything a reviewer needs to audit one
+// generated question.
+type QuestionProvenance struct {
+	Question       *Question        `json:"question"`
+	Passage        *RCPassage       `json:"passage,omitempty"`
+	QualityScores  QualitySubScores `json:"quality_scores"`
+	ValidationLogs []ValidationLog  `json:"validation_logs"`
+	// DistinctResponders counts users who answered, unlike times_served,
+	// which counts every serving
+	DistinctResponders int `json:"distinct_responders"`
+}
+
+// QualitySubScores breaks the stored quality score into its components.
+// Structural checks are recomputed from the question's current content.
+type QualitySubScores struct {
+	QualityScore           *float64 `json:"quality_score,omitempty"`
+	AdversarialScore       *string  `json:"adversarial_score,omitempty"`
+	StimulusLengthOK       bool     `json:"stimulus_length_ok"`
+	AllChoicesInRange      bool     `json:"all_choices_in_range"`
+	AllExplanationsPresent bool     `json:"all_explanations_present"`
+	MissingRequiredTrap    bool     `json:"missing_required_trap"`
+	CorrectLengthOutlier   bool     `json:"correct_length_outlier"`
+	HasMetaChoice          bool     `json:"has_meta_choice"`
+	LengthBalance          float64  `json:"length_balance"`
+}
+
 type RecalibrationCandidate struct {
 	QuestionID          int64   `json:"question_id"`
 	LabeledDifficulty   string  `json:"labeled_difficulty"`
@@ -336,6 +700,7 @@ type RecalibrationCandidate struct {
 	SuggestedDifficulty  string  `json:"suggested_difficulty"`
 	TimesServed          int     `json:"times_served"`
 	TimesCorrect         int     `json:"times_correct"`
+	DistinctResponders   int     `json:"distinct_responders"`
 }
 
 type RecalibrationReport struct {
@@ -390,3 +755,58 @@ type ImportResult struct {
 	Skipped        int `json:"skipped"`
 	BatchesCreated int `json:"batches_created"`
 }
+
+// ── Daily Challenge Types ─────────────────────────────
+
+// DailyChallengeResponse is the day's shared question. Attempt is the
+// user's answer, once they've made one.
+type DailyChallengeResponse struct {
+	Date     string                 `json:"date"`
+	Question DrillQuestion          `json:"question"`
+	Attempt  *DailyChallengeAttempt `json:"attempt,omitempty"`
+}
+
+type DailyChallengeAttempt struct {
+	QuestionID       int64    `json:"question_id"`
+	Correct          bool     `json:"correct"`
+	SelectedChoiceID string   `json:"selected_choice_id"`
+	TimeSpentSeconds *float64 `json:"time_spent_seconds,omitempty"`
+	// Ranked is false when the user had answered the question before, so
+	// the attempt is left off the leaderboard
+	Ranked      bool      `json:"ranked"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type DailyChallengeAnswerRequest struct {
+	QuestionID       int64  `json:"question_id"`
+	SelectedChoiceID string `json:"selected_choice_id"`
+	// SelectedChoiceIDs answers a multi-correct question and takes
+	// precedence over SelectedChoiceID
+	SelectedChoiceIDs []string `json:"selected_choice_ids,omitempty"`
+	// TimeSpentSeconds is ignored: the server times the attempt from when
+	// the user first fetched the challenge
+	TimeSpentSeconds *float64 `json:"time_spent_seconds,omitempty"`
+}
+
+// ChoiceIDs returns the submitted selection, whichever field carried it.
+func (r DailyChallengeAnswerRequest) ChoiceIDs() []string {
+	return choiceIDs(r.SelectedChoiceID, r.SelectedChoiceIDs)
+}
+
+// DailyChallengeEntry ranks an attempt: correct answers first, then fastest
+// from when the user fetched the challenge.
+type DailyChallengeEntry struct {
+	Rank             int      `json:"rank"`
+	UserID           int64    `json:"user_id"`
+	DisplayName      string   `json:"display_name"`
+	Username         string   `json:"username"`
+	Correct          bool     `json:"correct"`
+	TimeSpentSeconds *float64 `json:"time_spent_seconds,omitempty"`
+	IsCurrentUser    bool     `json:"is_current_user"`
+}
+
+type DailyChallengeLeaderboard struct {
+	Date     string                `json:"date"`
+	Attempts int                   `json:"attempts"`
+	Entries  []DailyChallengeEntry `json:"entries"`
+}
@@ -1,6 +1,11 @@
 package questions
 
-import "math"
+import (
+	"math"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
 
 // ExpectedAccuracy returns the probability a user with the given ability
 // gets a question with the given difficulty correct.
@@ -62,3 +67,29 @@ func TargetDifficulty(userAbility, slider int) int {
 	}
 	return int(math.Round(target))
 }
+
+// abilityDecayGraceDays is how long an ability can sit idle before it starts
+// to decay.
+const abilityDecayGraceDays = 14
+
+// DecayedAbility regresses an idle ability toward 50. Past the grace period
+// the distance from 50 shrinks by rate per idle day, scaled by
+// (1 - accuracy/2) so abilities backed by high accuracy fade more slowly.
+// A rate of 0 disables decay.
+func DecayedAbility(a models.UserAbilityScore, rate float64, now time.Time) int {
+	if rate <= 0 || a.LastUpdated.IsZero() {
+		return a.AbilityScore
+	}
+	idleDays := int(now.Sub(a.LastUpdated).Hours()/24) - abilityDecayGraceDays
+	if idleDays <= 0 {
+		return a.AbilityScore
+	}
+
+	accuracy := 0.0
+	if a.QuestionsAnswered > 0 {
+		accuracy = float64(a.QuestionsCorrect) / float64(a.QuestionsAnswered)
+	}
+	effective := math.Min(rate*(1-accuracy/2), 1)
+	retained := math.Pow(1-effective, float64(idleDays))
+	return int(math.Round(50 + float64(a.AbilityScore-50)*retained))
+}
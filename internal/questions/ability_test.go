@@ -3,6 +3,9 @@ package questions
 import (
 	"math"
 	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
 )
 
 func TestExpectedAccuracy(t *testing.T) {
@@ -135,3 +138,37 @@ func TestTargetDifficulty(t *testing.T) {
 		t.Errorf("TargetDifficulty(95, 100) = %d, want <= 100", got)
 	}
 }
+
+func TestDecayedAbility(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	rate := 0.02
+
+	idle := models.UserAbilityScore{AbilityScore: 85, QuestionsAnswered: 100, QuestionsCorrect: 60, LastUpdated: now.AddDate(0, -4, 0)}
+	got := DecayedAbility(idle, rate, now)
+	if got >= 85 || got <= 50 {
+		t.Errorf("4-month idle ability 85 decayed to %d, want between 50 and 85", got)
+	}
+
+	low := idle
+	low.AbilityScore = 20
+	if got := DecayedAbility(low, rate, now); got <= 20 || got >= 50 {
+		t.Errorf("4-month idle ability 20 decayed to %d, want between 20 and 50", got)
+	}
+
+	recent := idle
+	recent.LastUpdated = now.AddDate(0, 0, -3)
+	if got := DecayedAbility(recent, rate, now); got != 85 {
+		t.Errorf("3-day idle ability decayed to %d, want 85", got)
+	}
+
+	// Higher accuracy fades more slowly
+	accurate := idle
+	accurate.QuestionsCorrect = 95
+	if DecayedAbility(accurate, rate, now) <= got {
+		t.Errorf("accurate ability %d should retain more than %d", DecayedAbility(accurate, rate, now), got)
+	}
+
+	if got := DecayedAbility(idle, 0, now); got != 85 {
+		t.Errorf("rate 0 decayed ability to %d, want 85", got)
+	}
+}
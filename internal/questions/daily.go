@@ -0,0 +1,178 @@
+package questions
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// dailyChallengeLeaderboardSize is how many attempts the daily leaderboard
+// lists.
+const dailyChallengeLeaderboardSize = 50
+
+// challengeDay returns the UTC day containing t, which keys the daily
+// challenge.
+func challengeDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// pickDailyChallenge deterministically chooses one of candidates for day,
+// seeded by the date, so every server picks the same question.
+func pickDailyChallenge(candidates []int64, day time.Time) (int64, bool) {
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	h := fnv.New64a()
+	fmt.Fprint(h, day.Format("2006-01-02"))
+	return candidates[h.Sum64()%uint64(len(candidates))], true
+}
+
+// dailyChallengeStore is the subset of Store used to fix a day's challenge.
+type dailyChallengeStore interface {
+	GetDailyChallengeQuestionID(day time.Time) (int64, error)
+	GetDailyChallengeCandidates() ([]int64, error)
+	SetDailyChallenge(day time.Time, questionID int64) (int64, error)
+}
+
+// dailyChallengeQuestionID returns day's challenge question, picking and
+// saving it on the first request so that later changes to the candidate
+// pool don't move it mid-day.
+func dailyChallengeQuestionID(st dailyChallengeStore, day time.Time) (int64, error) {
+	id, err := st.GetDailyChallengeQuestionID(day)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("get daily challenge: %w", err)
+	}
+
+	candidates, err := st.GetDailyChallengeCandidates()
+	if err != nil {
+		return 0, err
+	}
+	id, ok := pickDailyChallenge(candidates, day)
+	if !ok {
+		return 0, fmt.Errorf("no daily challenge available")
+	}
+	return st.SetDailyChallenge(day, id)
+}
+
+// GetDailyChallenge returns today's shared question, with the user's
+// attempt if they've already answered it.
+func (s *Service) GetDailyChallenge(userID int64) (*models.DailyChallengeResponse, error) {
+	day := challengeDay(time.Now())
+	id, err := dailyChallengeQuestionID(s.store, day)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.store.GetDrillQuestionsByIDs([]int64{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no daily challenge available")
+	}
+
+	// The attempt is timed from the first fetch
+	if err := s.store.MarkDailyChallengeServed(day, userID); err != nil {
+		return nil, err
+	}
+	attempt, err := s.store.GetDailyChallengeAttempt(day, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DailyChallengeResponse{
+		Date:     day.Format("2006-01-02"),
+		Question: questions[0],
+		Attempt:  attempt,
+	}, nil
+}
+
+// SubmitDailyChallenge scores the user's one attempt at today's challenge
+// like any other answer, recording the attempt in the same transaction. The
+// time spent is measured by the server from when the user fetched the
+// challenge; the client's is ignored.
+func (s *Service) SubmitDailyChallenge(userID int64, req models.DailyChallengeAnswerRequest) (*models.SubmitAnswerResponse, error) {
+	day := challengeDay(time.Now())
+	id, err := dailyChallengeQuestionID(s.store, day)
+	if err != nil {
+		return nil, err
+	}
+	// The day rolled over between fetching and answering
+	if req.QuestionID != id {
+		return nil, fmt.Errorf("daily challenge has changed")
+	}
+
+	servedAt, err := s.store.GetDailyChallengeServedAt(day, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("daily challenge not fetched")
+		}
+		return nil, fmt.Errorf("get daily challenge served time: %w", err)
+	}
+	elapsed := time.Since(servedAt).Seconds()
+
+	question, err := s.store.GetQuestionWithChoices(id)
+	if err != nil {
+		return nil, fmt.Errorf("get daily challenge question: %w", err)
+	}
+	return s.submitSelection(userID, question, req.ChoiceIDs(), &elapsed, models.SourceDailyChallenge, &day)
+}
+
+// dailyAnswerRecorder is the transaction a daily challenge answer runs in;
+// *AnswerTx is the implementation.
+type dailyAnswerRecorder interface {
+	answerRecorder
+	HasAnswered(userID, questionID int64) (bool, error)
+	RecordDailyChallengeAttempt(day time.Time, userID, questionID int64, correct bool, selectedChoiceID string, timeSpentSeconds *float64, ranked bool) (bool, error)
+}
+
+// recordDailyAnswerCore claims the user's attempt at day's challenge and
+// records the answer in tx, so an answer that fails leaves the attempt
+// unclaimed and a repeat attempt records nothing. The attempt is unranked if
+// the user had answered the question before, outside the challenge.
+func recordDailyAnswerCore(tx dailyAnswerRecorder, day time.Time, userID int64, question *models.Question, correct bool, selectedChoiceID string, timeSpentSeconds *float64) (*models.AbilitySnapshot, error) {
+	answered, err := tx.HasAnswered(userID, question.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	recorded, err := tx.RecordDailyChallengeAttempt(day, userID, question.ID, correct, selectedChoiceID, timeSpentSeconds, !answered)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !recorded {
+		tx.Rollback()
+		return nil, fmt.Errorf("already attempted")
+	}
+	return recordAnswerCore(tx, userID, question, correct, &selectedChoiceID, timeSpentSeconds, models.SourceDailyChallenge)
+}
+
+// GetDailyChallengeLeaderboard ranks today's ranked attempts by
+// correctness, then speed.
+func (s *Service) GetDailyChallengeLeaderboard(userID int64) (*models.DailyChallengeLeaderboard, error) {
+	day := challengeDay(time.Now())
+	entries, total, err := s.store.GetDailyChallengeLeaderboard(day, dailyChallengeLeaderboardSize)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.DailyChallengeEntry{}
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+		entries[i].IsCurrentUser = entries[i].UserID == userID
+	}
+	return &models.DailyChallengeLeaderboard{
+		Date:     day.Format("2006-01-02"),
+		Attempts: total,
+		Entries:  entries,
+	}, nil
+}
@@ -0,0 +1,164 @@
+package questions
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// fakeDailyStore keeps daily challenge picks in memory.
+type fakeDailyStore struct {
+	candidates []int64
+	picks      map[string]int64
+}
+
+func (f *fakeDailyStore) GetDailyChallengeQuestionID(day time.Time) (int64, error) {
+	id, ok := f.picks[day.Format("2006-01-02")]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+func (f *fakeDailyStore) GetDailyChallengeCandidates() ([]int64, error) {
+	return f.candidates, nil
+}
+
+func (f *fakeDailyStore) SetDailyChallenge(day time.Time, questionID int64) (int64, error) {
+	key := day.Format("2006-01-02")
+	if _, ok := f.picks[key]; !ok {
+		f.picks[key] = questionID
+	}
+	return f.picks[key], nil
+}
+
+func TestDailyChallenge_SameQuestionForEveryone(t *testing.T) {
+	candidates := []int64{11, 12, 13, 14, 15, 16, 17}
+	morning := challengeDay(time.Date(2026, 10, 15, 0, 5, 0, 0, time.UTC))
+	evening := challengeDay(time.Date(2026, 10, 15, 23, 55, 0, 0, time.UTC))
+
+	// Two servers with empty stores pick independently and still agree
+	a, err := dailyChallengeQuestionID(&fakeDailyStore{candidates: candidates, picks: map[string]int64{}}, morning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dailyChallengeQuestionID(&fakeDailyStore{candidates: candidates, picks: map[string]int64{}}, evening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("picked %d and %d on the same day, want one question", a, b)
+	}
+
+	// Once fixed, the pick survives the candidate pool changing mid-day
+	st := &fakeDailyStore{candidates: candidates, picks: map[string]int64{}}
+	first, _ := dailyChallengeQuestionID(st, morning)
+	st.candidates = []int64{99}
+	if got, _ := dailyChallengeQuestionID(st, evening); got != first {
+		t.Errorf("later user got %d, want the day's question %d", got, first)
+	}
+
+	// The pick varies across days
+	seen := map[int64]bool{}
+	for d := 0; d < 30; d++ {
+		id, _ := pickDailyChallenge(candidates, morning.AddDate(0, 0, d))
+		seen[id] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("30 days all picked %v, want the question to rotate", seen)
+	}
+
+	if _, err := dailyChallengeQuestionID(&fakeDailyStore{picks: map[string]int64{}}, morning); err == nil {
+		t.Error("no candidates should be an error")
+	}
+}
+
+// fakeDailyAnswerTx adds daily challenge attempts to fakeAnswerTx, claimed
+// only on Commit. answered lists users with the question in their history.
+type fakeDailyAnswerTx struct {
+	*fakeAnswerTx
+	attempts map[int64]bool
+	claimed  []int64
+	answered map[int64]bool
+	ranked   map[int64]bool
+}
+
+func (f *fakeDailyAnswerTx) HasAnswered(userID, questionID int64) (bool, error) {
+	return f.answered[userID], nil
+}
+
+func (f *fakeDailyAnswerTx) RecordDailyChallengeAttempt(day time.Time, userID, questionID int64, correct bool, selectedChoiceID string, timeSpentSeconds *float64, ranked bool) (bool, error) {
+	if f.attempts[userID] {
+		return false, nil
+	}
+	f.claimed = append(f.claimed, userID)
+	if f.ranked == nil {
+		f.ranked = map[int64]bool{}
+	}
+	f.ranked[userID] = ranked
+	return true, nil
+}
+
+func (f *fakeDailyAnswerTx) Commit() error {
+	for _, id := range f.claimed {
+		f.attempts[id] = true
+	}
+	return f.fakeAnswerTx.Commit()
+}
+
+func (f *fakeDailyAnswerTx) Rollback() error {
+	f.claimed = nil
+	return f.fakeAnswerTx.Rollback()
+}
+
+func TestRecordDailyAnswerCore_AttemptCommitsWithAnswer(t *testing.T) {
+	q := &models.Question{ID: 9, Section: models.SectionLR, DifficultyScore: 50, CorrectAnswerID: "B"}
+	day := challengeDay(time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC))
+	attempts := map[int64]bool{}
+	state := &fakeAnswerState{}
+
+	// A failed answer leaves the day's attempt unclaimed
+	tx := &fakeDailyAnswerTx{fakeAnswerTx: &fakeAnswerTx{state: state, failOn: "history"}, attempts: attempts}
+	if _, err := recordDailyAnswerCore(tx, day, 1, q, true, "B", nil); err == nil {
+		t.Fatal("expected the history write to fail")
+	}
+	if attempts[1] {
+		t.Error("attempt claimed by an answer that failed")
+	}
+
+	tx = &fakeDailyAnswerTx{fakeAnswerTx: &fakeAnswerTx{state: state}, attempts: attempts}
+	if _, err := recordDailyAnswerCore(tx, day, 1, q, true, "B", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !attempts[1] || state.history != 1 || len(state.sources) != 1 || state.sources[0] != models.SourceDailyChallenge {
+		t.Errorf("attempt %v, state %+v; want the attempt and a daily_challenge answer", attempts[1], *state)
+	}
+
+	// A second attempt records nothing
+	tx = &fakeDailyAnswerTx{fakeAnswerTx: &fakeAnswerTx{state: state, pending: *state}, attempts: attempts}
+	if _, err := recordDailyAnswerCore(tx, day, 1, q, false, "C", nil); err == nil || err.Error() != "already attempted" {
+		t.Errorf("second attempt: err = %v, want already attempted", err)
+	}
+	if state.history != 1 {
+		t.Errorf("second attempt recorded history: %+v", *state)
+	}
+}
+
+func TestRecordDailyAnswerCore_UnrankedIfAnsweredBefore(t *testing.T) {
+	q := &models.Question{ID: 9, Section: models.SectionLR, DifficultyScore: 50, CorrectAnswerID: "B"}
+	day := challengeDay(time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC))
+	attempts := map[int64]bool{}
+	answered := map[int64]bool{2: true}
+
+	for userID, wantRanked := range map[int64]bool{1: true, 2: false} {
+		tx := &fakeDailyAnswerTx{fakeAnswerTx: &fakeAnswerTx{state: &fakeAnswerState{}}, attempts: attempts, answered: answered}
+		if _, err := recordDailyAnswerCore(tx, day, userID, q, true, "B", nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := tx.ranked[userID]; got != wantRanked {
+			t.Errorf("user %d (answered before: %v): ranked = %v, want %v", userID, answered[userID], got, wantRanked)
+		}
+	}
+}
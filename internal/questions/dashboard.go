@@ -0,0 +1,93 @@
+package questions
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// dashboardSources loads each section of the home dashboard for one user.
+// A nil source counts as unavailable.
+type dashboardSources struct {
+	gamification func() (*models.GamificationResponse, error)
+	ability      func() (*models.AbilityResponse, error)
+	stats        func() (*models.HistoryStatsResponse, error)
+	nudges       func() (*models.NudgesResponse, error)
+}
+
+// buildDashboard loads every section concurrently. Failed sections are left
+// out and listed in Unavailable; it's only an error if nothing loaded.
+func buildDashboard(src dashboardSources) (*models.DashboardResponse, error) {
+	var resp models.DashboardResponse
+	sections := []struct {
+		name string
+		load func() error
+	}{
+		{"gamification", func() (err error) {
+			if src.gamification == nil {
+				return fmt.Errorf("no source")
+			}
+			resp.Gamification, err = src.gamification()
+			return err
+		}},
+		{"ability", func() (err error) {
+			if src.ability == nil {
+				return fmt.Errorf("no source")
+			}
+			resp.Ability, err = src.ability()
+			return err
+		}},
+		{"stats", func() (err error) {
+			if src.stats == nil {
+				return fmt.Errorf("no source")
+			}
+			resp.Stats, err = src.stats()
+			return err
+		}},
+		{"nudges", func() (err error) {
+			if src.nudges == nil {
+				return fmt.Errorf("no source")
+			}
+			resp.Nudges, err = src.nudges()
+			return err
+		}},
+	}
+
+	errs := make([]error, len(sections))
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = section.load()
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			slog.Warn("dashboard section failed", "component", "dashboard", "section", sections[i].name, "err", err)
+			resp.Unavailable = append(resp.Unavailable, sections[i].name)
+		}
+	}
+	if len(resp.Unavailable) == len(sections) {
+		return nil, fmt.Errorf("dashboard unavailable")
+	}
+	return &resp, nil
+}
+
+// GetDashboard composes the gamification state, ability scores, history
+// stats and nudges the home screen shows.
+func (s *Service) GetDashboard(userID int64) (*models.DashboardResponse, error) {
+	src := dashboardSources{
+		ability: func() (*models.AbilityResponse, error) { return s.GetAbilities(userID) },
+		stats:   func() (*models.HistoryStatsResponse, error) { return s.GetUserHistoryStats(userID) },
+	}
+	if s.gamService != nil {
+		src.gamification = func() (*models.GamificationResponse, error) { return s.gamService.GetGamification(userID, false) }
+		src.nudges = func() (*models.NudgesResponse, error) { return s.gamService.GetNudges(userID) }
+	}
+	return buildDashboard(src)
+}
@@ -0,0 +1,46 @@
+package questions
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestBuildDashboard_DegradesPerSection(t *testing.T) {
+	src := dashboardSources{
+		gamification: func() (*models.GamificationResponse, error) { return &models.GamificationResponse{}, nil },
+		ability:      func() (*models.AbilityResponse, error) { return &models.AbilityResponse{}, nil },
+		stats:        func() (*models.HistoryStatsResponse, error) { return &models.HistoryStatsResponse{}, nil },
+		nudges:       func() (*models.NudgesResponse, error) { return &models.NudgesResponse{}, nil },
+	}
+
+	resp, err := buildDashboard(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Gamification == nil || resp.Ability == nil || resp.Stats == nil || resp.Nudges == nil {
+		t.Errorf("got %+v, want every section", resp)
+	}
+	if len(resp.Unavailable) != 0 {
+		t.Errorf("unavailable = %v, want none", resp.Unavailable)
+	}
+
+	// One failing query leaves the rest of the dashboard intact
+	src.stats = func() (*models.HistoryStatsResponse, error) { return nil, errors.New("db timeout") }
+	resp, err = buildDashboard(src)
+	if err != nil {
+		t.Fatalf("one failed section should not fail the dashboard: %v", err)
+	}
+	if resp.Stats != nil || resp.Gamification == nil || resp.Ability == nil || resp.Nudges == nil {
+		t.Errorf("got %+v, want everything but stats", resp)
+	}
+	if !reflect.DeepEqual(resp.Unavailable, []string{"stats"}) {
+		t.Errorf("unavailable = %v, want [stats]", resp.Unavailable)
+	}
+
+	if _, err := buildDashboard(dashboardSources{}); err == nil {
+		t.Error("nothing loading should be an error")
+	}
+}
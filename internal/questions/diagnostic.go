@@ -0,0 +1,251 @@
+package questions
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// diagnosticTargets are the difficulty scores the diagnostic draws one
+// question at per section, so a new user's answers span the whole range.
+var diagnosticTargets = []int{15, 35, 50, 65, 85}
+
+// diagnosticSections are the sections the diagnostic places.
+var diagnosticSections = []string{string(models.SectionLR), string(models.SectionRC)}
+
+// diagnosticPriorSD is the spread of the prior around the default ability
+// of 50. It keeps a short diagnostic from pinning a user at 0 or 100.
+const diagnosticPriorSD = 20.0
+
+// diagnosticPickStore is the subset of Store used to build a diagnostic.
+type diagnosticPickStore interface {
+	GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int, excludeIDs []int64) (*models.DrillQuestion, error)
+}
+
+// pickDiagnosticQuestions picks one question near each target in each
+// section, widening the window when the pool is thin there. Targets with no
+// question at all are skipped rather than failing the diagnostic.
+func pickDiagnosticQuestions(st diagnosticPickStore, userID int64) ([]models.DrillQuestion, error) {
+	questions := []models.DrillQuestion{}
+	var picked []int64
+	for _, section := range diagnosticSections {
+		for _, target := range diagnosticTargets {
+			var q *models.DrillQuestion
+			for _, spread := range []int{10, 20} {
+				var err error
+				q, err = st.GetOneAdaptiveQuestion(userID, section, "", target-spread, target+spread, picked)
+				if err != nil {
+					return nil, err
+				}
+				if q != nil {
+					break
+				}
+			}
+			if q == nil {
+				continue
+			}
+			picked = append(picked, q.ID)
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+// diagnosticOutcome is one graded diagnostic answer.
+type diagnosticOutcome struct {
+	difficulty int
+	correct    bool
+}
+
+// estimateAbility returns the ability that best explains outcomes under
+// ExpectedAccuracy, with a normal prior centered on 50. The search is over
+// whole scores, matching how abilities are stored.
+func estimateAbility(outcomes []diagnosticOutcome) int {
+	best, bestLogP := 50, math.Inf(-1)
+	for ability := 0; ability <= 100; ability++ {
+		dev := float64(ability-50) / diagnosticPriorSD
+		logP := -dev * dev / 2
+		for _, o := range outcomes {
+			p := ExpectedAccuracy(ability, o.difficulty)
+			if !o.correct {
+				p = 1 - p
+			}
+			logP += math.Log(p)
+		}
+		if logP > bestLogP {
+			best, bestLogP = ability, logP
+		}
+	}
+	return best
+}
+
+// diagnosticStore is the subset of Store used to grade a diagnostic.
+type diagnosticStore interface {
+	GetDiagnosticSession(userID, diagnosticID int64) ([]int64, bool, error)
+	GetQuestionWithChoices(questionID int64) (*models.Question, error)
+}
+
+// diagnosticGrades is a graded diagnostic, ready to place abilities from.
+type diagnosticGrades struct {
+	result    *models.DiagnosticResult
+	all       []diagnosticOutcome
+	bySection map[string][]diagnosticOutcome
+}
+
+// gradeDiagnostic grades answers to the user's diagnostic under policy. Only
+// questions the diagnostic served are accepted, and a submitted diagnostic
+// can't be graded again, so submit can't be used to look up answers. Only
+// full credit counts as correct.
+func gradeDiagnostic(st diagnosticStore, userID, diagnosticID int64, answers []models.DiagnosticAnswer, policy string) (*diagnosticGrades, error) {
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("no answers")
+	}
+	served, submitted, err := st.GetDiagnosticSession(userID, diagnosticID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("diagnostic not found")
+		}
+		return nil, err
+	}
+	if submitted {
+		return nil, fmt.Errorf("diagnostic already submitted")
+	}
+	inDiagnostic := make(map[int64]bool, len(served))
+	for _, id := range served {
+		inDiagnostic[id] = true
+	}
+
+	g := &diagnosticGrades{
+		result:    &models.DiagnosticResult{SectionAbilities: map[string]int{}},
+		bySection: make(map[string][]diagnosticOutcome),
+	}
+	seen := make(map[int64]bool)
+	for _, a := range answers {
+		if !inDiagnostic[a.QuestionID] {
+			return nil, fmt.Errorf("question not in diagnostic")
+		}
+		if seen[a.QuestionID] {
+			continue
+		}
+		seen[a.QuestionID] = true
+
+		q, err := st.GetQuestionWithChoices(a.QuestionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("question not found")
+			}
+			return nil, err
+		}
+		credit, err := gradeSelection(q, a.ChoiceIDs(), policy)
+		if err != nil {
+			return nil, err
+		}
+		correct := credit == 1
+
+		o := diagnosticOutcome{difficulty: q.DifficultyScore, correct: correct}
+		g.all = append(g.all, o)
+		g.bySection[string(q.Section)] = append(g.bySection[string(q.Section)], o)
+		g.result.Answered++
+		if correct {
+			g.result.Correct++
+		}
+	}
+	return g, nil
+}
+
+// diagnosticPlacer is the transaction a diagnostic's abilities are placed in.
+type diagnosticPlacer interface {
+	abilityStore
+	SetAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int) error
+	ClaimDiagnostic(userID, diagnosticID int64) (bool, error)
+	Commit() error
+	Rollback() error
+}
+
+// placeDiagnostic marks the diagnostic submitted and sets the user's overall
+// and section abilities straight to the estimates from g, instead of moving
+// them an answer at a time, then commits tx. On any error tx is rolled back
+// and nothing is applied. Answers aren't recorded in history, so the
+// questions can still be served in practice.
+func placeDiagnostic(tx diagnosticPlacer, userID, diagnosticID int64, g *diagnosticGrades, now time.Time) (*models.DiagnosticResult, error) {
+	defer tx.Rollback()
+
+	// Claimed in the same tx, so of two concurrent submits only one places
+	claimed, err := tx.ClaimDiagnostic(userID, diagnosticID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("diagnostic already submitted")
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	set := func(scope models.AbilityScope, scopeValue *string, score int) error {
+		if _, err := tx.GetOrCreateAbility(userID, scope, scopeValue); err != nil {
+			return fmt.Errorf("get %s ability: %w", scope, err)
+		}
+		if err := tx.SetAbility(userID, scope, scopeValue, score); err != nil {
+			return fmt.Errorf("set %s ability: %w", scope, err)
+		}
+		if err := tx.SnapshotAbility(userID, scope, scopeValue, score, day); err != nil {
+			return fmt.Errorf("snapshot %s ability: %w", scope, err)
+		}
+		return nil
+	}
+
+	result := g.result
+	result.OverallAbility = estimateAbility(g.all)
+	if err := set(models.ScopeOverall, nil, result.OverallAbility); err != nil {
+		return nil, err
+	}
+	for section, outcomes := range g.bySection {
+		score := estimateAbility(outcomes)
+		if err := set(models.ScopeSection, &section, score); err != nil {
+			return nil, err
+		}
+		result.SectionAbilities[section] = score
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit diagnostic: %w", err)
+	}
+	return result, nil
+}
+
+// StartDiagnostic returns a placement set of questions spanning the
+// difficulty range in each section, recorded so only it can be submitted.
+func (s *Service) StartDiagnostic(userID int64) (*models.DiagnosticStartResponse, error) {
+	questions, err := pickDiagnosticQuestions(s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no diagnostic questions available")
+	}
+	ids := make([]int64, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	diagnosticID, err := s.store.CreateDiagnosticSession(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DiagnosticStartResponse{DiagnosticID: diagnosticID, Questions: questions}, nil
+}
+
+// SubmitDiagnostic scores a finished diagnostic and sets the user's initial
+// abilities from it.
+func (s *Service) SubmitDiagnostic(userID int64, req models.DiagnosticSubmitRequest) (*models.DiagnosticResult, error) {
+	g, err := gradeDiagnostic(s.store, userID, req.DiagnosticID, req.Answers, s.grading)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.store.BeginAnswer()
+	if err != nil {
+		return nil, err
+	}
+	return placeDiagnostic(tx, userID, req.DiagnosticID, g, time.Now())
+}
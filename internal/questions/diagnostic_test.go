@@ -0,0 +1,299 @@
+package questions
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// fakeDiagnosticStore serves questions and diagnostics by ID and keeps
+// abilities in memory.
+type fakeDiagnosticStore struct {
+	questions   map[int64]*models.Question
+	diagnostics map[int64][]int64
+	submitted   map[int64]bool
+	abilities   map[string]int
+	snapshots   map[string]int
+}
+
+func abilityKey(scope models.AbilityScope, scopeValue *string) string {
+	if scopeValue == nil {
+		return string(scope)
+	}
+	return string(scope) + "/" + *scopeValue
+}
+
+func (f *fakeDiagnosticStore) GetQuestionWithChoices(questionID int64) (*models.Question, error) {
+	q, ok := f.questions[questionID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return q, nil
+}
+
+func (f *fakeDiagnosticStore) GetDiagnosticSession(userID, diagnosticID int64) ([]int64, bool, error) {
+	ids, ok := f.diagnostics[diagnosticID]
+	if !ok {
+		return nil, false, sql.ErrNoRows
+	}
+	return ids, f.submitted[diagnosticID], nil
+}
+
+// fakeDiagnosticTx buffers placement writes and applies them to st only on
+// Commit. failOn names a step that returns an error.
+type fakeDiagnosticTx struct {
+	st        *fakeDiagnosticStore
+	claimed   int64
+	abilities map[string]int
+	snapshots map[string]int
+	failOn    string
+}
+
+func (f *fakeDiagnosticTx) ClaimDiagnostic(userID, diagnosticID int64) (bool, error) {
+	if f.st.submitted[diagnosticID] {
+		return false, nil
+	}
+	f.claimed = diagnosticID
+	return true, nil
+}
+
+func (f *fakeDiagnosticTx) GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
+	return &models.UserAbilityScore{UserID: userID, Scope: scope, ScopeValue: scopeValue, AbilityScore: 50}, nil
+}
+
+func (f *fakeDiagnosticTx) UpdateAbility(userID int64, scope models.AbilityScope, scopeValue *string, newScore int, correct bool) error {
+	return fmt.Errorf("placement should set abilities, not update them")
+}
+
+func (f *fakeDiagnosticTx) SetAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int) error {
+	f.abilities[abilityKey(scope, scopeValue)] = score
+	return nil
+}
+
+func (f *fakeDiagnosticTx) SnapshotAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int, day time.Time) error {
+	if f.failOn == string(scope) {
+		return fmt.Errorf("injected %s failure", scope)
+	}
+	f.snapshots[abilityKey(scope, scopeValue)] = score
+	return nil
+}
+
+func (f *fakeDiagnosticTx) Commit() error {
+	f.st.submitted[f.claimed] = true
+	for k, v := range f.abilities {
+		f.st.abilities[k] = v
+	}
+	for k, v := range f.snapshots {
+		f.st.snapshots[k] = v
+	}
+	return nil
+}
+
+func (f *fakeDiagnosticTx) Rollback() error {
+	f.claimed = 0
+	f.abilities = map[string]int{}
+	f.snapshots = map[string]int{}
+	return nil
+}
+
+func (f *fakeDiagnosticStore) begin(failOn string) *fakeDiagnosticTx {
+	return &fakeDiagnosticTx{st: f, abilities: map[string]int{}, snapshots: map[string]int{}, failOn: failOn}
+}
+
+// submitDiagnostic grades and places answers to diagnostic 1 as
+// SubmitDiagnostic does.
+func submitDiagnostic(st *fakeDiagnosticStore, answers []models.DiagnosticAnswer, failOn string) (*models.DiagnosticResult, error) {
+	g, err := gradeDiagnostic(st, 1, 1, answers, gradingAllOrNothing)
+	if err != nil {
+		return nil, err
+	}
+	return placeDiagnostic(st.begin(failOn), 1, 1, g, time.Now())
+}
+
+// diagnosticFixture builds one question per diagnostic target in each
+// section, each with B as the correct answer, served as diagnostic 1.
+func diagnosticFixture() *fakeDiagnosticStore {
+	st := &fakeDiagnosticStore{
+		questions:   map[int64]*models.Question{},
+		diagnostics: map[int64][]int64{},
+		submitted:   map[int64]bool{},
+		abilities:   map[string]int{},
+		snapshots:   map[string]int{},
+	}
+	id := int64(1)
+	for _, section := range []models.Section{models.SectionLR, models.SectionRC} {
+		for _, d := range diagnosticTargets {
+			st.questions[id] = &models.Question{
+				ID:              id,
+				Section:         section,
+				DifficultyScore: d,
+				CorrectAnswerID: "B",
+				Choices: []models.AnswerChoice{
+					{ChoiceID: "A"}, {ChoiceID: "B", IsCorrect: true}, {ChoiceID: "C"}, {ChoiceID: "D"}, {ChoiceID: "E"},
+				},
+			}
+			st.diagnostics[1] = append(st.diagnostics[1], id)
+			id++
+		}
+	}
+	return st
+}
+
+func TestScoreDiagnostic_StrongResultSetsHighAbility(t *testing.T) {
+	st := diagnosticFixture()
+	var answers []models.DiagnosticAnswer
+	for id, q := range st.questions {
+		choice := "B"
+		// Miss only the hardest RC question
+		if q.Section == models.SectionRC && q.DifficultyScore == 85 {
+			choice = "A"
+		}
+		answers = append(answers, models.DiagnosticAnswer{QuestionID: id, SelectedChoiceID: choice})
+	}
+
+	result, err := submitDiagnostic(st, answers, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Answered != 10 || result.Correct != 9 {
+		t.Errorf("answered %d, correct %d; want 10, 9", result.Answered, result.Correct)
+	}
+
+	lr, rc := string(models.SectionLR), string(models.SectionRC)
+	for key, min := range map[string]int{"overall": 70, "section/" + lr: 70, "section/" + rc: 65} {
+		if got := st.abilities[key]; got < min {
+			t.Errorf("%s ability = %d, want at least %d", key, got, min)
+		}
+		if st.snapshots[key] != st.abilities[key] {
+			t.Errorf("%s snapshot = %d, want the set ability %d", key, st.snapshots[key], st.abilities[key])
+		}
+	}
+	if result.SectionAbilities[lr] <= result.SectionAbilities[rc] {
+		t.Errorf("perfect LR (%d) should place above RC with a miss (%d)", result.SectionAbilities[lr], result.SectionAbilities[rc])
+	}
+	if result.OverallAbility != st.abilities["overall"] {
+		t.Errorf("result overall %d, stored %d", result.OverallAbility, st.abilities["overall"])
+	}
+}
+
+func TestScoreDiagnostic_WeakResultAndErrors(t *testing.T) {
+	st := diagnosticFixture()
+	var answers []models.DiagnosticAnswer
+	for id := range st.questions {
+		answers = append(answers, models.DiagnosticAnswer{QuestionID: id, SelectedChoiceID: "A"})
+	}
+	result, err := submitDiagnostic(st, answers, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OverallAbility >= 30 {
+		t.Errorf("all wrong placed at %d, want well below 50", result.OverallAbility)
+	}
+
+	if _, err := gradeDiagnostic(st, 1, 2, answers, gradingAllOrNothing); err == nil || err.Error() != "diagnostic not found" {
+		t.Errorf("unknown diagnostic: got %v, want diagnostic not found", err)
+	}
+	st = diagnosticFixture()
+	if _, err := submitDiagnostic(st, nil, ""); err == nil || err.Error() != "no answers" {
+		t.Errorf("empty submit: got %v, want no answers", err)
+	}
+	st.diagnostics[1] = append(st.diagnostics[1], 999)
+	if _, err := submitDiagnostic(st, []models.DiagnosticAnswer{{QuestionID: 999, SelectedChoiceID: "A"}}, ""); err == nil || err.Error() != "question not found" {
+		t.Errorf("unknown question: got %v, want question not found", err)
+	}
+}
+
+func TestSubmitDiagnostic_OnlyServedQuestionsOnce(t *testing.T) {
+	st := diagnosticFixture()
+	st.questions[100] = &models.Question{ID: 100, Section: models.SectionLR, DifficultyScore: 50, CorrectAnswerID: "B",
+		Choices: []models.AnswerChoice{{ChoiceID: "A"}, {ChoiceID: "B", IsCorrect: true}}}
+
+	// Probing a question the diagnostic didn't serve reveals nothing
+	if _, err := submitDiagnostic(st, []models.DiagnosticAnswer{{QuestionID: 100, SelectedChoiceID: "B"}}, ""); err == nil || err.Error() != "question not in diagnostic" {
+		t.Errorf("unserved question: got %v, want question not in diagnostic", err)
+	}
+	if len(st.abilities) != 0 || st.submitted[1] {
+		t.Errorf("rejected submit changed state: abilities %v, submitted %v", st.abilities, st.submitted[1])
+	}
+
+	right := []models.DiagnosticAnswer{{QuestionID: 1, SelectedChoiceID: "B"}}
+	wrong := []models.DiagnosticAnswer{{QuestionID: 1, SelectedChoiceID: "A"}}
+	if _, err := submitDiagnostic(st, right, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submitDiagnostic(st, wrong, ""); err == nil || err.Error() != "diagnostic already submitted" {
+		t.Errorf("second submit: got %v, want diagnostic already submitted", err)
+	}
+
+	// A submit racing the first one is graded, but loses the claim
+	st = diagnosticFixture()
+	g1, err := gradeDiagnostic(st, 1, 1, right, gradingAllOrNothing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := gradeDiagnostic(st, 1, 1, wrong, gradingAllOrNothing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := placeDiagnostic(st.begin(""), 1, 1, g1, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := placeDiagnostic(st.begin(""), 1, 1, g2, time.Now()); err == nil || err.Error() != "diagnostic already submitted" {
+		t.Errorf("racing submit: got %v, want diagnostic already submitted", err)
+	}
+	if st.abilities["overall"] != first.OverallAbility {
+		t.Errorf("overall ability = %d, want the first placement's %d", st.abilities["overall"], first.OverallAbility)
+	}
+}
+
+func TestSubmitDiagnostic_GradesMultiCorrectSelection(t *testing.T) {
+	st := diagnosticFixture()
+	q := st.questions[1]
+	q.Choices[3].IsCorrect = true // B and D
+
+	result, err := submitDiagnostic(st, []models.DiagnosticAnswer{{QuestionID: 1, SelectedChoiceIDs: []string{"D", "B"}}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Correct != 1 {
+		t.Errorf("B and D on a B, D question: correct %d, want 1", result.Correct)
+	}
+
+	st = diagnosticFixture()
+	st.questions[1].Choices[3].IsCorrect = true
+	result, err = submitDiagnostic(st, []models.DiagnosticAnswer{{QuestionID: 1, SelectedChoiceID: "B"}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Correct != 0 {
+		t.Errorf("B alone on a B, D question: correct %d, want 0", result.Correct)
+	}
+}
+
+func TestPlaceDiagnostic_NoPartialCommit(t *testing.T) {
+	st := diagnosticFixture()
+	var answers []models.DiagnosticAnswer
+	for id := range st.questions {
+		answers = append(answers, models.DiagnosticAnswer{QuestionID: id, SelectedChoiceID: "B"})
+	}
+
+	// The section snapshot fails after the overall ability was set
+	if _, err := submitDiagnostic(st, answers, string(models.ScopeSection)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(st.abilities) != 0 || len(st.snapshots) != 0 || st.submitted[1] {
+		t.Errorf("failed placement left partial state: abilities %v, snapshots %v, submitted %v", st.abilities, st.snapshots, st.submitted[1])
+	}
+
+	// Nothing was claimed, so the user can submit again
+	if _, err := submitDiagnostic(st, answers, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.abilities) != 3 || !st.submitted[1] {
+		t.Errorf("retried placement: abilities %v, submitted %v; want overall and both sections", st.abilities, st.submitted[1])
+	}
+}
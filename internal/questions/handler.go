@@ -2,12 +2,16 @@ package questions
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/lsat-prep/backend/internal/generator"
 	"github.com/lsat-prep/backend/internal/models"
 )
 
@@ -25,40 +29,71 @@ func getUserID(r *http.Request) (int64, bool) {
 	return uid, ok
 }
 
-func (h *Handler) GenerateBatch(w http.ResponseWriter, r *http.Request) {
-	var req models.GenerateBatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
-		return
+// validateSelection checks a submitted answer's choices and returns a
+// client-facing error message, or "" if valid.
+func validateSelection(selected []string) string {
+	if len(selected) == 0 {
+		return "selected_choice_id is required"
+	}
+	validChoices := map[string]bool{"A": true, "B": true, "C": true, "D": true, "E": true}
+	for _, choiceID := range selected {
+		if !validChoices[choiceID] {
+			return "selected_choice_id must be A, B, C, D, or E"
+		}
 	}
+	return ""
+}
 
+// validateGenerateRequest checks the fields shared by generate and preview
+// requests and returns a client-facing error message, or "" if valid.
+func validateGenerateRequest(req models.GenerateBatchRequest) string {
 	// Validate section
 	if req.Section != models.SectionLR && req.Section != models.SectionRC {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "section must be 'logical_reasoning' or 'reading_comprehension'"})
-		return
+		return "section must be 'logical_reasoning' or 'reading_comprehension'"
 	}
 
 	// Validate LR subtype
 	if req.Section == models.SectionLR {
 		if req.LRSubtype == nil {
-			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "lr_subtype is required for logical_reasoning"})
-			return
+			return "lr_subtype is required for logical_reasoning"
 		}
 		if !models.ValidLRSubtypes[*req.LRSubtype] {
-			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid lr_subtype"})
-			return
+			return "invalid lr_subtype"
 		}
 	}
 
-	// Validate difficulty
-	if req.Difficulty != models.DifficultyEasy && req.Difficulty != models.DifficultyMedium && req.Difficulty != models.DifficultyHard {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "difficulty must be 'easy', 'medium', or 'hard'"})
+	// Validate difficulty; a score range can stand in for the band
+	if r := req.DifficultyScore; r != nil {
+		if r.Min < 0 || r.Max > 100 || r.Min > r.Max {
+			return "difficulty_score must have 0 <= min <= max <= 100"
+		}
+	}
+	if req.Difficulty == "" && req.DifficultyScore == nil {
+		return "difficulty must be 'easy', 'medium', or 'hard'"
+	}
+	if req.Difficulty != "" && req.Difficulty != models.DifficultyEasy && req.Difficulty != models.DifficultyMedium && req.Difficulty != models.DifficultyHard {
+		return "difficulty must be 'easy', 'medium', or 'hard'"
+	}
+
+	if req.ExperimentTag != nil && len(*req.ExperimentTag) > 100 {
+		return "experiment_tag must be at most 100 characters"
+	}
+	if req.Language != "" && !generator.SupportedLanguage(req.Language) {
+		return "unsupported language"
+	}
+	return ""
+}
+
+func (h *Handler) GenerateBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.GenerateBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
 		return
 	}
 
-	// Default count
-	if req.Count <= 0 {
-		req.Count = 6
+	if msg := validateGenerateRequest(req); msg != "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: msg})
+		return
 	}
 
 	resp, err := h.service.GenerateBatch(r.Context(), req)
@@ -70,19 +105,42 @@ func (h *Handler) GenerateBatch(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, resp)
 }
 
+func (h *Handler) PreviewBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.GeneratePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if msg := validateGenerateRequest(req.GenerateBatchRequest); msg != "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: msg})
+		return
+	}
+
+	resp, err := h.service.PreviewBatch(r.Context(), req)
+	if err != nil {
+		log.Printf("[handler] PreviewBatch error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Preview failed: " + err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) ListBatches(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	var status *models.BatchStatus
+	var filters models.BatchListFilters
 	if s := query.Get("status"); s != "" {
 		bs := models.BatchStatus(s)
-		status = &bs
+		filters.Status = &bs
 	}
+	filters.ExperimentTag = queryStringPtr(r, "experiment_tag")
 
 	limit := intQueryParam(query, "limit", 20)
 	offset := intQueryParam(query, "offset", 0)
 
-	batches, err := h.service.ListBatches(status, limit, offset)
+	batches, total, err := h.service.ListBatches(filters, limit, offset)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list batches"})
 		return
@@ -91,7 +149,16 @@ func (h *Handler) ListBatches(w http.ResponseWriter, r *http.Request) {
 	if batches == nil {
 		batches = []models.QuestionBatch{}
 	}
-	writeJSON(w, http.StatusOK, batches)
+
+	page, totalPages, hasMore := models.OffsetPageInfo(total, limit, offset, len(batches))
+	writeJSON(w, http.StatusOK, models.BatchListResponse{
+		Batches:    batches,
+		Total:      total,
+		Page:       page,
+		PageSize:   limit,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
+	})
 }
 
 func (h *Handler) GetBatch(w http.ResponseWriter, r *http.Request) {
@@ -102,89 +169,603 @@ func (h *Handler) GetBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	batch, err := h.service.GetBatch(id)
+	batch, err := h.service.GetBatch(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Batch not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batch)
+}
+
+func (h *Handler) GetBatchQuestions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid batch ID"})
+		return
+	}
+
+	questions, err := h.service.GetBatchQuestions(id)
+	if err != nil {
+		if err.Error() == "batch not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Batch not found"})
+			return
+		}
+		log.Printf("[handler] GetBatchQuestions error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get batch questions"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.DrillListResponse{
+		Questions: questions,
+		Total:     len(questions),
+		Page:      1,
+		PageSize:  len(questions),
+	})
+}
+
+func (h *Handler) RegenerateExplanations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	question, err := h.service.RegenerateExplanations(r.Context(), id)
+	if err != nil {
+		if err.Error() == "question not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+			return
+		}
+		log.Printf("[handler] RegenerateExplanations error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Explanation regeneration failed: " + err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, question)
+}
+
+func (h *Handler) SetFeatured(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	var req models.SetFeaturedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if req.Featured == nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "featured is required"})
+		return
+	}
+
+	if err := h.service.SetFeatured(id, *req.Featured); err != nil {
+		if err.Error() == "question not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+			return
+		}
+		log.Printf("[handler] SetFeatured error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update question"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "featured": *req.Featured})
+}
+
+func (h *Handler) SetQuestionDifficulty(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	var req models.SetDifficultyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if req.Difficulty == nil && req.DifficultyScore == nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "difficulty or difficulty_score is required"})
+		return
+	}
+	if d := req.Difficulty; d != nil && *d != models.DifficultyEasy && *d != models.DifficultyMedium && *d != models.DifficultyHard {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "difficulty must be 'easy', 'medium', or 'hard'"})
+		return
+	}
+	if s := req.DifficultyScore; s != nil && (*s < 0 || *s > 100) {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "difficulty_score must be between 0 and 100"})
+		return
+	}
+
+	question, err := h.service.SetQuestionDifficulty(id, req)
+	if err != nil {
+		switch err.Error() {
+		case "question not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		case "difficulty and difficulty_score disagree":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "difficulty_score is outside the band for difficulty"})
+		default:
+			log.Printf("[handler] SetQuestionDifficulty error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update question"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":               id,
+		"difficulty":       question.Difficulty,
+		"difficulty_score": question.DifficultyScore,
+	})
+}
+
+func (h *Handler) UpdateBatchAnnotation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid batch ID"})
+		return
+	}
+
+	var req models.UpdateBatchAnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if req.Notes == nil && req.ExperimentTag == nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "notes or experiment_tag is required"})
+		return
+	}
+	if req.ExperimentTag != nil && len(*req.ExperimentTag) > 100 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "experiment_tag must be at most 100 characters"})
+		return
+	}
+
+	batch, err := h.service.UpdateBatchAnnotation(id, req)
+	if err != nil {
+		if err.Error() == "batch not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Batch not found"})
+			return
+		}
+		log.Printf("[handler] UpdateBatchAnnotation error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update batch"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batch)
+}
+
+func (h *Handler) GetBatchAnswerPositions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid batch ID"})
+		return
+	}
+
+	resp, err := h.service.GetBatchAnswerPositions(id)
+	if err != nil {
+		if err.Error() == "batch not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Batch not found"})
+			return
+		}
+		log.Printf("[handler] GetBatchAnswerPositions error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get answer positions"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) PurgeFailedBatches(w http.ResponseWriter, r *http.Request) {
+	days := intQueryParam(r.URL.Query(), "older_than_days", 7)
+
+	result, err := h.service.PurgeFailedBatches(days)
+	if err != nil {
+		log.Printf("[handler] PurgeFailedBatches error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to purge batches"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	question, err := h.service.GetQuestion(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, question)
+}
+
+func (h *Handler) GetQuestionTraps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	resp, err := h.service.GetQuestionTraps(userID, id)
+	if err != nil {
+		switch err.Error() {
+		case "question not answered":
+			writeJSON(w, http.StatusForbidden, models.ErrorResponse{Error: "Answer the question before viewing its traps"})
+		case "question not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		default:
+			log.Printf("[handler] GetQuestionTraps error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get question traps"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetQuestionHint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	resp, err := h.service.GetQuestionHint(id)
+	if err != nil {
+		switch err.Error() {
+		case "question not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		case "hint not available":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "No hint available for this question"})
+		case "daily challenge hint":
+			writeJSON(w, http.StatusForbidden, models.ErrorResponse{Error: "Hints aren't available for today's daily challenge"})
+		default:
+			log.Printf("[handler] GetQuestionHint error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get hint"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) BatchGetQuestions(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchGetQuestionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	resp, err := h.service.GetQuestionsByIDs(req.QuestionIDs)
+	if err != nil {
+		if strings.Contains(err.Error(), "question_ids") {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		log.Printf("[handler] BatchGetQuestions error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get questions"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetQuestionProvenance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	provenance, err := h.service.GetQuestionProvenance(id)
+	if err != nil {
+		log.Printf("[handler] GetQuestionProvenance error: %v", err)
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, provenance)
+}
+
+func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.SubmitAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	selected := req.ChoiceIDs()
+	if msg := validateSelection(selected); msg != "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: msg})
+		return
+	}
+
+	if req.Source != "" && !models.ClientAnswerSources[req.Source] {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid source"})
+		return
+	}
+
+	resp, err := h.service.SubmitAnswerSelection(userID, id, selected, req.TimeSpentSeconds, req.Source)
+	if err != nil {
+		if err.Error() == "question not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+			return
+		}
+		if err.Error() == "selected choice not found" {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "selected_choice_id is not a choice of this question"})
+			return
+		}
+		log.Printf("[handler] SubmitAnswer error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to submit answer"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) AnswerAndNext(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	section := r.URL.Query().Get("section")
+	if section == "" {
+		section = string(models.SectionLR)
+	}
+	if section != string(models.SectionLR) && section != string(models.SectionRC) {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "section must be 'logical_reasoning' or 'reading_comprehension'"})
+		return
+	}
+
+	var req models.SubmitAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	selected := req.ChoiceIDs()
+	if msg := validateSelection(selected); msg != "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: msg})
+		return
+	}
+
+	resp, err := h.service.AnswerAndNext(userID, id, section, selected, req.TimeSpentSeconds)
+	if err != nil {
+		if err.Error() == "question not found" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+			return
+		}
+		if err.Error() == "selected choice not found" {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "selected_choice_id is not a choice of this question"})
+			return
+		}
+		log.Printf("[handler] AnswerAndNext error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to submit answer"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) FlagQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		return
+	}
+
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.FlagQuestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if err := h.service.FlagQuestion(userID, id, req.Reason); err != nil {
+		switch {
+		case err.Error() == "question not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		case strings.HasPrefix(err.Error(), "reason "):
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		default:
+			log.Printf("[handler] FlagQuestion error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to flag question"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{"message": "flagged for review"})
+}
+
+// ── Adaptive System Handlers ────────────────────────────
+
+func (h *Handler) GetAbility(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	abilities, err := h.service.GetAbilities(userID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Batch not found"})
+		log.Printf("[handler] GetAbility error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get ability scores"})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, batch)
+	writeJSON(w, http.StatusOK, abilities)
 }
 
-func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.ParseInt(vars["id"], 10, 64)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+func (h *Handler) GetStudyTime(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
 		return
 	}
 
-	question, err := h.service.GetQuestion(id)
+	resp, err := h.service.GetStudyTime(userID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		log.Printf("[handler] GetStudyTime error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get study time"})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, question)
+	writeJSON(w, http.StatusOK, resp)
 }
 
-func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.ParseInt(vars["id"], 10, 64)
+func (h *Handler) StartDiagnostic(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.StartDiagnostic(userID)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question ID"})
+		if err.Error() == "no diagnostic questions available" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "No diagnostic questions available"})
+			return
+		}
+		log.Printf("[handler] StartDiagnostic error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to start diagnostic"})
 		return
 	}
 
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) SubmitDiagnostic(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
 		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
 		return
 	}
 
-	var req models.SubmitAnswerRequest
+	var req models.DiagnosticSubmitRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
 		return
 	}
 
-	if req.SelectedChoiceID == "" {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "selected_choice_id is required"})
+	if req.DiagnosticID <= 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "diagnostic_id is required"})
 		return
 	}
 
-	validChoices := map[string]bool{"A": true, "B": true, "C": true, "D": true, "E": true}
-	if !validChoices[req.SelectedChoiceID] {
-		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "selected_choice_id must be A, B, C, D, or E"})
+	resp, err := h.service.SubmitDiagnostic(userID, req)
+	if err != nil {
+		switch err.Error() {
+		case "no answers":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "answers is required"})
+		case "diagnostic not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Diagnostic not found"})
+		case "diagnostic already submitted":
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: "Diagnostic already submitted"})
+		case "question not in diagnostic":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "question_id is not part of this diagnostic"})
+		case "question not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		case "selected choice not found":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "selected_choice_id is not a choice of this question"})
+		default:
+			log.Printf("[handler] SubmitDiagnostic error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to submit diagnostic"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
 		return
 	}
 
-	resp, err := h.service.SubmitAnswer(userID, id, req.SelectedChoiceID, req.TimeSpentSeconds)
+	resp, err := h.service.GetDashboard(userID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Question not found"})
+		log.Printf("[handler] GetDashboard error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get dashboard"})
 		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// ── Adaptive System Handlers ────────────────────────────
-
-func (h *Handler) GetAbility(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAbilityHistory(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
 		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
 		return
 	}
 
-	abilities, err := h.service.GetAbilities(userID)
+	scope := models.ScopeOverall
+	if v := r.URL.Query().Get("scope"); v != "" {
+		scope = models.AbilityScope(v)
+	}
+
+	resp, err := h.service.GetAbilityHistory(userID, scope, queryStringPtr(r, "value"))
 	if err != nil {
-		log.Printf("[handler] GetAbility error: %v", err)
-		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get ability scores"})
+		switch err.Error() {
+		case "invalid scope":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "scope must be overall, section, or subtype"})
+		case "scope value is required":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "value is required for section and subtype scopes"})
+		default:
+			log.Printf("[handler] GetAbilityHistory error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get ability history"})
+		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, abilities)
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (h *Handler) SetDifficultySlider(w http.ResponseWriter, r *http.Request) {
@@ -245,14 +826,169 @@ func (h *Handler) QuickDrill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.writeDrill(w, userID, questions, req.Count, models.SourceQuickDrill)
+}
+
+// writeDrill starts a drill session for questions and writes them as a
+// drill list carrying its ID.
+func (h *Handler) writeDrill(w http.ResponseWriter, userID int64, questions []models.DrillQuestion, pageSize int, source models.AnswerSource) {
+	drillID, err := h.service.StartDrillSession(userID, questions, source)
+	if err != nil {
+		log.Printf("[handler] StartDrillSession error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to start drill"})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, models.DrillListResponse{
+		DrillID:   drillID,
 		Questions: questions,
 		Total:     len(questions),
 		Page:      1,
-		PageSize:  req.Count,
+		PageSize:  pageSize,
+		Source:    source,
 	})
 }
 
+func (h *Handler) RetryMistakes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.RetryMistakesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if len(req.QuestionIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "question_ids is required"})
+		return
+	}
+	if len(req.QuestionIDs) > 50 {
+		req.QuestionIDs = req.QuestionIDs[:50]
+	}
+
+	questions, err := h.service.GetRetryMistakesDrill(userID, req.QuestionIDs)
+	if err != nil {
+		log.Printf("[handler] RetryMistakes error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get drill questions"})
+		return
+	}
+
+	h.writeDrill(w, userID, questions, len(questions), models.SourceReview)
+}
+
+func (h *Handler) GetDailyChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.GetDailyChallenge(userID)
+	if err != nil {
+		if err.Error() == "no daily challenge available" {
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "No daily challenge available"})
+			return
+		}
+		log.Printf("[handler] GetDailyChallenge error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get daily challenge"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) SubmitDailyChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.DailyChallengeAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if msg := validateSelection(req.ChoiceIDs()); msg != "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: msg})
+		return
+	}
+
+	resp, err := h.service.SubmitDailyChallenge(userID, req)
+	if err != nil {
+		switch err.Error() {
+		case "no daily challenge available":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "No daily challenge available"})
+		case "daily challenge has changed":
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: "Daily challenge has changed"})
+		case "daily challenge not fetched":
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: "Fetch the daily challenge before answering it"})
+		case "already attempted":
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: "Daily challenge already attempted"})
+		case "selected choice not found":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "selected_choice_id is not a choice of this question"})
+		default:
+			log.Printf("[handler] SubmitDailyChallenge error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to submit answer"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetDailyChallengeLeaderboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	resp, err := h.service.GetDailyChallengeLeaderboard(userID)
+	if err != nil {
+		log.Printf("[handler] GetDailyChallengeLeaderboard error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get leaderboard"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) NextQuestion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	section := r.URL.Query().Get("section")
+	if section == "" {
+		section = string(models.SectionLR)
+	}
+	if section != string(models.SectionLR) && section != string(models.SectionRC) {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "section must be 'logical_reasoning' or 'reading_comprehension'"})
+		return
+	}
+
+	resp, err := h.service.GetNextQuestion(userID, section)
+	if err != nil {
+		log.Printf("[handler] NextQuestion error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get next question"})
+		return
+	}
+	if resp.Question == nil {
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "No questions available right now"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) SubtypeDrill(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
@@ -297,20 +1033,32 @@ func (h *Handler) SubtypeDrill(w http.ResponseWriter, r *http.Request) {
 	if req.Count <= 0 {
 		req.Count = 6
 	}
+	if r.URL.Query().Get("include_flagged") == "true" {
+		req.IncludeFlagged = true
+	}
 
 	questions, err := h.service.GetSubtypeDrill(r.Context(), userID, req)
 	if err != nil {
+		switch err.Error() {
+		case "generation in progress":
+			retryAfter := int(h.service.GenerationRetryAfter().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeJSON(w, http.StatusAccepted, models.DrillPendingResponse{
+				Status:            "generating",
+				RetryAfterSeconds: retryAfter,
+				Message:           "Questions for this subtype are being generated, try again shortly",
+			})
+			return
+		case "no questions available":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "No questions available for this subtype"})
+			return
+		}
 		log.Printf("[handler] SubtypeDrill error: %v", err)
 		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get drill questions"})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, models.DrillListResponse{
-		Questions: questions,
-		Total:     len(questions),
-		Page:      1,
-		PageSize:  req.Count,
-	})
+	h.writeDrill(w, userID, questions, req.Count, models.SourceSubtypeDrill)
 }
 
 // ── Admin Handlers ──────────────────────────────────────
@@ -324,6 +1072,16 @@ func (h *Handler) GetQualityStats(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, stats)
 }
 
+func (h *Handler) GetStructuralStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetStructuralStats()
+	if err != nil {
+		log.Printf("[handler] GetStructuralStats error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get structural stats"})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
 func (h *Handler) GetGenerationStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.service.GetGenerationStats()
 	if err != nil {
@@ -333,6 +1091,55 @@ func (h *Handler) GetGenerationStats(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, stats)
 }
 
+func (h *Handler) GetDifficultyHistogram(w http.ResponseWriter, r *http.Request) {
+	histogram, err := h.service.GetDifficultyHistogram(mux.Vars(r)["subtype"])
+	if err != nil {
+		if err.Error() == "invalid subtype" {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid subtype"})
+			return
+		}
+		log.Printf("[handler] GetDifficultyHistogram error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get difficulty histogram"})
+		return
+	}
+	writeJSON(w, http.StatusOK, histogram)
+}
+
+func (h *Handler) StartRevalidation(w http.ResponseWriter, r *http.Request) {
+	var req models.RevalidateRequest
+	json.NewDecoder(r.Body).Decode(&req) // optional body
+
+	job, err := h.service.StartRevalidation(req.IncludeFlagged)
+	if err != nil {
+		switch err.Error() {
+		case "validation is disabled":
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Validation is disabled"})
+		case "revalidation already running":
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: "A revalidation job is already running"})
+		default:
+			log.Printf("[handler] StartRevalidation error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to start revalidation"})
+		}
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *Handler) GetRevalidationJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid job ID"})
+		return
+	}
+
+	job, err := h.service.GetRevalidationJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
 func (h *Handler) Recalibrate(w http.ResponseWriter, r *http.Request) {
 	report, err := h.service.RecalibrateDifficulty()
 	if err != nil {
@@ -357,14 +1164,81 @@ func (h *Handler) GetFlaggedQuestions(w http.ResponseWriter, r *http.Request) {
 		questions = []models.Question{}
 	}
 
+	page, totalPages, hasMore := models.OffsetPageInfo(total, limit, offset, len(questions))
 	writeJSON(w, http.StatusOK, models.QuestionListResponse{
-		Questions: questions,
-		Total:     total,
-		Page:      offset/limit + 1,
-		PageSize:  limit,
+		Questions:  questions,
+		Total:      total,
+		Page:       page,
+		PageSize:   limit,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
 	})
 }
 
+func (h *Handler) ListGenerationQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := intQueryParam(query, "page", 1)
+	pageSize := intQueryParam(query, "page_size", 20)
+
+	resp, err := h.service.ListGenerationQueue(query.Get("status"), page, pageSize)
+	if err != nil {
+		if err.Error() == "invalid status" {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "status must be pending, generating, completed, or failed"})
+			return
+		}
+		log.Printf("[handler] ListGenerationQueue error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list generation queue"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) ResetGenerationQueueItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid queue item ID"})
+		return
+	}
+
+	item, err := h.service.ResetGenerationQueueItem(id)
+	if err != nil {
+		switch err.Error() {
+		case "queue item not found":
+			writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Queue item not found"})
+		case "queue item not resettable":
+			writeJSON(w, http.StatusConflict, models.ErrorResponse{Error: "Only generating or failed items with no newer item for their bucket can be reset"})
+		default:
+			log.Printf("[handler] ResetGenerationQueueItem error: %v", err)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to reset queue item"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *Handler) SearchQuestions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "q is required"})
+		return
+	}
+	page := intQueryParam(query, "page", 1)
+	pageSize := intQueryParam(query, "page_size", 20)
+
+	resp, err := h.service.SearchQuestions(q, page, pageSize)
+	if err != nil {
+		log.Printf("[handler] SearchQuestions error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to search questions"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) ExportQuestions(w http.ResponseWriter, r *http.Request) {
 	envelope, err := h.service.ExportQuestions()
 	if err != nil {
@@ -418,9 +1292,11 @@ func (h *Handler) RCDrill(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if req.Count <= 0 {
-		req.Count = 8
+	if req.Count < 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "count must not be negative"})
+		return
 	}
+	req.Count = rcDrillCount(req.Count, h.service.RCDrillMax())
 
 	resp, err := h.service.GetRCDrill(r.Context(), userID, req)
 	if err != nil {
@@ -432,6 +1308,24 @@ func (h *Handler) RCDrill(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (h *Handler) ListPassages(w http.ResponseWriter, r *http.Request) {
+	filters := models.PassageListFilters{
+		SubjectArea: queryStringPtr(r, "subject_area"),
+		Comparative: queryBoolPtr(r, "comparative"),
+	}
+	page := intQueryParam(r.URL.Query(), "page", 1)
+	pageSize := intQueryParam(r.URL.Query(), "page_size", 20)
+
+	resp, err := h.service.ListPassages(filters, page, pageSize)
+	if err != nil {
+		log.Printf("[handler] ListPassages error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list passages"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) GetPassage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -450,6 +1344,26 @@ func (h *Handler) GetPassage(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, dp)
 }
 
+func (h *Handler) GetPassageText(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid passage ID"})
+		return
+	}
+
+	text, err := h.service.GetPassageText(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "Passage not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="passage-%d.txt"`, id))
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, text)
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
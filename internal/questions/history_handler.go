@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/lsat-prep/backend/internal/models"
@@ -14,8 +15,10 @@ import (
 func (h *Handler) RegisterHistoryRoutes(protected *mux.Router) {
 	protected.HandleFunc("/history", h.GetHistory).Methods("GET")
 	protected.HandleFunc("/history/mistakes", h.GetMistakes).Methods("GET")
+	protected.HandleFunc("/history/search", h.SearchHistory).Methods("GET")
 	protected.HandleFunc("/history/stats", h.GetHistoryStats).Methods("GET")
 	protected.HandleFunc("/history/drill-review", h.GetDrillReview).Methods("POST")
+	protected.HandleFunc("/history/bookmarks/check", h.CheckBookmarks).Methods("POST")
 
 	protected.HandleFunc("/bookmarks", h.GetBookmarks).Methods("GET")
 	protected.HandleFunc("/bookmarks/{questionID}", h.CreateBookmark).Methods("POST")
@@ -51,6 +54,35 @@ func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (h *Handler) SearchHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	q := queryStringPtr(r, "q")
+	if q == nil || strings.TrimSpace(*q) == "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "q is required"})
+		return
+	}
+
+	req := models.HistoryListRequest{
+		Search:   q,
+		Page:     intQueryParam(r.URL.Query(), "page", 1),
+		PageSize: intQueryParam(r.URL.Query(), "page_size", 20),
+	}
+
+	resp, err := h.service.GetUserHistory(userID, req)
+	if err != nil {
+		log.Printf("[handler] SearchHistory error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to search history"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) GetMistakes(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
@@ -176,6 +208,38 @@ func (h *Handler) DeleteBookmark(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"message": "unbookmarked"})
 }
 
+func (h *Handler) CheckBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserID(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
+		return
+	}
+
+	var req models.BookmarkCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if len(req.QuestionIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "question_ids is required"})
+		return
+	}
+	if len(req.QuestionIDs) > 100 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "At most 100 question_ids allowed"})
+		return
+	}
+
+	resp, err := h.service.CheckBookmarks(userID, req.QuestionIDs)
+	if err != nil {
+		log.Printf("[handler] CheckBookmarks error: %v", err)
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to check bookmarks"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) GetBookmarks(w http.ResponseWriter, r *http.Request) {
 	userID, ok := getUserID(r)
 	if !ok {
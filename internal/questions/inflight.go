@@ -0,0 +1,87 @@
+package questions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// inflightGenerations coalesces concurrent generations for the same bucket,
+// so many users exhausting one subtype at once trigger a single batch. The
+// zero value is ready to use.
+type inflightGenerations struct {
+	mu    sync.Mutex
+	calls map[string]*inflightGeneration
+}
+
+type inflightGeneration struct {
+	done    chan struct{}
+	waiters int // callers sharing the generation, logged when it finishes
+	resp    *models.GenerateBatchResponse
+	err     error
+}
+
+// generationKey identifies the bucket a generation request fills.
+func generationKey(req models.GenerateBatchRequest) string {
+	subtype := ""
+	if req.LRSubtype != nil {
+		subtype = string(*req.LRSubtype)
+	} else if req.RCSubtype != nil {
+		subtype = string(*req.RCSubtype)
+	}
+	scores := ""
+	if req.DifficultyScore != nil {
+		scores = fmt.Sprintf("%d-%d", req.DifficultyScore.Min, req.DifficultyScore.Max)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%t/%s/%d", req.Section, subtype, req.Difficulty, scores, req.SubjectArea, req.IsComparative, req.Language, req.Count)
+}
+
+// do runs generate for key unless a generation for key is already running,
+// in which case it waits for that one and returns its result.
+func (g *inflightGenerations) do(ctx context.Context, key string, generate func() (*models.GenerateBatchResponse, error)) (*models.GenerateBatchResponse, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		call.waiters++
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.resp, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*inflightGeneration)
+	}
+	call := &inflightGeneration{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	// Release waiters even if generate panics
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		waiters := call.waiters
+		g.mu.Unlock()
+		close(call.done)
+		if waiters > 0 {
+			slog.Info("coalesced generation", "component", "generation", "key", key, "waiters", waiters)
+		}
+	}()
+
+	call.resp, call.err = generate()
+	return call.resp, call.err
+}
+
+// generateCoalesced is GenerateBatch with concurrent requests for the same
+// bucket sharing one generation. The generation outlives ctx, since other
+// requests may be waiting on it.
+func (s *Service) generateCoalesced(ctx context.Context, req models.GenerateBatchRequest) (*models.GenerateBatchResponse, error) {
+	genCtx := context.WithoutCancel(ctx)
+	return s.inflight.do(ctx, generationKey(req), func() (*models.GenerateBatchResponse, error) {
+		return s.GenerateBatch(genCtx, req)
+	})
+}
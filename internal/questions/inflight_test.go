@@ -0,0 +1,108 @@
+package questions
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestInflightGenerations_CoalescesSameBucket(t *testing.T) {
+	var g inflightGenerations
+	flaw := models.SubtypeFlaw
+	key := generationKey(models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &flaw, Difficulty: models.DifficultyHard})
+
+	const n = 20
+	var calls atomic.Int32
+	release := make(chan struct{})
+	generate := func() (*models.GenerateBatchResponse, error) {
+		calls.Add(1)
+		<-release
+		return &models.GenerateBatchResponse{BatchID: 42}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int64, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := g.do(context.Background(), key, generate)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = resp.BatchID
+		}(i)
+	}
+
+	// Hold the generation open until every other caller is waiting on it
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		call := g.calls[key]
+		waiting := call != nil && call.waiters == n-1
+		g.mu.Unlock()
+		if waiting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("callers never coalesced onto one generation")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("generated %d times, want 1", got)
+	}
+	for i, id := range results {
+		if id != 42 {
+			t.Errorf("caller %d got batch %d, want shared batch 42", i, id)
+		}
+	}
+
+	// Once finished, the next request generates again
+	if _, err := g.do(context.Background(), key, func() (*models.GenerateBatchResponse, error) {
+		calls.Add(1)
+		return &models.GenerateBatchResponse{}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("generated %d times after the first finished, want 2", got)
+	}
+}
+
+func TestGenerationKey_SeparatesBuckets(t *testing.T) {
+	flaw, assumption := models.SubtypeFlaw, models.SubtypeAssumption
+	a := generationKey(models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &flaw, Difficulty: models.DifficultyHard})
+	b := generationKey(models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &assumption, Difficulty: models.DifficultyHard})
+	c := generationKey(models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &flaw, Difficulty: models.DifficultyEasy})
+	d := generationKey(models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &flaw, Difficulty: models.DifficultyHard, Language: "es"})
+	e := generationKey(models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &flaw, Difficulty: models.DifficultyHard, Count: 10})
+	if a == b || a == c || a == d || a == e {
+		t.Errorf("keys %q, %q, %q, %q, %q should all differ", a, b, c, d, e)
+	}
+}
+
+func TestInflightGenerations_ReleasesKeyOnPanic(t *testing.T) {
+	var g inflightGenerations
+	func() {
+		defer func() { recover() }()
+		g.do(context.Background(), "k", func() (*models.GenerateBatchResponse, error) {
+			panic("generator blew up")
+		})
+	}()
+
+	g.mu.Lock()
+	_, stuck := g.calls["k"]
+	g.mu.Unlock()
+	if stuck {
+		t.Error("a panicking generation should not leave its key in flight")
+	}
+}
@@ -0,0 +1,69 @@
+package questions
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// offPeakWindow is the daily span of UTC hours [start, end) during which
+// non-urgent queue items generate. It may wrap midnight (e.g. 22-6). The
+// zero value has no window, so every item generates immediately.
+type offPeakWindow struct {
+	start, end int
+	enabled    bool
+}
+
+// parseOffPeakWindow reads GEN_OFF_PEAK_START_HOUR and GEN_OFF_PEAK_END_HOUR.
+// Both must be set to hours 0-23 and differ, or there is no window.
+func parseOffPeakWindow() offPeakWindow {
+	startRaw, endRaw := os.Getenv("GEN_OFF_PEAK_START_HOUR"), os.Getenv("GEN_OFF_PEAK_END_HOUR")
+	if startRaw == "" && endRaw == "" {
+		return offPeakWindow{}
+	}
+	start, errStart := strconv.Atoi(startRaw)
+	end, errEnd := strconv.Atoi(endRaw)
+	if errStart != nil || errEnd != nil || start < 0 || start > 23 || end < 0 || end > 23 || start == end {
+		slog.Warn("ignoring invalid config", "component", "service", "key", "GEN_OFF_PEAK_START_HOUR/GEN_OFF_PEAK_END_HOUR",
+			"value", startRaw+"-"+endRaw, "want", "two different hours 0-23")
+		return offPeakWindow{}
+	}
+	return offPeakWindow{start: start, end: end, enabled: true}
+}
+
+// allows reports whether non-urgent generation may run at t.
+func (w offPeakWindow) allows(t time.Time) bool {
+	if !w.enabled {
+		return true
+	}
+	h := t.UTC().Hour()
+	if w.start < w.end {
+		return h >= w.start && h < w.end
+	}
+	return h >= w.start || h < w.end
+}
+
+func (w offPeakWindow) String() string {
+	if !w.enabled {
+		return "none"
+	}
+	return strconv.Itoa(w.start) + "-" + strconv.Itoa(w.end) + "h UTC"
+}
+
+// dueGenerations returns the pending items to generate now: high-priority
+// items always, the rest only inside the off-peak window.
+func dueGenerations(items []models.GenerationQueueItem, window offPeakWindow, now time.Time) []models.GenerationQueueItem {
+	if window.allows(now) {
+		return items
+	}
+	var due []models.GenerationQueueItem
+	for _, item := range items {
+		if item.HighPriority {
+			due = append(due, item)
+		}
+	}
+	return due
+}
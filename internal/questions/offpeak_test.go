@@ -0,0 +1,42 @@
+package questions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestDueGenerations_DefersNonUrgentUntilOffPeak(t *testing.T) {
+	t.Setenv("GEN_OFF_PEAK_START_HOUR", "22")
+	t.Setenv("GEN_OFF_PEAK_END_HOUR", "6")
+	window := parseOffPeakWindow()
+
+	urgent := models.GenerationQueueItem{ID: 1, HighPriority: true}
+	topUp := models.GenerationQueueItem{ID: 2}
+	queue := []models.GenerationQueueItem{urgent, topUp}
+
+	// Queued at peak: only the urgent item runs, on every tick until the window
+	clock := time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC)
+	for ; clock.Hour() != 22; clock = clock.Add(30 * time.Minute) {
+		due := dueGenerations(queue, window, clock)
+		if len(due) != 1 || due[0].ID != urgent.ID {
+			t.Fatalf("at %s got %v, want only the urgent item", clock.Format("15:04"), due)
+		}
+	}
+
+	// Inside the window (which wraps midnight) the top-up runs too
+	for _, at := range []time.Time{clock, clock.Add(3 * time.Hour), clock.Add(7*time.Hour + 59*time.Minute)} {
+		if due := dueGenerations(queue, window, at); len(due) != 2 {
+			t.Errorf("at %s got %d items, want both", at.Format("15:04"), len(due))
+		}
+	}
+	if due := dueGenerations(queue, window, clock.Add(8*time.Hour)); len(due) != 1 {
+		t.Errorf("at 06:00 got %d items, want the window closed", len(due))
+	}
+
+	// Without a window nothing is deferred
+	if due := dueGenerations(queue, offPeakWindow{}, time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC)); len(due) != 2 {
+		t.Errorf("no window got %d items, want both", len(due))
+	}
+}
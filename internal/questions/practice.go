@@ -0,0 +1,157 @@
+package questions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// Infinite practice serves one adaptive question at a time. Each user has an
+// implicit in-memory session per section that remembers what it has served,
+// so repeated calls advance even before the user answers. A session ends
+// after practiceSessionIdle without a request or when the section changes.
+const (
+	practiceSessionIdle   = 30 * time.Minute
+	practiceSessionMemory = 200 // served IDs remembered per session
+)
+
+type practiceSession struct {
+	section  string
+	served   []int64
+	count    int
+	lastSeen time.Time
+}
+
+func (ps *practiceSession) record(id int64) {
+	ps.count++
+	ps.exclude(id)
+}
+
+// exclude keeps id out of the rest of the session without counting it as
+// served.
+func (ps *practiceSession) exclude(id int64) {
+	ps.served = append(ps.served, id)
+	if len(ps.served) > practiceSessionMemory {
+		ps.served = ps.served[len(ps.served)-practiceSessionMemory:]
+	}
+}
+
+// practiceSessions holds the implicit practice session of each user.
+// Expired sessions are swept out as other sessions are touched.
+type practiceSessions struct {
+	mu        sync.Mutex
+	sessions  map[int64]*practiceSession
+	lastSweep time.Time
+}
+
+func newPracticeSessions() *practiceSessions {
+	return &practiceSessions{sessions: make(map[int64]*practiceSession)}
+}
+
+// session returns the user's live session in section, starting a new one if
+// it expired or the section changed. Callers must hold p.mu.
+func (p *practiceSessions) session(userID int64, section string, now time.Time) *practiceSession {
+	p.sweep(now)
+	sess := p.sessions[userID]
+	if sess == nil || sess.section != section || now.Sub(sess.lastSeen) > practiceSessionIdle {
+		sess = &practiceSession{section: section}
+		p.sessions[userID] = sess
+	}
+	sess.lastSeen = now
+	return sess
+}
+
+// sweep drops sessions idle past practiceSessionIdle, at most once per idle
+// period so touching a session stays cheap. Callers must hold p.mu.
+func (p *practiceSessions) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < practiceSessionIdle {
+		return
+	}
+	for userID, sess := range p.sessions {
+		if now.Sub(sess.lastSeen) > practiceSessionIdle {
+			delete(p.sessions, userID)
+		}
+	}
+	p.lastSweep = now
+}
+
+// nextQuestionStore is the subset of Store used to serve practice questions.
+type nextQuestionStore interface {
+	GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error)
+	GetDifficultySlider(userID int64) (int, error)
+	GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int, excludeIDs []int64) (*models.DrillQuestion, error)
+}
+
+// practicePick is the result of nextPracticeQuestion. Question is nil when
+// nothing new is left in the widened difficulty window; MinDiff and MaxDiff
+// are the user's normal window, used for inventory top-up.
+type practicePick struct {
+	Question         *models.DrillQuestion
+	Served           int
+	MinDiff, MaxDiff int
+}
+
+// nextPracticeQuestion picks one adaptive question for the user's practice
+// session in section, preferring questions the user hasn't answered and
+// skipping ones already served this session.
+func (p *practiceSessions) nextPracticeQuestion(st nextQuestionStore, userID int64, section string, now time.Time) (*practicePick, error) {
+	sectionAbility, err := st.GetOrCreateAbility(userID, models.ScopeSection, &section)
+	if err != nil {
+		sectionAbility = &models.UserAbilityScore{AbilityScore: 50}
+	}
+	slider, err := st.GetDifficultySlider(userID)
+	if err != nil || slider <= 0 {
+		slider = 50
+	}
+
+	target := TargetDifficulty(sectionAbility.AbilityScore, slider)
+	pick := &practicePick{MinDiff: max(0, target-15), MaxDiff: min(100, target+15)}
+
+	// Hold the lock only around session state, not the queries
+	p.mu.Lock()
+	sess := p.session(userID, section, now)
+	exclude := append([]int64(nil), sess.served...)
+	p.mu.Unlock()
+
+	windows := [][2]int{{pick.MinDiff, pick.MaxDiff}, {max(0, target-35), min(100, target+35)}}
+	for _, w := range windows {
+		q, err := st.GetOneAdaptiveQuestion(userID, section, "", w[0], w[1], exclude)
+		if err != nil {
+			return nil, err
+		}
+		if q != nil {
+			pick.Question = q
+			break
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pick.Question != nil {
+		sess.record(pick.Question.ID)
+	}
+	pick.Served = sess.count
+	return pick, nil
+}
+
+// answerAndNext submits an answer with submit and then picks the next
+// practice question in section. The answered question is excluded from the
+// session first, so it can't come straight back even if the user got it
+// wrong or reached it outside practice.
+func (p *practiceSessions) answerAndNext(st nextQuestionStore, userID int64, section string, questionID int64, now time.Time, submit func() (*models.SubmitAnswerResponse, error)) (*models.SubmitAnswerResponse, *practicePick, error) {
+	result, err := submit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	p.session(userID, section, now).exclude(questionID)
+	p.mu.Unlock()
+
+	pick, err := p.nextPracticeQuestion(st, userID, section, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, pick, nil
+}
@@ -0,0 +1,157 @@
+package questions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// fakePracticeStore serves questions from memory the way GetOneAdaptiveQuestion
+// does: unanswered questions first, within the subtype and difficulty window.
+type fakePracticeStore struct {
+	ability   int
+	questions []models.DrillQuestion
+	answered  map[int64]bool
+}
+
+func (f *fakePracticeStore) GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
+	return &models.UserAbilityScore{AbilityScore: f.ability}, nil
+}
+
+func (f *fakePracticeStore) GetDifficultySlider(userID int64) (int, error) {
+	return 50, nil
+}
+
+func (f *fakePracticeStore) GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int, excludeIDs []int64) (*models.DrillQuestion, error) {
+	excluded := map[int64]bool{}
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+	var seen *models.DrillQuestion
+	for i := range f.questions {
+		q := &f.questions[i]
+		if string(q.Section) != section || (subtype != "" && string(*q.LRSubtype) != subtype) ||
+			q.DifficultyScore < minDiff || q.DifficultyScore > maxDiff || excluded[q.ID] {
+			continue
+		}
+		if !f.answered[q.ID] {
+			return q, nil
+		}
+		if seen == nil {
+			seen = q
+		}
+	}
+	return seen, nil
+}
+
+func lrQuestion(id int64, subtype models.LRSubtype, score int) models.DrillQuestion {
+	return models.DrillQuestion{ID: id, Section: models.SectionLR, LRSubtype: &subtype, DifficultyScore: score}
+}
+
+func TestNextPracticeQuestion_AdvancesWithinWindow(t *testing.T) {
+	st := &fakePracticeStore{
+		ability: 60,
+		questions: []models.DrillQuestion{
+			lrQuestion(1, models.SubtypeFlaw, 58),
+			lrQuestion(2, models.SubtypeFlaw, 62),
+			lrQuestion(3, models.SubtypeWeaken, 70),
+			lrQuestion(4, models.SubtypeWeaken, 95), // outside even the wide window
+			lrQuestion(5, models.SubtypeAssumption, 55),
+		},
+		answered: map[int64]bool{5: true},
+	}
+	p := newPracticeSessions()
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	section := string(models.SectionLR)
+
+	served := map[int64]bool{}
+	for i := 1; i <= 3; i++ {
+		pick, err := p.nextPracticeQuestion(st, 1, section, now.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := pick.Question
+		if q == nil {
+			t.Fatalf("call %d returned no question", i)
+		}
+		if served[q.ID] {
+			t.Fatalf("call %d repeated question %d", i, q.ID)
+		}
+		if st.answered[q.ID] {
+			t.Errorf("call %d served answered question %d while unseen ones remain", i, q.ID)
+		}
+		if q.DifficultyScore < pick.MinDiff || q.DifficultyScore > pick.MaxDiff {
+			t.Errorf("call %d served difficulty %d outside window %d-%d", i, q.DifficultyScore, pick.MinDiff, pick.MaxDiff)
+		}
+		if pick.Served != i {
+			t.Errorf("call %d: session count = %d, want %d", i, pick.Served, i)
+		}
+		served[q.ID] = true
+	}
+
+	// A new session after going idle starts over
+	pick, err := p.nextPracticeQuestion(st, 1, section, now.Add(2*time.Hour))
+	if err != nil || pick.Question == nil || pick.Served != 1 {
+		t.Errorf("new session pick = %+v, %v; want a question and count 1", pick, err)
+	}
+}
+
+func TestAnswerAndNext_ReturnsResultAndFreshQuestion(t *testing.T) {
+	st := &fakePracticeStore{
+		ability: 60,
+		questions: []models.DrillQuestion{
+			lrQuestion(1, models.SubtypeFlaw, 60),
+			lrQuestion(2, models.SubtypeFlaw, 62),
+		},
+		answered: map[int64]bool{},
+	}
+	p := newPracticeSessions()
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	section := string(models.SectionLR)
+
+	// Question 1 was reached outside practice and answered wrong, so the
+	// store would rank it first again
+	result, pick, err := p.answerAndNext(st, 1, section, 1, now, func() (*models.SubmitAnswerResponse, error) {
+		return &models.SubmitAnswerResponse{Correct: false, CorrectAnswerID: "C"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.CorrectAnswerID != "C" {
+		t.Errorf("result = %+v, want the submitted answer's result", result)
+	}
+	if pick.Question == nil || pick.Question.ID != 2 {
+		t.Fatalf("next = %+v, want fresh question 2", pick.Question)
+	}
+
+	// Answering the last one leaves nothing new to serve
+	_, pick, err = p.answerAndNext(st, 1, section, 2, now.Add(time.Minute), func() (*models.SubmitAnswerResponse, error) {
+		return &models.SubmitAnswerResponse{Correct: true}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pick.Question != nil {
+		t.Errorf("next = question %d, want none after both were answered", pick.Question.ID)
+	}
+}
+
+func TestPracticeSessions_EvictsIdleSessions(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	p := newPracticeSessions()
+	p.session(1, "logical_reasoning", now)
+	p.session(2, "logical_reasoning", now.Add(20*time.Minute))
+
+	// User 1 has been idle past the limit; user 2 hasn't
+	p.session(3, "reading_comprehension", now.Add(45*time.Minute))
+	if _, ok := p.sessions[1]; ok {
+		t.Error("idle session for user 1 should have been evicted")
+	}
+	if _, ok := p.sessions[2]; !ok {
+		t.Error("live session for user 2 should be kept")
+	}
+	if len(p.sessions) != 2 {
+		t.Errorf("%d sessions, want users 2 and 3", len(p.sessions))
+	}
+}
@@ -0,0 +1,145 @@
+package questions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/generator"
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// revalidateConcurrency bounds how many questions a re-validation job scores
+// at once, since each one makes validator LLM calls.
+const revalidateConcurrency = 4
+
+// revalidationStore is the subset of Store used by a re-validation job.
+type revalidationStore interface {
+	GetQuestionWithChoices(questionID int64) (*models.Question, error)
+	GetPassage(passageID int64) (*models.RCPassage, error)
+	UpdateQuestionValidation(questionID int64, status string, reasoning *string, adversarialScore *string, qualityScore *float64, flagged bool) error
+	UpdateRevalidationJob(job models.RevalidationJob) error
+}
+
+// StartRevalidation starts a background job that runs Stages 2-3 over every
+// unvalidated question (and flagged ones if includeFlagged) and returns the
+// job record. Only one job runs at a time.
+func (s *Service) StartRevalidation(includeFlagged bool) (*models.RevalidationJob, error) {
+	if !s.validationEnabled || s.validator == nil {
+		return nil, fmt.Errorf("validation is disabled")
+	}
+	if !s.revalidating.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("revalidation already running")
+	}
+
+	ids, err := s.store.GetRevalidationQuestionIDs(includeFlagged)
+	if err != nil {
+		s.revalidating.Store(false)
+		return nil, err
+	}
+	job, err := s.store.CreateRevalidationJob(includeFlagged, len(ids))
+	if err != nil {
+		s.revalidating.Store(false)
+		return nil, err
+	}
+
+	go func(job models.RevalidationJob) {
+		defer s.revalidating.Store(false)
+		s.runRevalidation(context.Background(), s.store, &job, ids)
+	}(*job)
+
+	return job, nil
+}
+
+func (s *Service) GetRevalidationJob(jobID int64) (*models.RevalidationJob, error) {
+	return s.store.GetRevalidationJob(jobID)
+}
+
+// runRevalidation scores ids with at most revalidateConcurrency in flight,
+// saving the job's progress after each question.
+func (s *Service) runRevalidation(ctx context.Context, st revalidationStore, job *models.RevalidationJob, ids []int64) {
+	slog.Info("revalidating questions", "component", "revalidate", "job_id", job.ID, "questions", len(ids))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, revalidateConcurrency)
+
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status, err := s.revalidateQuestion(ctx, st, id)
+			if err != nil {
+				slog.Warn("revalidate question failed", "component", "revalidate", "job_id", job.ID, "question_id", id, "err", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			job.Processed++
+			switch {
+			case err != nil:
+				job.Failed++
+			case status == string(models.ValidationPassed):
+				job.Passed++
+			case status == string(models.ValidationFlagged):
+				job.Flagged++
+			case status == string(models.ValidationRejected):
+				job.Rejected++
+			}
+			if err := st.UpdateRevalidationJob(*job); err != nil {
+				slog.Warn("save progress failed", "component", "revalidate", "job_id", job.ID, "err", err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	job.Status = "completed"
+	job.FinishedAt = &now
+	if err := st.UpdateRevalidationJob(*job); err != nil {
+		slog.Error("save result failed", "component", "revalidate", "job_id", job.ID, "err", err)
+	}
+	slog.Info("revalidation complete", "component", "revalidate", "job_id", job.ID,
+		"passed", job.Passed, "flagged", job.Flagged, "rejected", job.Rejected, "failed", job.Failed)
+}
+
+// revalidateQuestion runs Stages 2-3 on one stored question and saves its new
+// status and quality score. A question the validator couldn't score is left
+// unchanged and reported as an error.
+func (s *Service) revalidateQuestion(ctx context.Context, st revalidationStore, questionID int64) (string, error) {
+	q, err := st.GetQuestionWithChoices(questionID)
+	if err != nil {
+		return "", err
+	}
+
+	batch := &generator.GeneratedBatch{Questions: []generator.GeneratedQuestion{toGeneratedQuestion(q)}}
+	if q.PassageID != nil {
+		p, err := st.GetPassage(*q.PassageID)
+		if err != nil {
+			return "", err
+		}
+		batch.Passage = toGeneratedPassage(p)
+	}
+
+	req := models.GenerateBatchRequest{
+		Section:    q.Section,
+		LRSubtype:  q.LRSubtype,
+		RCSubtype:  q.RCSubtype,
+		Difficulty: q.Difficulty,
+	}
+	opt := s.scoreBatch(ctx, req, q.BatchID, batch, true).opts[0]
+	if opt.ValidationStatus == string(models.ValidationUnvalidated) {
+		return "", fmt.Errorf("validator returned no result")
+	}
+
+	if err := st.UpdateQuestionValidation(questionID, opt.ValidationStatus, opt.ValidationReason,
+		opt.AdversarialScore, opt.QualityScore, opt.Flagged); err != nil {
+		return "", fmt.Errorf("update validation: %w", err)
+	}
+	return opt.ValidationStatus, nil
+}
@@ -0,0 +1,135 @@
+package questions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lsat-prep/backend/internal/generator"
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// answerKeyValidator agrees with the answer key with high confidence, except
+// for questions whose stem is in disagree.
+type answerKeyValidator struct {
+	disagree map[string]bool
+}
+
+func (v *answerKeyValidator) ValidateBatch(ctx context.Context, batch *generator.GeneratedBatch) (*generator.BatchValidationResult, error) {
+	res := &generator.BatchValidationResult{TotalQuestions: len(batch.Questions)}
+	for i, q := range batch.Questions {
+		selected := q.CorrectAnswerID
+		if v.disagree[q.QuestionStem] {
+			selected = "E"
+		}
+		res.Results = append(res.Results, generator.ValidationResult{
+			QuestionIndex: i, SelectedAnswer: selected, GeneratedAnswer: q.CorrectAnswerID,
+			Matches: selected == q.CorrectAnswerID, Confidence: "high",
+		})
+	}
+	return res, nil
+}
+
+func (v *answerKeyValidator) AdversarialCheckBatch(ctx context.Context, batch *generator.GeneratedBatch) ([]generator.AdversarialResult, error) {
+	return nil, nil
+}
+
+func (v *answerKeyValidator) ModelName() string { return "fake-validator" }
+
+// fakeRevalidationStore holds questions and the saved job progress.
+type fakeRevalidationStore struct {
+	mu        sync.Mutex
+	questions map[int64]*models.Question
+	saves     int
+	job       models.RevalidationJob
+}
+
+func (f *fakeRevalidationStore) GetQuestionWithChoices(questionID int64) (*models.Question, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q, ok := f.questions[questionID]
+	if !ok {
+		return nil, fmt.Errorf("get question: no rows")
+	}
+	cp := *q
+	return &cp, nil
+}
+
+func (f *fakeRevalidationStore) GetPassage(passageID int64) (*models.RCPassage, error) {
+	return nil, fmt.Errorf("no passage %d", passageID)
+}
+
+func (f *fakeRevalidationStore) UpdateQuestionValidation(questionID int64, status string, reasoning *string, adversarialScore *string, qualityScore *float64, flagged bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := f.questions[questionID]
+	q.ValidationStatus = models.ValidationStatus(status)
+	q.QualityScore = qualityScore
+	q.Flagged = flagged
+	return nil
+}
+
+func (f *fakeRevalidationStore) UpdateRevalidationJob(job models.RevalidationJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saves++
+	f.job = job
+	return nil
+}
+
+func TestRunRevalidation_ScoresUnvalidatedQuestions(t *testing.T) {
+	sub := models.SubtypeStrengthen
+	st := &fakeRevalidationStore{questions: map[int64]*models.Question{}}
+	var ids []int64
+	for i := int64(1); i <= 6; i++ {
+		gq := fakeQuestion("B")
+		gq.QuestionStem = fmt.Sprintf("Question %d: which of the following most strengthens the argument?", i)
+		q := &models.Question{
+			ID: i, BatchID: 1, Section: models.SectionLR, LRSubtype: &sub, Difficulty: models.DifficultyMedium,
+			Stimulus: gq.Stimulus, QuestionStem: gq.QuestionStem, CorrectAnswerID: gq.CorrectAnswerID,
+			ValidationStatus: models.ValidationUnvalidated,
+		}
+		for _, c := range gq.Choices {
+			ac := models.AnswerChoice{ChoiceID: c.ID, ChoiceText: c.Text, Explanation: c.Explanation, IsCorrect: c.ID == "B"}
+			if c.WrongAnswerType != nil {
+				ac.WrongAnswerType = *c.WrongAnswerType
+			}
+			q.Choices = append(q.Choices, ac)
+		}
+		st.questions[i] = q
+		ids = append(ids, i)
+	}
+	// 99 was deleted after the job picked it up
+	ids = append(ids, 99)
+
+	val := &answerKeyValidator{disagree: map[string]bool{st.questions[3].QuestionStem: true}}
+	s := &Service{validator: val, validationEnabled: true}
+	job := &models.RevalidationJob{ID: 1, Status: "running", Total: len(ids)}
+	s.runRevalidation(context.Background(), st, job, ids)
+
+	for id, q := range st.questions {
+		if q.ValidationStatus == models.ValidationUnvalidated {
+			t.Errorf("question %d still unvalidated after the job", id)
+		}
+		if q.QualityScore == nil {
+			t.Errorf("question %d has no quality score", id)
+		}
+	}
+	if st.questions[3].ValidationStatus != models.ValidationRejected {
+		t.Errorf("question 3 status = %s, want rejected after validator disagreed", st.questions[3].ValidationStatus)
+	}
+
+	if st.job.Status != "completed" || st.job.FinishedAt == nil {
+		t.Errorf("job = %+v, want completed with a finish time", st.job)
+	}
+	if st.job.Processed != 7 || st.job.Failed != 1 || st.job.Rejected < 1 {
+		t.Errorf("job counts = %+v, want 7 processed, 1 failed, at least 1 rejected", st.job)
+	}
+	if got := st.job.Passed + st.job.Flagged + st.job.Rejected + st.job.Failed; got != st.job.Processed {
+		t.Errorf("outcome counts sum to %d, want %d processed", got, st.job.Processed)
+	}
+	if st.saves != 8 {
+		t.Errorf("job saved %d times, want once per question plus the final save", st.saves)
+	}
+}
@@ -2,12 +2,17 @@ package questions
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lsat-prep/backend/internal/gamification"
@@ -28,15 +33,47 @@ var allRCSubtypes = []string{
 	"rc_analogy", "rc_relationship", "rc_agreement",
 }
 
+// Generator produces raw question batches (Stage 1). *generator.Generator is
+// the default implementation; the LLM provider behind it is chosen by config.
+type Generator interface {
+	GenerateLRBatch(ctx context.Context, subtype models.LRSubtype, difficulty models.Difficulty, count int, language string, target *models.ScoreRange) (*generator.GeneratedBatch, *generator.LLMResponse, error)
+	GenerateRCBatch(ctx context.Context, difficulty models.Difficulty, questionsPerPassage int, subjectArea string, comparative bool, language string, target *models.ScoreRange) (*generator.GeneratedBatch, *generator.LLMResponse, error)
+	RegenerateExplanations(ctx context.Context, q generator.GeneratedQuestion, passage *generator.GeneratedPassage, language string) (*generator.ExplanationRewrite, *generator.LLMResponse, error)
+	ModelName() string
+}
+
+// Validator runs self-verification (Stage 2) and adversarial checks (Stage 3).
+type Validator interface {
+	ValidateBatch(ctx context.Context, batch *generator.GeneratedBatch) (*generator.BatchValidationResult, error)
+	AdversarialCheckBatch(ctx context.Context, batch *generator.GeneratedBatch) ([]generator.AdversarialResult, error)
+	ModelName() string
+}
+
 type Service struct {
 	store              *Store
-	generator          *generator.Generator
-	validator          *generator.Validator
+	generator          Generator
+	validator          Validator
 	validationEnabled  bool
+	passConfidence     string
+	grading            string
+	quality            generator.QualityThresholds
 	adversarialEnabled bool
 	autoGenEnabledLR   bool
 	autoGenEnabledRC   bool
-	autoGenMinUnseen   int
+	autoGenSubtypes    subtypeFilter
+	autoGenMinUnseenLR int
+	autoGenMinUnseenRC int
+	rcPerPassage       int
+	comparativeTarget  float64
+	comparativeMin     int
+	genWorkerInterval  time.Duration
+	genStaleTimeout    time.Duration
+	recalMinResponders int
+	offPeak            offPeakWindow
+	admins             map[int64]bool
+	practice           *practiceSessions
+	revalidating       atomic.Bool
+	inflight           inflightGenerations
 	gamService         *gamification.Service
 }
 
@@ -45,49 +82,268 @@ func (s *Service) SetGamificationService(gs *gamification.Service) {
 	s.gamService = gs
 }
 
-func NewService(store *Store, gen *generator.Generator, val *generator.Validator) *Service {
+func NewService(store *Store, gen Generator, val Validator) *Service {
 	validationEnabled := os.Getenv("VALIDATION_ENABLED") != "false"
 	adversarialEnabled := os.Getenv("ADVERSARIAL_ENABLED") != "false"
 
+	// Lowest validator confidence that passes rather than flags a question
+	passConfidence := generator.PassConfidence()
+
+	// Quality score boundaries for rejecting and flagging questions
+	quality := generator.QualityThresholdsFromEnv()
+
+	// How multi-correct questions earn credit
+	grading := multiCorrectGrading()
+
 	// Auto-generation section flags
 	autoGenEnabledLR := os.Getenv("AUTO_GEN_ENABLED_LR") != "false"
 	autoGenEnabledRC := os.Getenv("AUTO_GEN_ENABLED_RC") == "true"
 
-	// Minimum unseen questions before triggering generation
-	autoGenMinUnseen := 4
-	if v := os.Getenv("AUTO_GEN_MIN_UNSEEN"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			autoGenMinUnseen = n
+	// Subtypes auto-generation is limited to, or kept off, within an
+	// enabled section, e.g. while a new subtype is validated
+	autoGenSubtypes := subtypeFilter{
+		allow: parseSubtypeList("AUTO_GEN_SUBTYPES"),
+		deny:  parseSubtypeList("AUTO_GEN_EXCLUDE_SUBTYPES"),
+	}
+
+	// Minimum unseen questions before triggering generation. RC questions
+	// come a passage at a time, so each section has its own threshold;
+	// AUTO_GEN_MIN_UNSEEN sets the default for both.
+	autoGenMinUnseen := envPositiveInt("AUTO_GEN_MIN_UNSEEN", 4)
+	autoGenMinUnseenLR := envPositiveInt("AUTO_GEN_MIN_UNSEEN_LR", autoGenMinUnseen)
+	autoGenMinUnseenRC := envPositiveInt("AUTO_GEN_MIN_UNSEEN_RC", autoGenMinUnseen)
+
+	// Questions generated per RC passage
+	rcPerPassage := defaultRCPerPassage
+	if v := os.Getenv("RC_QUESTIONS_PER_PASSAGE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= minRCPerPassage && n <= maxRCPerPassage {
+			rcPerPassage = n
+		} else {
+			slog.Warn("ignoring invalid config", "component", "service", "key", "RC_QUESTIONS_PER_PASSAGE", "value", v, "want", fmt.Sprintf("%d-%d", minRCPerPassage, maxRCPerPassage))
+		}
+	}
+
+	// Share of RC passages that should be comparative, enforced once the
+	// library has RC_COMPARATIVE_MIN passages
+	comparativeTarget := defaultComparativeTarget
+	if v := os.Getenv("RC_COMPARATIVE_TARGET"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			comparativeTarget = f
+		} else {
+			slog.Warn("ignoring invalid config", "component", "service", "key", "RC_COMPARATIVE_TARGET", "value", v, "want", "0-1")
 		}
 	}
+	comparativeMin := envPositiveInt("RC_COMPARATIVE_MIN", defaultComparativeMin)
+
+	// How often the background worker drains the generation queue
+	genWorkerInterval := envDuration("GEN_WORKER_INTERVAL", 30*time.Second)
+
+	// How long an item may sit in 'generating' before it's presumed abandoned
+	genStaleTimeout := envDuration("GEN_STALE_TIMEOUT", 30*time.Minute)
+
+	// Distinct users a question needs before recalibration trusts its
+	// accuracy; 0 trusts times_served alone
+	recalMinResponders := envPositiveInt("RECALIBRATE_MIN_RESPONDERS", 0)
+
+	// Hours when non-urgent queue items (global and RC top-ups) generate
+	offPeak := parseOffPeakWindow()
+
+	// Users allowed admin-only serving options such as include_flagged
+	admins := parseAdminIDs(os.Getenv("ADMIN_USER_IDS"))
 
 	// Disable validation in mock mode
-	if os.Getenv("MOCK_GENERATOR") == "true" {
+	if generator.Provider() == "mock" {
 		validationEnabled = false
 		adversarialEnabled = false
 	}
 
-	log.Printf("Service: validation=%v adversarial=%v autoGenLR=%v autoGenRC=%v minUnseen=%d",
-		validationEnabled, adversarialEnabled, autoGenEnabledLR, autoGenEnabledRC, autoGenMinUnseen)
+	slog.Info("question service configured", "component", "service",
+		"validation", validationEnabled, "pass_confidence", passConfidence, "quality_reject_below", quality.RejectBelow, "quality_flag_below", quality.FlagBelow, "grading", grading, "adversarial", adversarialEnabled,
+		"auto_gen_lr", autoGenEnabledLR, "auto_gen_rc", autoGenEnabledRC, "auto_gen_subtypes", autoGenSubtypes.String(),
+		"min_unseen_lr", autoGenMinUnseenLR, "min_unseen_rc", autoGenMinUnseenRC,
+		"rc_per_passage", rcPerPassage, "comparative_target", comparativeTarget, "comparative_min", comparativeMin,
+		"gen_worker_interval", genWorkerInterval, "gen_stale_timeout", genStaleTimeout,
+		"recalibrate_min_responders", recalMinResponders, "off_peak", offPeak.String())
 
 	return &Service{
 		store:              store,
 		generator:          gen,
 		validator:          val,
 		validationEnabled:  validationEnabled,
+		passConfidence:     passConfidence,
+		grading:            grading,
+		quality:            quality,
 		adversarialEnabled: adversarialEnabled,
 		autoGenEnabledLR:   autoGenEnabledLR,
 		autoGenEnabledRC:   autoGenEnabledRC,
-		autoGenMinUnseen:   autoGenMinUnseen,
+		autoGenSubtypes:    autoGenSubtypes,
+		autoGenMinUnseenLR: autoGenMinUnseenLR,
+		autoGenMinUnseenRC: autoGenMinUnseenRC,
+		rcPerPassage:       rcPerPassage,
+		comparativeTarget:  comparativeTarget,
+		comparativeMin:     comparativeMin,
+		genWorkerInterval:  genWorkerInterval,
+		genStaleTimeout:    genStaleTimeout,
+		recalMinResponders: recalMinResponders,
+		offPeak:            offPeak,
+		admins:             admins,
+		practice:           newPracticeSessions(),
+	}
+}
+
+// envPositiveInt returns the positive integer in env var key, or def if it's
+// unset or invalid.
+func envPositiveInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// parseAdminIDs parses a comma-separated list of admin user IDs, skipping
+// entries that aren't IDs.
+func parseAdminIDs(raw string) map[int64]bool {
+	admins := make(map[int64]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			slog.Warn("ignoring invalid config", "component", "service", "key", "ADMIN_USER_IDS", "value", part)
+			continue
+		}
+		admins[id] = true
+	}
+	return admins
+}
+
+// subtypeFilter narrows LR auto-generation to some subtypes. An empty allow
+// list allows every LR subtype that isn't denied. RC is generated a passage
+// at a time, covering several subtypes, so RC subtypes are never filtered.
+type subtypeFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// allows reports whether subtype may be auto-generated.
+func (f subtypeFilter) allows(subtype string) bool {
+	if models.ValidRCSubtypes[models.RCSubtype(subtype)] {
+		return true
+	}
+	if f.deny[subtype] {
+		return false
+	}
+	return len(f.allow) == 0 || f.allow[subtype]
+}
+
+func (f subtypeFilter) String() string {
+	list := func(m map[string]bool) string {
+		var names []string
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return "all"
+	}
+	return fmt.Sprintf("allow=%s deny=%s", list(f.allow), list(f.deny))
+}
+
+// parseSubtypeList parses the comma-separated LR subtypes in env var key.
+// Other names, RC subtypes included, are ignored.
+func parseSubtypeList(key string) map[string]bool {
+	subtypes := make(map[string]bool)
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if models.ValidRCSubtypes[models.RCSubtype(part)] {
+			slog.Warn("ignoring invalid config", "component", "service", "key", key, "value", part, "want", "LR subtypes only; RC is generated by passage")
+			continue
+		}
+		if !models.ValidLRSubtypes[models.LRSubtype(part)] {
+			slog.Warn("ignoring invalid config", "component", "service", "key", key, "value", part)
+			continue
+		}
+		subtypes[part] = true
+	}
+	return subtypes
+}
+
+// canServeFlagged reports whether userID's drills may include flagged
+// questions: only admins who asked for them.
+func (s *Service) canServeFlagged(userID int64, requested bool) bool {
+	return requested && s.admins[userID]
+}
+
+// envDuration returns the positive duration (e.g. "30s") in env var key, or
+// def if it's unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("ignoring invalid config", "component", "service", "key", key, "value", v, "want", "a positive duration like 30s")
 	}
+	return def
 }
 
+// RC passages get between minRCPerPassage and maxRCPerPassage questions,
+// matching the range the RC system prompt asks for.
+const (
+	defaultRCPerPassage = 6
+	minRCPerPassage     = 5
+	maxRCPerPassage     = 8
+)
+
+// By default 1 in 4 RC passages is comparative, matching the real LSAT, once
+// the library has a few passages.
+const (
+	defaultComparativeTarget = 0.25
+	defaultComparativeMin    = 4
+)
+
 // ── Question Generation (3-Stage Pipeline) ──────────────
 
+// questionsPerPassage returns the configured RC questions-per-passage target.
+func (s *Service) questionsPerPassage() int {
+	if s.rcPerPassage > 0 {
+		return s.rcPerPassage
+	}
+	return defaultRCPerPassage
+}
+
+// defaultCount returns the batch size used when a request doesn't set one.
+func (s *Service) defaultCount(section models.Section) int {
+	if section == models.SectionRC {
+		return s.questionsPerPassage()
+	}
+	return 6
+}
+
+// fillDifficultyBand sets a request's missing difficulty band from the
+// middle of its score range.
+func fillDifficultyBand(req *models.GenerateBatchRequest) {
+	if req.Difficulty == "" && req.DifficultyScore != nil {
+		req.Difficulty = mapScoreToDifficulty((req.DifficultyScore.Min + req.DifficultyScore.Max) / 2)
+	}
+}
+
 func (s *Service) GenerateBatch(ctx context.Context, req models.GenerateBatchRequest) (*models.GenerateBatchResponse, error) {
 	if req.Count <= 0 {
-		req.Count = 6
+		req.Count = s.defaultCount(req.Section)
+	}
+	if req.Language == "" {
+		req.Language = generator.DefaultLanguage
 	}
+	fillDifficultyBand(&req)
 
 	// Create batch record (status: pending)
 	batch, err := s.store.CreateBatch(req)
@@ -103,80 +359,211 @@ func (s *Service) GenerateBatch(ctx context.Context, req models.GenerateBatchReq
 	// ── Stage 1: Generate questions ──────────────────────────
 	startTime := time.Now()
 
-	var genBatch *generator.GeneratedBatch
-	var llmResp *generator.LLMResponse
+	genBatch, llmResp, err := s.generateQuestions(ctx, req)
+	if err != nil {
+		s.store.FailBatch(batch.ID, err.Error())
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+
+	promptTokens := 0
+	outputTokens := 0
+	if llmResp != nil {
+		promptTokens = llmResp.PromptTokens
+		outputTokens = llmResp.OutputTokens
+	}
+
+	slog.Info("stage 1 complete", "component", "generation", "batch_id", batch.ID, "generated", len(genBatch.Questions))
+
+	if s.validationEnabled && s.validator != nil {
+		if err := s.store.UpdateBatchStatus(batch.ID, models.BatchValidating); err != nil {
+			slog.Warn("failed to update batch status to validating", "component", "generation", "batch_id", batch.ID, "err", err)
+		}
+	}
+
+	// ── Stages 2-3: Verification, adversarial check, scoring ──
+	scored := s.scoreBatch(ctx, req, batch.ID, genBatch, true)
+
+	// ── Filter out rejected questions before saving ──────────
+	filteredBatch, filteredOpts, kept := filterRejected(genBatch, scored.opts)
+
+	// Save surviving questions (use background context so saves aren't lost if HTTP client disconnects)
+	savedIDs, err := s.store.SaveGeneratedBatch(context.Background(), batch.ID, filteredBatch, req, filteredOpts)
+	if err != nil {
+		for _, vlog := range scored.logs {
+			s.store.LogValidation(vlog)
+		}
+		errMsg := err.Error()
+		s.store.FailBatch(batch.ID, errMsg)
+		return nil, fmt.Errorf("save batch: %w", err)
+	}
+
+	// Logs are written once the questions have IDs, so each is attached to
+	// its own question rather than the whole batch
+	questionIDs := make([]int64, len(genBatch.Questions))
+	skipped := 0
+	for j, i := range kept {
+		questionIDs[i] = savedIDs[j]
+		if savedIDs[j] == 0 {
+			skipped++
+		}
+	}
+	for _, vlog := range scored.questionLogs(questionIDs) {
+		s.store.LogValidation(vlog)
+	}
+	if skipped > 0 {
+		slog.Warn("skipped duplicate questions", "component", "generation", "batch_id", batch.ID, "skipped", skipped)
+	}
+
+	// Mark completed
+	elapsed := time.Since(startTime).Milliseconds()
+	completion := BatchCompletion{
+		Passed:              scored.passed,
+		Flagged:             scored.flagged,
+		Rejected:            scored.rejected,
+		TimeMs:              elapsed,
+		PromptTokens:        promptTokens,
+		OutputTokens:        outputTokens,
+		ValidationTokens:    scored.validationTokens(),
+		ModelUsed:           s.generator.ModelName(),
+		PromptVersion:       genBatch.PromptVersion,
+		GenerationCostCents: generator.EstimateCostCents(s.generator.ModelName(), promptTokens, outputTokens),
+		ValidationCostCents: s.validationCostCents(scored),
+	}
+	if s.validationEnabled && s.validator != nil {
+		completion.ValidationModel = s.validator.ModelName()
+	}
+	if err := s.store.CompleteBatch(batch.ID, completion); err != nil {
+		return nil, fmt.Errorf("complete batch: %w", err)
+	}
+
+	return &models.GenerateBatchResponse{
+		BatchID:           batch.ID,
+		Status:            models.BatchCompleted,
+		QuestionsPassed:   scored.passed,
+		QuestionsFlagged:  scored.flagged,
+		QuestionsRejected: scored.rejected,
+		Message:           fmt.Sprintf("Generated %d questions (%d passed, %d flagged, %d rejected)", len(genBatch.Questions), scored.passed, scored.flagged, scored.rejected),
+	}, nil
+}
 
+// generateQuestions runs Stage 1 against the configured generator.
+func (s *Service) generateQuestions(ctx context.Context, req models.GenerateBatchRequest) (*generator.GeneratedBatch, *generator.LLMResponse, error) {
 	switch req.Section {
 	case models.SectionLR:
 		if req.LRSubtype == nil {
-			s.store.FailBatch(batch.ID, "lr_subtype required for logical_reasoning")
-			return nil, fmt.Errorf("lr_subtype required for logical_reasoning")
+			return nil, nil, fmt.Errorf("lr_subtype required for logical_reasoning")
 		}
-		genBatch, llmResp, err = s.generator.GenerateLRBatch(ctx, *req.LRSubtype, req.Difficulty, req.Count)
+		return s.generator.GenerateLRBatch(ctx, *req.LRSubtype, req.Difficulty, req.Count, req.Language, req.DifficultyScore)
 	case models.SectionRC:
-		genBatch, llmResp, err = s.generator.GenerateRCBatch(ctx, req.Difficulty, req.Count, req.SubjectArea, req.IsComparative)
+		return s.generator.GenerateRCBatch(ctx, req.Difficulty, req.Count, req.SubjectArea, req.IsComparative, req.Language, req.DifficultyScore)
 	default:
-		s.store.FailBatch(batch.ID, "invalid section")
-		return nil, fmt.Errorf("invalid section: %s", req.Section)
+		return nil, nil, fmt.Errorf("invalid section: %s", req.Section)
 	}
+}
 
-	if err != nil {
-		errMsg := err.Error()
-		s.store.FailBatch(batch.ID, errMsg)
-		return nil, fmt.Errorf("generation failed: %w", err)
+// scoredBatch holds the per-question outcome of Stages 2-3.
+type scoredBatch struct {
+	opts []QuestionSaveOptions
+	logs []models.ValidationLog
+	// logQuestion is the index in the batch of the question each log is for
+	logQuestion            []int
+	passed                 int
+	flagged                int
+	rejected               int
+	validationPromptTokens int
+	validationOutputTokens int
+}
+
+func (sb *scoredBatch) validationTokens() int {
+	return sb.validationPromptTokens + sb.validationOutputTokens
+}
+
+// questionLogs returns the logs with each attached to its question, given
+// the saved ID of each question in the batch. Logs for questions that weren't
+// saved (ID 0) keep only the batch ID.
+func (sb *scoredBatch) questionLogs(questionIDs []int64) []models.ValidationLog {
+	logs := make([]models.ValidationLog, len(sb.logs))
+	for k, l := range sb.logs {
+		if i := sb.logQuestion[k]; i < len(questionIDs) && questionIDs[i] != 0 {
+			id := questionIDs[i]
+			l.QuestionID = &id
+		}
+		logs[k] = l
 	}
+	return logs
+}
 
-	promptTokens := 0
-	outputTokens := 0
-	if llmResp != nil {
-		promptTokens = llmResp.PromptTokens
-		outputTokens = llmResp.OutputTokens
+// validationCostCents prices the Stage 2-3 tokens against the validator's model,
+// which may be cheaper than the generation model.
+func (s *Service) validationCostCents(sb *scoredBatch) int {
+	if s.validator == nil {
+		return 0
+	}
+	return generator.EstimateCostCents(s.validator.ModelName(), sb.validationPromptTokens, sb.validationOutputTokens)
+}
+
+// validationStatus maps a Stage 2 result to a question's validation status:
+// rejected if the validator picked another answer, passed if it agreed with
+// at least passConfidence, else flagged.
+func validationStatus(vr *generator.ValidationResult, passConfidence string) (status string, reasoning *string, flagged bool) {
+	if !vr.Matches {
+		r := fmt.Sprintf("Validator selected %s (expected %s): %s",
+			vr.SelectedAnswer, vr.GeneratedAnswer, vr.Reasoning)
+		return string(models.ValidationRejected), &r, false
+	}
+	if generator.ConfidencePasses(vr.Confidence, passConfidence) {
+		return string(models.ValidationPassed), nil, false
 	}
+	r := fmt.Sprintf("Low confidence (%s): %s", vr.Confidence, vr.Reasoning)
+	return string(models.ValidationFlagged), &r, true
+}
 
-	log.Printf("Stage 1 complete: generated %d questions for batch %d", len(genBatch.Questions), batch.ID)
+// scoreBatch runs self-verification and the adversarial check (when enabled
+// and validate is set), then computes a quality score and validation status
+// for every question.
+func (s *Service) scoreBatch(ctx context.Context, req models.GenerateBatchRequest, batchID int64, genBatch *generator.GeneratedBatch, validate bool) *scoredBatch {
+	result := &scoredBatch{}
 
 	// ── Stage 2: Self-Verification ───────────────────────────
 	var batchValidation *generator.BatchValidationResult
-	validationTokens := 0
-
-	if s.validationEnabled && s.validator != nil {
-		if err := s.store.UpdateBatchStatus(batch.ID, models.BatchValidating); err != nil {
-			log.Printf("WARN: failed to update batch status to validating: %v", err)
-		}
 
-		batchValidation, err = s.validator.ValidateBatch(ctx, genBatch)
+	if validate && s.validationEnabled && s.validator != nil {
+		bv, err := s.validator.ValidateBatch(ctx, genBatch)
 		if err != nil {
-			log.Printf("WARN: Stage 2 validation failed for batch %d: %v — skipping validation", batch.ID, err)
+			slog.Warn("stage 2 validation failed, skipping validation", "component", "generation", "batch_id", batchID, "err", err)
 		} else {
-			validationTokens += batchValidation.TotalPromptTokens + batchValidation.TotalOutputTokens
-			log.Printf("Stage 2 complete: passed=%d flagged=%d rejected=%d",
-				batchValidation.PassedCount, batchValidation.FlaggedCount, batchValidation.RejectedCount)
+			batchValidation = bv
+			result.validationPromptTokens += bv.TotalPromptTokens
+			result.validationOutputTokens += bv.TotalOutputTokens
+			slog.Info("stage 2 complete", "component", "generation", "batch_id", batchID,
+				"passed", bv.PassedCount, "flagged", bv.FlaggedCount, "rejected", bv.RejectedCount)
 		}
 	}
 
 	// ── Stage 3: Adversarial Check ───────────────────────────
 	var adversarialResults []generator.AdversarialResult
 
-	if s.adversarialEnabled && s.validator != nil && req.Difficulty != models.DifficultyEasy {
+	if validate && s.adversarialEnabled && s.validator != nil && req.Difficulty != models.DifficultyEasy {
 		advResults, err := s.validator.AdversarialCheckBatch(ctx, genBatch)
 		if err != nil {
-			log.Printf("WARN: Stage 3 adversarial check failed for batch %d: %v — skipping", batch.ID, err)
+			slog.Warn("stage 3 adversarial check failed, skipping", "component", "generation", "batch_id", batchID, "err", err)
 		} else {
 			adversarialResults = advResults
 			for _, ar := range advResults {
-				validationTokens += ar.PromptTokens + ar.OutputTokens
+				result.validationPromptTokens += ar.PromptTokens
+				result.validationOutputTokens += ar.OutputTokens
 			}
-			log.Printf("Stage 3 complete: checked %d questions", len(advResults))
+			slog.Info("stage 3 complete", "component", "generation", "batch_id", batchID, "checked", len(advResults))
 		}
 	}
 
 	// ── Compute quality scores and build save options ─────────
 	isRC := req.Section == models.SectionRC
-	passedCount := 0
-	flaggedCount := 0
-	rejectedCount := 0
-
-	opts := make([]QuestionSaveOptions, len(genBatch.Questions))
+	var lrSubtype models.LRSubtype
+	if req.LRSubtype != nil {
+		lrSubtype = *req.LRSubtype
+	}
+	result.opts = make([]QuestionSaveOptions, len(genBatch.Questions))
 
 	for i, q := range genBatch.Questions {
 		// Get validation result for this question
@@ -194,11 +581,11 @@ func (s *Service) GenerateBatch(ctx context.Context, req models.GenerateBatchReq
 		}
 
 		// Compute structural score
-		structural := generator.ComputeStructuralScore(q, isRC)
+		structural := generator.ComputeStructuralScore(q, isRC, lrSubtype)
 
 		// Compute composite quality score
 		qualityScore := generator.ComputeQualityScore(vr, ar, structural)
-		classification := generator.ClassifyQuality(qualityScore)
+		classification := s.quality.Classify(qualityScore)
 
 		// Determine validation status
 		valStatus := "unvalidated"
@@ -207,19 +594,7 @@ func (s *Service) GenerateBatch(ctx context.Context, req models.GenerateBatchReq
 		flagged := false
 
 		if vr != nil {
-			if !vr.Matches {
-				valStatus = string(models.ValidationRejected)
-				reasoning := fmt.Sprintf("Validator selected %s (expected %s): %s",
-					vr.SelectedAnswer, vr.GeneratedAnswer, vr.Reasoning)
-				valReasoning = &reasoning
-			} else if vr.Confidence == "high" {
-				valStatus = string(models.ValidationPassed)
-			} else {
-				valStatus = string(models.ValidationFlagged)
-				reasoning := fmt.Sprintf("Low confidence (%s): %s", vr.Confidence, vr.Reasoning)
-				valReasoning = &reasoning
-				flagged = true
-			}
+			valStatus, valReasoning, flagged = validationStatus(vr, s.passConfidence)
 		}
 
 		if ar != nil {
@@ -245,29 +620,48 @@ func (s *Service) GenerateBatch(ctx context.Context, req models.GenerateBatchReq
 			flagged = true
 		}
 
-		opts[i] = QuestionSaveOptions{
-			ValidationStatus: valStatus,
-			QualityScore:     &qualityScore,
-			ValidationReason: valReasoning,
-			AdversarialScore: advScore,
-			Flagged:          flagged,
+		// Structural problems reject or flag for review even when the score is high
+		if structural.ShouldReject() && valStatus != string(models.ValidationRejected) {
+			valStatus = string(models.ValidationRejected)
+			reasoning := "Structural check: answer choice references other choices"
+			valReasoning = &reasoning
+		} else if structural.ShouldFlag() && valStatus != string(models.ValidationRejected) {
+			flagged = true
+			if valStatus == string(models.ValidationPassed) {
+				valStatus = string(models.ValidationFlagged)
+			}
+			if valReasoning == nil {
+				reasoning := "Structural check: missing required wrong-answer archetype"
+				valReasoning = &reasoning
+			}
+		}
+
+		lengthBalance := structural.LengthBalance
+		result.opts[i] = QuestionSaveOptions{
+			ValidationStatus:     valStatus,
+			QualityScore:         &qualityScore,
+			ValidationReason:     valReasoning,
+			AdversarialScore:     advScore,
+			Flagged:              flagged,
+			LengthBalance:        &lengthBalance,
+			CorrectLengthOutlier: structural.CorrectLengthOutlier,
 		}
 
 		// Count for batch summary (only passed + flagged get saved for serving)
 		switch valStatus {
 		case string(models.ValidationRejected):
-			rejectedCount++
+			result.rejected++
 		case string(models.ValidationFlagged):
-			flaggedCount++
+			result.flagged++
 		default:
-			passedCount++
+			result.passed++
 		}
 
-		// Log validation results
+		// Collect validation logs
 		if vr != nil {
-			s.store.LogValidation(models.ValidationLog{
+			result.logs = append(result.logs, models.ValidationLog{
 				QuestionID:      nil,
-				BatchID:         &batch.ID,
+				BatchID:         &batchID,
 				Stage:           "verification",
 				ModelUsed:       s.validator.ModelName(),
 				GeneratedAnswer: vr.GeneratedAnswer,
@@ -278,65 +672,116 @@ func (s *Service) GenerateBatch(ctx context.Context, req models.GenerateBatchReq
 				PromptTokens:    vr.PromptTokens,
 				OutputTokens:    vr.OutputTokens,
 			})
+			result.logQuestion = append(result.logQuestion, i)
 		}
 
 		if ar != nil {
-			s.store.LogValidation(models.ValidationLog{
-				BatchID:      &batch.ID,
+			result.logs = append(result.logs, models.ValidationLog{
+				BatchID:      &batchID,
 				Stage:        "adversarial",
 				ModelUsed:    s.validator.ModelName(),
 				Reasoning:    ar.OverallRecommendation,
 				PromptTokens: ar.PromptTokens,
 				OutputTokens: ar.OutputTokens,
 			})
+			result.logQuestion = append(result.logQuestion, i)
 		}
 	}
 
-	// ── Filter out rejected questions before saving ──────────
-	filteredBatch, filteredOpts := filterRejected(genBatch, opts)
+	return result
+}
 
-	// Save surviving questions (use background context so saves aren't lost if HTTP client disconnects)
-	if err := s.store.SaveGeneratedBatch(context.Background(), batch.ID, filteredBatch, req, filteredOpts); err != nil {
-		errMsg := err.Error()
-		s.store.FailBatch(batch.ID, errMsg)
-		return nil, fmt.Errorf("save batch: %w", err)
+// PreviewBatch runs generation (and optionally validation) without creating a
+// batch or saving anything, so prompt changes can be inspected safely.
+func (s *Service) PreviewBatch(ctx context.Context, req models.GeneratePreviewRequest) (*models.GeneratePreviewResponse, error) {
+	if req.Count <= 0 {
+		req.Count = s.defaultCount(req.Section)
 	}
 
-	// Mark completed
-	elapsed := time.Since(startTime).Milliseconds()
-	if err := s.store.CompleteBatch(batch.ID, passedCount, flaggedCount, rejectedCount,
-		elapsed, promptTokens, outputTokens, validationTokens, s.generator.ModelName()); err != nil {
-		return nil, fmt.Errorf("complete batch: %w", err)
+	genReq := req.GenerateBatchRequest
+	fillDifficultyBand(&genReq)
+	genBatch, llmResp, err := s.generateQuestions(ctx, genReq)
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
 	}
 
-	return &models.GenerateBatchResponse{
-		BatchID:           batch.ID,
-		Status:            models.BatchCompleted,
-		QuestionsPassed:   passedCount,
-		QuestionsFlagged:  flaggedCount,
-		QuestionsRejected: rejectedCount,
-		Message:           fmt.Sprintf("Generated %d questions (%d passed, %d flagged, %d rejected)", len(genBatch.Questions), passedCount, flaggedCount, rejectedCount),
-	}, nil
+	scored := s.scoreBatch(ctx, genReq, 0, genBatch, req.Validate)
+
+	resp := &models.GeneratePreviewResponse{
+		ModelUsed:        s.generator.ModelName(),
+		PromptVersion:    genBatch.PromptVersion,
+		ValidationTokens: scored.validationTokens(),
+		Questions:        make([]models.PreviewQuestion, 0, len(genBatch.Questions)),
+	}
+	if llmResp != nil {
+		resp.PromptTokens = llmResp.PromptTokens
+		resp.OutputTokens = llmResp.OutputTokens
+	}
+	if genBatch.Passage != nil {
+		resp.Passage = &models.ExportPassage{
+			Title:         genBatch.Passage.Title,
+			SubjectArea:   genBatch.Passage.SubjectArea,
+			Content:       genBatch.Passage.Content,
+			IsComparative: genBatch.Passage.IsComparative,
+			PassageB:      genBatch.Passage.PassageB,
+		}
+	}
+
+	for i, gq := range genBatch.Questions {
+		pq := models.PreviewQuestion{
+			Stimulus:        gq.Stimulus,
+			QuestionStem:    gq.QuestionStem,
+			CorrectAnswerID: gq.CorrectAnswerID,
+			Explanation:     gq.Explanation,
+			Choices:         make([]models.ExportChoice, 0, len(gq.Choices)),
+		}
+		for _, gc := range gq.Choices {
+			ec := models.ExportChoice{
+				ChoiceID:    gc.ID,
+				ChoiceText:  gc.Text,
+				Explanation: gc.Explanation,
+				IsCorrect:   gc.ID == gq.CorrectAnswerID,
+			}
+			if gc.WrongAnswerType != nil {
+				ec.WrongAnswerType = *gc.WrongAnswerType
+			}
+			pq.Choices = append(pq.Choices, ec)
+		}
+		if i < len(scored.opts) {
+			opt := scored.opts[i]
+			pq.ValidationStatus = opt.ValidationStatus
+			pq.QualityScore = opt.QualityScore
+			pq.ValidationReasoning = opt.ValidationReason
+			pq.AdversarialScore = opt.AdversarialScore
+		}
+		resp.Questions = append(resp.Questions, pq)
+	}
+
+	return resp, nil
 }
 
-// filterRejected removes rejected questions from the batch and options slices.
-func filterRejected(batch *generator.GeneratedBatch, opts []QuestionSaveOptions) (*generator.GeneratedBatch, []QuestionSaveOptions) {
+// filterRejected removes rejected questions from the batch and options slices,
+// returning the batch index of each question kept.
+func filterRejected(batch *generator.GeneratedBatch, opts []QuestionSaveOptions) (*generator.GeneratedBatch, []QuestionSaveOptions, []int) {
 	filtered := &generator.GeneratedBatch{
-		Passage: batch.Passage,
+		Passage:       batch.Passage,
+		PromptVersion: batch.PromptVersion,
 	}
 	var filteredOpts []QuestionSaveOptions
+	var kept []int
 
 	for i, q := range batch.Questions {
 		if i < len(opts) && opts[i].ValidationStatus == string(models.ValidationRejected) {
 			continue
 		}
 		filtered.Questions = append(filtered.Questions, q)
+		kept = append(kept, i)
 		if i < len(opts) {
 			filteredOpts = append(filteredOpts, opts[i])
 		}
 	}
 
-	return filtered, filteredOpts
+	return filtered, filteredOpts, kept
 }
 
 // ── Batch/Question Access ────────────────────────────────
@@ -345,127 +790,860 @@ func (s *Service) GetBatch(batchID int64) (*models.QuestionBatch, error) {
 	return s.store.GetBatch(batchID)
 }
 
-func (s *Service) ListBatches(status *models.BatchStatus, limit, offset int) ([]models.QuestionBatch, error) {
-	return s.store.ListBatches(status, limit, offset)
+// GetBatchQuestions returns a batch's questions without answers, for
+// previewing a freshly generated batch.
+func (s *Service) GetBatchQuestions(batchID int64) ([]models.DrillQuestion, error) {
+	if _, err := s.store.GetBatch(batchID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("batch not found")
+		}
+		return nil, err
+	}
+	return s.store.GetDrillQuestionsByBatch(batchID)
 }
 
-func (s *Service) GetQuestion(questionID int64) (*models.Question, error) {
-	return s.store.GetQuestionWithChoices(questionID)
+func (s *Service) ListBatches(filters models.BatchListFilters, limit, offset int) ([]models.QuestionBatch, int, error) {
+	return s.store.ListBatches(filters, limit, offset)
 }
 
-func (s *Service) GetDrillQuestions(section models.Section, subtype *models.LRSubtype, difficulty models.Difficulty, count int) ([]models.Question, error) {
-	return s.store.GetDrillQuestions(section, subtype, difficulty, count)
+func (s *Service) UpdateBatchAnnotation(batchID int64, req models.UpdateBatchAnnotationRequest) (*models.QuestionBatch, error) {
+	if err := s.store.UpdateBatchAnnotation(batchID, req.Notes, req.ExperimentTag); err != nil {
+		return nil, err
+	}
+	batch, err := s.store.GetBatch(batchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("batch not found")
+	}
+	return batch, err
 }
 
-func (s *Service) GetPassage(passageID int64) (*models.RCPassage, error) {
-	return s.store.GetPassage(passageID)
+// answerPositionStore is the subset of Store used to audit a batch's answer
+// positions.
+type answerPositionStore interface {
+	GetBatch(batchID int64) (*models.QuestionBatch, error)
+	GetBatchAnswerPositions(batchID int64) (map[string]int, error)
 }
 
-// ── Answer Submission + Ability Updates ──────────────────
+// GetBatchAnswerPositions returns how the batch's correct answers fall
+// across positions A-E.
+func (s *Service) GetBatchAnswerPositions(batchID int64) (*models.BatchAnswerPositions, error) {
+	return batchAnswerPositions(s.store, batchID)
+}
 
-func (s *Service) SubmitAnswer(userID int64, questionID int64, selectedChoiceID string, timeSpentSeconds *float64) (*models.SubmitAnswerResponse, error) {
-	question, err := s.store.GetQuestionWithChoices(questionID)
+func batchAnswerPositions(st answerPositionStore, batchID int64) (*models.BatchAnswerPositions, error) {
+	if _, err := st.GetBatch(batchID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("batch not found")
+		}
+		return nil, err
+	}
+	counts, err := st.GetBatchAnswerPositions(batchID)
 	if err != nil {
 		return nil, err
 	}
 
-	isCorrect := question.CorrectAnswerID == selectedChoiceID
-
-	// Increment counters
-	s.store.IncrementServed(questionID)
-	if isCorrect {
-		s.store.IncrementCorrect(questionID)
+	resp := &models.BatchAnswerPositions{BatchID: batchID, Positions: make(map[string]int)}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		resp.Positions[id] = 0
 	}
-
-	// Record user history (with selected answer and time)
-	if err := s.store.RecordAnswer(userID, questionID, isCorrect, &selectedChoiceID, timeSpentSeconds); err != nil {
-		log.Printf("WARN: failed to record answer history: %v", err)
+	for id, n := range counts {
+		resp.Positions[id] += n
+		resp.Total += n
 	}
+	return resp, nil
+}
 
-	// Update ability scores
-	var abilitySnapshot *models.AbilitySnapshot
-	snapshot, err := s.UpdateAbilityScores(userID, question, isCorrect)
-	if err != nil {
-		log.Printf("WARN: failed to update ability scores: %v", err)
-	} else {
-		abilitySnapshot = snapshot
-	}
+// PurgeFailedBatches removes failed and empty batches created more than
+// olderThanDays days ago.
+func (s *Service) PurgeFailedBatches(olderThanDays int) (*models.BatchPurgeResult, error) {
+	return s.store.PurgeFailedBatches(time.Now().AddDate(0, 0, -olderThanDays))
+}
 
-	// Gamification: award XP, update daily goal, streak, counters
-	var xpAwarded int
-	if s.gamService != nil {
-		if isCorrect && abilitySnapshot != nil {
-			xpAwarded = s.gamService.AwardQuestionXP(userID, question.DifficultyScore, abilitySnapshot.SubtypeAbility)
-		}
-		s.gamService.UpdateDailyGoal(userID, 1)
-		s.gamService.UpdateStreak(userID)
-		s.gamService.IncrementCounters(userID, isCorrect)
-	}
+func (s *Service) SetFeatured(questionID int64, featured bool) error {
+	return s.store.SetFeatured(questionID, featured)
+}
 
-	// Async check generation queue for this question's difficulty range
-	subtype := ""
-	if question.LRSubtype != nil {
-		subtype = string(*question.LRSubtype)
-	} else if question.RCSubtype != nil {
-		subtype = string(*question.RCSubtype)
-	}
-	if subtype != "" {
-		subtypePtr := subtype
-		go s.CheckAndQueueGeneration(string(question.Section), &subtypePtr,
-			max(0, question.DifficultyScore-15), min(100, question.DifficultyScore+15))
-		go s.CheckUserInventoryAndQueue(userID, string(question.Section), subtype)
+// resolveDifficulty fills in whichever of the enum and score req leaves out
+// so the two agree. A lone enum keeps the current score when it already falls
+// in the enum's band and otherwise moves it to the nearest edge of the band.
+func resolveDifficulty(currentScore int, req models.SetDifficultyRequest) (models.Difficulty, int, error) {
+	switch {
+	case req.Difficulty != nil && req.DifficultyScore != nil:
+		if mapScoreToDifficulty(*req.DifficultyScore) != *req.Difficulty {
+			return "", 0, fmt.Errorf("difficulty and difficulty_score disagree")
+		}
+		return *req.Difficulty, *req.DifficultyScore, nil
+	case req.DifficultyScore != nil:
+		return mapScoreToDifficulty(*req.DifficultyScore), *req.DifficultyScore, nil
+	case req.Difficulty != nil:
+		band := generator.DifficultyBand(*req.Difficulty)
+		return *req.Difficulty, max(band.Min, min(band.Max, currentScore)), nil
+	default:
+		return "", 0, fmt.Errorf("difficulty or difficulty_score is required")
 	}
+}
 
-	return &models.SubmitAnswerResponse{
-		Correct:         isCorrect,
-		CorrectAnswerID: question.CorrectAnswerID,
-		Explanation:     question.Explanation,
-		Choices:         question.Choices,
-		AbilityUpdated:  abilitySnapshot,
-		XPAwarded:       xpAwarded,
-	}, nil
+// difficultyOverrideStore is the subset of Store used to override a
+// question's difficulty.
+type difficultyOverrideStore interface {
+	GetQuestionWithChoices(questionID int64) (*models.Question, error)
+	SetQuestionDifficulty(questionID int64, difficulty models.Difficulty, score int) error
 }
 
-func (s *Service) UpdateAbilityScores(userID int64, question *models.Question, correct bool) (*models.AbilitySnapshot, error) {
-	section := string(question.Section)
-	subtype := ""
-	if question.LRSubtype != nil {
-		subtype = string(*question.LRSubtype)
-	} else if question.RCSubtype != nil {
-		subtype = string(*question.RCSubtype)
+func setQuestionDifficulty(st difficultyOverrideStore, questionID int64, req models.SetDifficultyRequest) (*models.Question, error) {
+	q, err := st.GetQuestionWithChoices(questionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("question not found")
+		}
+		return nil, err
 	}
-
-	// 1. Update overall
-	overall, err := s.store.GetOrCreateAbility(userID, models.ScopeOverall, nil)
+	difficulty, score, err := resolveDifficulty(q.DifficultyScore, req)
 	if err != nil {
-		return nil, fmt.Errorf("get overall ability: %w", err)
+		return nil, err
 	}
-	newOverall := ComputeNewAbility(overall.AbilityScore, question.DifficultyScore, correct, overall.QuestionsAnswered)
-	if err := s.store.UpdateAbility(userID, models.ScopeOverall, nil, newOverall, correct); err != nil {
+	if err := st.SetQuestionDifficulty(questionID, difficulty, score); err != nil {
+		return nil, err
+	}
+	q.Difficulty = difficulty
+	q.DifficultyScore = score
+	return q, nil
+}
+
+// SetQuestionDifficulty overrides a question's difficulty by hand. The
+// override is kept through later recalibration.
+func (s *Service) SetQuestionDifficulty(questionID int64, req models.SetDifficultyRequest) (*models.Question, error) {
+	return setQuestionDifficulty(s.store, questionID, req)
+}
+
+func (s *Service) GetQuestion(questionID int64) (*models.Question, error) {
+	return s.store.GetQuestionWithChoices(questionID)
+}
+
+// questionTraps lists q's wrong choices with a description of the trap
+// archetype each one is labelled with.
+func questionTraps(q *models.Question) *models.QuestionTrapsResponse {
+	resp := &models.QuestionTrapsResponse{
+		QuestionID:      q.ID,
+		CorrectAnswerID: q.CorrectAnswerID,
+		Traps:           []models.QuestionTrap{},
+	}
+	for _, c := range q.Choices {
+		if c.ChoiceID == q.CorrectAnswerID {
+			continue
+		}
+		resp.Traps = append(resp.Traps, models.QuestionTrap{
+			ChoiceID:             c.ChoiceID,
+			ChoiceText:           c.ChoiceText,
+			WrongAnswerType:      c.WrongAnswerType,
+			Explanation:          c.Explanation,
+			ArchetypeDescription: generator.TrapDescription(c.WrongAnswerType),
+		})
+	}
+	return resp
+}
+
+// GetQuestionTraps returns the trap breakdown of a question the user has
+// already answered; before that it would give the answer away.
+func (s *Service) GetQuestionTraps(userID, questionID int64) (*models.QuestionTrapsResponse, error) {
+	answered, err := s.store.HasAnswered(userID, questionID)
+	if err != nil {
+		return nil, fmt.Errorf("check answered: %w", err)
+	}
+	if !answered {
+		return nil, fmt.Errorf("question not answered")
+	}
+	question, err := s.store.GetQuestionWithChoices(questionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("question not found")
+		}
+		return nil, err
+	}
+	return questionTraps(question), nil
+}
+
+// maxHintLength caps a hint, in bytes, so it stays a nudge.
+const maxHintLength = 200
+
+var (
+	// sentenceEnd splits an explanation into sentences
+	sentenceEnd = regexp.MustCompile(`[.!?]+(\s+|$)`)
+	// choiceReference matches a sentence that names an answer choice, such
+	// as "(B)", "choice B", "answer B is" or "B is correct"
+	choiceReference = regexp.MustCompile(`\([A-E]\)|\b(?i:answer|choice|option)s?\s+\(?[A-E]\b|\b[A-E]\s+(?:is|was)\b|(?i:correct answer|right answer|best answer)`)
+	// choiceLetter matches a bare choice letter, as in "Only D addresses the gap"
+	choiceLetter = regexp.MustCompile(`\b[A-E]\b`)
+	// leadingArticle matches a sentence that opens with the article "A"
+	leadingArticle = regexp.MustCompile(`^A\s+[a-z]`)
+)
+
+// namesChoice reports whether sentence names an answer choice, either
+// explicitly or as a bare letter. A sentence-initial "A" followed by a
+// lowercase word is read as the article.
+func namesChoice(sentence string) bool {
+	if choiceReference.MatchString(sentence) {
+		return true
+	}
+	if leadingArticle.MatchString(sentence) {
+		sentence = sentence[1:]
+	}
+	return choiceLetter.MatchString(sentence)
+}
+
+// questionHint returns the first sentence of q's explanation, or of its
+// correct choice's explanation, that doesn't name a choice, so it can be
+// shown before the user answers. It returns "" if every sentence would give
+// the answer away.
+func questionHint(q *models.Question) string {
+	sources := []string{q.Explanation}
+	for _, c := range q.Choices {
+		if c.ChoiceID == q.CorrectAnswerID {
+			sources = append(sources, c.Explanation)
+		}
+	}
+	for _, text := range sources {
+		for _, sentence := range splitSentences(text) {
+			if namesChoice(sentence) {
+				continue
+			}
+			if len(sentence) > maxHintLength {
+				cut := strings.LastIndex(sentence[:maxHintLength], " ")
+				if cut <= 0 {
+					cut = maxHintLength
+				}
+				return sentence[:cut] + "…"
+			}
+			if !sentenceEnd.MatchString(sentence) {
+				sentence += "."
+			}
+			return sentence
+		}
+	}
+	return ""
+}
+
+// splitSentences splits text into its non-empty sentences, each keeping its
+// own terminator ("?" stays a question).
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, end := range sentenceEnd.FindAllStringIndex(text, -1) {
+		if sentence := strings.TrimSpace(text[start:end[1]]); sentenceEnd.ReplaceAllString(sentence, "") != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end[1]
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// hintStore is the subset of Store used to give a hint.
+type hintStore interface {
+	dailyChallengeStore
+	GetQuestionWithChoices(questionID int64) (*models.Question, error)
+}
+
+// GetQuestionHint returns a hint for a question the user may not have
+// answered yet.
+func (s *Service) GetQuestionHint(questionID int64) (*models.QuestionHintResponse, error) {
+	return getQuestionHint(s.store, questionID, time.Now())
+}
+
+// getQuestionHint returns questionHint for the question, refusing today's
+// daily challenge, which is ranked on answering it unaided.
+func getQuestionHint(st hintStore, questionID int64, now time.Time) (*models.QuestionHintResponse, error) {
+	daily, err := dailyChallengeQuestionID(st, challengeDay(now))
+	if err != nil && err.Error() != "no daily challenge available" {
+		return nil, err
+	}
+	if err == nil && daily == questionID {
+		return nil, fmt.Errorf("daily challenge hint")
+	}
+
+	question, err := st.GetQuestionWithChoices(questionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("question not found")
+		}
+		return nil, err
+	}
+	hint := questionHint(question)
+	if hint == "" {
+		return nil, fmt.Errorf("hint not available")
+	}
+	return &models.QuestionHintResponse{QuestionID: questionID, Hint: hint}, nil
+}
+
+// maxBatchGetIDs caps how many questions one batch-get request can load.
+const maxBatchGetIDs = 50
+
+// batchGetStore is the subset of Store used to load questions by ID.
+type batchGetStore interface {
+	GetQuestionsByIDs(questionIDs []int64) ([]models.Question, error)
+	GetPassagesByIDs(passageIDs []int64) (map[int64]models.DrillPassage, error)
+}
+
+// GetQuestionsByIDs returns the requested questions in request order, with
+// the passages they reference, and lists IDs that don't exist.
+func (s *Service) GetQuestionsByIDs(questionIDs []int64) (*models.BatchGetQuestionsResponse, error) {
+	return batchGetQuestions(s.store, questionIDs)
+}
+
+func batchGetQuestions(st batchGetStore, questionIDs []int64) (*models.BatchGetQuestionsResponse, error) {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, id := range questionIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("question_ids is required")
+	}
+	if len(ids) > maxBatchGetIDs {
+		return nil, fmt.Errorf("at most %d question_ids allowed", maxBatchGetIDs)
+	}
+
+	found, err := st.GetQuestionsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.Question, len(found))
+	for _, q := range found {
+		byID[q.ID] = q
+	}
+
+	resp := &models.BatchGetQuestionsResponse{
+		Questions: []models.Question{},
+		Passages:  []models.DrillPassage{},
+		NotFound:  []int64{},
+	}
+	var passageIDs []int64
+	passageSeen := make(map[int64]bool)
+	for _, id := range ids {
+		q, ok := byID[id]
+		if !ok {
+			resp.NotFound = append(resp.NotFound, id)
+			continue
+		}
+		resp.Questions = append(resp.Questions, q)
+		if q.PassageID != nil && !passageSeen[*q.PassageID] {
+			passageSeen[*q.PassageID] = true
+			passageIDs = append(passageIDs, *q.PassageID)
+		}
+	}
+
+	if len(passageIDs) > 0 {
+		passages, err := st.GetPassagesByIDs(passageIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, pid := range passageIDs {
+			if p, ok := passages[pid]; ok {
+				resp.Passages = append(resp.Passages, p)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// GetQuestionProvenance returns a question with its passage, quality
+// sub-scores, and validation logs for content review.
+func (s *Service) GetQuestionProvenance(questionID int64) (*models.QuestionProvenance, error) {
+	q, err := s.store.GetQuestionWithChoices(questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var passage *models.RCPassage
+	if q.PassageID != nil {
+		passage, err = s.store.GetPassage(*q.PassageID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logs, err := s.store.GetValidationLogs(q.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	responders, err := s.store.GetDistinctResponders(q.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	prov := buildQuestionProvenance(q, passage, logs)
+	prov.DistinctResponders = responders
+	return prov, nil
+}
+
+// toGeneratedQuestion converts a stored question back to generator form.
+func toGeneratedQuestion(q *models.Question) generator.GeneratedQuestion {
+	gq := generator.GeneratedQuestion{
+		Stimulus:        q.Stimulus,
+		QuestionStem:    q.QuestionStem,
+		CorrectAnswerID: q.CorrectAnswerID,
+		Explanation:     q.Explanation,
+	}
+	for _, c := range q.Choices {
+		gc := generator.GeneratedChoice{ID: c.ChoiceID, Text: c.ChoiceText, Explanation: c.Explanation}
+		if c.WrongAnswerType != "" {
+			wat := c.WrongAnswerType
+			gc.WrongAnswerType = &wat
+		}
+		gq.Choices = append(gq.Choices, gc)
+	}
+	return gq
+}
+
+// toGeneratedPassage converts a stored RC passage back to generator form.
+func toGeneratedPassage(p *models.RCPassage) *generator.GeneratedPassage {
+	return &generator.GeneratedPassage{
+		Title:         p.Title,
+		SubjectArea:   p.SubjectArea,
+		Content:       p.Content,
+		IsComparative: p.IsComparative,
+		PassageB:      p.PassageB,
+	}
+}
+
+// RegenerateExplanations has the generator rewrite a question's explanations
+// and saves them, leaving the question text, choices and answer untouched.
+func (s *Service) RegenerateExplanations(ctx context.Context, questionID int64) (*models.Question, error) {
+	return regenerateExplanations(ctx, s.generator, s.store, questionID)
+}
+
+// explanationStore is the subset of Store used to rewrite explanations.
+type explanationStore interface {
+	GetQuestionWithChoices(questionID int64) (*models.Question, error)
+	GetPassage(passageID int64) (*models.RCPassage, error)
+	UpdateQuestionExplanations(questionID int64, explanation string, choiceExplanations map[string]string) error
+}
+
+func regenerateExplanations(ctx context.Context, gen Generator, st explanationStore, questionID int64) (*models.Question, error) {
+	q, err := st.GetQuestionWithChoices(questionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("question not found")
+		}
+		return nil, err
+	}
+
+	var passage *generator.GeneratedPassage
+	if q.PassageID != nil {
+		p, err := st.GetPassage(*q.PassageID)
+		if err != nil {
+			return nil, err
+		}
+		passage = toGeneratedPassage(p)
+	}
+
+	// Rewrite in the language the question was generated in
+	rw, llmResp, err := gen.RegenerateExplanations(ctx, toGeneratedQuestion(q), passage, q.Language)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("rewrote explanations", "component", "explanations", "question_id", questionID,
+		"prompt_tokens", llmResp.PromptTokens, "output_tokens", llmResp.OutputTokens)
+
+	choiceExplanations := make(map[string]string, len(rw.Choices))
+	for _, c := range rw.Choices {
+		choiceExplanations[c.ID] = c.Explanation
+	}
+	if err := st.UpdateQuestionExplanations(questionID, rw.Explanation, choiceExplanations); err != nil {
+		return nil, err
+	}
+
+	q.Explanation = rw.Explanation
+	for i := range q.Choices {
+		q.Choices[i].Explanation = choiceExplanations[q.Choices[i].ChoiceID]
+	}
+	return q, nil
+}
+
+func buildQuestionProvenance(q *models.Question, passage *models.RCPassage, logs []models.ValidationLog) *models.QuestionProvenance {
+	gq := toGeneratedQuestion(q)
+
+	var lrSubtype models.LRSubtype
+	if q.LRSubtype != nil {
+		lrSubtype = *q.LRSubtype
+	}
+	structural := generator.ComputeStructuralScore(gq, q.Section == models.SectionRC, lrSubtype)
+
+	if logs == nil {
+		logs = []models.ValidationLog{}
+	}
+
+	// Prefer the length checks stored at scoring time, so provenance shows
+	// what the question was judged on rather than a recomputation
+	lengthBalance, lengthOutlier := structural.LengthBalance, structural.CorrectLengthOutlier
+	if q.LengthBalance != nil {
+		lengthBalance, lengthOutlier = *q.LengthBalance, q.LengthOutlier
+	}
+
+	return &models.QuestionProvenance{
+		Question: q,
+		Passage:  passage,
+		QualityScores: models.QualitySubScores{
+			QualityScore:           q.QualityScore,
+			AdversarialScore:       q.AdversarialScore,
+			StimulusLengthOK:       structural.StimulusLengthOK,
+			AllChoicesInRange:      structural.AllChoicesInRange,
+			AllExplanationsPresent: structural.AllExplanationsPresent,
+			MissingRequiredTrap:    structural.MissingRequiredTrap,
+			CorrectLengthOutlier:   lengthOutlier,
+			HasMetaChoice:          structural.HasMetaChoice,
+			LengthBalance:          lengthBalance,
+		},
+		ValidationLogs: logs,
+	}
+}
+
+func (s *Service) GetDrillQuestions(section models.Section, subtype *models.LRSubtype, difficulty models.Difficulty, count int, includeFlagged bool) ([]models.Question, error) {
+	return s.store.GetDrillQuestions(section, subtype, difficulty, count, includeFlagged)
+}
+
+func (s *Service) GetPassage(passageID int64) (*models.RCPassage, error) {
+	return s.store.GetPassage(passageID)
+}
+
+// passageDocument renders a passage as a plain-text document: the title, then
+// the passage body, with both halves labelled for comparative passages.
+func passageDocument(p *models.RCPassage) string {
+	var b strings.Builder
+	b.WriteString(p.Title)
+	b.WriteString("\n\n")
+	if p.IsComparative {
+		b.WriteString("Passage A\n\n")
+		b.WriteString(strings.TrimSpace(p.Content))
+		b.WriteString("\n\nPassage B\n\n")
+		b.WriteString(strings.TrimSpace(p.PassageB))
+	} else {
+		b.WriteString(strings.TrimSpace(p.Content))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// GetPassageText returns a passage formatted as plain text for download.
+func (s *Service) GetPassageText(passageID int64) (string, error) {
+	passage, err := s.store.GetPassage(passageID)
+	if err != nil {
+		return "", err
+	}
+	return passageDocument(passage), nil
+}
+
+func (s *Service) ListPassages(filters models.PassageListFilters, page, pageSize int) (*models.PassageListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 50 {
+		pageSize = 50
+	}
+
+	passages, total, err := s.store.ListPassages(filters, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if passages == nil {
+		passages = []models.PassageSummary{}
+	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
+	return &models.PassageListResponse{
+		Passages:   passages,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// ── Answer Submission + Ability Updates ──────────────────
+
+// Grading policies for multi-correct questions, chosen by
+// MULTI_CORRECT_GRADING. Single-answer questions grade the same under both.
+const (
+	// gradingAllOrNothing credits only the exact set of correct choices
+	gradingAllOrNothing = "all_or_nothing"
+	// gradingPartial credits each correct choice picked, less one for each
+	// wrong choice picked, as a share of the correct set
+	gradingPartial = "partial"
+)
+
+// multiCorrectGrading returns the configured grading policy.
+func multiCorrectGrading() string {
+	switch v := os.Getenv("MULTI_CORRECT_GRADING"); v {
+	case "", gradingAllOrNothing:
+		return gradingAllOrNothing
+	case gradingPartial:
+		return gradingPartial
+	default:
+		slog.Warn("ignoring invalid config", "component", "service", "key", "MULTI_CORRECT_GRADING", "value", v, "want", gradingAllOrNothing+" or "+gradingPartial)
+		return gradingAllOrNothing
+	}
+}
+
+// gradeSelection returns the credit, from 0 to 1, that selected earns on q
+// under policy. Full credit is a correct answer. A choice the question
+// doesn't have is an error rather than a wrong answer, so a malformed
+// question can't silently cost the user.
+func gradeSelection(q *models.Question, selected []string, policy string) (float64, error) {
+	if len(selected) == 0 {
+		return 0, fmt.Errorf("selected choice not found")
+	}
+	picked := make(map[string]bool, len(selected))
+	for _, id := range selected {
+		found := false
+		for _, c := range q.Choices {
+			if c.ChoiceID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("selected choice not found")
+		}
+		picked[id] = true
+	}
+
+	correct := q.CorrectChoiceIDs()
+	hits := 0
+	for _, id := range correct {
+		if picked[id] {
+			hits++
+		}
+	}
+	misses := len(picked) - hits
+	if hits == len(correct) && misses == 0 {
+		return 1, nil
+	}
+	if policy != gradingPartial || len(correct) == 1 {
+		return 0, nil
+	}
+	return max(0, float64(hits-misses)/float64(len(correct))), nil
+}
+
+// selectionKey is how history records a selection: its distinct choice
+// letters in order, e.g. "AC", which is just the letter for one choice.
+func selectionKey(selected []string) string {
+	seen := make(map[string]bool, len(selected))
+	var ids []string
+	for _, id := range selected {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, "")
+}
+
+// SubmitAnswerSelection grades and records an answer of one or more choices.
+// Only full credit counts as correct for history, ability and XP. source is
+// recorded with the answer so stats can be broken down by practice mode.
+func (s *Service) SubmitAnswerSelection(userID int64, questionID int64, selected []string, timeSpentSeconds *float64, source models.AnswerSource) (*models.SubmitAnswerResponse, error) {
+	question, err := s.store.GetQuestionWithChoices(questionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("question not found")
+		}
+		return nil, err
+	}
+	return s.submitSelection(userID, question, selected, timeSpentSeconds, source, nil)
+}
+
+// submitSelection grades and records an answer to question. If dailyDay is
+// set, the answer is also the user's attempt at that day's challenge, and
+// fails with "already attempted" if they've made one.
+func (s *Service) submitSelection(userID int64, question *models.Question, selected []string, timeSpentSeconds *float64, source models.AnswerSource, dailyDay *time.Time) (*models.SubmitAnswerResponse, error) {
+	questionID := question.ID
+	credit, err := gradeSelection(question, selected, s.grading)
+	if err != nil {
+		return nil, err
+	}
+	isCorrect := credit == 1
+
+	selectedChoiceID := selectionKey(selected)
+
+	// Counters, history and ability scores commit together
+	tx, err := s.store.BeginAnswer()
+	if err != nil {
+		return nil, err
+	}
+	var abilitySnapshot *models.AbilitySnapshot
+	if dailyDay != nil {
+		abilitySnapshot, err = recordDailyAnswerCore(tx, *dailyDay, userID, question, isCorrect, selectedChoiceID, timeSpentSeconds)
+	} else {
+		abilitySnapshot, err = recordAnswerCore(tx, userID, question, isCorrect, &selectedChoiceID, timeSpentSeconds, source)
+	}
+	if err != nil {
+		if err.Error() == "already attempted" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("record answer: %w", err)
+	}
+
+	// Gamification is best-effort: update streak first so the XP multiplier reflects today,
+	// then award XP, daily goal, counters
+	var xpAwarded int
+	if s.gamService != nil {
+		s.gamService.UpdateStreak(userID)
+		if isCorrect && abilitySnapshot != nil {
+			xpAwarded = s.gamService.AwardQuestionXP(userID, questionID, question.DifficultyScore, abilitySnapshot.XPAbility())
+		}
+		s.gamService.UpdateDailyGoal(userID, 1)
+		s.gamService.IncrementCounters(userID, isCorrect)
+		s.gamService.RecordQuestEvent(userID, gamification.QuestEvent{
+			Kind:       gamification.QuestAnswer,
+			Section:    question.Section,
+			Difficulty: question.Difficulty,
+			Correct:    isCorrect,
+		})
+	}
+
+	// Async check generation queue for this question's difficulty range
+	subtype := ""
+	if question.LRSubtype != nil {
+		subtype = string(*question.LRSubtype)
+	} else if question.RCSubtype != nil {
+		subtype = string(*question.RCSubtype)
+	}
+	if subtype != "" {
+		subtypePtr := subtype
+		go s.CheckAndQueueGeneration(string(question.Section), &subtypePtr,
+			max(0, question.DifficultyScore-15), min(100, question.DifficultyScore+15))
+		go s.CheckUserInventoryAndQueue(userID, string(question.Section), subtype)
+		go s.CheckWeakAreaAndQueue(userID, string(question.Section), subtype)
+	}
+
+	resp := &models.SubmitAnswerResponse{
+		Correct:         isCorrect,
+		Credit:          credit,
+		CorrectAnswerID: question.CorrectAnswerID,
+		Explanation:     question.Explanation,
+		Choices:         question.Choices,
+		AbilityUpdated:  abilitySnapshot,
+		XPAwarded:       xpAwarded,
+	}
+	if correct := question.CorrectChoiceIDs(); len(correct) > 1 {
+		resp.CorrectAnswerIDs = correct
+	}
+	return resp, nil
+}
+
+// answerRecorder is the transaction recordAnswerCore runs in; *AnswerTx is
+// the implementation.
+type answerRecorder interface {
+	abilityStore
+	IncrementServed(questionID int64) error
+	IncrementCorrect(questionID int64) error
+	RecordAnswer(userID, questionID int64, correct bool, selectedChoiceID *string, timeSpentSeconds *float64, source models.AnswerSource) error
+	Commit() error
+	Rollback() error
+}
+
+// recordAnswerCore applies an answer's counters, history and ability updates
+// in tx and commits. On any error tx is rolled back and nothing is applied.
+func recordAnswerCore(tx answerRecorder, userID int64, question *models.Question, correct bool, selectedChoiceID *string, timeSpentSeconds *float64, source models.AnswerSource) (*models.AbilitySnapshot, error) {
+	defer tx.Rollback()
+
+	if err := tx.IncrementServed(question.ID); err != nil {
+		return nil, fmt.Errorf("increment served: %w", err)
+	}
+	if correct {
+		if err := tx.IncrementCorrect(question.ID); err != nil {
+			return nil, fmt.Errorf("increment correct: %w", err)
+		}
+	}
+	if err := tx.RecordAnswer(userID, question.ID, correct, selectedChoiceID, timeSpentSeconds, source); err != nil {
+		return nil, fmt.Errorf("record history: %w", err)
+	}
+	snapshot, err := updateAbilityScores(tx, userID, question, correct)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit answer: %w", err)
+	}
+	return snapshot, nil
+}
+
+// abilityStore is the subset of Store used to read and update ability scores.
+type abilityStore interface {
+	GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error)
+	UpdateAbility(userID int64, scope models.AbilityScope, scopeValue *string, newScore int, correct bool) error
+	SnapshotAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int, day time.Time) error
+}
+
+func (s *Service) UpdateAbilityScores(userID int64, question *models.Question, correct bool) (*models.AbilitySnapshot, error) {
+	return updateAbilityScores(s.store, userID, question, correct)
+}
+
+func updateAbilityScores(st abilityStore, userID int64, question *models.Question, correct bool) (*models.AbilitySnapshot, error) {
+	section := string(question.Section)
+	subtype := ""
+	if question.LRSubtype != nil {
+		subtype = string(*question.LRSubtype)
+	} else if question.RCSubtype != nil {
+		subtype = string(*question.RCSubtype)
+	}
+
+	// 1. Update overall
+	overall, err := st.GetOrCreateAbility(userID, models.ScopeOverall, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get overall ability: %w", err)
+	}
+	newOverall := ComputeNewAbility(overall.AbilityScore, question.DifficultyScore, correct, overall.QuestionsAnswered)
+	if err := st.UpdateAbility(userID, models.ScopeOverall, nil, newOverall, correct); err != nil {
 		return nil, fmt.Errorf("update overall ability: %w", err)
 	}
 
 	// 2. Update section
-	sectionAbility, err := s.store.GetOrCreateAbility(userID, models.ScopeSection, &section)
+	sectionAbility, err := st.GetOrCreateAbility(userID, models.ScopeSection, &section)
 	if err != nil {
 		return nil, fmt.Errorf("get section ability: %w", err)
 	}
 	newSection := ComputeNewAbility(sectionAbility.AbilityScore, question.DifficultyScore, correct, sectionAbility.QuestionsAnswered)
-	if err := s.store.UpdateAbility(userID, models.ScopeSection, &section, newSection, correct); err != nil {
+	if err := st.UpdateAbility(userID, models.ScopeSection, &section, newSection, correct); err != nil {
 		return nil, fmt.Errorf("update section ability: %w", err)
 	}
 
 	// 3. Update subtype (only if we have one)
-	newSubtype := newSection // default fallback
+	var newSubtype *int
 	if subtype != "" {
-		subtypeAbility, err := s.store.GetOrCreateAbility(userID, models.ScopeSubtype, &subtype)
+		subtypeAbility, err := st.GetOrCreateAbility(userID, models.ScopeSubtype, &subtype)
 		if err != nil {
 			return nil, fmt.Errorf("get subtype ability: %w", err)
 		}
-		newSubtype = ComputeNewAbility(subtypeAbility.AbilityScore, question.DifficultyScore, correct, subtypeAbility.QuestionsAnswered)
-		if err := s.store.UpdateAbility(userID, models.ScopeSubtype, &subtype, newSubtype, correct); err != nil {
+		score := ComputeNewAbility(subtypeAbility.AbilityScore, question.DifficultyScore, correct, subtypeAbility.QuestionsAnswered)
+		if err := st.UpdateAbility(userID, models.ScopeSubtype, &subtype, score, correct); err != nil {
 			return nil, fmt.Errorf("update subtype ability: %w", err)
 		}
+		newSubtype = &score
+	}
+
+	// Keep one history point per scope per day, holding the latest score
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if err := st.SnapshotAbility(userID, models.ScopeOverall, nil, newOverall, day); err != nil {
+		return nil, fmt.Errorf("snapshot overall ability: %w", err)
+	}
+	if err := st.SnapshotAbility(userID, models.ScopeSection, &section, newSection, day); err != nil {
+		return nil, fmt.Errorf("snapshot section ability: %w", err)
+	}
+	if newSubtype != nil {
+		if err := st.SnapshotAbility(userID, models.ScopeSubtype, &subtype, *newSubtype, day); err != nil {
+			return nil, fmt.Errorf("snapshot subtype ability: %w", err)
+		}
 	}
 
 	return &models.AbilitySnapshot{
@@ -477,6 +1655,19 @@ func (s *Service) UpdateAbilityScores(userID int64, question *models.Question, c
 
 // ── Adaptive Drill Serving ──────────────────────────────
 
+// StartDrillSession records questions as a served drill and returns its ID.
+// An empty drill gets no session and ID 0.
+func (s *Service) StartDrillSession(userID int64, questions []models.DrillQuestion, source models.AnswerSource) (int64, error) {
+	if len(questions) == 0 {
+		return 0, nil
+	}
+	ids := make([]int64, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	return s.store.CreateDrillSession(userID, ids, source)
+}
+
 func (s *Service) GetQuickDrill(ctx context.Context, userID int64, req models.QuickDrillRequest) ([]models.DrillQuestion, error) {
 	if req.Count <= 0 {
 		req.Count = 6
@@ -492,15 +1683,7 @@ func (s *Service) GetQuickDrill(ctx context.Context, userID int64, req models.Qu
 		if err != nil {
 			return nil, err
 		}
-		if resp == nil {
-			return []models.DrillQuestion{}, nil
-		}
-		// Convert RCDrillResponse questions (which include passage) to DrillQuestion slice
-		var questions []models.DrillQuestion
-		for _, q := range resp.Questions {
-			questions = append(questions, q)
-		}
-		return questions, nil
+		return rcQuickDrillQuestions(resp), nil
 	}
 
 	// Get user's section ability
@@ -540,33 +1723,10 @@ func (s *Service) GetQuickDrill(ctx context.Context, userID int64, req models.Qu
 	// Shuffle subtypes for variety
 	rand.Shuffle(len(subtypes), func(i, j int) { subtypes[i], subtypes[j] = subtypes[j], subtypes[i] })
 
-	// For each subtype, try to fetch 1 unseen question in the difficulty window.
-	// Stop once we have enough questions.
-	var questions []models.DrillQuestion
+	questions := pickQuickDrillQuestions(s.store, userID, subtypes, req.Count, [2]int{minDiff, maxDiff}, [2]int{max(0, target-35), min(100, target+35)})
 	seenQuestionIDs := make(map[int64]bool)
-
-	for _, st := range subtypes {
-		if len(questions) >= req.Count {
-			break
-		}
-
-		querySection := "logical_reasoning"
-		if strings.HasPrefix(st, "rc_") {
-			querySection = "reading_comprehension"
-		}
-
-		q, err := s.store.GetOneAdaptiveQuestion(userID, querySection, st, minDiff, maxDiff)
-		if err != nil || q == nil {
-			q, err = s.store.GetOneAdaptiveQuestion(userID, querySection, st, max(0, target-35), min(100, target+35))
-			if err != nil || q == nil {
-				continue
-			}
-		}
-
-		if !seenQuestionIDs[q.ID] {
-			seenQuestionIDs[q.ID] = true
-			questions = append(questions, *q)
-		}
+	for _, q := range questions {
+		seenQuestionIDs[q.ID] = true
 	}
 
 	// If we still don't have enough, fetch any unseen questions from the section
@@ -577,7 +1737,7 @@ func (s *Service) GetQuickDrill(ctx context.Context, userID int64, req models.Qu
 		for id := range seenQuestionIDs {
 			excludeIDs = append(excludeIDs, id)
 		}
-		fallback, err := s.store.GetAdaptiveQuestions(userID, section, nil, minDiff, maxDiff, remaining, excludeIDs)
+		fallback, err := s.store.GetAdaptiveQuestions(userID, section, nil, minDiff, maxDiff, remaining, excludeIDs, false)
 		if err == nil {
 			for _, q := range fallback {
 				seenQuestionIDs[q.ID] = true
@@ -591,20 +1751,166 @@ func (s *Service) GetQuickDrill(ctx context.Context, userID int64, req models.Qu
 			for id := range seenQuestionIDs {
 				excludeIDs = append(excludeIDs, id)
 			}
-			fallback, err = s.store.GetAdaptiveQuestions(userID, section, nil, max(0, target-35), min(100, target+35), remaining, excludeIDs)
+			fallback, err = s.store.GetAdaptiveQuestions(userID, section, nil, max(0, target-35), min(100, target+35), remaining, excludeIDs, false)
 			if err == nil {
 				questions = append(questions, fallback...)
 			}
 		}
 	}
 
-	// Shuffle final order
-	rand.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	// Shuffle final order
+	rand.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+
+	// Async: check generation queue
+	go s.CheckAndQueueGeneration(req.Section, nil, minDiff, maxDiff)
+
+	return questions, nil
+}
+
+// rcQuickDrillQuestions flattens an RC drill into quick drill questions. The
+// flat list has nowhere else to carry the passage, so each question gets the
+// drill's passage if it doesn't already have one.
+func rcQuickDrillQuestions(resp *models.RCDrillResponse) []models.DrillQuestion {
+	questions := []models.DrillQuestion{}
+	if resp == nil {
+		return questions
+	}
+	for _, q := range resp.Questions {
+		if q.Passage == nil {
+			passage := resp.Passage
+			q.Passage = &passage
+		}
+		questions = append(questions, q)
+	}
+	return questions
+}
+
+// quickDrillStore is the subset of Store used to pick quick drill questions.
+type quickDrillStore interface {
+	GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int, excludeIDs []int64) (*models.DrillQuestion, error)
+	GetOneAdaptiveQuestionFromPassage(userID int64, subtype string, passageID int64, minDiff, maxDiff int) (*models.DrillQuestion, error)
+}
+
+// pickQuickDrillQuestions tries to fetch one question per subtype, in the
+// window and then the wider one, until it has count. The first RC question
+// fixes the drill's passage and later RC subtypes only draw from it, so the
+// user reads one passage per drill.
+func pickQuickDrillQuestions(st quickDrillStore, userID int64, subtypes []string, count int, window, wide [2]int) []models.DrillQuestion {
+	var questions []models.DrillQuestion
+	seen := make(map[int64]bool)
+	var passageID int64
+
+	for _, sub := range subtypes {
+		if len(questions) >= count {
+			break
+		}
+
+		var q *models.DrillQuestion
+		var err error
+		for _, w := range [][2]int{window, wide} {
+			switch {
+			case !strings.HasPrefix(sub, "rc_"):
+				q, err = st.GetOneAdaptiveQuestion(userID, "logical_reasoning", sub, w[0], w[1], nil)
+			case passageID != 0:
+				q, err = st.GetOneAdaptiveQuestionFromPassage(userID, sub, passageID, w[0], w[1])
+			default:
+				q, err = st.GetOneAdaptiveQuestion(userID, "reading_comprehension", sub, w[0], w[1], nil)
+			}
+			if err == nil && q != nil {
+				break
+			}
+		}
+		if err != nil || q == nil || seen[q.ID] {
+			continue
+		}
+
+		if q.Passage != nil && passageID == 0 {
+			passageID = q.Passage.ID
+		}
+		seen[q.ID] = true
+		questions = append(questions, *q)
+	}
+	return questions
+}
+
+// GetRetryMistakesDrill returns, as a new drill, the questions among
+// questionIDs that the user last answered wrong.
+func (s *Service) GetRetryMistakesDrill(userID int64, questionIDs []int64) ([]models.DrillQuestion, error) {
+	return retryMistakesDrill(s.store, userID, questionIDs)
+}
+
+// retryMistakesStore is the subset of Store used to build a retry drill.
+type retryMistakesStore interface {
+	GetMissedQuestionIDs(userID int64, questionIDs []int64) ([]int64, error)
+	GetDrillQuestionsByIDs(questionIDs []int64) ([]models.DrillQuestion, error)
+}
+
+// retryMistakesDrill keeps the questions the user's history shows as
+// missed, so IDs the user never answered can't be used to pull questions,
+// and returns them in the original drill order.
+func retryMistakesDrill(st retryMistakesStore, userID int64, questionIDs []int64) ([]models.DrillQuestion, error) {
+	missedIDs, err := st.GetMissedQuestionIDs(userID, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(missedIDs) == 0 {
+		return []models.DrillQuestion{}, nil
+	}
+
+	fetched, err := st.GetDrillQuestionsByIDs(missedIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.DrillQuestion, len(fetched))
+	for _, q := range fetched {
+		byID[q.ID] = q
+	}
+
+	questions := []models.DrillQuestion{}
+	for _, id := range questionIDs {
+		if q, ok := byID[id]; ok {
+			questions = append(questions, q)
+			delete(byID, id)
+		}
+	}
+	return questions, nil
+}
+
+// GetNextQuestion serves the next question of the user's infinite practice
+// session in section. It returns a nil question when the pool is exhausted.
+func (s *Service) GetNextQuestion(userID int64, section string) (*models.NextQuestionResponse, error) {
+	pick, err := s.practice.nextPracticeQuestion(s.store, userID, section, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Async: top up inventory around the user's window
+	go s.CheckAndQueueGeneration(section, nil, pick.MinDiff, pick.MaxDiff)
 
-	// Async: check generation queue
-	go s.CheckAndQueueGeneration(req.Section, nil, minDiff, maxDiff)
+	return &models.NextQuestionResponse{
+		Question:     pick.Question,
+		SessionCount: pick.Served,
+	}, nil
+}
 
-	return questions, nil
+// AnswerAndNext submits an answer and returns the next practice question in
+// section in the same call.
+func (s *Service) AnswerAndNext(userID, questionID int64, section string, selected []string, timeSpentSeconds *float64) (*models.AnswerAndNextResponse, error) {
+	result, pick, err := s.practice.answerAndNext(s.store, userID, section, questionID, time.Now(), func() (*models.SubmitAnswerResponse, error) {
+		return s.SubmitAnswerSelection(userID, questionID, selected, timeSpentSeconds, models.SourcePractice)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Async: top up inventory around the user's window
+	go s.CheckAndQueueGeneration(section, nil, pick.MinDiff, pick.MaxDiff)
+
+	return &models.AnswerAndNextResponse{
+		Result:       result,
+		Next:         pick.Question,
+		SessionCount: pick.Served,
+	}, nil
 }
 
 func (s *Service) GetSubtypeDrill(ctx context.Context, userID int64, req models.SubtypeDrillRequest) ([]models.DrillQuestion, error) {
@@ -638,9 +1944,10 @@ func (s *Service) GetSubtypeDrill(ctx context.Context, userID int64, req models.
 	target := TargetDifficulty(subtypeAbility.AbilityScore, slider)
 	minDiff := max(0, target-15)
 	maxDiff := min(100, target+15)
+	includeFlagged := s.canServeFlagged(userID, req.IncludeFlagged)
 
 	questions, err := s.store.GetAdaptiveQuestions(
-		userID, req.Section, &subtype, minDiff, maxDiff, req.Count, nil,
+		userID, req.Section, &subtype, minDiff, maxDiff, req.Count, nil, includeFlagged,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("get subtype drill: %w", err)
@@ -651,7 +1958,7 @@ func (s *Service) GetSubtypeDrill(ctx context.Context, userID int64, req models.
 		minDiff = max(0, target-35)
 		maxDiff = min(100, target+35)
 		questions, err = s.store.GetAdaptiveQuestions(
-			userID, req.Section, &subtype, minDiff, maxDiff, req.Count, nil,
+			userID, req.Section, &subtype, minDiff, maxDiff, req.Count, nil, includeFlagged,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("get subtype drill (wide): %w", err)
@@ -671,29 +1978,71 @@ func (s *Service) GetSubtypeDrill(ctx context.Context, userID int64, req models.
 			genReq.LRSubtype = &ls
 		}
 
-		_, genErr := s.GenerateBatch(ctx, genReq)
+		_, genErr := s.generateCoalesced(ctx, genReq)
 		if genErr != nil {
-			log.Printf("WARN: synchronous generation failed for subtype drill: %v", genErr)
+			slog.Warn("synchronous generation failed for subtype drill", "component", "drill", "err", genErr)
 		} else {
 			// Retry fetch after generation
 			questions, _ = s.store.GetAdaptiveQuestions(
-				userID, req.Section, &subtype, minDiff, maxDiff, req.Count, nil,
+				userID, req.Section, &subtype, minDiff, maxDiff, req.Count, nil, includeFlagged,
 			)
 		}
 	}
 
+	if len(questions) == 0 {
+		return nil, s.emptyDrillError(s.store, userID, req.Section, subtype, minDiff, maxDiff)
+	}
+
 	// Async queue
 	go s.CheckAndQueueGeneration(req.Section, &subtype, minDiff, maxDiff)
-
 	return questions, nil
 }
 
+// emptyDrillQueuer is the subset of Store used to queue generation for a
+// drill that found nothing to serve.
+type emptyDrillQueuer interface {
+	poolQueuer
+	inventoryQueuer
+}
+
+// emptyDrillError explains a drill that found nothing to serve. It queues
+// generation for the drill; if that leaves generation pending or running,
+// the client should retry after GenerationRetryAfter. With auto-generation
+// off, or nothing to generate, there's nothing to wait for.
+func (s *Service) emptyDrillError(st emptyDrillQueuer, userID int64, section, subtype string, minDiff, maxDiff int) error {
+	if !s.autoGenEnabledFor(section, subtype) {
+		return fmt.Errorf("no questions available")
+	}
+	queued := queuePoolGeneration(st, section, &subtype, minDiff, maxDiff)
+	userQueued, err := queueLowInventory(st, userID, section, subtype, s.minUnseen(section))
+	if err != nil {
+		slog.Warn("count unseen failed", "component", "drill",
+			"user_id", userID, "section", section, "subtype", subtype, "err", err)
+	}
+	if queued || userQueued {
+		return fmt.Errorf("generation in progress")
+	}
+	return fmt.Errorf("no questions available")
+}
+
+// GenerationRetryAfter is how long a client should wait before retrying a
+// drill whose questions are being generated: one generation worker pass.
+func (s *Service) GenerationRetryAfter() time.Duration {
+	if s.genWorkerInterval > 0 {
+		return s.genWorkerInterval
+	}
+	return 30 * time.Second
+}
+
 // ── RC Drill Serving ────────────────────────────────────
 
+// RCDrillMax is the most questions an RC drill serves (RC_DRILL_MAX).
+func (s *Service) RCDrillMax() int {
+	return s.store.rcDrillMax
+}
+
 func (s *Service) GetRCDrill(ctx context.Context, userID int64, req models.RCDrillRequest) (*models.RCDrillResponse, error) {
-	if req.Count <= 0 {
-		req.Count = 8
-	}
+	req.Count = rcDrillCount(req.Count, s.RCDrillMax())
 
 	// Get user's RC section ability
 	section := "reading_comprehension"
@@ -742,13 +2091,13 @@ func (s *Service) GetRCDrill(ctx context.Context, userID int64, req models.RCDri
 		genReq := models.GenerateBatchRequest{
 			Section:    models.SectionRC,
 			Difficulty: difficulty,
-			Count:      6,
+			Count:      s.questionsPerPassage(),
 		}
 
-		log.Printf("[rc-drill] No passage found, generating synchronously")
-		_, genErr := s.GenerateBatch(ctx, genReq)
+		slog.Info("no passage found, generating synchronously", "component", "rc-drill")
+		_, genErr := s.generateCoalesced(ctx, genReq)
 		if genErr != nil {
-			log.Printf("WARN: RC synchronous generation failed: %v", genErr)
+			slog.Warn("RC synchronous generation failed", "component", "rc-drill", "err", genErr)
 		} else {
 			// Retry after generation
 			passage, questions, err = s.store.GetRCPassageWithQuestions(
@@ -768,66 +2117,87 @@ func (s *Service) GetRCDrill(ctx context.Context, userID int64, req models.RCDri
 	drillPassage := passage.ToDrillPassage()
 	drillQuestions := make([]models.DrillQuestion, 0, len(questions))
 	for _, q := range questions {
-		dq := models.DrillQuestion{
-			ID:              q.ID,
-			Section:         q.Section,
-			LRSubtype:       q.LRSubtype,
-			RCSubtype:       q.RCSubtype,
-			Difficulty:      q.Difficulty,
-			DifficultyScore: q.DifficultyScore,
-			Stimulus:        q.Stimulus,
-			QuestionStem:    q.QuestionStem,
-			Passage:         &drillPassage,
-		}
-		for _, c := range q.Choices {
-			dq.Choices = append(dq.Choices, models.DrillChoice{
-				ChoiceID:   c.ChoiceID,
-				ChoiceText: c.ChoiceText,
-			})
-		}
+		dq := q.ToDrillQuestion()
+		dq.Passage = &drillPassage
 		drillQuestions = append(drillQuestions, dq)
 	}
 
 	// Async check RC inventory
 	go s.CheckRCInventory(minDiff, maxDiff, req.RCSubtype)
 
+	drillID, err := s.StartDrillSession(userID, drillQuestions, models.SourceRCDrill)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.RCDrillResponse{
+		DrillID:   drillID,
 		Passage:   drillPassage,
 		Questions: drillQuestions,
 		Total:     len(drillQuestions),
 		Page:      1,
 		PageSize:  req.Count,
+		Source:    models.SourceRCDrill,
 	}, nil
 }
 
 var rcSubjectAreas = []string{"law", "natural_science", "social_science", "humanities"}
 
+// rcInventoryStore is the subset of Store used to queue RC generation.
+type rcInventoryStore interface {
+	CountRCPassagesInBucket(minDiff, maxDiff int) int
+	UpsertRCGenerationQueue(minDiff, maxDiff int, targetDiff string, subjectArea string, isComparative bool, questionsNeeded int) error
+	GetSubjectAreaCounts() (map[string]int, error)
+	GetComparativeRatio() (int, int)
+}
+
 func (s *Service) NextRCSubjectArea() string {
-	last := s.store.GetLastRCSubjectArea()
-	if last == "" {
+	return nextRCSubjectArea(s.store)
+}
+
+// nextRCSubjectArea picks the subject area with the fewest passages, queued
+// ones included, breaking ties in rcSubjectAreas order.
+func nextRCSubjectArea(st rcInventoryStore) string {
+	counts, err := st.GetSubjectAreaCounts()
+	if err != nil {
+		slog.Warn("subject area counts failed", "component", "rc-inventory", "err", err)
 		return rcSubjectAreas[0]
 	}
-	for i, sa := range rcSubjectAreas {
-		if sa == last {
-			return rcSubjectAreas[(i+1)%len(rcSubjectAreas)]
+	next := rcSubjectAreas[0]
+	for _, sa := range rcSubjectAreas[1:] {
+		if counts[sa] < counts[next] {
+			next = sa
 		}
 	}
-	return rcSubjectAreas[0]
+	return next
 }
 
 func (s *Service) ShouldGenerateComparative() bool {
-	comparative, total := s.store.GetComparativeRatio()
-	if total < 4 {
+	return shouldGenerateComparative(s.store, s.comparativeTarget, s.comparativeMin)
+}
+
+// shouldGenerateComparative reports whether the comparative share of RC
+// passages is below target, once there are at least minPassages.
+func shouldGenerateComparative(st rcInventoryStore, target float64, minPassages int) bool {
+	comparative, total := st.GetComparativeRatio()
+	if total < minPassages || total == 0 {
 		return false
 	}
-	return float64(comparative)/float64(total) < 0.25
+	return float64(comparative)/float64(total) < target
 }
 
 func (s *Service) CheckRCInventory(minDiff, maxDiff int, rcSubtype *string) {
 	if !s.autoGenEnabledRC {
 		return
 	}
+	checkRCInventory(s.store, minDiff, maxDiff, s.questionsPerPassage(), s.comparativeTarget, s.comparativeMin)
+}
 
+// checkRCInventory queues a passage of questionsPerPassage questions for
+// each difficulty bucket overlapping minDiff-maxDiff that is low on passages.
+// Passages are comparative while the comparative share is under
+// comparativeTarget.
+func checkRCInventory(st rcInventoryStore, minDiff, maxDiff, questionsPerPassage int, comparativeTarget float64, comparativeMin int) {
 	type bucket struct {
 		min, max   int
 		difficulty string
@@ -841,13 +2211,13 @@ func (s *Service) CheckRCInventory(minDiff, maxDiff int, rcSubtype *string) {
 		if b.max < minDiff || b.min > maxDiff {
 			continue
 		}
-		count := s.store.CountRCPassagesInBucket(b.min, b.max)
+		count := st.CountRCPassagesInBucket(b.min, b.max)
 		if count < 3 {
-			subjectArea := s.NextRCSubjectArea()
-			isComparative := s.ShouldGenerateComparative()
-			s.store.UpsertRCGenerationQueue(b.min, b.max, b.difficulty, subjectArea, isComparative)
-			log.Printf("[rc-inventory] Queued RC generation: bucket=%d-%d subject=%s comparative=%v",
-				b.min, b.max, subjectArea, isComparative)
+			subjectArea := nextRCSubjectArea(st)
+			isComparative := shouldGenerateComparative(st, comparativeTarget, comparativeMin)
+			st.UpsertRCGenerationQueue(b.min, b.max, b.difficulty, subjectArea, isComparative, questionsPerPassage)
+			slog.Info("queued RC generation", "component", "rc-inventory",
+				"bucket_min", b.min, "bucket_max", b.max, "subject_area", subjectArea, "comparative", isComparative)
 		}
 	}
 }
@@ -864,7 +2234,26 @@ func mapScoreToDifficulty(score int) models.Difficulty {
 
 // ── Generation Queue ────────────────────────────────────
 
-func (s *Service) CheckAndQueueGeneration(section string, subtype *string, minDiff, maxDiff int) {
+// CheckAndQueueGeneration tops up the global pool for section and subtype in
+// the buckets overlapping minDiff–maxDiff. It reports whether any of them
+// has generation pending or running.
+func (s *Service) CheckAndQueueGeneration(section string, subtype *string, minDiff, maxDiff int) bool {
+	if subtype != nil && !s.autoGenSubtypes.allows(*subtype) {
+		return false
+	}
+	return queuePoolGeneration(s.store, section, subtype, minDiff, maxDiff)
+}
+
+// poolQueuer is the subset of Store used to top up the global pool.
+type poolQueuer interface {
+	generationQueuer
+	CountQuestionsInBucket(section string, subtype *string, minDiff, maxDiff int) (int, error)
+}
+
+// queuePoolGeneration queues generation for each bucket overlapping
+// minDiff–maxDiff that holds fewer than 6 questions, reporting whether any
+// was queued or already had generation under way.
+func queuePoolGeneration(st poolQueuer, section string, subtype *string, minDiff, maxDiff int) bool {
 	type bucket struct {
 		min, max   int
 		difficulty string
@@ -874,13 +2263,14 @@ func (s *Service) CheckAndQueueGeneration(section string, subtype *string, minDi
 		{61, 80, "hard"}, {81, 100, "hard"},
 	}
 
+	queued := false
 	for _, b := range buckets {
 		if b.max < minDiff || b.min > maxDiff {
 			continue
 		}
-		count, err := s.store.CountQuestionsInBucket(section, subtype, b.min, b.max)
+		count, err := st.CountQuestionsInBucket(section, subtype, b.min, b.max)
 		if err != nil {
-			log.Printf("[gen-queue] count error: %v", err)
+			slog.Warn("count questions in bucket failed", "component", "gen-queue", "err", err)
 			continue
 		}
 		if count < 6 {
@@ -888,54 +2278,103 @@ func (s *Service) CheckAndQueueGeneration(section string, subtype *string, minDi
 			if needed < 1 {
 				needed = 1
 			}
-			s.store.UpsertGenerationQueue(section, subtype, b.min, b.max, b.difficulty, needed)
+			if st.UpsertGenerationQueue(section, subtype, b.min, b.max, b.difficulty, needed, false) == nil {
+				queued = true
+			}
 		}
 	}
+	return queued
 }
 
-// CheckUserInventoryAndQueue checks if a specific user is running low on
-// unseen questions for a given subtype and queues generation if so.
-// This complements CheckAndQueueGeneration (which checks global counts)
-// by ensuring individual users don't exhaust their question pool.
-func (s *Service) CheckUserInventoryAndQueue(userID int64, section string, subtype string) {
-	// Check if auto-gen is enabled for this section
+// minUnseen returns the unseen-question threshold below which a user's
+// inventory in section triggers generation.
+func (s *Service) minUnseen(section string) int {
+	if section == string(models.SectionRC) {
+		return s.autoGenMinUnseenRC
+	}
+	return s.autoGenMinUnseenLR
+}
+
+// autoGenEnabled reports whether auto-generation is on for section.
+func (s *Service) autoGenEnabled(section string) bool {
 	switch section {
 	case "logical_reasoning":
-		if !s.autoGenEnabledLR {
-			return
-		}
+		return s.autoGenEnabledLR
 	case "reading_comprehension":
-		if !s.autoGenEnabledRC {
-			return
-		}
-	default:
-		return
+		return s.autoGenEnabledRC
 	}
+	return false
+}
 
-	// Count unseen questions for this user+subtype
-	unseen, err := s.store.CountUnseenForUser(userID, section, subtype)
+// autoGenEnabledFor reports whether auto-generation is on for subtype in
+// section.
+func (s *Service) autoGenEnabledFor(section, subtype string) bool {
+	return s.autoGenEnabled(section) && s.autoGenSubtypes.allows(subtype)
+}
+
+// CheckUserInventoryAndQueue checks if a specific user is running low on
+// unseen questions for a given subtype and queues generation if so.
+// This complements CheckAndQueueGeneration (which checks global counts)
+// by ensuring individual users don't exhaust their question pool. It
+// reports whether generation was queued.
+func (s *Service) CheckUserInventoryAndQueue(userID int64, section string, subtype string) bool {
+	// Check if auto-gen is enabled for this section and subtype
+	if !s.autoGenEnabledFor(section, subtype) {
+		return false
+	}
+
+	queued, err := queueLowInventory(s.store, userID, section, subtype, s.minUnseen(section))
 	if err != nil {
-		log.Printf("[user-gen] count unseen error for user=%d section=%s subtype=%s: %v",
-			userID, section, subtype, err)
-		return
+		slog.Warn("count unseen failed", "component", "user-gen",
+			"user_id", userID, "section", section, "subtype", subtype, "err", err)
 	}
+	return queued
+}
 
-	if unseen >= s.autoGenMinUnseen {
-		return
+// inventoryQueuer is the subset of Store used to queue generation for a
+// user's own inventory.
+type inventoryQueuer interface {
+	generationQueuer
+	CountUnseenForUser(userID int64, section string, subtype string) (int, error)
+	GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error)
+}
+
+// queueLowInventory queues generation around the user's level in subtype
+// when they have fewer than threshold unseen questions left, reporting
+// whether it queued any.
+func queueLowInventory(st inventoryQueuer, userID int64, section, subtype string, threshold int) (bool, error) {
+	// Count unseen questions for this user+subtype
+	unseen, err := st.CountUnseenForUser(userID, section, subtype)
+	if err != nil {
+		return false, err
+	}
+	if unseen >= threshold {
+		return false, nil
 	}
 
-	log.Printf("[user-gen] user=%d low on %s/%s: unseen=%d threshold=%d, queueing generation",
-		userID, section, subtype, unseen, s.autoGenMinUnseen)
+	slog.Info("user low on unseen questions, queueing generation", "component", "user-gen",
+		"user_id", userID, "section", section, "subtype", subtype, "unseen", unseen, "threshold", threshold)
 
 	// Get user's ability score for this subtype to determine target difficulty
-	subtypeAbility, err := s.store.GetOrCreateAbility(userID, models.ScopeSubtype, &subtype)
+	subtypeAbility, err := st.GetOrCreateAbility(userID, models.ScopeSubtype, &subtype)
 	if err != nil {
-		log.Printf("[user-gen] get ability error: %v", err)
+		slog.Warn("get ability failed", "component", "user-gen", "user_id", userID, "err", err)
 		subtypeAbility = &models.UserAbilityScore{AbilityScore: 50}
 	}
 
 	// Compute target difficulty from ability (centered, slider=50)
-	target := TargetDifficulty(subtypeAbility.AbilityScore, 50)
+	return queueUserGeneration(st, section, subtype, TargetDifficulty(subtypeAbility.AbilityScore, 50)), nil
+}
+
+// generationQueuer is the subset of Store used to queue generation.
+type generationQueuer interface {
+	UpsertGenerationQueue(section string, subtype *string, minDiff, maxDiff int, targetDiff string, needed int, highPriority bool) error
+}
+
+// queueUserGeneration queues generation for a user's subtype in the
+// difficulty buckets overlapping target±15, reporting whether any bucket
+// was queued or already had generation under way.
+func queueUserGeneration(st generationQueuer, section, subtype string, target int) bool {
 	minDiff := max(0, target-15)
 	maxDiff := min(100, target+15)
 
@@ -949,39 +2388,237 @@ func (s *Service) CheckUserInventoryAndQueue(userID int64, section string, subty
 		{61, 80, "hard"}, {81, 100, "hard"},
 	}
 
+	queued := false
 	for _, b := range buckets {
 		if b.max < minDiff || b.min > maxDiff {
 			continue
 		}
-		s.store.UpsertGenerationQueue(section, &subtype, b.min, b.max, b.difficulty, 6)
+		// A user is waiting on these, so they skip the off-peak window
+		if st.UpsertGenerationQueue(section, &subtype, b.min, b.max, b.difficulty, 6, true) == nil {
+			queued = true
+		}
+	}
+	return queued
+}
+
+// A subtype is one of the user's weak areas once they've answered
+// weakAreaMinAnswered of it with accuracy below weakAreaAccuracy. Weak
+// subtypes are topped up while the user still has fewer than
+// weakAreaUnseenFactor times the usual unseen threshold, so the extra
+// practice they need is ready before they run low.
+const (
+	weakAreaMinAnswered  = 10
+	weakAreaAccuracy     = 0.6
+	weakAreaUnseenFactor = 3
+)
+
+// weakAreaStore is the subset of Store used to top up weak subtypes.
+type weakAreaStore interface {
+	inventoryQueuer
+	GetSubtypeAccuracy(userID int64, subtype string) (answered, correct int, err error)
+}
+
+// queueWeakSubtype queues generation at the user's level for subtype if it's
+// a weak area and their unseen pool is only moderate. It reports whether it
+// queued anything.
+func queueWeakSubtype(st weakAreaStore, userID int64, section, subtype string, minUnseen int) (bool, error) {
+	answered, correct, err := st.GetSubtypeAccuracy(userID, subtype)
+	if err != nil {
+		return false, fmt.Errorf("get subtype accuracy: %w", err)
+	}
+	if answered < weakAreaMinAnswered || float64(correct)/float64(answered) >= weakAreaAccuracy {
+		return false, nil
+	}
+
+	unseen, err := st.CountUnseenForUser(userID, section, subtype)
+	if err != nil {
+		return false, fmt.Errorf("count unseen: %w", err)
+	}
+	if unseen >= weakAreaUnseenFactor*minUnseen {
+		return false, nil
+	}
+
+	ability, err := st.GetOrCreateAbility(userID, models.ScopeSubtype, &subtype)
+	if err != nil {
+		ability = &models.UserAbilityScore{AbilityScore: 50}
+	}
+	queueUserGeneration(st, section, subtype, TargetDifficulty(ability.AbilityScore, 50))
+	return true, nil
+}
+
+// CheckWeakAreaAndQueue proactively queues generation for subtype when the
+// user's accuracy in it is low, before CheckUserInventoryAndQueue would.
+func (s *Service) CheckWeakAreaAndQueue(userID int64, section string, subtype string) {
+	if !s.autoGenEnabledFor(section, subtype) {
+		return
+	}
+	queued, err := queueWeakSubtype(s.store, userID, section, subtype, s.minUnseen(section))
+	if err != nil {
+		slog.Warn("weak area check failed", "component", "user-gen",
+			"user_id", userID, "section", section, "subtype", subtype, "err", err)
+		return
+	}
+	if queued {
+		slog.Info("user weak in subtype, queueing generation", "component", "user-gen",
+			"user_id", userID, "section", section, "subtype", subtype)
+	}
+}
+
+// generationQueueStatuses are the states a generation_queue item moves
+// through.
+var generationQueueStatuses = map[string]bool{
+	"pending": true, "generating": true, "completed": true, "failed": true,
+}
+
+// generationQueueLister is the subset of Store used to page through the
+// generation queue.
+type generationQueueLister interface {
+	ListGenerationQueue(status string, page, pageSize int) ([]models.GenerationQueueItem, int, error)
+}
+
+// ListGenerationQueue pages through the generation queue for admins,
+// optionally filtered to one status.
+func (s *Service) ListGenerationQueue(status string, page, pageSize int) (*models.GenerationQueueListResponse, error) {
+	return listGenerationQueue(s.store, status, page, pageSize)
+}
+
+func listGenerationQueue(st generationQueueLister, status string, page, pageSize int) (*models.GenerationQueueListResponse, error) {
+	if status != "" && !generationQueueStatuses[status] {
+		return nil, fmt.Errorf("invalid status")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	items, total, err := st.ListGenerationQueue(status, page, pageSize)
+	if err != nil {
+		return nil, err
 	}
+	if items == nil {
+		items = []models.GenerationQueueItem{}
+	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
+	return &models.GenerationQueueListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
+	}, nil
 }
 
 func (s *Service) StartGenerationWorker(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+	slog.Info("background generation worker started", "component", "gen-worker", "interval", s.genWorkerInterval)
+	runEvery(ctx, s.genWorkerInterval, func() {
+		s.processGenerationQueue(ctx)
+	})
+	slog.Info("shutting down", "component", "gen-worker")
+}
 
-	log.Println("[gen-worker] Background generation worker started")
+// runEvery calls tick once per interval until ctx is cancelled.
+func runEvery(ctx context.Context, interval time.Duration, tick func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("[gen-worker] Shutting down")
 			return
 		case <-ticker.C:
-			s.processGenerationQueue(ctx)
+			tick()
+		}
+	}
+}
+
+// staleGenerationStore is the subset of Store used to recover items a
+// crashed worker left in 'generating'.
+type staleGenerationStore interface {
+	GetGeneratingItems() ([]models.GenerationQueueItem, error)
+	UpdateGenerationStatus(id int64, status string, errMsg *string) error
+}
+
+// failStaleGenerations marks items that have been generating for longer than
+// timeout as failed, which unblocks their bucket for the next top-up. Items
+// queued before started_at was recorded are timed from created_at. It
+// returns how many items it failed.
+func failStaleGenerations(st staleGenerationStore, now time.Time, timeout time.Duration) (int, error) {
+	items, err := st.GetGeneratingItems()
+	if err != nil {
+		return 0, err
+	}
+	failed := 0
+	for _, item := range items {
+		started := item.CreatedAt
+		if item.StartedAt != nil {
+			started = *item.StartedAt
+		}
+		if now.Sub(started) < timeout {
+			continue
+		}
+		errMsg := fmt.Sprintf("stale: still generating after %s", timeout)
+		if err := st.UpdateGenerationStatus(item.ID, "failed", &errMsg); err != nil {
+			return failed, fmt.Errorf("fail stale item %d: %w", item.ID, err)
+		}
+		failed++
+	}
+	return failed, nil
+}
+
+// queueResetStore is the subset of Store used to reset a queue item.
+type queueResetStore interface {
+	GetGenerationQueueItem(id int64) (*models.GenerationQueueItem, error)
+	ResetGenerationItem(id int64) (bool, error)
+}
+
+// ResetGenerationQueueItem puts a generating or failed item back to pending
+// so the worker retries it, unless a newer item already covers its bucket.
+func (s *Service) ResetGenerationQueueItem(id int64) (*models.GenerationQueueItem, error) {
+	return resetGenerationQueueItem(s.store, id)
+}
+
+func resetGenerationQueueItem(st queueResetStore, id int64) (*models.GenerationQueueItem, error) {
+	item, err := st.GetGenerationQueueItem(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("queue item not found")
 		}
+		return nil, err
+	}
+	if item.Status != "generating" && item.Status != "failed" {
+		return nil, fmt.Errorf("queue item not resettable")
+	}
+	reset, err := st.ResetGenerationItem(id)
+	if err != nil {
+		return nil, fmt.Errorf("reset queue item: %w", err)
+	}
+	if !reset {
+		// Picked up by the worker meanwhile, or superseded by a newer item
+		return nil, fmt.Errorf("queue item not resettable")
 	}
+	return st.GetGenerationQueueItem(id)
 }
 
 func (s *Service) processGenerationQueue(ctx context.Context) {
+	if n, err := failStaleGenerations(s.store, time.Now(), s.genStaleTimeout); err != nil {
+		slog.Error("recover stale items failed", "component", "gen-queue", "err", err)
+	} else if n > 0 {
+		slog.Warn("failed stale generating items", "component", "gen-queue", "items", n)
+	}
+
 	items, err := s.store.GetPendingGenerations(5)
 	if err != nil {
-		log.Printf("[gen-queue] error fetching queue: %v", err)
+		slog.Error("fetch queue failed", "component", "gen-queue", "err", err)
 		return
 	}
 
-	for _, item := range items {
+	for _, item := range dueGenerations(items, s.offPeak, time.Now()) {
 		s.store.UpdateGenerationStatus(item.ID, "generating", nil)
 
 		genReq := models.GenerateBatchRequest{
@@ -1001,17 +2638,18 @@ func (s *Service) processGenerationQueue(ctx context.Context) {
 			genReq.SubjectArea = *item.SubjectArea
 		}
 		genReq.IsComparative = item.IsComparative
+		genReq.DifficultyScore = &models.ScoreRange{Min: item.DifficultyBucketMin, Max: item.DifficultyBucketMax}
 
-		_, err := s.GenerateBatch(ctx, genReq)
+		_, err := s.generateCoalesced(ctx, genReq)
 		if err != nil {
 			errMsg := err.Error()
 			s.store.UpdateGenerationStatus(item.ID, "failed", &errMsg)
-			log.Printf("[gen-queue] failed: section=%s subtype=%v bucket=%d-%d err=%v",
-				item.Section, item.LRSubtype, item.DifficultyBucketMin, item.DifficultyBucketMax, err)
+			slog.Error("generation failed", "component", "gen-queue", "queue_id", item.ID, "section", item.Section,
+				"bucket_min", item.DifficultyBucketMin, "bucket_max", item.DifficultyBucketMax, "err", err)
 		} else {
 			s.store.UpdateGenerationStatus(item.ID, "completed", nil)
-			log.Printf("[gen-queue] completed: section=%s subtype=%v bucket=%d-%d",
-				item.Section, item.LRSubtype, item.DifficultyBucketMin, item.DifficultyBucketMax)
+			slog.Info("generation completed", "component", "gen-queue", "queue_id", item.ID, "section", item.Section,
+				"bucket_min", item.DifficultyBucketMin, "bucket_max", item.DifficultyBucketMax)
 		}
 	}
 }
@@ -1030,6 +2668,27 @@ func (s *Service) GetAbilities(userID int64) (*models.AbilityResponse, error) {
 	return resp, nil
 }
 
+// GetAbilityHistory returns the user's daily ability for one scope. Section
+// and subtype scopes need a scopeValue; the overall scope takes none.
+func (s *Service) GetAbilityHistory(userID int64, scope models.AbilityScope, scopeValue *string) (*models.AbilityHistoryResponse, error) {
+	switch scope {
+	case models.ScopeOverall:
+		scopeValue = nil
+	case models.ScopeSection, models.ScopeSubtype:
+		if scopeValue == nil {
+			return nil, fmt.Errorf("scope value is required")
+		}
+	default:
+		return nil, fmt.Errorf("invalid scope")
+	}
+
+	points, err := s.store.GetAbilityHistory(userID, scope, scopeValue)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AbilityHistoryResponse{Scope: scope, ScopeValue: scopeValue, Points: points}, nil
+}
+
 func (s *Service) SetDifficultySlider(userID int64, value int) error {
 	return s.store.SetDifficultySlider(userID, value)
 }
@@ -1040,25 +2699,120 @@ func (s *Service) GetQualityStats() (*models.QualityStats, error) {
 	return s.store.GetQualityStats()
 }
 
+func (s *Service) GetStructuralStats() (*models.StructuralStats, error) {
+	return s.store.GetStructuralStats()
+}
+
 func (s *Service) GetGenerationStats() (*models.GenerationStats, error) {
 	return s.store.GetGenerationStats()
 }
 
+// GetDifficultyHistogram returns the servable inventory of subtype by
+// difficulty bucket.
+func (s *Service) GetDifficultyHistogram(subtype string) (*models.DifficultyHistogram, error) {
+	section := ""
+	for _, st := range allLRSubtypes {
+		if st == subtype {
+			section = string(models.SectionLR)
+		}
+	}
+	for _, st := range allRCSubtypes {
+		if st == subtype {
+			section = string(models.SectionRC)
+		}
+	}
+	if section == "" {
+		return nil, fmt.Errorf("invalid subtype")
+	}
+	return s.store.GetDifficultyHistogram(section, subtype)
+}
+
 func (s *Service) GetFlaggedQuestions(limit, offset int) ([]models.Question, int, error) {
 	return s.store.GetFlaggedQuestions(limit, offset)
 }
 
+// maxFlagReasonLength caps the rationale a user can attach to a flag.
+const maxFlagReasonLength = 1000
+
+// FlagQuestion lets a user dispute a question from drill review. The
+// question is queued for admin review with the user's reason.
+func (s *Service) FlagQuestion(userID, questionID int64, reason string) error {
+	reason, err := validateFlagReason(reason)
+	if err != nil {
+		return err
+	}
+	return s.store.FlagQuestion(userID, questionID, reason)
+}
+
+// validateFlagReason trims reason and checks it's present and not too long.
+func validateFlagReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", fmt.Errorf("reason is required")
+	}
+	if len(reason) > maxFlagReasonLength {
+		return "", fmt.Errorf("reason must be at most %d characters", maxFlagReasonLength)
+	}
+	return reason, nil
+}
+
+func (s *Service) SearchQuestions(query string, page, pageSize int) (*models.QuestionListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 50 {
+		pageSize = 50
+	}
+
+	questions, total, err := s.store.SearchQuestions(query, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if questions == nil {
+		questions = []models.Question{}
+	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
+	return &models.QuestionListResponse{
+		Questions:  questions,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// trustedCandidates drops candidates answered by fewer than minResponders
+// distinct users, whose accuracy may reflect a few users retrying rather than
+// the question's difficulty. A minResponders of 0 keeps every candidate.
+func trustedCandidates(candidates []models.RecalibrationCandidate, minResponders int) []models.RecalibrationCandidate {
+	if minResponders <= 0 {
+		return candidates
+	}
+	var trusted []models.RecalibrationCandidate
+	for _, c := range candidates {
+		if c.DistinctResponders >= minResponders {
+			trusted = append(trusted, c)
+		}
+	}
+	return trusted
+}
+
 func (s *Service) RecalibrateDifficulty() (*models.RecalibrationReport, error) {
 	candidates, err := s.store.GetRecalibrationCandidates(50)
 	if err != nil {
 		return nil, fmt.Errorf("get recalibration candidates: %w", err)
 	}
+	candidates = trustedCandidates(candidates, s.recalMinResponders)
 
 	recalibrated := 0
 	for _, c := range candidates {
 		err := s.store.UpdateQuestionDifficulty(c.QuestionID, c.SuggestedDifficulty)
 		if err != nil {
-			log.Printf("WARN: failed to recalibrate question %d: %v", c.QuestionID, err)
+			slog.Warn("recalibrate question failed", "component", "recalibrate", "question_id", c.QuestionID, "err", err)
 			continue
 		}
 		recalibrated++
@@ -1180,7 +2934,7 @@ func (s *Service) ImportQuestions(ctx context.Context, envelope models.ExportEnv
 	}
 
 	result.TotalInPayload = len(envelope.Questions)
-	result.Skipped = totalSkipped
+	result.Skipped += totalSkipped
 	return result, nil
 }
 
@@ -1254,11 +3008,14 @@ func (s *Service) GetUserHistory(userID int64, req models.HistoryListRequest) (*
 	if questions == nil {
 		questions = []models.HistoryQuestion{}
 	}
+	totalPages, hasMore := models.PageInfo(total, req.Page, req.PageSize)
 	return &models.HistoryListResponse{
-		Questions: questions,
-		Total:     total,
-		Page:      req.Page,
-		PageSize:  req.PageSize,
+		Questions:  questions,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
 	}, nil
 }
 
@@ -1280,16 +3037,42 @@ func (s *Service) GetUserMistakes(userID int64, page, pageSize int) (*models.His
 	if questions == nil {
 		questions = []models.HistoryQuestion{}
 	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
 	return &models.HistoryListResponse{
-		Questions: questions,
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
+		Questions:  questions,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
 	}, nil
 }
 
 func (s *Service) GetUserHistoryStats(userID int64) (*models.HistoryStatsResponse, error) {
-	return s.store.GetUserHistoryStats(userID)
+	stats, err := s.store.GetUserHistoryStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	studyTime, err := s.GetStudyTime(userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.StudyTime = *studyTime
+	return stats, nil
+}
+
+// GetStudyTime returns how long the user has spent answering today and this
+// week.
+func (s *Service) GetStudyTime(userID int64) (*models.StudyTimeResponse, error) {
+	today, err := s.store.GetStudyTime(userID, "today")
+	if err != nil {
+		return nil, err
+	}
+	week, err := s.store.GetStudyTime(userID, "week")
+	if err != nil {
+		return nil, err
+	}
+	return &models.StudyTimeResponse{Today: *today, Week: *week}, nil
 }
 
 func (s *Service) GetDrillReview(userID int64, questionIDs []int64) ([]models.HistoryQuestion, error) {
@@ -1304,6 +3087,46 @@ func (s *Service) DeleteBookmark(userID, questionID int64) error {
 	return s.store.DeleteBookmark(userID, questionID)
 }
 
+// CheckBookmarks returns which of questionIDs the user has bookmarked.
+func (s *Service) CheckBookmarks(userID int64, questionIDs []int64) (*models.BookmarkCheckResponse, error) {
+	return checkBookmarks(s.store, userID, questionIDs)
+}
+
+// bookmarkFilterStore is the subset of Store used to check bookmarks.
+type bookmarkFilterStore interface {
+	FilterBookmarked(userID int64, questionIDs []int64) ([]int64, error)
+}
+
+// checkBookmarks dedupes questionIDs, looks them up in one query, and
+// returns the bookmarked ones in request order.
+func checkBookmarks(st bookmarkFilterStore, userID int64, questionIDs []int64) (*models.BookmarkCheckResponse, error) {
+	seen := make(map[int64]bool, len(questionIDs))
+	var unique []int64
+	for _, id := range questionIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	found, err := st.FilterBookmarked(userID, unique)
+	if err != nil {
+		return nil, err
+	}
+	bookmarked := make(map[int64]bool, len(found))
+	for _, id := range found {
+		bookmarked[id] = true
+	}
+
+	resp := &models.BookmarkCheckResponse{Bookmarked: []int64{}}
+	for _, id := range unique {
+		if bookmarked[id] {
+			resp.Bookmarked = append(resp.Bookmarked, id)
+		}
+	}
+	return resp, nil
+}
+
 func (s *Service) GetBookmarks(userID int64, page, pageSize int) (*models.BookmarkListResponse, error) {
 	if page <= 0 {
 		page = 1
@@ -1319,10 +3142,13 @@ func (s *Service) GetBookmarks(userID int64, page, pageSize int) (*models.Bookma
 	if err != nil {
 		return nil, err
 	}
+	totalPages, hasMore := models.PageInfo(total, page, pageSize)
 	return &models.BookmarkListResponse{
-		Bookmarks: bookmarks,
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
+		Bookmarks:  bookmarks,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    hasMore,
 	}, nil
 }
@@ -0,0 +1,1832 @@
+package questions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/gamification"
+	"github.com/lsat-prep/backend/internal/generator"
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+// fakeGenerator is an in-memory Generator that returns a fixed batch.
+type fakeGenerator struct {
+	batch        *generator.GeneratedBatch
+	calls        int
+	lastCount    int
+	lastLanguage string
+	lastTarget   *models.ScoreRange
+}
+
+func (f *fakeGenerator) GenerateLRBatch(ctx context.Context, subtype models.LRSubtype, difficulty models.Difficulty, count int, language string, target *models.ScoreRange) (*generator.GeneratedBatch, *generator.LLMResponse, error) {
+	f.calls++
+	f.lastCount = count
+	f.lastLanguage = language
+	f.lastTarget = target
+	return f.batch, &generator.LLMResponse{PromptTokens: 100, OutputTokens: 200}, nil
+}
+
+func (f *fakeGenerator) GenerateRCBatch(ctx context.Context, difficulty models.Difficulty, questionsPerPassage int, subjectArea string, comparative bool, language string, target *models.ScoreRange) (*generator.GeneratedBatch, *generator.LLMResponse, error) {
+	f.calls++
+	f.lastCount = questionsPerPassage
+	f.lastLanguage = language
+	f.lastTarget = target
+	return f.batch, &generator.LLMResponse{PromptTokens: 100, OutputTokens: 200}, nil
+}
+
+func (f *fakeGenerator) RegenerateExplanations(ctx context.Context, q generator.GeneratedQuestion, passage *generator.GeneratedPassage, language string) (*generator.ExplanationRewrite, *generator.LLMResponse, error) {
+	f.calls++
+	f.lastLanguage = language
+	rw := &generator.ExplanationRewrite{Explanation: "Rewritten: " + q.CorrectAnswerID + " is right."}
+	for _, c := range q.Choices {
+		rw.Choices = append(rw.Choices, generator.ExplanationRewriteChoice{ID: c.ID, Explanation: "Rewritten " + c.ID})
+	}
+	return rw, &generator.LLMResponse{PromptTokens: 50, OutputTokens: 80}, nil
+}
+
+func (f *fakeGenerator) ModelName() string { return "fake-generator" }
+
+// fakeValidator returns a scripted verification result per question.
+type fakeValidator struct {
+	selected   []string
+	confidence []string
+	model      string
+}
+
+func (f *fakeValidator) ValidateBatch(ctx context.Context, batch *generator.GeneratedBatch) (*generator.BatchValidationResult, error) {
+	res := &generator.BatchValidationResult{TotalQuestions: len(batch.Questions)}
+	for i, q := range batch.Questions {
+		vr := generator.ValidationResult{
+			QuestionIndex:   i,
+			SelectedAnswer:  f.selected[i],
+			GeneratedAnswer: q.CorrectAnswerID,
+			Matches:         f.selected[i] == q.CorrectAnswerID,
+			Confidence:      f.confidence[i],
+			PromptTokens:    10,
+			OutputTokens:    5,
+		}
+		res.TotalPromptTokens += vr.PromptTokens
+		res.TotalOutputTokens += vr.OutputTokens
+		res.Results = append(res.Results, vr)
+	}
+	return res, nil
+}
+
+func (f *fakeValidator) AdversarialCheckBatch(ctx context.Context, batch *generator.GeneratedBatch) ([]generator.AdversarialResult, error) {
+	results := make([]generator.AdversarialResult, len(batch.Questions))
+	for i := range batch.Questions {
+		results[i] = generator.AdversarialResult{QuestionIndex: i, PromptTokens: 1, OutputTokens: 1}
+	}
+	return results, nil
+}
+
+func (f *fakeValidator) ModelName() string {
+	if f.model != "" {
+		return f.model
+	}
+	return "fake-validator"
+}
+
+func fakeQuestion(correct string) generator.GeneratedQuestion {
+	// Wrong answers cover the trap archetypes required for strengthen/weaken
+	traps := []string{"weakener", "strengthener", "irrelevant", "out_of_scope"}
+	var choices []generator.GeneratedChoice
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		c := generator.GeneratedChoice{
+			ID:          id,
+			Text:        "Choice " + id + " " + strings.Repeat("text ", 6),
+			Explanation: "Explanation for " + id,
+		}
+		if id != correct {
+			trap := traps[0]
+			traps = traps[1:]
+			c.WrongAnswerType = &trap
+		}
+		choices = append(choices, c)
+	}
+	return generator.GeneratedQuestion{
+		Stimulus:        strings.Repeat("A stimulus sentence with an argument. ", 5),
+		QuestionStem:    "Which of the following most strengthens the argument?",
+		Choices:         choices,
+		CorrectAnswerID: correct,
+		Explanation:     "Because.",
+	}
+}
+
+func TestGenerationPipeline_FakeProvider(t *testing.T) {
+	gen := &fakeGenerator{batch: &generator.GeneratedBatch{
+		Questions:     []generator.GeneratedQuestion{fakeQuestion("A"), fakeQuestion("B"), fakeQuestion("C")},
+		PromptVersion: "test",
+	}}
+	val := &fakeValidator{
+		selected:   []string{"A", "B", "D"},
+		confidence: []string{"high", "medium", "high"},
+	}
+	s := &Service{generator: gen, validator: val, validationEnabled: true, adversarialEnabled: true, quality: generator.DefaultQualityThresholds}
+
+	subtype := models.SubtypeStrengthen
+	req := models.GenerateBatchRequest{
+		Section:    models.SectionLR,
+		LRSubtype:  &subtype,
+		Difficulty: models.DifficultyMedium,
+		Count:      3,
+	}
+
+	genBatch, llmResp, err := s.generateQuestions(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gen.calls != 1 || llmResp.PromptTokens != 100 {
+		t.Fatalf("expected fake generator to be called once, got %d calls", gen.calls)
+	}
+
+	scored := s.scoreBatch(context.Background(), req, 1, genBatch, true)
+
+	if scored.passed != 1 || scored.flagged != 1 || scored.rejected != 1 {
+		t.Errorf("expected 1/1/1 passed/flagged/rejected, got %d/%d/%d", scored.passed, scored.flagged, scored.rejected)
+	}
+	// 3 × (10+5) verification + 3 × (1+1) adversarial
+	if scored.validationTokens() != 51 {
+		t.Errorf("expected 51 validation tokens, got %d", scored.validationTokens())
+	}
+	if len(scored.logs) != 6 {
+		t.Errorf("expected 6 validation logs, got %d", len(scored.logs))
+	}
+	for _, l := range scored.logs {
+		if l.ModelUsed != "fake-validator" {
+			t.Errorf("expected logs attributed to fake-validator, got %q", l.ModelUsed)
+		}
+	}
+
+	filtered, opts, kept := filterRejected(genBatch, scored.opts)
+	if len(filtered.Questions) != 2 || len(opts) != 2 || len(kept) != 2 {
+		t.Errorf("expected 2 questions after filtering, got %d", len(filtered.Questions))
+	}
+	if filtered.PromptVersion != "test" {
+		t.Errorf("expected prompt version to survive filtering, got %q", filtered.PromptVersion)
+	}
+
+	// Question 1 was saved as 101 and question 2 skipped as a duplicate;
+	// each log must be attached only to its own question
+	questionIDs := []int64{101, 0, 0}
+	for k, l := range scored.questionLogs(questionIDs) {
+		i := scored.logQuestion[k]
+		switch {
+		case i == 0 && (l.QuestionID == nil || *l.QuestionID != 101):
+			t.Errorf("log %d for question 0: question_id = %v, want 101", k, l.QuestionID)
+		case i != 0 && l.QuestionID != nil:
+			t.Errorf("log %d for unsaved question %d: question_id = %d, want none", k, i, *l.QuestionID)
+		}
+	}
+}
+
+func TestGenerateQuestions_RequiresLRSubtype(t *testing.T) {
+	s := &Service{generator: &fakeGenerator{}}
+	_, _, err := s.generateQuestions(context.Background(), models.GenerateBatchRequest{Section: models.SectionLR})
+	if err == nil {
+		t.Error("expected error when lr_subtype is missing")
+	}
+}
+
+func TestValidationCostUsesValidatorModel(t *testing.T) {
+	gen := &fakeGenerator{}
+	val := &fakeValidator{model: "claude-haiku-4-5"}
+	s := &Service{generator: gen, validator: val}
+
+	if val.ModelName() == gen.ModelName() {
+		t.Fatal("expected validator and generator to use different models")
+	}
+
+	scored := &scoredBatch{validationPromptTokens: 1_000_000, validationOutputTokens: 1_000_000}
+	// Haiku: $1 in + $5 out per MTok = 600 cents. Opus would be 3000.
+	if got := s.validationCostCents(scored); got != 600 {
+		t.Errorf("validation cost = %d cents, want 600", got)
+	}
+	if got := scored.validationTokens(); got != 2_000_000 {
+		t.Errorf("validation tokens = %d, want 2000000", got)
+	}
+}
+
+func TestPreviewBatch_DoesNotPersist(t *testing.T) {
+	gen := &fakeGenerator{batch: &generator.GeneratedBatch{
+		Questions:     []generator.GeneratedQuestion{fakeQuestion("A"), fakeQuestion("B")},
+		PromptVersion: "test",
+	}}
+	val := &fakeValidator{selected: []string{"A", "C"}, confidence: []string{"high", "high"}}
+	// A nil store panics on any write, so a successful preview proves nothing was persisted.
+	s := &Service{store: nil, generator: gen, validator: val, validationEnabled: true}
+
+	subtype := models.SubtypeWeaken
+	req := models.GeneratePreviewRequest{
+		GenerateBatchRequest: models.GenerateBatchRequest{
+			Section:    models.SectionLR,
+			LRSubtype:  &subtype,
+			Difficulty: models.DifficultyEasy,
+		},
+		Validate: true,
+	}
+
+	resp, err := s.PreviewBatch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Questions) != 2 {
+		t.Fatalf("expected 2 preview questions, got %d", len(resp.Questions))
+	}
+	if resp.Questions[0].ValidationStatus != string(models.ValidationPassed) {
+		t.Errorf("expected first question passed, got %q", resp.Questions[0].ValidationStatus)
+	}
+	// Rejected questions are still returned so prompt engineers can inspect them
+	if resp.Questions[1].ValidationStatus != string(models.ValidationRejected) {
+		t.Errorf("expected second question rejected, got %q", resp.Questions[1].ValidationStatus)
+	}
+	if resp.Questions[0].QualityScore == nil {
+		t.Error("expected quality score on preview question")
+	}
+	if len(resp.Questions[0].Choices) != 5 || !resp.Questions[0].Choices[0].IsCorrect {
+		t.Error("expected choices with correct answer marked")
+	}
+}
+
+func TestPreviewBatch_SkipsValidationUnlessRequested(t *testing.T) {
+	gen := &fakeGenerator{batch: &generator.GeneratedBatch{
+		Questions: []generator.GeneratedQuestion{fakeQuestion("A")},
+	}}
+	val := &fakeValidator{selected: []string{"B"}, confidence: []string{"high"}}
+	s := &Service{generator: gen, validator: val, validationEnabled: true, adversarialEnabled: true}
+
+	subtype := models.SubtypeFlaw
+	resp, err := s.PreviewBatch(context.Background(), models.GeneratePreviewRequest{
+		GenerateBatchRequest: models.GenerateBatchRequest{
+			Section: models.SectionLR, LRSubtype: &subtype, Difficulty: models.DifficultyHard,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ValidationTokens != 0 {
+		t.Errorf("expected no validation tokens, got %d", resp.ValidationTokens)
+	}
+	if resp.Questions[0].ValidationStatus != "unvalidated" {
+		t.Errorf("expected unvalidated status, got %q", resp.Questions[0].ValidationStatus)
+	}
+}
+
+func TestScoreBatch_FlagsMissingRequiredTrap(t *testing.T) {
+	q := fakeQuestion("A")
+	for i := range q.Choices {
+		if q.Choices[i].WrongAnswerType != nil && *q.Choices[i].WrongAnswerType == "weakener" {
+			irrelevant := "irrelevant"
+			q.Choices[i].WrongAnswerType = &irrelevant
+		}
+	}
+	genBatch := &generator.GeneratedBatch{Questions: []generator.GeneratedQuestion{q}}
+	val := &fakeValidator{selected: []string{"A"}, confidence: []string{"high"}}
+	s := &Service{validator: val, validationEnabled: true, quality: generator.DefaultQualityThresholds}
+
+	subtype := models.SubtypeStrengthen
+	req := models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &subtype, Difficulty: models.DifficultyEasy}
+
+	scored := s.scoreBatch(context.Background(), req, 1, genBatch, true)
+	if scored.flagged != 1 {
+		t.Errorf("expected strengthen question without weakener to be flagged, got passed=%d flagged=%d", scored.passed, scored.flagged)
+	}
+	if !scored.opts[0].Flagged {
+		t.Error("expected Flagged option set")
+	}
+}
+
+func TestScoreBatch_RejectsMetaChoice(t *testing.T) {
+	q := fakeQuestion("A")
+	q.Choices[4].Text = "Both (B) and (C), taken together."
+	genBatch := &generator.GeneratedBatch{Questions: []generator.GeneratedQuestion{q, fakeQuestion("B")}}
+	val := &fakeValidator{selected: []string{"A", "B"}, confidence: []string{"high", "high"}}
+	s := &Service{validator: val, validationEnabled: true, quality: generator.DefaultQualityThresholds}
+
+	subtype := models.SubtypeStrengthen
+	req := models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &subtype, Difficulty: models.DifficultyEasy}
+
+	scored := s.scoreBatch(context.Background(), req, 1, genBatch, true)
+	if scored.opts[0].ValidationStatus != string(models.ValidationRejected) {
+		t.Errorf("expected meta-choice question rejected, got %q", scored.opts[0].ValidationStatus)
+	}
+	if scored.rejected != 1 || scored.passed != 1 {
+		t.Errorf("expected 1 rejected and 1 passed, got rejected=%d passed=%d", scored.rejected, scored.passed)
+	}
+}
+
+func TestBuildQuestionProvenance_RCQuestion(t *testing.T) {
+	passageID := int64(7)
+	questionID := int64(42)
+	batchID := int64(3)
+	score := 0.82
+	adversarial := "clean"
+	matches := true
+
+	gq := fakeQuestion("C")
+	q := &models.Question{
+		ID:               questionID,
+		BatchID:          batchID,
+		Section:          models.SectionRC,
+		QuestionStem:     gq.QuestionStem,
+		CorrectAnswerID:  "C",
+		Explanation:      gq.Explanation,
+		PassageID:        &passageID,
+		QualityScore:     &score,
+		AdversarialScore: &adversarial,
+	}
+	for _, c := range gq.Choices {
+		ac := models.AnswerChoice{ChoiceID: c.ID, ChoiceText: c.Text, Explanation: c.Explanation, IsCorrect: c.ID == "C"}
+		if c.WrongAnswerType != nil {
+			ac.WrongAnswerType = *c.WrongAnswerType
+		}
+		q.Choices = append(q.Choices, ac)
+	}
+	passage := &models.RCPassage{ID: passageID, Title: "Tidal Energy", Content: "Passage content."}
+	logs := []models.ValidationLog{
+		{BatchID: &batchID, Stage: "verification", Matches: &matches, Confidence: "high"},
+		{BatchID: &batchID, Stage: "adversarial"},
+	}
+
+	p := buildQuestionProvenance(q, passage, logs)
+	if p.Question == nil || p.Question.ID != questionID || len(p.Question.Choices) != 5 {
+		t.Fatalf("expected question %d with 5 choices, got %+v", questionID, p.Question)
+	}
+	if p.Passage == nil || p.Passage.ID != passageID {
+		t.Errorf("expected passage %d, got %+v", passageID, p.Passage)
+	}
+	if p.QualityScores.QualityScore == nil || *p.QualityScores.QualityScore != score {
+		t.Errorf("expected quality score %.2f, got %v", score, p.QualityScores.QualityScore)
+	}
+	if p.QualityScores.AdversarialScore == nil || *p.QualityScores.AdversarialScore != adversarial {
+		t.Errorf("expected adversarial score %q, got %v", adversarial, p.QualityScores.AdversarialScore)
+	}
+	// RC questions skip the stimulus length check
+	if !p.QualityScores.StimulusLengthOK || !p.QualityScores.AllChoicesInRange || !p.QualityScores.AllExplanationsPresent {
+		t.Errorf("expected structural checks to pass, got %+v", p.QualityScores)
+	}
+	if len(p.ValidationLogs) != 2 {
+		t.Errorf("expected 2 validation logs, got %d", len(p.ValidationLogs))
+	}
+}
+
+func TestBuildQuestionProvenance_UsesStoredLengthChecks(t *testing.T) {
+	gq := fakeQuestion("A")
+	balance := 0.31
+	q := &models.Question{Section: models.SectionLR, CorrectAnswerID: "A", LengthBalance: &balance, LengthOutlier: true}
+	for _, c := range gq.Choices {
+		q.Choices = append(q.Choices, models.AnswerChoice{ChoiceID: c.ID, ChoiceText: c.Text, Explanation: c.Explanation, IsCorrect: c.ID == "A"})
+	}
+
+	p := buildQuestionProvenance(q, nil, nil)
+	if p.QualityScores.LengthBalance != balance || !p.QualityScores.CorrectLengthOutlier {
+		t.Errorf("expected stored balance %.2f and outlier, got %.2f, %v", balance, p.QualityScores.LengthBalance, p.QualityScores.CorrectLengthOutlier)
+	}
+
+	q.LengthBalance, q.LengthOutlier = nil, false
+	p = buildQuestionProvenance(q, nil, nil)
+	if p.QualityScores.LengthBalance == balance {
+		t.Error("expected the balance to be recomputed when none was stored")
+	}
+}
+
+// fakeAnswerTx buffers writes and applies them to the shared state only on
+// Commit. failOn names a step that returns an error.
+type fakeAnswerTx struct {
+	state   *fakeAnswerState
+	pending fakeAnswerState
+	failOn  string
+	start   map[models.AbilityScope]int // ability before the answer; default 50
+}
+
+type fakeAnswerState struct {
+	served, correct, history int
+	abilities                map[string]int
+	snapshots                map[string]int // "scope/value/day" -> score
+	sources                  []models.AnswerSource
+}
+
+func (f *fakeAnswerTx) step(name string) error {
+	if f.failOn == name {
+		return fmt.Errorf("injected %s failure", name)
+	}
+	return nil
+}
+
+func (f *fakeAnswerTx) IncrementServed(questionID int64) error {
+	f.pending.served++
+	return f.step("served")
+}
+
+func (f *fakeAnswerTx) IncrementCorrect(questionID int64) error {
+	f.pending.correct++
+	return f.step("correct")
+}
+
+func (f *fakeAnswerTx) RecordAnswer(userID, questionID int64, correct bool, selectedChoiceID *string, timeSpentSeconds *float64, source models.AnswerSource) error {
+	if err := f.step("history"); err != nil {
+		return err
+	}
+	f.pending.history++
+	f.pending.sources = append(f.pending.sources, source)
+	return nil
+}
+
+func (f *fakeAnswerTx) GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
+	score, ok := f.start[scope]
+	if !ok {
+		score = 50
+	}
+	return &models.UserAbilityScore{AbilityScore: score}, nil
+}
+
+func (f *fakeAnswerTx) UpdateAbility(userID int64, scope models.AbilityScope, scopeValue *string, newScore int, correct bool) error {
+	if f.pending.abilities == nil {
+		f.pending.abilities = map[string]int{}
+	}
+	f.pending.abilities[string(scope)] = newScore
+	return f.step("ability")
+}
+
+func (f *fakeAnswerTx) SnapshotAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int, day time.Time) error {
+	if f.pending.snapshots == nil {
+		f.pending.snapshots = map[string]int{}
+	}
+	value := ""
+	if scopeValue != nil {
+		value = *scopeValue
+	}
+	f.pending.snapshots[fmt.Sprintf("%s/%s/%s", scope, value, day.Format("2006-01-02"))] = score
+	return nil
+}
+
+func (f *fakeAnswerTx) Commit() error {
+	*f.state = f.pending
+	return nil
+}
+
+func (f *fakeAnswerTx) Rollback() error {
+	f.pending = fakeAnswerState{}
+	return nil
+}
+
+func TestRecordAnswerCore_NoPartialCommit(t *testing.T) {
+	flaw := models.SubtypeFlaw
+	q := &models.Question{ID: 9, Section: models.SectionLR, LRSubtype: &flaw, DifficultyScore: 50, CorrectAnswerID: "B"}
+	choice := "B"
+
+	for _, step := range []string{"history", "ability"} {
+		state := &fakeAnswerState{}
+		tx := &fakeAnswerTx{state: state, failOn: step}
+		if _, err := recordAnswerCore(tx, 1, q, true, &choice, nil, ""); err == nil {
+			t.Errorf("%s failure: expected an error", step)
+		}
+		if state.served != 0 || state.correct != 0 || state.history != 0 || len(state.abilities) != 0 {
+			t.Errorf("%s failure after IncrementServed left partial state: %+v", step, *state)
+		}
+	}
+
+	state := &fakeAnswerState{}
+	snapshot, err := recordAnswerCore(&fakeAnswerTx{state: state}, 1, q, true, &choice, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.served != 1 || state.correct != 1 || state.history != 1 || len(state.abilities) != 3 {
+		t.Errorf("committed state = %+v, want every step applied", *state)
+	}
+	if snapshot.SubtypeAbility == nil || *snapshot.SubtypeAbility <= 50 {
+		t.Errorf("correct answer should raise ability; got %+v", snapshot)
+	}
+}
+
+func TestRecordAnswerCore_SnapshotsAbilityDaily(t *testing.T) {
+	flaw := models.SubtypeFlaw
+	q := &models.Question{ID: 9, Section: models.SectionLR, LRSubtype: &flaw, DifficultyScore: 50, CorrectAnswerID: "B"}
+	choice := "B"
+
+	state := &fakeAnswerState{}
+	var last *models.AbilitySnapshot
+	for i := 0; i < 3; i++ {
+		// Carry state forward so the fake upserts like the table does
+		tx := &fakeAnswerTx{state: state, pending: *state}
+		snapshot, err := recordAnswerCore(tx, 1, q, true, &choice, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = snapshot
+	}
+
+	if len(state.snapshots) != 3 {
+		t.Fatalf("snapshots = %v, want one per scope for the day", state.snapshots)
+	}
+	day := time.Now().UTC().Format("2006-01-02")
+	if got := state.snapshots["overall//"+day]; got != last.OverallAbility {
+		t.Errorf("overall snapshot = %d, want latest score %d", got, last.OverallAbility)
+	}
+	if got := state.snapshots["subtype/flaw/"+day]; got != *last.SubtypeAbility {
+		t.Errorf("subtype snapshot = %d, want latest score %d", got, *last.SubtypeAbility)
+	}
+}
+
+func TestGetAbilityHistory_ValidatesScope(t *testing.T) {
+	s := &Service{}
+	if _, err := s.GetAbilityHistory(1, "weekly", nil); err == nil || err.Error() != "invalid scope" {
+		t.Errorf("unknown scope: err = %v, want invalid scope", err)
+	}
+	if _, err := s.GetAbilityHistory(1, models.ScopeSubtype, nil); err == nil || err.Error() != "scope value is required" {
+		t.Errorf("subtype without value: err = %v, want scope value is required", err)
+	}
+}
+
+func TestAbilitySnapshot_NoSubtypeUsesSectionAbility(t *testing.T) {
+	q := &models.Question{ID: 4, Section: models.SectionLR, DifficultyScore: 60, CorrectAnswerID: "A"}
+	choice := "A"
+	tx := &fakeAnswerTx{
+		state: &fakeAnswerState{},
+		start: map[models.AbilityScope]int{models.ScopeOverall: 85, models.ScopeSection: 30},
+	}
+
+	snapshot, err := recordAnswerCore(tx, 1, q, true, &choice, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.SubtypeAbility != nil {
+		t.Errorf("subtype-less question has subtype ability %d, want nil", *snapshot.SubtypeAbility)
+	}
+	if _, ok := tx.state.abilities[string(models.ScopeSubtype)]; ok {
+		t.Error("subtype ability updated for a subtype-less question")
+	}
+	if got := snapshot.XPAbility(); got != snapshot.SectionAbility {
+		t.Errorf("XP ability = %d, want section ability %d", got, snapshot.SectionAbility)
+	}
+
+	xp := gamification.DefaultXPConfig()
+	if got, want := xp.QuestionXP(q.DifficultyScore, snapshot.XPAbility(), 0), xp.QuestionXP(q.DifficultyScore, snapshot.SectionAbility, 0); got != want {
+		t.Errorf("question XP = %d, want %d from section ability", got, want)
+	}
+
+	withSubtype := models.AbilitySnapshot{SectionAbility: 30, SubtypeAbility: new(int)}
+	*withSubtype.SubtypeAbility = 70
+	if withSubtype.XPAbility() != 70 {
+		t.Errorf("XP ability = %d, want subtype ability 70", withSubtype.XPAbility())
+	}
+}
+
+// fakeBookmarkStore holds one user's bookmarked question IDs.
+type fakeBookmarkStore struct {
+	bookmarked map[int64]bool
+	queried    [][]int64
+}
+
+func (f *fakeBookmarkStore) FilterBookmarked(userID int64, questionIDs []int64) ([]int64, error) {
+	f.queried = append(f.queried, questionIDs)
+	var ids []int64
+	for _, id := range questionIDs {
+		if f.bookmarked[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func TestCheckBookmarks_ReturnsBookmarkedSubset(t *testing.T) {
+	st := &fakeBookmarkStore{bookmarked: map[int64]bool{3: true, 8: true, 42: true}}
+
+	resp, err := checkBookmarks(st, 1, []int64{8, 5, 3, 8, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(resp.Bookmarked) != "[8 3]" {
+		t.Errorf("bookmarked = %v, want [8 3]", resp.Bookmarked)
+	}
+	if len(st.queried) != 1 || len(st.queried[0]) != 4 {
+		t.Errorf("queries = %v, want one query with 4 unique IDs", st.queried)
+	}
+
+	resp, err = checkBookmarks(st, 1, []int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Bookmarked == nil || len(resp.Bookmarked) != 0 {
+		t.Errorf("bookmarked = %#v, want empty list", resp.Bookmarked)
+	}
+}
+
+// fakeRetryStore holds one user's answer history and the question bank.
+type fakeRetryStore struct {
+	history map[int64]bool // question ID -> last answer correct
+}
+
+func (f *fakeRetryStore) GetMissedQuestionIDs(userID int64, questionIDs []int64) ([]int64, error) {
+	var ids []int64
+	for _, id := range questionIDs {
+		if correct, answered := f.history[id]; answered && !correct {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeRetryStore) GetDrillQuestionsByIDs(questionIDs []int64) ([]models.DrillQuestion, error) {
+	var qs []models.DrillQuestion
+	for _, id := range questionIDs {
+		qs = append(qs, models.DrillQuestion{ID: id, Choices: []models.DrillChoice{{ChoiceID: "A"}}})
+	}
+	return qs, nil
+}
+
+func TestRetryMistakesDrill_OnlyMissedQuestions(t *testing.T) {
+	st := &fakeRetryStore{history: map[int64]bool{
+		11: true, 12: false, 13: true, 14: false, 15: false, 16: true,
+	}}
+
+	// 99 was never answered by this user, so it isn't theirs to retry
+	questions, err := retryMistakesDrill(st, 1, []int64{15, 11, 12, 13, 14, 16, 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, q := range questions {
+		got = append(got, q.ID)
+	}
+	if fmt.Sprint(got) != "[15 12 14]" {
+		t.Errorf("retry drill = %v, want missed questions [15 12 14] in drill order", got)
+	}
+
+	questions, err = retryMistakesDrill(st, 1, []int64{11, 13})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if questions == nil || len(questions) != 0 {
+		t.Errorf("perfect drill retry = %#v, want empty list", questions)
+	}
+}
+
+// fakeRCInventoryStore records RC generation queue inserts.
+type fakeRCInventoryStore struct {
+	passages map[int]int // bucket min -> passages
+	queued   map[int]int // bucket min -> questions_needed
+	areas    map[string]int
+
+	comparative, total int
+	comparativeQueued  map[int]bool // bucket min -> is_comparative
+}
+
+func (f *fakeRCInventoryStore) CountRCPassagesInBucket(minDiff, maxDiff int) int {
+	return f.passages[minDiff]
+}
+
+func (f *fakeRCInventoryStore) UpsertRCGenerationQueue(minDiff, maxDiff int, targetDiff string, subjectArea string, isComparative bool, questionsNeeded int) error {
+	f.queued[minDiff] = questionsNeeded
+	if f.comparativeQueued != nil {
+		f.comparativeQueued[minDiff] = isComparative
+	}
+	return nil
+}
+
+func (f *fakeRCInventoryStore) GetSubjectAreaCounts() (map[string]int, error) {
+	return f.areas, nil
+}
+
+func (f *fakeRCInventoryStore) GetComparativeRatio() (int, int) {
+	return f.comparative, f.total
+}
+
+func TestRCQuestionsPerPassage_PromptAndQueueAgree(t *testing.T) {
+	s := &Service{generator: &fakeGenerator{batch: &generator.GeneratedBatch{}}, rcPerPassage: 7}
+
+	if _, err := s.PreviewBatch(context.Background(), models.GeneratePreviewRequest{
+		GenerateBatchRequest: models.GenerateBatchRequest{Section: models.SectionRC, Difficulty: models.DifficultyMedium},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	gen := s.generator.(*fakeGenerator)
+	if gen.lastCount != 7 {
+		t.Errorf("generator asked for %d questions per passage, want 7", gen.lastCount)
+	}
+	if prompt := generator.BuildRCUserPrompt(models.DifficultyMedium, gen.lastCount, "", false); !strings.Contains(prompt, "passage with 7 questions") {
+		t.Error("RC prompt does not request the configured 7 questions")
+	}
+
+	st := &fakeRCInventoryStore{passages: map[int]int{41: 5}, queued: map[int]int{}}
+	checkRCInventory(st, 30, 70, s.questionsPerPassage(), defaultComparativeTarget, defaultComparativeMin)
+	if st.queued[21] != 7 || st.queued[61] != 7 {
+		t.Errorf("queued = %v, want buckets 21 and 61 with 7 questions each", st.queued)
+	}
+	if _, ok := st.queued[41]; ok {
+		t.Error("stocked bucket 41-60 should not be queued")
+	}
+}
+
+func TestNextRCSubjectArea_PicksUnderrepresentedArea(t *testing.T) {
+	st := &fakeRCInventoryStore{areas: map[string]int{
+		"law": 12, "natural_science": 9, "social_science": 2, "humanities": 10,
+	}}
+	if got := nextRCSubjectArea(st); got != "social_science" {
+		t.Errorf("next area = %q, want underrepresented social_science", got)
+	}
+
+	// An area with no passages at all goes first; ties keep rotation order
+	st.areas = map[string]int{"law": 3, "natural_science": 3}
+	if got := nextRCSubjectArea(st); got != "social_science" {
+		t.Errorf("next area = %q, want empty social_science", got)
+	}
+	st.areas = map[string]int{}
+	if got := nextRCSubjectArea(st); got != "law" {
+		t.Errorf("next area for empty library = %q, want law", got)
+	}
+}
+
+func TestCheckRCInventory_ConfiguredComparativeTarget(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "mock")
+	t.Setenv("RC_COMPARATIVE_TARGET", "0.5")
+	t.Setenv("RC_COMPARATIVE_MIN", "10")
+	s := NewService(nil, nil, nil)
+	if s.comparativeTarget != 0.5 || s.comparativeMin != 10 {
+		t.Fatalf("comparative config = %.2f/%d, want 0.5/10", s.comparativeTarget, s.comparativeMin)
+	}
+
+	queue := func(comparative, total int) bool {
+		st := &fakeRCInventoryStore{
+			passages: map[int]int{}, queued: map[int]int{}, comparativeQueued: map[int]bool{},
+			comparative: comparative, total: total,
+		}
+		checkRCInventory(st, 41, 60, 6, s.comparativeTarget, s.comparativeMin)
+		return st.comparativeQueued[41]
+	}
+	// 4 of 12 is above the default 25% but below the configured 50%
+	if !queue(4, 12) {
+		t.Error("4/12 comparative should queue a comparative passage at a 50% target")
+	}
+	if queue(7, 12) {
+		t.Error("7/12 comparative is above the 50% target, should queue a single passage")
+	}
+	if queue(0, 8) {
+		t.Error("8 passages is under the configured minimum of 10, should not force comparative")
+	}
+}
+
+// fakeExplanationStore holds one stored question.
+type fakeExplanationStore struct {
+	q *models.Question
+}
+
+func (f *fakeExplanationStore) GetQuestionWithChoices(questionID int64) (*models.Question, error) {
+	cp := *f.q
+	cp.Choices = append([]models.AnswerChoice(nil), f.q.Choices...)
+	return &cp, nil
+}
+
+func (f *fakeExplanationStore) GetPassage(passageID int64) (*models.RCPassage, error) {
+	return nil, fmt.Errorf("no passage %d", passageID)
+}
+
+func (f *fakeExplanationStore) UpdateQuestionExplanations(questionID int64, explanation string, choiceExplanations map[string]string) error {
+	f.q.Explanation = explanation
+	for i := range f.q.Choices {
+		if text, ok := choiceExplanations[f.q.Choices[i].ChoiceID]; ok {
+			f.q.Choices[i].Explanation = text
+		}
+	}
+	return nil
+}
+
+func TestRegenerateExplanations_KeepsAnswerAndChoices(t *testing.T) {
+	q := &models.Question{ID: 4, Section: models.SectionLR, Stimulus: "Stimulus", QuestionStem: "Stem", CorrectAnswerID: "C", Explanation: "Weak.", Language: "es"}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		q.Choices = append(q.Choices, models.AnswerChoice{ChoiceID: id, ChoiceText: "Choice " + id, Explanation: "Weak " + id, IsCorrect: id == "C"})
+	}
+	st := &fakeExplanationStore{q: q}
+
+	gen := &fakeGenerator{}
+	got, err := regenerateExplanations(context.Background(), gen, st, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gen.lastLanguage != "es" {
+		t.Errorf("rewrite language = %q, want the question's es", gen.lastLanguage)
+	}
+
+	for _, stored := range []*models.Question{got, st.q} {
+		if stored.Explanation != "Rewritten: C is right." {
+			t.Errorf("explanation = %q, want rewritten", stored.Explanation)
+		}
+		if stored.CorrectAnswerID != "C" || stored.Stimulus != "Stimulus" || stored.QuestionStem != "Stem" {
+			t.Errorf("question text or answer changed: %+v", stored)
+		}
+		for _, c := range stored.Choices {
+			if c.Explanation != "Rewritten "+c.ChoiceID {
+				t.Errorf("choice %s explanation = %q, want rewritten", c.ChoiceID, c.Explanation)
+			}
+			if c.ChoiceText != "Choice "+c.ChoiceID || c.IsCorrect != (c.ChoiceID == "C") {
+				t.Errorf("choice %s changed: %+v", c.ChoiceID, c)
+			}
+		}
+	}
+}
+
+func TestGenerateQuestions_PassesLanguage(t *testing.T) {
+	gen := &fakeGenerator{batch: &generator.GeneratedBatch{}}
+	s := &Service{generator: gen}
+	sub := models.SubtypeFlaw
+
+	req := models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &sub, Difficulty: models.DifficultyMedium, Count: 3, Language: "es"}
+	if msg := validateGenerateRequest(req); msg != "" {
+		t.Fatalf("valid request rejected: %s", msg)
+	}
+	if _, _, err := s.generateQuestions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if gen.lastLanguage != "es" {
+		t.Errorf("generator language = %q, want es", gen.lastLanguage)
+	}
+
+	req.Language = "xx"
+	if validateGenerateRequest(req) == "" {
+		t.Error("unsupported language should be rejected")
+	}
+}
+
+func TestNewService_PerSectionMinUnseen(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "mock")
+	t.Setenv("AUTO_GEN_MIN_UNSEEN", "5")
+	t.Setenv("AUTO_GEN_MIN_UNSEEN_RC", "12")
+	t.Setenv("AUTO_GEN_MIN_UNSEEN_LR", "")
+
+	s := NewService(nil, nil, nil)
+	if got := s.minUnseen(string(models.SectionRC)); got != 12 {
+		t.Errorf("RC threshold = %d, want 12", got)
+	}
+	if got := s.minUnseen(string(models.SectionLR)); got != 5 {
+		t.Errorf("LR threshold = %d, want AUTO_GEN_MIN_UNSEEN fallback 5", got)
+	}
+
+	t.Setenv("AUTO_GEN_MIN_UNSEEN_LR", "3")
+	s = NewService(nil, nil, nil)
+	if got := s.minUnseen(string(models.SectionLR)); got != 3 {
+		t.Errorf("LR threshold = %d, want 3", got)
+	}
+	if got := s.minUnseen(string(models.SectionRC)); got != 12 {
+		t.Errorf("RC threshold = %d, want 12", got)
+	}
+}
+
+func TestGenerationWorker_ConfiguredIntervalProcessesSooner(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "mock")
+	t.Setenv("GEN_WORKER_INTERVAL", "")
+	if got := NewService(nil, nil, nil).genWorkerInterval; got != 30*time.Second {
+		t.Errorf("default interval = %s, want 30s", got)
+	}
+	t.Setenv("GEN_WORKER_INTERVAL", "10ms")
+	s := NewService(nil, nil, nil)
+	if s.genWorkerInterval != 10*time.Millisecond {
+		t.Fatalf("interval = %s, want 10ms", s.genWorkerInterval)
+	}
+
+	// Count queue passes in a fixed window at each interval
+	passes := func(interval time.Duration) int {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		n := 0
+		runEvery(ctx, interval, func() { n++ })
+		return n
+	}
+	if fast, slow := passes(s.genWorkerInterval), passes(30*time.Second); fast < 3 || slow != 0 {
+		t.Errorf("passes in 200ms: %d at 10ms, %d at 30s; want several vs none", fast, slow)
+	}
+}
+
+func TestGenerateForScoreRange_StoresHighDifficultyScore(t *testing.T) {
+	gen := &fakeGenerator{batch: &generator.GeneratedBatch{}}
+	s := &Service{generator: gen}
+	sub := models.SubtypeFlaw
+	target := &models.ScoreRange{Min: 81, Max: 100}
+
+	req := models.GenerateBatchRequest{Section: models.SectionLR, LRSubtype: &sub, Count: 3, DifficultyScore: target}
+	if msg := validateGenerateRequest(req); msg != "" {
+		t.Fatalf("score-range request without a band rejected: %s", msg)
+	}
+	fillDifficultyBand(&req)
+	if req.Difficulty != models.DifficultyHard {
+		t.Errorf("band for 81-100 = %q, want hard", req.Difficulty)
+	}
+	if _, _, err := s.generateQuestions(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if gen.lastTarget != target {
+		t.Errorf("generator target = %v, want %v", gen.lastTarget, target)
+	}
+
+	for i := 0; i < 200; i++ {
+		if got := difficultyScoreFor(req); got < 81 || got > 100 {
+			t.Fatalf("stored difficulty_score = %d, want 81-100", got)
+		}
+	}
+
+	req.DifficultyScore = &models.ScoreRange{Min: 90, Max: 80}
+	if validateGenerateRequest(req) == "" {
+		t.Error("inverted score range should be rejected")
+	}
+}
+
+// fakeBatchGetStore holds questions and passages by ID.
+type fakeBatchGetStore struct {
+	questions map[int64]models.Question
+	passages  map[int64]models.DrillPassage
+}
+
+func (f *fakeBatchGetStore) GetQuestionsByIDs(questionIDs []int64) ([]models.Question, error) {
+	var out []models.Question
+	for _, id := range questionIDs {
+		if q, ok := f.questions[id]; ok {
+			out = append(out, q)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBatchGetStore) GetPassagesByIDs(passageIDs []int64) (map[int64]models.DrillPassage, error) {
+	out := make(map[int64]models.DrillPassage)
+	for _, id := range passageIDs {
+		if p, ok := f.passages[id]; ok {
+			out[id] = p
+		}
+	}
+	return out, nil
+}
+
+func TestBatchGetQuestions_ReturnsRequestedAndReportsUnknown(t *testing.T) {
+	passageID := int64(7)
+	st := &fakeBatchGetStore{
+		questions: map[int64]models.Question{
+			1: {ID: 1, Section: models.SectionLR},
+			2: {ID: 2, Section: models.SectionRC, PassageID: &passageID},
+			3: {ID: 3, Section: models.SectionRC, PassageID: &passageID},
+		},
+		passages: map[int64]models.DrillPassage{7: {ID: 7, Title: "Tides"}},
+	}
+
+	resp, err := batchGetQuestions(st, []int64{3, 99, 1, 2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, q := range resp.Questions {
+		got = append(got, q.ID)
+	}
+	if fmt.Sprint(got) != "[3 1 2]" {
+		t.Errorf("questions = %v, want [3 1 2] in request order", got)
+	}
+	if fmt.Sprint(resp.NotFound) != "[99]" {
+		t.Errorf("not_found = %v, want [99]", resp.NotFound)
+	}
+	if len(resp.Passages) != 1 || resp.Passages[0].ID != 7 {
+		t.Errorf("passages = %+v, want passage 7 once", resp.Passages)
+	}
+
+	tooMany := make([]int64, maxBatchGetIDs+1)
+	for i := range tooMany {
+		tooMany[i] = int64(i + 1)
+	}
+	if _, err := batchGetQuestions(st, tooMany); err == nil {
+		t.Errorf("%d ids should be rejected", len(tooMany))
+	}
+	if _, err := batchGetQuestions(st, nil); err == nil {
+		t.Error("no ids should be rejected")
+	}
+}
+
+// fakeQuickDrillStore serves the first matching question per subtype,
+// optionally restricted to one passage.
+type fakeQuickDrillStore struct {
+	questions []models.DrillQuestion
+}
+
+func (f *fakeQuickDrillStore) pick(section, subtype string, passageID int64) *models.DrillQuestion {
+	for i := range f.questions {
+		q := &f.questions[i]
+		if string(q.Section) != section {
+			continue
+		}
+		if q.LRSubtype != nil && string(*q.LRSubtype) != subtype || q.RCSubtype != nil && string(*q.RCSubtype) != subtype {
+			continue
+		}
+		if passageID != 0 && (q.Passage == nil || q.Passage.ID != passageID) {
+			continue
+		}
+		return q
+	}
+	return nil
+}
+
+func (f *fakeQuickDrillStore) GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int, excludeIDs []int64) (*models.DrillQuestion, error) {
+	return f.pick(section, subtype, 0), nil
+}
+
+func (f *fakeQuickDrillStore) GetOneAdaptiveQuestionFromPassage(userID int64, subtype string, passageID int64, minDiff, maxDiff int) (*models.DrillQuestion, error) {
+	return f.pick(string(models.SectionRC), subtype, passageID), nil
+}
+
+func TestPickQuickDrillQuestions_RCSharesOnePassage(t *testing.T) {
+	rc := func(id int64, subtype models.RCSubtype, passageID int64) models.DrillQuestion {
+		return models.DrillQuestion{ID: id, Section: models.SectionRC, RCSubtype: &subtype, Passage: &models.DrillPassage{ID: passageID}}
+	}
+	// Each subtype's first match lives in a different passage, but passage
+	// 10 has questions for every subtype
+	st := &fakeQuickDrillStore{questions: []models.DrillQuestion{
+		rc(1, models.RCSubtypeMainIdea, 10),
+		rc(2, models.RCSubtypeDetail, 20),
+		rc(3, models.RCSubtypeInference, 30),
+		rc(4, models.RCSubtypeDetail, 10),
+		rc(5, models.RCSubtypeInference, 10),
+		lrQuestion(6, models.SubtypeFlaw, 50),
+	}}
+
+	subtypes := []string{"rc_main_idea", "flaw", "rc_detail", "rc_inference"}
+	questions := pickQuickDrillQuestions(st, 1, subtypes, 6, [2]int{35, 65}, [2]int{15, 85})
+	if len(questions) != 4 {
+		t.Fatalf("picked %d questions, want 4", len(questions))
+	}
+	for _, q := range questions {
+		if q.Section == models.SectionRC && q.Passage.ID != 10 {
+			t.Errorf("RC question %d is from passage %d, want every RC question from passage 10", q.ID, q.Passage.ID)
+		}
+	}
+}
+
+func TestEmptyDrillError_SignalsRetryOnlyWhenGenerating(t *testing.T) {
+	s := &Service{autoGenEnabledLR: true, autoGenMinUnseenLR: 4, genWorkerInterval: 10 * time.Second}
+	lr := string(models.SectionLR)
+
+	// A thin pool queues generation, so the client should retry
+	st := &fakeWeakAreaStore{inBucket: 2, unseen: 20, ability: 50}
+	err := s.emptyDrillError(st, 1, lr, "flaw", 35, 65)
+	if err == nil || err.Error() != "generation in progress" || len(st.queued) == 0 {
+		t.Fatalf("empty drill with a thin pool = %v, queued %v; want generation in progress", err, st.queued)
+	}
+	if got := s.GenerationRetryAfter(); got != 10*time.Second {
+		t.Errorf("retry after = %s, want one worker interval (10s)", got)
+	}
+
+	// The user has run out even though the pool is full
+	st = &fakeWeakAreaStore{inBucket: 20, unseen: 0, ability: 50}
+	if err := s.emptyDrillError(st, 1, lr, "flaw", 35, 65); err == nil || err.Error() != "generation in progress" {
+		t.Errorf("empty drill for an exhausted user = %v, want generation in progress", err)
+	}
+
+	// Nothing needed generating, so there is nothing to wait for
+	st = &fakeWeakAreaStore{inBucket: 20, unseen: 20, ability: 50}
+	if err := s.emptyDrillError(st, 1, lr, "flaw", 35, 65); err == nil || err.Error() != "no questions available" || len(st.queued) > 0 {
+		t.Errorf("empty drill with nothing queued = %v, queued %v; want no questions available", err, st.queued)
+	}
+
+	// RC auto-generation is off
+	st = &fakeWeakAreaStore{inBucket: 0, unseen: 0}
+	if err := s.emptyDrillError(st, 1, string(models.SectionRC), "rc_main_idea", 35, 65); err == nil || err.Error() != "no questions available" || len(st.queued) > 0 {
+		t.Errorf("empty RC drill without auto-generation = %v, queued %v; want no questions available", err, st.queued)
+	}
+
+	if got := (&Service{}).GenerationRetryAfter(); got != 30*time.Second {
+		t.Errorf("default retry after = %s, want 30s", got)
+	}
+}
+
+func TestRCQuickDrillQuestions_EveryQuestionHasPassage(t *testing.T) {
+	passage := models.DrillPassage{ID: 4, Title: "Coral Reefs", Content: "..."}
+	resp := &models.RCDrillResponse{
+		Passage: passage,
+		Questions: []models.DrillQuestion{
+			{ID: 1, Section: models.SectionRC, Passage: &passage},
+			{ID: 2, Section: models.SectionRC},
+			{ID: 3, Section: models.SectionRC},
+		},
+	}
+
+	questions := rcQuickDrillQuestions(resp)
+	if len(questions) != 3 {
+		t.Fatalf("got %d questions, want 3", len(questions))
+	}
+	for _, q := range questions {
+		if q.Passage == nil || q.Passage.ID != 4 {
+			t.Errorf("question %d passage = %+v, want passage 4", q.ID, q.Passage)
+		}
+	}
+
+	if got := rcQuickDrillQuestions(nil); got == nil || len(got) != 0 {
+		t.Errorf("no drill = %#v, want an empty list", got)
+	}
+}
+
+func TestGradeSelection_RejectsMissingChoice(t *testing.T) {
+	q := &models.Question{CorrectAnswerID: "B"}
+	for _, id := range []string{"A", "B", "C", "D"} {
+		q.Choices = append(q.Choices, models.AnswerChoice{ChoiceID: id})
+	}
+
+	if _, err := gradeSelection(q, []string{"E"}, gradingAllOrNothing); err == nil || err.Error() != "selected choice not found" {
+		t.Errorf("submitting E to a question without E = %v, want selected choice not found", err)
+	}
+	if credit, err := gradeSelection(q, []string{"B"}, gradingAllOrNothing); err != nil || credit != 1 {
+		t.Errorf("B = %v, %v; want correct", credit, err)
+	}
+	if credit, err := gradeSelection(q, []string{"D"}, gradingAllOrNothing); err != nil || credit != 0 {
+		t.Errorf("D = %v, %v; want wrong", credit, err)
+	}
+}
+
+func TestGradeSelection_MultiCorrectCredit(t *testing.T) {
+	// A "select all that apply" question: B and D are correct
+	q := &models.Question{CorrectAnswerID: "B"}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		q.Choices = append(q.Choices, models.AnswerChoice{ChoiceID: id, IsCorrect: id == "B" || id == "D"})
+	}
+
+	cases := []struct {
+		selected []string
+		policy   string
+		want     float64
+	}{
+		{[]string{"D", "B"}, gradingAllOrNothing, 1},
+		{[]string{"D", "B"}, gradingPartial, 1},
+		{[]string{"B"}, gradingAllOrNothing, 0},
+		{[]string{"B"}, gradingPartial, 0.5},
+		// A wrong pick cancels a right one
+		{[]string{"B", "C"}, gradingPartial, 0},
+		{[]string{"B", "D", "E"}, gradingPartial, 0.5},
+		{[]string{"A", "C", "E"}, gradingPartial, 0},
+	}
+	for _, c := range cases {
+		got, err := gradeSelection(q, c.selected, c.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("%v under %s = %v, want %v", c.selected, c.policy, got, c.want)
+		}
+	}
+
+	// Single-answer questions ignore the policy
+	single := &models.Question{CorrectAnswerID: "C"}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		single.Choices = append(single.Choices, models.AnswerChoice{ChoiceID: id, IsCorrect: id == "C"})
+	}
+	if got, _ := gradeSelection(single, []string{"C", "A"}, gradingPartial); got != 0 {
+		t.Errorf("extra pick on a single-answer question earned %v, want 0", got)
+	}
+	if got, _ := gradeSelection(single, []string{"C"}, gradingPartial); got != 1 {
+		t.Errorf("single correct answer earned %v, want 1", got)
+	}
+
+	if key := selectionKey([]string{"D", "B", "D"}); key != "BD" {
+		t.Errorf("selectionKey = %q, want BD", key)
+	}
+}
+
+func TestToDrillQuestion_SelectCountForMultiCorrect(t *testing.T) {
+	q := &models.Question{CorrectAnswerID: "B"}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		q.Choices = append(q.Choices, models.AnswerChoice{ChoiceID: id, IsCorrect: id == "B" || id == "D"})
+	}
+	if dq := q.ToDrillQuestion(); dq.SelectCount != 2 {
+		t.Errorf("select count = %d, want 2 for a question with B and D correct", dq.SelectCount)
+	}
+
+	q.Choices[3].IsCorrect = false
+	if dq := q.ToDrillQuestion(); dq.SelectCount != 0 {
+		t.Errorf("select count = %d, want it omitted for a single-answer question", dq.SelectCount)
+	}
+
+	// Every answer path reads a multi-choice selection the same way
+	daily := models.DailyChallengeAnswerRequest{SelectedChoiceID: "A", SelectedChoiceIDs: []string{"B", "D"}}
+	if got := daily.ChoiceIDs(); len(got) != 2 || got[0] != "B" || got[1] != "D" {
+		t.Errorf("daily challenge selection = %v, want [B D]", got)
+	}
+	if got := (models.DailyChallengeAnswerRequest{SelectedChoiceID: "C"}).ChoiceIDs(); len(got) != 1 || got[0] != "C" {
+		t.Errorf("single daily challenge selection = %v, want [C]", got)
+	}
+}
+
+func TestAutoGenSubtypes_AllowListWithinEnabledSection(t *testing.T) {
+	t.Setenv("AUTO_GEN_SUBTYPES", "flaw, parallel_flaw, not_a_subtype, rc_main_idea")
+	t.Setenv("AUTO_GEN_EXCLUDE_SUBTYPES", "parallel_flaw, rc_inference")
+	s := &Service{
+		autoGenEnabledLR: true,
+		autoGenSubtypes: subtypeFilter{
+			allow: parseSubtypeList("AUTO_GEN_SUBTYPES"),
+			deny:  parseSubtypeList("AUTO_GEN_EXCLUDE_SUBTYPES"),
+		},
+	}
+	lr := string(models.SectionLR)
+
+	if !s.autoGenEnabledFor(lr, "flaw") {
+		t.Error("flaw is allowed and LR is enabled, want auto-gen on")
+	}
+	for _, subtype := range []string{"assumption", "parallel_flaw", "not_a_subtype"} {
+		if s.autoGenEnabledFor(lr, subtype) {
+			t.Errorf("%s isn't allowed, want auto-gen off", subtype)
+		}
+	}
+	// The allow list doesn't override a disabled section
+	if s.autoGenEnabledFor(string(models.SectionRC), string(models.RCSubtypeMainIdea)) {
+		t.Error("RC is disabled, want auto-gen off")
+	}
+
+	// The lists are LR only: RC subtypes in them are dropped, and an LR
+	// allow list doesn't shut off RC
+	if s.autoGenSubtypes.allow["rc_main_idea"] || s.autoGenSubtypes.deny["rc_inference"] {
+		t.Errorf("lists = %s, want RC subtypes ignored", s.autoGenSubtypes)
+	}
+	s.autoGenEnabledRC = true
+	for _, subtype := range []models.RCSubtype{models.RCSubtypeMainIdea, models.RCSubtypeInference} {
+		if !s.autoGenEnabledFor(string(models.SectionRC), string(subtype)) {
+			t.Errorf("RC %s with an LR allow list: want auto-gen on", subtype)
+		}
+	}
+
+	// An empty drill in a disallowed subtype queues nothing and isn't retried
+	st := &fakeWeakAreaStore{inBucket: 0, unseen: 0}
+	if err := s.emptyDrillError(st, 1, lr, "assumption", 0, 100); err == nil || err.Error() != "no questions available" {
+		t.Errorf("empty assumption drill = %v, want no questions available", err)
+	}
+	if len(st.queued) > 0 {
+		t.Errorf("queued %v for a disallowed subtype, want nothing", st.queued)
+	}
+
+	if !(subtypeFilter{}).allows("assumption") {
+		t.Error("no lists should allow every subtype")
+	}
+}
+
+func TestQuestionHint_DoesNotRevealCorrectChoice(t *testing.T) {
+	q := &models.Question{
+		CorrectAnswerID: "B",
+		Explanation:     "(B) is correct because the argument assumes the survey was representative. The conclusion generalizes from a small, self-selected sample. Choice D is tempting but irrelevant.",
+		Choices: []models.AnswerChoice{
+			{ChoiceID: "A", Explanation: "Out of scope."},
+			{ChoiceID: "B", Explanation: "This names the gap between the sample and the population."},
+		},
+	}
+	letter := regexp.MustCompile(`\b` + q.CorrectAnswerID + `\b`)
+
+	hint := questionHint(q)
+	if hint != "The conclusion generalizes from a small, self-selected sample." {
+		t.Errorf("hint = %q, want the first sentence that names no choice", hint)
+	}
+	if letter.MatchString(hint) {
+		t.Errorf("hint %q reveals the correct choice %s", hint, q.CorrectAnswerID)
+	}
+
+	// Falls back to the correct choice's explanation
+	q.Explanation = "The correct answer is B. Answer B is the only one that works."
+	if hint := questionHint(q); hint != "This names the gap between the sample and the population." || letter.MatchString(hint) {
+		t.Errorf("fallback hint = %q", hint)
+	}
+
+	// Bare letters give the answer away too; a leading article doesn't
+	q.Explanation = "Only B addresses the gap in the sample. A survey of volunteers can't stand for everyone."
+	if hint := questionHint(q); hint != "A survey of volunteers can't stand for everyone." {
+		t.Errorf("hint = %q, want the sentence without a bare choice letter", hint)
+	}
+	q.Explanation = "Only D addresses the gap in the sample."
+	if hint := questionHint(q); hint != "This names the gap between the sample and the population." {
+		t.Errorf("hint = %q, want the bare letter D skipped", hint)
+	}
+
+	// Every sentence gives the answer away
+	q.Choices[1].Explanation = "B is right."
+	if hint := questionHint(q); hint != "" {
+		t.Errorf("hint = %q, want none rather than a leak", hint)
+	}
+
+	q.Explanation = strings.Repeat("word ", 100)
+	if hint := questionHint(q); len(hint) > maxHintLength+len("…") || !strings.HasSuffix(hint, "…") {
+		t.Errorf("long hint = %q (%d bytes), want it truncated", hint, len(hint))
+	}
+
+	// A sentence keeps its own terminator, and one without gets a period
+	for explanation, want := range map[string]string{
+		"What does the survey leave out? B covers it.": "What does the survey leave out?",
+		"Consider who answered the survey!":            "Consider who answered the survey!",
+		"Think about who answered":                     "Think about who answered.",
+	} {
+		q.Explanation = explanation
+		if hint := questionHint(q); hint != want {
+			t.Errorf("hint from %q = %q, want %q", explanation, hint, want)
+		}
+	}
+}
+
+// fakeHintStore serves one question alongside the daily challenge picks.
+type fakeHintStore struct {
+	*fakeDailyStore
+	question *models.Question
+}
+
+func (f *fakeHintStore) GetQuestionWithChoices(questionID int64) (*models.Question, error) {
+	if questionID != f.question.ID {
+		return nil, sql.ErrNoRows
+	}
+	return f.question, nil
+}
+
+func TestGetQuestionHint_RefusesTodaysDailyChallenge(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	q := &models.Question{ID: 7, CorrectAnswerID: "B", Explanation: "Consider who answered the survey."}
+	st := &fakeHintStore{
+		fakeDailyStore: &fakeDailyStore{picks: map[string]int64{"2026-10-15": 7}},
+		question:       q,
+	}
+
+	if _, err := getQuestionHint(st, 7, now); err == nil || err.Error() != "daily challenge hint" {
+		t.Errorf("today's challenge: got %v, want daily challenge hint", err)
+	}
+	// Once the day has passed it's an ordinary question
+	if resp, err := getQuestionHint(st, 7, now.AddDate(0, 0, 1)); err != nil || resp.Hint != q.Explanation {
+		t.Errorf("yesterday's challenge: got %+v, %v; want the hint", resp, err)
+	}
+
+	// With no daily challenge candidates at all, hints still work
+	st.fakeDailyStore = &fakeDailyStore{picks: map[string]int64{}}
+	if resp, err := getQuestionHint(st, 7, now); err != nil || resp.Hint != q.Explanation {
+		t.Errorf("no daily challenge: got %+v, %v; want the hint", resp, err)
+	}
+}
+
+func TestIncludeFlagged_OnlyForAdmins(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "mock")
+	t.Setenv("ADMIN_USER_IDS", "3, bogus, 9")
+	s := NewService(nil, nil, nil)
+
+	// admits reports whether a question in status passes the serving filter
+	admits := func(userID int64, requested bool, status models.ValidationStatus) bool {
+		return strings.Contains(servingFilter(s.canServeFlagged(userID, requested)), "'"+string(status)+"'")
+	}
+
+	if admits(5, true, models.ValidationFlagged) {
+		t.Error("normal user asking for flagged questions should never get them")
+	}
+	if admits(3, false, models.ValidationFlagged) {
+		t.Error("admin not asking for flagged questions should not get them")
+	}
+	if !admits(9, true, models.ValidationFlagged) {
+		t.Error("admin asking for flagged questions should get them")
+	}
+	for _, userID := range []int64{5, 9} {
+		if !admits(userID, true, models.ValidationPassed) || admits(userID, true, models.ValidationRejected) {
+			t.Errorf("user %d: passed questions should serve and rejected ones never", userID)
+		}
+	}
+}
+
+func TestPassageDocument_IncludesBothComparativeHalves(t *testing.T) {
+	p := &models.RCPassage{
+		Title:         "Two Views on Urban Trees",
+		Content:       "Passage A argues canopy cover lowers summer temperatures.",
+		IsComparative: true,
+		PassageB:      "Passage B contends maintenance costs outweigh the cooling.",
+	}
+	text := passageDocument(p)
+	for _, want := range []string{p.Title, p.Content, p.PassageB, "Passage A\n", "Passage B\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, p.Content) > strings.Index(text, p.PassageB) {
+		t.Error("Passage A should come before Passage B")
+	}
+
+	single := passageDocument(&models.RCPassage{Title: "Coral", Content: "Reefs recover slowly."})
+	if strings.Contains(single, "Passage B") {
+		t.Errorf("non-comparative passage should not have a Passage B heading:\n%s", single)
+	}
+}
+
+// fakeWeakAreaStore records generation queued for a user's subtypes.
+type fakeWeakAreaStore struct {
+	answered, correct int
+	unseen            int
+	inBucket          int
+	ability           int
+	queued            []string
+}
+
+func (f *fakeWeakAreaStore) GetSubtypeAccuracy(userID int64, subtype string) (int, int, error) {
+	return f.answered, f.correct, nil
+}
+
+func (f *fakeWeakAreaStore) CountUnseenForUser(userID int64, section string, subtype string) (int, error) {
+	return f.unseen, nil
+}
+
+func (f *fakeWeakAreaStore) CountQuestionsInBucket(section string, subtype *string, minDiff, maxDiff int) (int, error) {
+	return f.inBucket, nil
+}
+
+func (f *fakeWeakAreaStore) GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
+	return &models.UserAbilityScore{AbilityScore: f.ability}, nil
+}
+
+func (f *fakeWeakAreaStore) UpsertGenerationQueue(section string, subtype *string, minDiff, maxDiff int, targetDiff string, needed int, highPriority bool) error {
+	f.queued = append(f.queued, fmt.Sprintf("%s/%d-%d", *subtype, minDiff, maxDiff))
+	return nil
+}
+
+func TestQueueWeakSubtype_QueuesLowAccuracySubtype(t *testing.T) {
+	// 4 of 12 right with a moderate pool of unseen questions left
+	st := &fakeWeakAreaStore{answered: 12, correct: 4, unseen: 8, ability: 30}
+	queued, err := queueWeakSubtype(st, 1, "logical_reasoning", "flaw", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !queued || len(st.queued) == 0 {
+		t.Fatal("low-accuracy subtype should be queued")
+	}
+	// Ability 30 targets 15-45, the buckets around the user's level
+	if got, want := strings.Join(st.queued, " "), "flaw/0-20 flaw/21-40 flaw/41-60"; got != want {
+		t.Errorf("queued %s, want %s", got, want)
+	}
+
+	for name, st := range map[string]*fakeWeakAreaStore{
+		"accurate":      {answered: 12, correct: 10, unseen: 8},
+		"too few":       {answered: 5, correct: 0, unseen: 8},
+		"plenty unseen": {answered: 12, correct: 4, unseen: 40},
+	} {
+		if queued, _ := queueWeakSubtype(st, 1, "logical_reasoning", "flaw", 4); queued || len(st.queued) > 0 {
+			t.Errorf("%s: queued %v, want nothing", name, st.queued)
+		}
+	}
+}
+
+func TestQuestionTraps_DescribesEachWrongChoice(t *testing.T) {
+	q := &models.Question{
+		ID:              7,
+		CorrectAnswerID: "B",
+		Choices: []models.AnswerChoice{
+			{ChoiceID: "A", Explanation: "Unrelated to the gap.", WrongAnswerType: "irrelevant"},
+			{ChoiceID: "B", Explanation: "Closes the gap."},
+			{ChoiceID: "C", Explanation: "Attacks the conclusion.", WrongAnswerType: "Weakener"},
+			{ChoiceID: "D", Explanation: "A different topic.", WrongAnswerType: "out-of-scope"},
+			{ChoiceID: "E", Explanation: "Already stated.", WrongAnswerType: "restates_premise"},
+		},
+	}
+
+	resp := questionTraps(q)
+	if len(resp.Traps) != 4 {
+		t.Fatalf("got %d traps, want the 4 wrong choices", len(resp.Traps))
+	}
+	for _, trap := range resp.Traps {
+		if trap.ChoiceID == "B" {
+			t.Error("correct answer should not be listed as a trap")
+		}
+		if trap.ArchetypeDescription == "" {
+			t.Errorf("choice %s (%s) has no archetype description", trap.ChoiceID, trap.WrongAnswerType)
+		}
+		if trap.Explanation == "" {
+			t.Errorf("choice %s lost its explanation", trap.ChoiceID)
+		}
+	}
+}
+
+func TestValidationStatus_MediumConfidenceFollowsPolicy(t *testing.T) {
+	medium := &generator.ValidationResult{Matches: true, Confidence: "medium", Reasoning: "Probably B"}
+
+	t.Setenv("VALIDATION_PASS_CONFIDENCE", "")
+	if status, _, flagged := validationStatus(medium, generator.PassConfidence()); status != string(models.ValidationFlagged) || !flagged {
+		t.Errorf("default policy: medium got %s (flagged=%v), want flagged", status, flagged)
+	}
+
+	t.Setenv("VALIDATION_PASS_CONFIDENCE", "medium")
+	if status, reasoning, flagged := validationStatus(medium, generator.PassConfidence()); status != string(models.ValidationPassed) || flagged || reasoning != nil {
+		t.Errorf("medium policy: medium got %s (flagged=%v), want passed", status, flagged)
+	}
+	if status, _, _ := validationStatus(&generator.ValidationResult{Matches: true, Confidence: "low"}, "medium"); status != string(models.ValidationFlagged) {
+		t.Errorf("medium policy: low got %s, want flagged", status)
+	}
+
+	// Disagreeing with the answer key is rejected whatever the confidence
+	wrong := &generator.ValidationResult{Matches: false, Confidence: "high", SelectedAnswer: "C", GeneratedAnswer: "B"}
+	if status, _, _ := validationStatus(wrong, "low"); status != string(models.ValidationRejected) {
+		t.Errorf("mismatch got %s, want rejected", status)
+	}
+
+	t.Setenv("VALIDATION_PASS_CONFIDENCE", "bogus")
+	if got := generator.PassConfidence(); got != generator.DefaultPassConfidence {
+		t.Errorf("invalid setting gave %q, want default %q", got, generator.DefaultPassConfidence)
+	}
+}
+
+// fakeQueueLister pages through queue items in memory.
+type fakeQueueLister struct {
+	items []models.GenerationQueueItem
+}
+
+func (f *fakeQueueLister) ListGenerationQueue(status string, page, pageSize int) ([]models.GenerationQueueItem, int, error) {
+	var matched []models.GenerationQueueItem
+	for _, item := range f.items {
+		if status == "" || item.Status == status {
+			matched = append(matched, item)
+		}
+	}
+	start := min((page-1)*pageSize, len(matched))
+	end := min(start+pageSize, len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+func TestListGenerationQueue_ShowsPendingAndFailed(t *testing.T) {
+	timeout := "LLM request timed out"
+	st := &fakeQueueLister{items: []models.GenerationQueueItem{
+		{ID: 1, Section: "logical_reasoning", Status: "pending", HighPriority: true},
+		{ID: 2, Section: "logical_reasoning", Status: "failed", ErrorMessage: &timeout},
+		{ID: 3, Section: "reading_comprehension", Status: "completed"},
+	}}
+
+	resp, err := listGenerationQueue(st, "", 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 3 || len(resp.Items) != 3 {
+		t.Fatalf("got %d of %d items, want all 3", len(resp.Items), resp.Total)
+	}
+	byID := map[int64]models.GenerationQueueItem{}
+	for _, item := range resp.Items {
+		byID[item.ID] = item
+	}
+	if !byID[1].HighPriority || byID[1].Status != "pending" {
+		t.Errorf("pending item = %+v, want it listed with its priority", byID[1])
+	}
+	if byID[2].ErrorMessage == nil || *byID[2].ErrorMessage != timeout {
+		t.Errorf("failed item = %+v, want its error message", byID[2])
+	}
+
+	failed, err := listGenerationQueue(st, "failed", 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed.Items) != 1 || failed.Items[0].ID != 2 {
+		t.Errorf("status=failed got %+v, want only item 2", failed.Items)
+	}
+
+	paged, _ := listGenerationQueue(st, "", 1, 2)
+	if len(paged.Items) != 2 || !paged.HasMore || paged.TotalPages != 2 {
+		t.Errorf("page 1 of 2 = %d items, has_more=%v, total_pages=%d", len(paged.Items), paged.HasMore, paged.TotalPages)
+	}
+
+	if _, err := listGenerationQueue(st, "stuck", 1, 20); err == nil || err.Error() != "invalid status" {
+		t.Errorf("unknown status gave %v, want invalid status", err)
+	}
+}
+
+// fakeQueueStore holds generation queue items in memory.
+type fakeQueueStore struct {
+	items map[int64]*models.GenerationQueueItem
+}
+
+func (f *fakeQueueStore) GetGeneratingItems() ([]models.GenerationQueueItem, error) {
+	var out []models.GenerationQueueItem
+	for _, item := range f.items {
+		if item.Status == "generating" {
+			out = append(out, *item)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeQueueStore) UpdateGenerationStatus(id int64, status string, errMsg *string) error {
+	f.items[id].Status = status
+	f.items[id].ErrorMessage = errMsg
+	return nil
+}
+
+func (f *fakeQueueStore) GetGenerationQueueItem(id int64) (*models.GenerationQueueItem, error) {
+	item, ok := f.items[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *item
+	return &copied, nil
+}
+
+func (f *fakeQueueStore) ResetGenerationItem(id int64) (bool, error) {
+	item := f.items[id]
+	if item.Status != "generating" && item.Status != "failed" {
+		return false, nil
+	}
+	for _, n := range f.items {
+		if n.ID > id && sameQueueBucket(*n, *item) && (n.Status == "pending" || n.Status == "generating") {
+			return false, nil
+		}
+	}
+	item.Status = "pending"
+	item.ErrorMessage = nil
+	item.StartedAt = nil
+	return true, nil
+}
+
+// sameQueueBucket reports whether two queue items cover the same bucket.
+func sameQueueBucket(a, b models.GenerationQueueItem) bool {
+	sameStr := func(x, y *string) bool { return (x == nil && y == nil) || (x != nil && y != nil && *x == *y) }
+	return a.Section == b.Section && sameStr(a.LRSubtype, b.LRSubtype) && sameStr(a.RCSubtype, b.RCSubtype) &&
+		a.DifficultyBucketMin == b.DifficultyBucketMin && a.DifficultyBucketMax == b.DifficultyBucketMax
+}
+
+func TestFailStaleGenerations_StuckItemIsRecoverable(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	stuckStart := now.Add(-2 * time.Hour)
+	freshStart := now.Add(-5 * time.Minute)
+	st := &fakeQueueStore{items: map[int64]*models.GenerationQueueItem{
+		1: {ID: 1, DifficultyBucketMin: 1, Status: "generating", StartedAt: &stuckStart, CreatedAt: stuckStart},
+		2: {ID: 2, DifficultyBucketMin: 21, Status: "generating", StartedAt: &freshStart, CreatedAt: stuckStart},
+		// Queued before started_at existed; timed from created_at
+		3: {ID: 3, DifficultyBucketMin: 41, Status: "generating", CreatedAt: stuckStart},
+		4: {ID: 4, DifficultyBucketMin: 61, Status: "completed", CreatedAt: stuckStart},
+	}}
+
+	n, err := failStaleGenerations(st, now, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("failed %d items, want 2", n)
+	}
+	for id, want := range map[int64]string{1: "failed", 2: "generating", 3: "failed", 4: "completed"} {
+		if got := st.items[id].Status; got != want {
+			t.Errorf("item %d status = %q, want %q", id, got, want)
+		}
+	}
+	if st.items[1].ErrorMessage == nil {
+		t.Error("stale item should record why it failed")
+	}
+
+	// The admin reset puts the stuck item back in the queue
+	item, err := resetGenerationQueueItem(st, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Status != "pending" || item.ErrorMessage != nil || item.StartedAt != nil {
+		t.Errorf("reset item = %+v, want a clean pending item", item)
+	}
+
+	if _, err := resetGenerationQueueItem(st, 4); err == nil || err.Error() != "queue item not resettable" {
+		t.Errorf("resetting a completed item: err = %v, want not resettable", err)
+	}
+	if _, err := resetGenerationQueueItem(st, 99); err == nil || err.Error() != "queue item not found" {
+		t.Errorf("resetting a missing item: err = %v, want not found", err)
+	}
+
+	// Item 3 is superseded once a newer item for its bucket is queued
+	st.items[5] = &models.GenerationQueueItem{ID: 5, DifficultyBucketMin: 41, Status: "pending", CreatedAt: now}
+	if _, err := resetGenerationQueueItem(st, 3); err == nil || err.Error() != "queue item not resettable" {
+		t.Errorf("resetting a superseded item: err = %v, want not resettable", err)
+	}
+	if st.items[3].Status != "failed" {
+		t.Errorf("superseded item status = %q, want it left failed", st.items[3].Status)
+	}
+}
+
+// fakeBatchQuestions holds seeded questions by batch.
+type fakeBatchQuestions struct {
+	questions map[int64][]models.Question
+}
+
+func (f *fakeBatchQuestions) GetBatch(batchID int64) (*models.QuestionBatch, error) {
+	if _, ok := f.questions[batchID]; !ok {
+		return nil, fmt.Errorf("get batch: %w", sql.ErrNoRows)
+	}
+	return &models.QuestionBatch{ID: batchID}, nil
+}
+
+func (f *fakeBatchQuestions) GetBatchAnswerPositions(batchID int64) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, q := range f.questions[batchID] {
+		counts[q.CorrectAnswerID]++
+	}
+	return counts, nil
+}
+
+func TestBatchAnswerPositions_MatchesSeededChoices(t *testing.T) {
+	var clustered []models.Question
+	for _, id := range []string{"B", "B", "B", "D", "B", "A"} {
+		clustered = append(clustered, models.Question{CorrectAnswerID: id})
+	}
+	st := &fakeBatchQuestions{questions: map[int64][]models.Question{
+		7: clustered,
+		8: {{CorrectAnswerID: "E"}},
+	}}
+
+	got, err := batchAnswerPositions(st, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"A": 1, "B": 4, "C": 0, "D": 1, "E": 0}
+	if got.Total != 6 || len(got.Positions) != len(want) {
+		t.Fatalf("got %+v, want 6 answers over positions %v", got, want)
+	}
+	for pos, n := range want {
+		if got.Positions[pos] != n {
+			t.Errorf("position %s = %d, want %d", pos, got.Positions[pos], n)
+		}
+	}
+
+	if _, err := batchAnswerPositions(st, 99); err == nil || err.Error() != "batch not found" {
+		t.Errorf("missing batch: err = %v, want batch not found", err)
+	}
+}
+
+// fakeDifficultyStore holds questions and which were overridden by hand.
+type fakeDifficultyStore struct {
+	questions map[int64]*models.Question
+	manual    map[int64]bool
+}
+
+func (f *fakeDifficultyStore) GetQuestionWithChoices(questionID int64) (*models.Question, error) {
+	q, ok := f.questions[questionID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *q
+	return &copied, nil
+}
+
+func (f *fakeDifficultyStore) SetQuestionDifficulty(questionID int64, difficulty models.Difficulty, score int) error {
+	q, ok := f.questions[questionID]
+	if !ok {
+		return fmt.Errorf("question not found")
+	}
+	q.Difficulty, q.DifficultyScore = difficulty, score
+	f.manual[questionID] = true
+	return nil
+}
+
+func TestSetQuestionDifficulty_OverrideSticksAndStaysConsistent(t *testing.T) {
+	st := &fakeDifficultyStore{
+		questions: map[int64]*models.Question{1: {ID: 1, Difficulty: models.DifficultyMedium, DifficultyScore: 50}},
+		manual:    map[int64]bool{},
+	}
+	hard, easy := models.DifficultyHard, models.DifficultyEasy
+	score := func(n int) *int { return &n }
+
+	cases := []struct {
+		name      string
+		req       models.SetDifficultyRequest
+		wantEnum  models.Difficulty
+		wantScore int
+	}{
+		{"score alone", models.SetDifficultyRequest{DifficultyScore: score(80)}, models.DifficultyHard, 80},
+		{"enum alone keeps an in-band score", models.SetDifficultyRequest{Difficulty: &hard}, models.DifficultyHard, 80},
+		{"enum alone moves the score into its band", models.SetDifficultyRequest{Difficulty: &easy}, models.DifficultyEasy, 35},
+		{"both", models.SetDifficultyRequest{Difficulty: &hard, DifficultyScore: score(90)}, models.DifficultyHard, 90},
+	}
+	for _, c := range cases {
+		got, err := setQuestionDifficulty(st, 1, c.req)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		stored := st.questions[1]
+		if got.Difficulty != c.wantEnum || got.DifficultyScore != c.wantScore ||
+			stored.Difficulty != c.wantEnum || stored.DifficultyScore != c.wantScore {
+			t.Errorf("%s: returned %s/%d, stored %s/%d; want %s/%d", c.name,
+				got.Difficulty, got.DifficultyScore, stored.Difficulty, stored.DifficultyScore, c.wantEnum, c.wantScore)
+		}
+		if mapScoreToDifficulty(stored.DifficultyScore) != stored.Difficulty {
+			t.Errorf("%s: stored %s/%d disagree", c.name, stored.Difficulty, stored.DifficultyScore)
+		}
+	}
+
+	if _, err := setQuestionDifficulty(st, 1, models.SetDifficultyRequest{Difficulty: &easy, DifficultyScore: score(90)}); err == nil {
+		t.Error("easy with a score of 90 should be rejected")
+	}
+	if st.questions[1].DifficultyScore != 90 {
+		t.Errorf("rejected override changed the score to %d", st.questions[1].DifficultyScore)
+	}
+	if _, err := setQuestionDifficulty(st, 2, models.SetDifficultyRequest{Difficulty: &easy}); err == nil || err.Error() != "question not found" {
+		t.Errorf("missing question: err = %v, want question not found", err)
+	}
+
+	// The override is marked, and recalibration skips marked questions
+	if !st.manual[1] {
+		t.Error("override wasn't marked as manual")
+	}
+	if !strings.Contains(recalibrationCandidatesQuery, "NOT q.difficulty_manual") {
+		t.Error("recalibration candidates should exclude manual overrides")
+	}
+}
+
+func TestTrustedCandidates_RequireDistinctResponders(t *testing.T) {
+	// Question 7 was served 62 times, but mostly to one user retrying it
+	candidates := []models.RecalibrationCandidate{
+		{QuestionID: 7, TimesServed: 62, DistinctResponders: 3},
+		{QuestionID: 9, TimesServed: 55, DistinctResponders: 40},
+	}
+	trusted := trustedCandidates(candidates, 20)
+	if len(trusted) != 1 || trusted[0].QuestionID != 9 {
+		t.Errorf("trusted %+v, want only question 9", trusted)
+	}
+	if got := trustedCandidates(candidates, 0); len(got) != 2 {
+		t.Errorf("no minimum: trusted %d candidates, want all 2", len(got))
+	}
+}
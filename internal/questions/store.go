@@ -4,18 +4,69 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/lsat-prep/backend/internal/generator"
 	"github.com/lsat-prep/backend/internal/models"
 )
 
 type Store struct {
 	db *sql.DB
+
+	// abilityDecayRate is the fraction of an idle ability's distance from 50
+	// lost per day; see DecayedAbility. 0 disables decay.
+	abilityDecayRate float64
+
+	// repeatWindowHours and repeatWindowAnswers keep adaptive serving from
+	// re-serving a question answered within that many hours, or among the
+	// user's last that many answers, unless nothing else is left to serve.
+	// 0 disables each.
+	repeatWindowHours   int
+	repeatWindowAnswers int
+
+	// passageUserCap is the most questions from one RC passage a user is
+	// served before drills move them to another passage. 0 disables it.
+	passageUserCap int
+
+	// rcDrillMax is the most questions one RC drill serves, and the count
+	// used when a request doesn't give one.
+	rcDrillMax int
+}
+
+// defaultRCDrillMax is rcDrillMax unless RC_DRILL_MAX overrides it.
+const defaultRCDrillMax = 8
+
+// rcDrillCount is the number of questions an RC drill asking for count
+// serves: the max when count is unset, and never more than it.
+func rcDrillCount(count, drillMax int) int {
+	if count <= 0 || count > drillMax {
+		return drillMax
+	}
+	return count
 }
 
 func NewStore(db *sql.DB) *Store {
-	return &Store{db: db}
+	var decayRate float64
+	if v := os.Getenv("ABILITY_DECAY_RATE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			decayRate = f
+		} else {
+			log.Printf("Store: ignoring ABILITY_DECAY_RATE=%q: want a number in [0, 1]", v)
+		}
+	}
+	return &Store{
+		db:                  db,
+		abilityDecayRate:    decayRate,
+		repeatWindowHours:   envPositiveInt("SERVE_REPEAT_WINDOW_HOURS", 0),
+		repeatWindowAnswers: envPositiveInt("SERVE_REPEAT_WINDOW_ANSWERS", 0),
+		passageUserCap:      envPositiveInt("RC_PASSAGE_USER_CAP", 0),
+		rcDrillMax:          envPositiveInt("RC_DRILL_MAX", defaultRCDrillMax),
+	}
 }
 
 // ── Batch Management ────────────────────────────────────
@@ -23,12 +74,12 @@ func NewStore(db *sql.DB) *Store {
 func (s *Store) CreateBatch(req models.GenerateBatchRequest) (*models.QuestionBatch, error) {
 	var batch models.QuestionBatch
 	err := s.db.QueryRow(
-		`INSERT INTO question_batches (section, lr_subtype, difficulty, status)
-		 VALUES ($1, $2, $3, $4)
-		 RETURNING id, section, lr_subtype, difficulty, status, question_count, created_at`,
-		req.Section, req.LRSubtype, req.Difficulty, models.BatchPending,
+		`INSERT INTO question_batches (section, lr_subtype, difficulty, status, notes, experiment_tag)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, section, lr_subtype, difficulty, status, question_count, notes, experiment_tag, created_at`,
+		req.Section, req.LRSubtype, req.Difficulty, models.BatchPending, req.Notes, req.ExperimentTag,
 	).Scan(&batch.ID, &batch.Section, &batch.LRSubtype, &batch.Difficulty,
-		&batch.Status, &batch.QuestionCount, &batch.CreatedAt)
+		&batch.Status, &batch.QuestionCount, &batch.Notes, &batch.ExperimentTag, &batch.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("create batch: %w", err)
 	}
@@ -51,96 +102,365 @@ func (s *Store) FailBatch(batchID int64, errMsg string) error {
 	return err
 }
 
-func (s *Store) CompleteBatch(batchID int64, passed, flagged, rejected int, timeMs int64, promptTokens, outputTokens, validationTokens int, modelUsed string) error {
-	totalCount := passed + flagged
+// BatchCompletion is the summary written to a batch when generation finishes.
+type BatchCompletion struct {
+	Passed              int
+	Flagged             int
+	Rejected            int
+	TimeMs              int64
+	PromptTokens        int
+	OutputTokens        int
+	ValidationTokens    int
+	ModelUsed           string
+	ValidationModel     string
+	PromptVersion       string
+	GenerationCostCents int
+	ValidationCostCents int
+}
+
+func (s *Store) CompleteBatch(batchID int64, c BatchCompletion) error {
+	totalCount := c.Passed + c.Flagged
 	_, err := s.db.Exec(
 		`UPDATE question_batches
 		 SET status = $1, question_count = $2, questions_passed = $3, questions_flagged = $4,
 		     questions_rejected = $5, generation_time_ms = $6, prompt_tokens = $7,
-		     output_tokens = $8, validation_tokens = $9, model_used = $10, completed_at = NOW()
-		 WHERE id = $11`,
-		models.BatchCompleted, totalCount, passed, flagged, rejected,
-		timeMs, promptTokens, outputTokens, validationTokens, modelUsed, batchID,
+		     output_tokens = $8, validation_tokens = $9, model_used = $10, prompt_version = $11,
+		     validation_model = $12, validation_cost_cents = $13, total_cost_cents = $14,
+		     completed_at = NOW()
+		 WHERE id = $15`,
+		models.BatchCompleted, totalCount, c.Passed, c.Flagged, c.Rejected,
+		c.TimeMs, c.PromptTokens, c.OutputTokens, c.ValidationTokens, c.ModelUsed, nullString(c.PromptVersion),
+		nullString(c.ValidationModel), c.ValidationCostCents, c.GenerationCostCents+c.ValidationCostCents,
+		batchID,
 	)
 	return err
 }
 
-func (s *Store) GetBatch(batchID int64) (*models.QuestionBatch, error) {
-	var batch models.QuestionBatch
-	err := s.db.QueryRow(
-		`SELECT id, section, lr_subtype, difficulty, status, question_count,
+// UpdateBatchAnnotation sets notes and/or experiment_tag on a batch; nil fields are left unchanged.
+func (s *Store) UpdateBatchAnnotation(batchID int64, notes, experimentTag *string) error {
+	result, err := s.db.Exec(
+		`UPDATE question_batches
+		 SET notes = COALESCE($2, notes), experiment_tag = COALESCE($3, experiment_tag)
+		 WHERE id = $1`,
+		batchID, notes, experimentTag,
+	)
+	if err != nil {
+		return fmt.Errorf("update batch annotation: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("batch not found")
+	}
+	return nil
+}
+
+// batchPurgeCandidate is an old finished batch and how many questions it
+// still holds.
+type batchPurgeCandidate struct {
+	ID        int64
+	Status    models.BatchStatus
+	Questions int
+}
+
+// purgeableBatchIDs picks the candidates that are only noise: failed batches
+// and completed ones with no surviving questions. Batches that still hold
+// questions are kept, since deleting those would take users' history too.
+func purgeableBatchIDs(candidates []batchPurgeCandidate) []int64 {
+	var ids []int64
+	for _, c := range candidates {
+		if c.Questions > 0 {
+			continue
+		}
+		if c.Status == models.BatchFailed || c.Status == models.BatchCompleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+// PurgeFailedBatches deletes batches created before olderThan that
+// purgeableBatchIDs selects, along with their validation logs and passages.
+func (s *Store) PurgeFailedBatches(olderThan time.Time) (*models.BatchPurgeResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`SELECT b.id, b.status, (SELECT COUNT(*) FROM questions q WHERE q.batch_id = b.id)
+		 FROM question_batches b
+		 WHERE b.created_at < $1 AND b.status IN ('failed', 'completed')
+		 FOR UPDATE OF b`,
+		olderThan,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query purge candidates: %w", err)
+	}
+	var candidates []batchPurgeCandidate
+	for rows.Next() {
+		var c batchPurgeCandidate
+		if err := rows.Scan(&c.ID, &c.Status, &c.Questions); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan purge candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.BatchPurgeResult{OlderThan: olderThan}
+	ids := purgeableBatchIDs(candidates)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	inClause := strings.Join(placeholders, ",")
+
+	// Children first: validation logs and passages reference the batch
+	steps := []struct {
+		query string
+		count *int
+	}{
+		{`DELETE FROM validation_logs WHERE batch_id IN (%s)`, &result.ValidationLogsRemoved},
+		{`DELETE FROM rc_passages WHERE batch_id IN (%s)`, &result.PassagesRemoved},
+		{`DELETE FROM question_batches WHERE id IN (%s)`, &result.BatchesRemoved},
+	}
+	for _, step := range steps {
+		res, err := tx.Exec(fmt.Sprintf(step.query, inClause), args...)
+		if err != nil {
+			return nil, fmt.Errorf("purge batches: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		*step.count = int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit batch purge: %w", err)
+	}
+	return result, nil
+}
+
+// UpdateQuestionExplanations replaces a question's overall explanation and
+// the explanation of each choice in choiceExplanations, all or nothing.
+func (s *Store) UpdateQuestionExplanations(questionID int64, explanation string, choiceExplanations map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE questions SET explanation = $2 WHERE id = $1`, questionID, explanation)
+	if err != nil {
+		return fmt.Errorf("update explanation: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("question not found")
+	}
+
+	for choiceID, text := range choiceExplanations {
+		result, err := tx.Exec(
+			`UPDATE answer_choices SET explanation = $3 WHERE question_id = $1 AND choice_id = $2`,
+			questionID, choiceID, text,
+		)
+		if err != nil {
+			return fmt.Errorf("update choice %s explanation: %w", choiceID, err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("choice %s not found", choiceID)
+		}
+	}
+	return tx.Commit()
+}
+
+// SetFeatured marks or unmarks a question as featured.
+func (s *Store) SetFeatured(questionID int64, featured bool) error {
+	result, err := s.db.Exec(`UPDATE questions SET featured = $2 WHERE id = $1`, questionID, featured)
+	if err != nil {
+		return fmt.Errorf("set featured: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("question not found")
+	}
+	return nil
+}
+
+const batchSelectCols = `id, section, lr_subtype, difficulty, status, question_count,
 		        questions_passed, questions_flagged, questions_rejected,
 		        model_used, prompt_tokens, output_tokens, validation_tokens,
-		        generation_time_ms, total_cost_cents, error_message, created_at, completed_at
-		 FROM question_batches WHERE id = $1`,
-		batchID,
-	).Scan(&batch.ID, &batch.Section, &batch.LRSubtype, &batch.Difficulty,
+		        generation_time_ms, total_cost_cents, validation_model, validation_cost_cents,
+		        error_message, notes, experiment_tag, prompt_version, created_at, completed_at`
+
+func scanBatch(row interface{ Scan(...interface{}) error }, batch *models.QuestionBatch) error {
+	return row.Scan(&batch.ID, &batch.Section, &batch.LRSubtype, &batch.Difficulty,
 		&batch.Status, &batch.QuestionCount,
 		&batch.QuestionsPassed, &batch.QuestionsFlagged, &batch.QuestionsRejected,
 		&batch.ModelUsed, &batch.PromptTokens, &batch.OutputTokens, &batch.ValidationTokens,
-		&batch.GenerationTimeMs, &batch.TotalCostCents, &batch.ErrorMessage, &batch.CreatedAt, &batch.CompletedAt)
-	if err != nil {
+		&batch.GenerationTimeMs, &batch.TotalCostCents, &batch.ValidationModel, &batch.ValidationCostCents,
+		&batch.ErrorMessage, &batch.Notes, &batch.ExperimentTag, &batch.PromptVersion, &batch.CreatedAt, &batch.CompletedAt)
+}
+
+func (s *Store) GetBatch(batchID int64) (*models.QuestionBatch, error) {
+	var batch models.QuestionBatch
+	row := s.db.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM question_batches WHERE id = $1`, batchSelectCols),
+		batchID,
+	)
+	if err := scanBatch(row, &batch); err != nil {
 		return nil, fmt.Errorf("get batch: %w", err)
 	}
 	return &batch, nil
 }
 
-func (s *Store) ListBatches(status *models.BatchStatus, limit, offset int) ([]models.QuestionBatch, error) {
-	var rows *sql.Rows
-	var err error
+// GetBatchAnswerPositions counts the batch's questions by correct answer ID.
+func (s *Store) GetBatchAnswerPositions(batchID int64) (map[string]int, error) {
+	rows, err := s.db.Query(
+		`SELECT correct_answer_id, COUNT(*) FROM questions WHERE batch_id = $1 GROUP BY correct_answer_id`,
+		batchID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("batch answer positions: %w", err)
+	}
+	defer rows.Close()
 
-	selectCols := `id, section, lr_subtype, difficulty, status, question_count,
-		        questions_passed, questions_flagged, questions_rejected,
-		        model_used, prompt_tokens, output_tokens, validation_tokens,
-		        generation_time_ms, total_cost_cents, error_message, created_at, completed_at`
+	counts := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("scan answer position: %w", err)
+		}
+		counts[id] = n
+	}
+	return counts, rows.Err()
+}
 
-	if status != nil {
-		rows, err = s.db.Query(
-			fmt.Sprintf(`SELECT %s FROM question_batches WHERE status = $1
-			 ORDER BY created_at DESC LIMIT $2 OFFSET $3`, selectCols),
-			*status, limit, offset,
-		)
-	} else {
-		rows, err = s.db.Query(
-			fmt.Sprintf(`SELECT %s FROM question_batches
-			 ORDER BY created_at DESC LIMIT $1 OFFSET $2`, selectCols),
-			limit, offset,
-		)
+// buildBatchFilters turns batch list filters into a WHERE fragment and its positional args.
+func buildBatchFilters(f models.BatchListFilters) (string, []interface{}) {
+	var args []interface{}
+	var filters []string
+	paramIdx := 1
+
+	if f.Status != nil {
+		filters = append(filters, fmt.Sprintf("status = $%d", paramIdx))
+		args = append(args, *f.Status)
+		paramIdx++
 	}
+	if f.ExperimentTag != nil {
+		filters = append(filters, fmt.Sprintf("experiment_tag = $%d", paramIdx))
+		args = append(args, *f.ExperimentTag)
+		paramIdx++
+	}
+
+	if len(filters) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(filters, " AND "), args
+}
+
+// ListBatches returns a page of batches matching f, newest first, and how
+// many match in all.
+func (s *Store) ListBatches(f models.BatchListFilters, limit, offset int) ([]models.QuestionBatch, int, error) {
+	filterSQL, args := buildBatchFilters(f)
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM question_batches `+filterSQL, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count batches: %w", err)
+	}
+
+	paramIdx := len(args) + 1
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(
+		fmt.Sprintf(`SELECT %s FROM question_batches %s
+		 ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, batchSelectCols, filterSQL, paramIdx, paramIdx+1),
+		args...,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("list batches: %w", err)
+		return nil, 0, fmt.Errorf("list batches: %w", err)
 	}
 	defer rows.Close()
 
 	var batches []models.QuestionBatch
 	for rows.Next() {
 		var b models.QuestionBatch
-		if err := rows.Scan(&b.ID, &b.Section, &b.LRSubtype, &b.Difficulty,
-			&b.Status, &b.QuestionCount,
-			&b.QuestionsPassed, &b.QuestionsFlagged, &b.QuestionsRejected,
-			&b.ModelUsed, &b.PromptTokens, &b.OutputTokens, &b.ValidationTokens,
-			&b.GenerationTimeMs, &b.TotalCostCents, &b.ErrorMessage, &b.CreatedAt, &b.CompletedAt); err != nil {
-			return nil, fmt.Errorf("scan batch: %w", err)
+		if err := scanBatch(rows, &b); err != nil {
+			return nil, 0, fmt.Errorf("scan batch: %w", err)
 		}
 		batches = append(batches, b)
 	}
-	return batches, rows.Err()
+	return batches, total, rows.Err()
 }
 
 // ── Question Storage ────────────────────────────────────
 
 type QuestionSaveOptions struct {
-	ValidationStatus string
-	QualityScore     *float64
-	ValidationReason *string
-	AdversarialScore *string
-	Flagged          bool
+	ValidationStatus     string
+	QualityScore         *float64
+	ValidationReason     *string
+	AdversarialScore     *string
+	Flagged              bool
+	LengthBalance        *float64
+	CorrectLengthOutlier bool
+}
+
+// difficultyScoreFor picks the stored difficulty_score for a question in a
+// batch generated from req: within the requested score range if there is
+// one, otherwise from the difficulty band.
+func difficultyScoreFor(req models.GenerateBatchRequest) int {
+	if req.DifficultyScore != nil {
+		return generator.AssignDifficultyScoreInRange(*req.DifficultyScore)
+	}
+	return generator.AssignDifficultyScore(req.Difficulty)
+}
+
+// questionContentKey is the key of idx_questions_content_unique. RC
+// questions share an empty stimulus and often a stem, so the passage is part
+// of the key.
+const questionContentKey = `(COALESCE(passage_id, 0), md5(stimulus), md5(question_stem))`
+
+// questionContentConflict skips a question whose stimulus+stem is already
+// saved for the same passage (or for none).
+const questionContentConflict = `ON CONFLICT ` + questionContentKey + ` WHERE duplicate_of IS NULL DO NOTHING`
+
+// insertedQuestion interprets the error from scanning the id of an insert
+// guarded by questionContentConflict: no row means the question was a
+// duplicate and skipped, not that the insert failed.
+func insertedQuestion(err error) (bool, error) {
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// dropUnusedPassage deletes a passage inserted for a batch whose questions
+// were all skipped as duplicates, so it isn't left without questions.
+func dropUnusedPassage(db execer, passageID *int64, saved int) error {
+	if passageID == nil || saved > 0 {
+		return nil
+	}
+	if _, err := db.Exec(`DELETE FROM rc_passages WHERE id = $1`, *passageID); err != nil {
+		return fmt.Errorf("drop unused passage: %w", err)
+	}
+	return nil
 }
 
-func (s *Store) SaveGeneratedBatch(ctx context.Context, batchID int64, batch *generator.GeneratedBatch, req models.GenerateBatchRequest, opts []QuestionSaveOptions) error {
+// SaveGeneratedBatch saves the batch's passage and questions, skipping any
+// question already saved by a concurrent batch. It returns the saved IDs
+// indexed like batch.Questions, with 0 for each skipped question.
+func (s *Store) SaveGeneratedBatch(ctx context.Context, batchID int64, batch *generator.GeneratedBatch, req models.GenerateBatchRequest, opts []QuestionSaveOptions) ([]int64, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
@@ -156,19 +476,22 @@ func (s *Store) SaveGeneratedBatch(ctx context.Context, batchID int64, batch *ge
 		if batch.Passage.IsComparative && batch.Passage.PassageB != "" {
 			wc += len(strings.Fields(batch.Passage.PassageB))
 		}
+		grade := generator.FleschKincaidGrade(passageText(batch.Passage.Content, batch.Passage.PassageB))
 		err := tx.QueryRow(
-			`INSERT INTO rc_passages (batch_id, title, subject_area, content, is_comparative, passage_b, word_count)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			`INSERT INTO rc_passages (batch_id, title, subject_area, content, is_comparative, passage_b, word_count, reading_grade)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
 			batchID, batch.Passage.Title, subjectArea, batch.Passage.Content,
-			batch.Passage.IsComparative, nullString(batch.Passage.PassageB), wc,
+			batch.Passage.IsComparative, nullString(batch.Passage.PassageB), wc, grade,
 		).Scan(&pid)
 		if err != nil {
-			return fmt.Errorf("insert passage: %w", err)
+			return nil, fmt.Errorf("insert passage: %w", err)
 		}
 		passageID = &pid
 	}
 
 	// Insert each question + its choices
+	ids := make([]int64, len(batch.Questions))
+	saved := 0
 	for i, gq := range batch.Questions {
 		var questionID int64
 		valStatus := "unvalidated"
@@ -176,6 +499,8 @@ func (s *Store) SaveGeneratedBatch(ctx context.Context, batchID int64, batch *ge
 		var valReasoning *string
 		var advScore *string
 		flagged := false
+		var lengthBalance *float64
+		lengthOutlier := false
 
 		if i < len(opts) {
 			valStatus = opts[i].ValidationStatus
@@ -183,24 +508,35 @@ func (s *Store) SaveGeneratedBatch(ctx context.Context, batchID int64, batch *ge
 			valReasoning = opts[i].ValidationReason
 			advScore = opts[i].AdversarialScore
 			flagged = opts[i].Flagged
+			lengthBalance = opts[i].LengthBalance
+			lengthOutlier = opts[i].CorrectLengthOutlier
 		}
 
-		diffScore := generator.AssignDifficultyScore(req.Difficulty)
+		diffScore := difficultyScoreFor(req)
 
 		err := tx.QueryRow(
 			`INSERT INTO questions
 			 (batch_id, section, lr_subtype, rc_subtype, difficulty, difficulty_score,
 			  stimulus, question_stem, correct_answer_id, explanation, passage_id,
-			  quality_score, validation_status, validation_reasoning, adversarial_score, flagged)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			  quality_score, validation_status, validation_reasoning, adversarial_score, flagged, prompt_version,
+			  choice_length_balance, correct_length_outlier, language)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, COALESCE($20, 'en'))
+			 `+questionContentConflict+`
 			 RETURNING id`,
 			batchID, req.Section, req.LRSubtype, req.RCSubtype, req.Difficulty, diffScore,
 			gq.Stimulus, gq.QuestionStem, gq.CorrectAnswerID, gq.Explanation,
-			passageID, qualityScore, valStatus, valReasoning, advScore, flagged,
+			passageID, qualityScore, valStatus, valReasoning, advScore, flagged, nullString(batch.PromptVersion),
+			lengthBalance, lengthOutlier, nullString(req.Language),
 		).Scan(&questionID)
+		inserted, err := insertedQuestion(err)
 		if err != nil {
-			return fmt.Errorf("insert question: %w", err)
+			return nil, fmt.Errorf("insert question: %w", err)
+		}
+		if !inserted {
+			continue
 		}
+		ids[i] = questionID
+		saved++
 
 		for _, gc := range gq.Choices {
 			isCorrect := gc.ID == gq.CorrectAnswerID
@@ -215,12 +551,18 @@ func (s *Store) SaveGeneratedBatch(ctx context.Context, batchID int64, batch *ge
 				questionID, gc.ID, gc.Text, gc.Explanation, isCorrect, wrongType,
 			)
 			if err != nil {
-				return fmt.Errorf("insert choice: %w", err)
+				return nil, fmt.Errorf("insert choice: %w", err)
 			}
 		}
 	}
 
-	return tx.Commit()
+	if err := dropUnusedPassage(tx, passageID, saved); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
 // ── Validation Logging ──────────────────────────────────
@@ -239,6 +581,36 @@ func (s *Store) LogValidation(vlog models.ValidationLog) error {
 	return err
 }
 
+// GetValidationLogs returns the validation logs written for a question.
+func (s *Store) GetValidationLogs(questionID int64) ([]models.ValidationLog, error) {
+	rows, err := s.db.Query(`
+		SELECT id, question_id, batch_id, stage, COALESCE(model_used, ''),
+		       COALESCE(generated_answer, ''), COALESCE(validator_answer, ''), matches,
+		       COALESCE(confidence, ''), COALESCE(reasoning, ''),
+		       COALESCE(adversarial_details::text, ''), COALESCE(prompt_tokens, 0),
+		       COALESCE(output_tokens, 0), created_at
+		FROM validation_logs
+		WHERE question_id = $1
+		ORDER BY created_at, id`, questionID)
+	if err != nil {
+		return nil, fmt.Errorf("query validation logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []models.ValidationLog{}
+	for rows.Next() {
+		var l models.ValidationLog
+		if err := rows.Scan(&l.ID, &l.QuestionID, &l.BatchID, &l.Stage, &l.ModelUsed,
+			&l.GeneratedAnswer, &l.ValidatorAnswer, &l.Matches,
+			&l.Confidence, &l.Reasoning, &l.AdversarialDetails,
+			&l.PromptTokens, &l.OutputTokens, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan validation log: %w", err)
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
 func (s *Store) UpdateQuestionValidation(questionID int64, status string, reasoning *string, adversarialScore *string, qualityScore *float64, flagged bool) error {
 	_, err := s.db.Exec(
 		`UPDATE questions SET validation_status = $1, validation_reasoning = $2,
@@ -249,22 +621,254 @@ func (s *Store) UpdateQuestionValidation(questionID int64, status string, reason
 	return err
 }
 
+// GetRevalidationQuestionIDs returns the IDs of unvalidated questions, plus
+// flagged ones when includeFlagged is set, oldest first.
+func (s *Store) GetRevalidationQuestionIDs(includeFlagged bool) ([]int64, error) {
+	rows, err := s.db.Query(
+		`SELECT id FROM questions
+		 WHERE validation_status = 'unvalidated' OR ($1 AND validation_status = 'flagged')
+		 ORDER BY id`,
+		includeFlagged,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query revalidation questions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan revalidation question: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *Store) CreateRevalidationJob(includeFlagged bool, total int) (*models.RevalidationJob, error) {
+	job := &models.RevalidationJob{Status: "running", IncludeFlagged: includeFlagged, Total: total}
+	err := s.db.QueryRow(
+		`INSERT INTO revalidation_jobs (include_flagged, total) VALUES ($1, $2)
+		 RETURNING id, started_at`,
+		includeFlagged, total,
+	).Scan(&job.ID, &job.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create revalidation job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateRevalidationJob saves a job's status and progress counters.
+func (s *Store) UpdateRevalidationJob(job models.RevalidationJob) error {
+	_, err := s.db.Exec(
+		`UPDATE revalidation_jobs
+		 SET status = $2, processed = $3, passed = $4, flagged = $5, rejected = $6, failed = $7, finished_at = $8
+		 WHERE id = $1`,
+		job.ID, job.Status, job.Processed, job.Passed, job.Flagged, job.Rejected, job.Failed, job.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update revalidation job: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetRevalidationJob(jobID int64) (*models.RevalidationJob, error) {
+	var job models.RevalidationJob
+	err := s.db.QueryRow(
+		`SELECT id, status, include_flagged, total, processed, passed, flagged, rejected, failed, started_at, finished_at
+		 FROM revalidation_jobs WHERE id = $1`,
+		jobID,
+	).Scan(&job.ID, &job.Status, &job.IncludeFlagged, &job.Total, &job.Processed,
+		&job.Passed, &job.Flagged, &job.Rejected, &job.Failed, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, fmt.Errorf("get revalidation job: %w", err)
+	}
+	return &job, nil
+}
+
+// ── Daily Challenge ──────────────────────────────────────
+
+// GetDailyChallengeQuestionID returns the question fixed for day, or
+// sql.ErrNoRows if the day hasn't been picked yet.
+func (s *Store) GetDailyChallengeQuestionID(day time.Time) (int64, error) {
+	var id int64
+	err := s.db.QueryRow(
+		`SELECT question_id FROM daily_challenges WHERE challenge_date = $1`, day,
+	).Scan(&id)
+	return id, err
+}
+
+// GetDailyChallengeCandidates returns the IDs of passed, high-quality,
+// unflagged LR questions eligible to be a daily challenge, in ID order.
+func (s *Store) GetDailyChallengeCandidates() ([]int64, error) {
+	rows, err := s.db.Query(
+		`SELECT id FROM questions
+		 WHERE section = 'logical_reasoning'
+		   AND validation_status = 'passed'
+		   AND quality_score >= 0.80
+		   AND NOT flagged
+		 ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query daily challenge candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan daily challenge candidate: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SetDailyChallenge fixes questionID as day's challenge unless one is
+// already set, and returns whichever question the day ended up with.
+func (s *Store) SetDailyChallenge(day time.Time, questionID int64) (int64, error) {
+	_, err := s.db.Exec(
+		`INSERT INTO daily_challenges (challenge_date, question_id) VALUES ($1, $2)
+		 ON CONFLICT (challenge_date) DO NOTHING`,
+		day, questionID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("set daily challenge: %w", err)
+	}
+	return s.GetDailyChallengeQuestionID(day)
+}
+
+// MarkDailyChallengeServed records when the user first fetched day's
+// challenge. Later fetches keep the first time.
+func (s *Store) MarkDailyChallengeServed(day time.Time, userID int64) error {
+	_, err := s.db.Exec(
+		`INSERT INTO daily_challenge_serves (challenge_date, user_id) VALUES ($1, $2)
+		 ON CONFLICT (challenge_date, user_id) DO NOTHING`,
+		day, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("mark daily challenge served: %w", err)
+	}
+	return nil
+}
+
+// GetDailyChallengeServedAt returns when the user first fetched day's
+// challenge, or sql.ErrNoRows if they haven't.
+func (s *Store) GetDailyChallengeServedAt(day time.Time, userID int64) (time.Time, error) {
+	var servedAt time.Time
+	err := s.db.QueryRow(
+		`SELECT served_at FROM daily_challenge_serves WHERE challenge_date = $1 AND user_id = $2`,
+		day, userID,
+	).Scan(&servedAt)
+	return servedAt, err
+}
+
+// recordDailyChallengeAttempt saves a user's attempt at day's challenge,
+// unranked unless ranked. It returns false if the user had already attempted
+// it.
+func recordDailyChallengeAttempt(db execer, day time.Time, userID, questionID int64, correct bool, selectedChoiceID string, timeSpentSeconds *float64, ranked bool) (bool, error) {
+	res, err := db.Exec(
+		`INSERT INTO daily_challenge_attempts
+		 (challenge_date, user_id, question_id, correct, selected_choice_id, time_spent_seconds, ranked)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (challenge_date, user_id) DO NOTHING`,
+		day, userID, questionID, correct, selectedChoiceID, timeSpentSeconds, ranked,
+	)
+	if err != nil {
+		return false, fmt.Errorf("record daily challenge attempt: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetDailyChallengeAttempt returns the user's attempt at day's challenge, or
+// nil if they haven't made one.
+func (s *Store) GetDailyChallengeAttempt(day time.Time, userID int64) (*models.DailyChallengeAttempt, error) {
+	var a models.DailyChallengeAttempt
+	err := s.db.QueryRow(
+		`SELECT question_id, correct, selected_choice_id, time_spent_seconds, ranked, attempted_at
+		 FROM daily_challenge_attempts WHERE challenge_date = $1 AND user_id = $2`,
+		day, userID,
+	).Scan(&a.QuestionID, &a.Correct, &a.SelectedChoiceID, &a.TimeSpentSeconds, &a.Ranked, &a.AttemptedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get daily challenge attempt: %w", err)
+	}
+	return &a, nil
+}
+
+// GetDailyChallengeLeaderboard returns the top limit ranked attempts at
+// day's challenge, correct answers first and then fastest, plus the total
+// number of ranked attempts. Entries are unnumbered.
+func (s *Store) GetDailyChallengeLeaderboard(day time.Time, limit int) ([]models.DailyChallengeEntry, int, error) {
+	rows, err := s.db.Query(
+		`SELECT u.id, u.name, COALESCE(u.username, ''), a.correct, a.time_spent_seconds,
+		        COUNT(*) OVER ()
+		 FROM daily_challenge_attempts a
+		 JOIN users u ON u.id = a.user_id
+		 WHERE a.challenge_date = $1 AND a.ranked
+		 ORDER BY a.correct DESC, a.time_spent_seconds ASC NULLS LAST, a.attempted_at ASC
+		 LIMIT $2`,
+		day, limit,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query daily challenge leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.DailyChallengeEntry
+	total := 0
+	for rows.Next() {
+		var e models.DailyChallengeEntry
+		if err := rows.Scan(&e.UserID, &e.DisplayName, &e.Username, &e.Correct, &e.TimeSpentSeconds, &total); err != nil {
+			return nil, 0, fmt.Errorf("scan daily challenge entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
 // ── Serving Questions to Users ──────────────────────────
 
+// CreateDrillSession records the questions served as one drill and returns
+// the drill's ID, which the client sends back to complete it.
+func (s *Store) CreateDrillSession(userID int64, questionIDs []int64, source models.AnswerSource) (int64, error) {
+	var id int64
+	err := s.db.QueryRow(
+		`INSERT INTO drill_sessions (user_id, question_ids, source)
+		 VALUES ($1, $2, NULLIF($3, ''))
+		 RETURNING id`,
+		userID, pq.Array(questionIDs), string(source),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("create drill session: %w", err)
+	}
+	return id, nil
+}
+
 func (s *Store) GetQuestionWithChoices(questionID int64) (*models.Question, error) {
 	var q models.Question
 	err := s.db.QueryRow(
 		`SELECT id, batch_id, section, lr_subtype, rc_subtype, difficulty, difficulty_score,
 		        stimulus, question_stem, correct_answer_id, explanation, passage_id, quality_score,
 		        validation_status, validation_reasoning, adversarial_score,
-		        flagged, times_served, times_correct, created_at
+		        flagged, times_served, times_correct, created_at, language,
+		        choice_length_balance, correct_length_outlier
 		 FROM questions WHERE id = $1`,
 		questionID,
 	).Scan(&q.ID, &q.BatchID, &q.Section, &q.LRSubtype, &q.RCSubtype, &q.Difficulty, &q.DifficultyScore,
 		&q.Stimulus, &q.QuestionStem, &q.CorrectAnswerID, &q.Explanation,
 		&q.PassageID, &q.QualityScore,
 		&q.ValidationStatus, &q.ValidationReasoning, &q.AdversarialScore,
-		&q.Flagged, &q.TimesServed, &q.TimesCorrect, &q.CreatedAt)
+		&q.Flagged, &q.TimesServed, &q.TimesCorrect, &q.CreatedAt, &q.Language,
+		&q.LengthBalance, &q.LengthOutlier)
 	if err != nil {
 		return nil, fmt.Errorf("get question: %w", err)
 	}
@@ -278,50 +882,153 @@ func (s *Store) GetQuestionWithChoices(questionID int64) (*models.Question, erro
 	return &q, nil
 }
 
-func (s *Store) GetDrillQuestions(section models.Section, subtype *models.LRSubtype, difficulty models.Difficulty, count int) ([]models.Question, error) {
-	var rows *sql.Rows
-	var err error
+// GetQuestionsByIDs loads the questions in questionIDs with their choices.
+// IDs that don't exist are skipped.
+func (s *Store) GetQuestionsByIDs(questionIDs []int64) ([]models.Question, error) {
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(questionIDs))
+	args := make([]interface{}, len(questionIDs))
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT id, batch_id, section, lr_subtype, rc_subtype, difficulty, difficulty_score,
+		        stimulus, question_stem, correct_answer_id, explanation, passage_id, quality_score,
+		        validation_status, validation_reasoning, adversarial_score,
+		        flagged, times_served, times_correct, created_at, language
+		 FROM questions WHERE id IN (%s)`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query questions by id: %w", err)
+	}
+	defer rows.Close()
 
+	var questions []models.Question
+	var ids []int64
+	for rows.Next() {
+		var q models.Question
+		if err := rows.Scan(&q.ID, &q.BatchID, &q.Section, &q.LRSubtype, &q.RCSubtype, &q.Difficulty, &q.DifficultyScore,
+			&q.Stimulus, &q.QuestionStem, &q.CorrectAnswerID, &q.Explanation,
+			&q.PassageID, &q.QualityScore,
+			&q.ValidationStatus, &q.ValidationReasoning, &q.AdversarialScore,
+			&q.Flagged, &q.TimesServed, &q.TimesCorrect, &q.CreatedAt, &q.Language); err != nil {
+			return nil, fmt.Errorf("scan question: %w", err)
+		}
+		questions = append(questions, q)
+		ids = append(ids, q.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	choiceMap, err := s.loadChoicesForQuestions(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range questions {
+		questions[i].Choices = choiceMap[questions[i].ID]
+		if questions[i].Choices == nil {
+			questions[i].Choices = []models.AnswerChoice{}
+		}
+	}
+	return questions, nil
+}
+
+// GetPassagesByIDs loads the passages in passageIDs, keyed by ID.
+func (s *Store) GetPassagesByIDs(passageIDs []int64) (map[int64]models.DrillPassage, error) {
+	return s.loadPassagesForIDs(passageIDs)
+}
+
+// fairServeOrder is a weighted-random sort key (Efraimidis-Spirakis) over
+// times_served. A question's weight is 1/(1+times_served): sorting ascending
+// favors rarely served questions without always picking the least served.
+// 1 - RANDOM() keeps the log argument in (0, 1]. Callers alias questions as q.
+const fairServeOrder = `-LN(1 - RANDOM()) * (1 + q.times_served)`
+
+// servingFilter restricts served questions to passed or unvalidated ones of
+// acceptable quality. includeFlagged also admits flagged questions of any
+// quality, for admins evaluating them live.
+func servingFilter(includeFlagged bool) string {
+	if includeFlagged {
+		return `AND q.validation_status IN ('passed', 'unvalidated', 'flagged')`
+	}
+	return `AND q.validation_status IN ('passed', 'unvalidated')
+		AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)`
+}
+
+func (s *Store) GetDrillQuestions(section models.Section, subtype *models.LRSubtype, difficulty models.Difficulty, count int, includeFlagged bool) ([]models.Question, error) {
 	qCols := `q.id, q.batch_id, q.section, q.lr_subtype, q.rc_subtype, q.difficulty, q.difficulty_score,
 		q.stimulus, q.question_stem, q.correct_answer_id, q.explanation,
 		q.passage_id, q.quality_score, q.validation_status, q.validation_reasoning,
 		q.adversarial_score, q.flagged, q.times_served, q.times_correct, q.created_at`
 	acCols := `ac.id, ac.choice_id, ac.choice_text, ac.explanation, ac.is_correct, COALESCE(ac.wrong_answer_type, '')`
 
-	// Filter: only serve passed/unvalidated questions with acceptable quality
 	// Flagged questions require admin review before serving
-	validationFilter := `AND q.validation_status IN ('passed', 'unvalidated')
-		AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)`
+	validationFilter := servingFilter(includeFlagged)
 
+	// Pick questions by fairServeOrder first, then join their choices, so the
+	// random order can't split a question's choices across the limit.
+	args := []interface{}{section, difficulty}
+	subtypeFilter := ""
 	if subtype != nil {
-		rows, err = s.db.Query(
-			fmt.Sprintf(`SELECT %s, %s
-			 FROM questions q
-			 JOIN answer_choices ac ON ac.question_id = q.id
-			 WHERE q.section = $1 AND q.lr_subtype = $2 AND q.difficulty = $3
-			 %s
-			 ORDER BY q.times_served ASC, q.id, ac.choice_id
-			 LIMIT $4`, qCols, acCols, validationFilter),
-			section, *subtype, difficulty, count*5,
-		)
-	} else {
-		rows, err = s.db.Query(
-			fmt.Sprintf(`SELECT %s, %s
-			 FROM questions q
-			 JOIN answer_choices ac ON ac.question_id = q.id
-			 WHERE q.section = $1 AND q.difficulty = $2
-			 %s
-			 ORDER BY q.times_served ASC, q.id, ac.choice_id
-			 LIMIT $3`, qCols, acCols, validationFilter),
-			section, difficulty, count*5,
-		)
+		args = append(args, *subtype)
+		subtypeFilter = "AND q.lr_subtype = $3"
 	}
+	args = append(args, count)
+	ids, err := s.queryIDs(
+		fmt.Sprintf(`SELECT q.id
+		 FROM questions q
+		 WHERE q.section = $1 AND q.difficulty = $2
+		 %s
+		 %s
+		 ORDER BY %s
+		 LIMIT $%d`, subtypeFilter, validationFilter, fairServeOrder, len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get drill questions: %w", err)
+	}
+	if len(ids) == 0 {
+		return []models.Question{}, nil
+	}
+	placeholders := make([]string, len(ids))
+	idArgs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		idArgs[i] = id
+	}
+	rows, err := s.db.Query(
+		fmt.Sprintf(`SELECT %s, %s
+		 FROM questions q
+		 JOIN answer_choices ac ON ac.question_id = q.id
+		 WHERE q.id IN (%s)
+		 ORDER BY q.id, ac.choice_id`, qCols, acCols, strings.Join(placeholders, ",")),
+		idArgs...,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("get drill questions: %w", err)
 	}
 	defer rows.Close()
 
-	return s.scanQuestionsWithChoices(rows, count)
+	loaded, err := s.scanQuestionsWithChoices(rows, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve in pick order
+	byID := make(map[int64]models.Question, len(loaded))
+	for _, q := range loaded {
+		byID[q.ID] = q
+	}
+	questions := make([]models.Question, 0, len(loaded))
+	for _, id := range ids {
+		if q, ok := byID[id]; ok {
+			questions = append(questions, q)
+		}
+	}
+	return questions, nil
 }
 
 func (s *Store) scanQuestionsWithChoices(rows *sql.Rows, maxQuestions int) ([]models.Question, error) {
@@ -390,20 +1097,39 @@ func (s *Store) getChoicesForQuestion(questionID int64) ([]models.AnswerChoice,
 	return choices, rows.Err()
 }
 
+// execer is the subset of *sql.DB and *sql.Tx used by statements that run
+// either standalone or inside an AnswerTx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 func (s *Store) IncrementServed(questionID int64) error {
-	_, err := s.db.Exec(`UPDATE questions SET times_served = times_served + 1 WHERE id = $1`, questionID)
-	return err
+	return incrementServed(s.db, questionID)
 }
 
 func (s *Store) IncrementCorrect(questionID int64) error {
-	_, err := s.db.Exec(`UPDATE questions SET times_correct = times_correct + 1 WHERE id = $1`, questionID)
+	return incrementCorrect(s.db, questionID)
+}
+
+func incrementServed(db execer, questionID int64) error {
+	_, err := db.Exec(`UPDATE questions SET times_served = times_served + 1 WHERE id = $1`, questionID)
+	return err
+}
+
+func incrementCorrect(db execer, questionID int64) error {
+	_, err := db.Exec(`UPDATE questions SET times_correct = times_correct + 1 WHERE id = $1`, questionID)
 	return err
 }
 
 // ── Ability Scores ──────────────────────────────────────
 
 func (s *Store) GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
-	_, err := s.db.Exec(
+	return getOrCreateAbility(s.db, s.abilityDecayRate, userID, scope, scopeValue)
+}
+
+func getOrCreateAbility(db execer, decayRate float64, userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
+	_, err := db.Exec(
 		`INSERT INTO user_ability_scores (user_id, scope, scope_value)
 		 VALUES ($1, $2, $3)
 		 ON CONFLICT (user_id, scope, scope_value) DO NOTHING`,
@@ -414,7 +1140,7 @@ func (s *Store) GetOrCreateAbility(userID int64, scope models.AbilityScope, scop
 	}
 
 	var a models.UserAbilityScore
-	err = s.db.QueryRow(
+	err = db.QueryRow(
 		`SELECT id, user_id, scope, scope_value, ability_score,
 		        questions_answered, questions_correct, last_updated
 		 FROM user_ability_scores
@@ -425,15 +1151,24 @@ func (s *Store) GetOrCreateAbility(userID int64, scope models.AbilityScope, scop
 	if err != nil {
 		return nil, fmt.Errorf("get ability: %w", err)
 	}
+
+	// Decay is applied on read rather than stored: last_updated keeps the
+	// time of the last answer, and the next UpdateAbility persists a score
+	// computed from the decayed value.
+	a.AbilityScore = DecayedAbility(a, decayRate, time.Now())
 	return &a, nil
 }
 
 func (s *Store) UpdateAbility(userID int64, scope models.AbilityScope, scopeValue *string, newScore int, correct bool) error {
+	return updateAbility(s.db, userID, scope, scopeValue, newScore, correct)
+}
+
+func updateAbility(db execer, userID int64, scope models.AbilityScope, scopeValue *string, newScore int, correct bool) error {
 	correctIncrement := 0
 	if correct {
 		correctIncrement = 1
 	}
-	_, err := s.db.Exec(
+	_, err := db.Exec(
 		`UPDATE user_ability_scores
 		 SET ability_score = $1,
 		     questions_answered = questions_answered + 1,
@@ -445,6 +1180,169 @@ func (s *Store) UpdateAbility(userID int64, scope models.AbilityScope, scopeValu
 	return err
 }
 
+// SetAbility overwrites the scope's ability without counting an answer, for
+// placement. The row must already exist (see GetOrCreateAbility).
+func (s *Store) SetAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int) error {
+	return setAbility(s.db, userID, scope, scopeValue, score)
+}
+
+func setAbility(db execer, userID int64, scope models.AbilityScope, scopeValue *string, score int) error {
+	_, err := db.Exec(
+		`UPDATE user_ability_scores
+		 SET ability_score = $1,
+		     last_updated = NOW()
+		 WHERE user_id = $2 AND scope = $3 AND scope_value IS NOT DISTINCT FROM $4`,
+		score, userID, scope, scopeValue,
+	)
+	return err
+}
+
+// SnapshotAbility records score as the scope's ability for day, replacing
+// any earlier snapshot that day.
+func (s *Store) SnapshotAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int, day time.Time) error {
+	return snapshotAbility(s.db, userID, scope, scopeValue, score, day)
+}
+
+func snapshotAbility(db execer, userID int64, scope models.AbilityScope, scopeValue *string, score int, day time.Time) error {
+	_, err := db.Exec(
+		`INSERT INTO ability_history (user_id, scope, scope_value, snapshot_date, ability_score)
+		 VALUES ($1, $2, COALESCE($3, ''), $4, $5)
+		 ON CONFLICT (user_id, scope, scope_value, snapshot_date)
+		 DO UPDATE SET ability_score = EXCLUDED.ability_score`,
+		userID, scope, scopeValue, day, score,
+	)
+	return err
+}
+
+// GetAbilityHistory returns the user's daily snapshots for one scope, oldest
+// first.
+func (s *Store) GetAbilityHistory(userID int64, scope models.AbilityScope, scopeValue *string) ([]models.AbilityHistoryPoint, error) {
+	rows, err := s.db.Query(
+		`SELECT snapshot_date, ability_score FROM ability_history
+		 WHERE user_id = $1 AND scope = $2 AND scope_value = COALESCE($3, '')
+		 ORDER BY snapshot_date`,
+		userID, scope, scopeValue,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get ability history: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.AbilityHistoryPoint{}
+	for rows.Next() {
+		var day time.Time
+		var p models.AbilityHistoryPoint
+		if err := rows.Scan(&day, &p.AbilityScore); err != nil {
+			return nil, fmt.Errorf("scan ability history: %w", err)
+		}
+		p.Date = day.Format("2006-01-02")
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// AnswerTx records the core effects of an answer (question counters, answer
+// history and ability scores) in one transaction, so a failure part way
+// through leaves none of them applied.
+type AnswerTx struct {
+	tx        *sql.Tx
+	decayRate float64
+}
+
+func (s *Store) BeginAnswer() (*AnswerTx, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	return &AnswerTx{tx: tx, decayRate: s.abilityDecayRate}, nil
+}
+
+func (a *AnswerTx) IncrementServed(questionID int64) error {
+	return incrementServed(a.tx, questionID)
+}
+
+func (a *AnswerTx) IncrementCorrect(questionID int64) error {
+	return incrementCorrect(a.tx, questionID)
+}
+
+func (a *AnswerTx) RecordAnswer(userID, questionID int64, correct bool, selectedChoiceID *string, timeSpentSeconds *float64, source models.AnswerSource) error {
+	return recordAnswer(a.tx, userID, questionID, correct, selectedChoiceID, timeSpentSeconds, source)
+}
+
+// RecordDailyChallengeAttempt saves the answer as the user's attempt at day's
+// challenge. It returns false if the user had already attempted it.
+func (a *AnswerTx) RecordDailyChallengeAttempt(day time.Time, userID, questionID int64, correct bool, selectedChoiceID string, timeSpentSeconds *float64, ranked bool) (bool, error) {
+	return recordDailyChallengeAttempt(a.tx, day, userID, questionID, correct, selectedChoiceID, timeSpentSeconds, ranked)
+}
+
+func (a *AnswerTx) HasAnswered(userID, questionID int64) (bool, error) {
+	return hasAnswered(a.tx, userID, questionID)
+}
+
+func (a *AnswerTx) GetOrCreateAbility(userID int64, scope models.AbilityScope, scopeValue *string) (*models.UserAbilityScore, error) {
+	return getOrCreateAbility(a.tx, a.decayRate, userID, scope, scopeValue)
+}
+
+func (a *AnswerTx) UpdateAbility(userID int64, scope models.AbilityScope, scopeValue *string, newScore int, correct bool) error {
+	return updateAbility(a.tx, userID, scope, scopeValue, newScore, correct)
+}
+
+func (a *AnswerTx) SetAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int) error {
+	return setAbility(a.tx, userID, scope, scopeValue, score)
+}
+
+// ClaimDiagnostic marks the user's diagnostic submitted. It reports false,
+// changing nothing, if it already was.
+func (a *AnswerTx) ClaimDiagnostic(userID, diagnosticID int64) (bool, error) {
+	result, err := a.tx.Exec(
+		`UPDATE diagnostic_sessions SET submitted_at = NOW()
+		 WHERE id = $1 AND user_id = $2 AND submitted_at IS NULL`,
+		diagnosticID, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("claim diagnostic: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func (a *AnswerTx) SnapshotAbility(userID int64, scope models.AbilityScope, scopeValue *string, score int, day time.Time) error {
+	return snapshotAbility(a.tx, userID, scope, scopeValue, score, day)
+}
+
+func (a *AnswerTx) Commit() error   { return a.tx.Commit() }
+func (a *AnswerTx) Rollback() error { return a.tx.Rollback() }
+
+// CreateDiagnosticSession records the questions served as a diagnostic and
+// returns its ID.
+func (s *Store) CreateDiagnosticSession(userID int64, questionIDs []int64) (int64, error) {
+	var id int64
+	err := s.db.QueryRow(
+		`INSERT INTO diagnostic_sessions (user_id, question_ids) VALUES ($1, $2) RETURNING id`,
+		userID, pq.Array(questionIDs),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("create diagnostic session: %w", err)
+	}
+	return id, nil
+}
+
+// GetDiagnosticSession returns the question IDs of the user's diagnostic and
+// whether it was submitted, or sql.ErrNoRows if the user wasn't served it.
+func (s *Store) GetDiagnosticSession(userID, diagnosticID int64) ([]int64, bool, error) {
+	var ids pq.Int64Array
+	var submitted bool
+	err := s.db.QueryRow(
+		`SELECT question_ids, submitted_at IS NOT NULL FROM diagnostic_sessions
+		 WHERE id = $1 AND user_id = $2`,
+		diagnosticID, userID,
+	).Scan(&ids, &submitted)
+	if err != nil {
+		return nil, false, err
+	}
+	return ids, submitted, nil
+}
+
 func (s *Store) GetAllAbilities(userID int64) (*models.AbilityResponse, error) {
 	rows, err := s.db.Query(
 		`SELECT scope, scope_value, ability_score
@@ -487,18 +1385,26 @@ func (s *Store) GetAllAbilities(userID int64) (*models.AbilityResponse, error) {
 
 // ── Question History ────────────────────────────────────
 
-func (s *Store) RecordAnswer(userID, questionID int64, correct bool, selectedChoiceID *string, timeSpentSeconds *float64) error {
-	_, err := s.db.Exec(
-		`INSERT INTO user_question_history (user_id, question_id, correct, selected_choice_id, time_spent_seconds, attempt_count)
-		 VALUES ($1, $2, $3, $4, $5, 1)
+// RecordAnswer saves the user's latest attempt at a question. source is the
+// practice mode it was answered in, or "" when unknown; like the rest of the
+// row it replaces the source of any earlier attempt.
+func (s *Store) RecordAnswer(userID, questionID int64, correct bool, selectedChoiceID *string, timeSpentSeconds *float64, source models.AnswerSource) error {
+	return recordAnswer(s.db, userID, questionID, correct, selectedChoiceID, timeSpentSeconds, source)
+}
+
+func recordAnswer(db execer, userID, questionID int64, correct bool, selectedChoiceID *string, timeSpentSeconds *float64, source models.AnswerSource) error {
+	_, err := db.Exec(
+		`INSERT INTO user_question_history (user_id, question_id, correct, selected_choice_id, time_spent_seconds, attempt_count, source)
+		 VALUES ($1, $2, $3, $4, $5, 1, NULLIF($6, ''))
 		 ON CONFLICT (user_id, question_id)
 		 DO UPDATE SET
 		    correct = $3,
 		    selected_choice_id = $4,
 		    time_spent_seconds = $5,
 		    attempt_count = user_question_history.attempt_count + 1,
-		    answered_at = NOW()`,
-		userID, questionID, correct, selectedChoiceID, timeSpentSeconds,
+		    answered_at = NOW(),
+		    source = NULLIF($6, '')`,
+		userID, questionID, correct, selectedChoiceID, timeSpentSeconds, string(source),
 	)
 	return err
 }
@@ -529,98 +1435,249 @@ func (s *Store) CountUnseenForUser(userID int64, section string, subtype string)
 	return count, err
 }
 
-// ── Adaptive Serving ────────────────────────────────────
+// HasAnswered reports whether the user has answered the question.
+func (s *Store) HasAnswered(userID, questionID int64) (bool, error) {
+	return hasAnswered(s.db, userID, questionID)
+}
+
+func hasAnswered(db execer, userID, questionID int64) (bool, error) {
+	var answered bool
+	err := db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM user_question_history WHERE user_id = $1 AND question_id = $2)`,
+		userID, questionID,
+	).Scan(&answered)
+	return answered, err
+}
 
-func (s *Store) GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int) (*models.DrillQuestion, error) {
+// GetSubtypeAccuracy returns how many questions of subtype the user has
+// answered and how many of those they got right.
+func (s *Store) GetSubtypeAccuracy(userID int64, subtype string) (answered, correct int, err error) {
+	err = s.db.QueryRow(
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE h.correct = true)
+		 FROM user_question_history h
+		 JOIN questions q ON q.id = h.question_id
+		 WHERE h.user_id = $1
+		   AND COALESCE(q.lr_subtype, q.rc_subtype) = $2`,
+		userID, subtype,
+	).Scan(&answered, &correct)
+	return answered, correct, err
+}
+
+// GetDifficultyHistogram counts the servable questions of a section+subtype
+// in each 10-point difficulty_score bucket.
+func (s *Store) GetDifficultyHistogram(section string, subtype string) (*models.DifficultyHistogram, error) {
 	var filterClause string
 	if strings.HasPrefix(subtype, "rc_") {
-		filterClause = "AND q.rc_subtype = $5"
+		filterClause = "AND rc_subtype = $2"
 	} else {
-		filterClause = "AND q.lr_subtype = $5"
+		filterClause = "AND lr_subtype = $2"
 	}
 
-	// First, pick one question
-	pickQuery := fmt.Sprintf(`
-		SELECT q.id, q.section, q.lr_subtype, q.rc_subtype, q.difficulty, q.difficulty_score,
-		       q.stimulus, q.question_stem, q.passage_id
-		FROM questions q
-		LEFT JOIN user_question_history h ON h.question_id = q.id AND h.user_id = $1
-		WHERE q.section = $2
-		  AND q.difficulty_score >= $3
-		  AND q.difficulty_score <= $4
-		  %s
-		  AND q.validation_status IN ('passed', 'unvalidated')
-		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)
-		ORDER BY
-		    CASE WHEN h.id IS NULL THEN 0 ELSE 1 END,
-		    RANDOM()
-		LIMIT 1`, filterClause)
+	rows, err := s.db.Query(
+		fmt.Sprintf(`SELECT difficulty_score, COUNT(*)
+		 FROM questions
+		 WHERE section = $1
+		   %s
+		   AND validation_status IN ('passed', 'unvalidated')
+		   AND (quality_score >= 0.50 OR quality_score IS NULL)
+		 GROUP BY difficulty_score`, filterClause),
+		section, subtype,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query difficulty histogram: %w", err)
+	}
+	defer rows.Close()
 
-	var id int64
-	var sect, difficulty string
-	var lrSubtype, rcSubtype *string
-	var diffScore int
-	var stimulus, stem string
-	var passageID *int64
+	counts := make(map[int]int)
+	for rows.Next() {
+		var score, count int
+		if err := rows.Scan(&score, &count); err != nil {
+			return nil, fmt.Errorf("scan difficulty histogram: %w", err)
+		}
+		counts[score] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	buckets, total := bucketDifficultyCounts(counts)
+	return &models.DifficultyHistogram{
+		Section: section,
+		Subtype: subtype,
+		Total:   total,
+		Buckets: buckets,
+	}, nil
+}
 
-	err := s.db.QueryRow(pickQuery, userID, section, minDiff, maxDiff, subtype).Scan(
-		&id, &sect, &lrSubtype, &rcSubtype, &difficulty, &diffScore, &stimulus, &stem, &passageID)
+// bucketDifficultyCounts folds per-score counts into ten buckets 0-9, 10-19,
+// ..., 90-100, always returning all ten so empty ranges show up as gaps.
+func bucketDifficultyCounts(counts map[int]int) ([]models.DifficultyBucket, int) {
+	buckets := make([]models.DifficultyBucket, 10)
+	for i := range buckets {
+		buckets[i] = models.DifficultyBucket{Min: i * 10, Max: i*10 + 9}
+	}
+	buckets[9].Max = 100
+
+	total := 0
+	for score, n := range counts {
+		i := min(max(score, 0)/10, 9)
+		buckets[i].Count += n
+		total += n
+	}
+	return buckets, total
+}
+
+// ── Adaptive Serving ────────────────────────────────────
+
+// recentlyMastered matches history rows the user got right on their first
+// try within the last 30 days. GetAdaptiveQuestions and GetOneAdaptiveQuestion
+// skip these; passage serving only ranks them last.
+const recentlyMastered = `(h.correct AND h.attempt_count = 1 AND h.answered_at > NOW() - INTERVAL '30 days')`
+
+// servingTier groups candidates for serving: questions the user hasn't
+// answered first, then ones they missed, then other seen ones, with recently
+// mastered ones last. Callers alias the user's history as h.
+const servingTier = `CASE WHEN h.id IS NULL THEN 0 WHEN NOT h.correct THEN 1 WHEN ` + recentlyMastered + ` THEN 3 ELSE 2 END`
+
+// adaptiveOrder ranks serving candidates by servingTier, featured questions
+// first within each tier, then at random. Callers alias questions as q.
+const adaptiveOrder = servingTier + `,
+		    CASE WHEN q.featured THEN 0 ELSE 1 END,
+		    RANDOM()`
+
+// notRecentlyMastered filters out questions matching recentlyMastered.
+const notRecentlyMastered = `AND NOT COALESCE(` + recentlyMastered + `, FALSE)`
+
+// pickServing runs a query selecting q.id and returns the IDs of its first
+// limit rows in adaptiveOrder. The order and limit are applied in SQL, so
+// only the served IDs are read however large the pool is.
+func (s *Store) pickServing(query string, args []interface{}, limit int) ([]int64, error) {
+	query, args = servingPickQuery(query, args, limit)
+	return s.queryIDs(query, args...)
+}
+
+// queryIDs runs a query selecting a single ID column.
+func (s *Store) queryIDs(query string, args ...interface{}) ([]int64, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("get one adaptive question: %w", err)
+		ids = append(ids, id)
 	}
+	return ids, rows.Err()
+}
+
+// servingPickQuery appends adaptiveOrder and a LIMIT bound to the next
+// parameter to query.
+func servingPickQuery(query string, args []interface{}, limit int) (string, []interface{}) {
+	args = append(args[:len(args):len(args)], limit)
+	return fmt.Sprintf("%s\n\t\tORDER BY %s\n\t\tLIMIT $%d", query, adaptiveOrder, len(args)), args
+}
+
+// repeatWindowFilter filters out questions the user answered within the last
+// hours, or among their last answers, so a question isn't served twice in
+// quick succession. Callers alias questions as q and the user's history as h,
+// with the user ID as $1.
+func repeatWindowFilter(hours, answers int) string {
+	var clauses []string
+	if hours > 0 {
+		clauses = append(clauses, fmt.Sprintf("AND (h.id IS NULL OR h.answered_at <= NOW() - INTERVAL '%d hours')", hours))
+	}
+	if answers > 0 {
+		clauses = append(clauses, fmt.Sprintf(`AND q.id NOT IN (
+		      SELECT question_id FROM user_question_history
+		      WHERE user_id = $1
+		      ORDER BY answered_at DESC
+		      LIMIT %d)`, answers))
+	}
+	return strings.Join(clauses, "\n\t\t  ")
+}
 
-	dq := &models.DrillQuestion{
-		ID:              id,
-		Section:         models.Section(sect),
-		Difficulty:      models.Difficulty(difficulty),
-		DifficultyScore: diffScore,
-		Stimulus:        stimulus,
-		QuestionStem:    stem,
+// repeatWindowPasses returns the repeat window filters to pick with, in
+// order: the window, then none at all. A user who has answered every
+// candidate recently gets a repeat rather than nothing.
+func repeatWindowPasses(hours, answers int) []string {
+	window := repeatWindowFilter(hours, answers)
+	if window == "" {
+		return []string{""}
 	}
-	if lrSubtype != nil {
-		ls := models.LRSubtype(*lrSubtype)
-		dq.LRSubtype = &ls
+	return []string{window, ""}
+}
+
+// repeatWindowPasses is repeatWindowPasses for the store's configured window.
+func (s *Store) repeatWindowPasses() []string {
+	return repeatWindowPasses(s.repeatWindowHours, s.repeatWindowAnswers)
+}
+
+// GetOneAdaptiveQuestion picks one question in the difficulty window,
+// preferring ones the user hasn't answered. An empty subtype matches any
+// subtype in the section; excludeIDs are never returned.
+func (s *Store) GetOneAdaptiveQuestion(userID int64, section string, subtype string, minDiff, maxDiff int, excludeIDs []int64) (*models.DrillQuestion, error) {
+	args := []interface{}{userID, section, minDiff, maxDiff}
+	var filterClauses []string
+	if subtype != "" {
+		args = append(args, subtype)
+		if strings.HasPrefix(subtype, "rc_") {
+			filterClauses = append(filterClauses, fmt.Sprintf("AND q.rc_subtype = $%d", len(args)))
+		} else {
+			filterClauses = append(filterClauses, fmt.Sprintf("AND q.lr_subtype = $%d", len(args)))
+		}
 	}
-	if rcSubtype != nil {
-		rs := models.RCSubtype(*rcSubtype)
-		dq.RCSubtype = &rs
+	if len(excludeIDs) > 0 {
+		placeholders := make([]string, len(excludeIDs))
+		for i, id := range excludeIDs {
+			args = append(args, id)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		filterClauses = append(filterClauses, fmt.Sprintf("AND q.id NOT IN (%s)", strings.Join(placeholders, ",")))
 	}
+	filterClause := strings.Join(filterClauses, " ")
 
-	// Then fetch all choices for that question
-	choiceRows, err := s.db.Query(
-		`SELECT choice_id, choice_text FROM answer_choices WHERE question_id = $1 ORDER BY choice_id`, id)
-	if err != nil {
-		return nil, fmt.Errorf("get choices: %w", err)
-	}
-	defer choiceRows.Close()
+	// Pick one question, outside the repeat window if there is one
+	var ids []int64
+	for _, window := range s.repeatWindowPasses() {
+		pickQuery := fmt.Sprintf(`
+		SELECT q.id
+		FROM questions q
+		LEFT JOIN user_question_history h ON h.question_id = q.id AND h.user_id = $1
+		WHERE q.section = $2
+		  AND q.difficulty_score >= $3
+		  AND q.difficulty_score <= $4
+		  %s
+		  `+notRecentlyMastered+`
+		  %s
+		  AND q.validation_status IN ('passed', 'unvalidated')
+		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)`, filterClause, window)
 
-	for choiceRows.Next() {
-		var choiceID, choiceText string
-		if err := choiceRows.Scan(&choiceID, &choiceText); err != nil {
-			return nil, err
+		var err error
+		ids, err = s.pickServing(pickQuery, args, 1)
+		if err != nil {
+			return nil, fmt.Errorf("get one adaptive question: %w", err)
 		}
-		dq.Choices = append(dq.Choices, models.DrillChoice{
-			ChoiceID:   choiceID,
-			ChoiceText: choiceText,
-		})
-	}
-
-	// Attach passage for RC questions
-	if passageID != nil {
-		passage, err := s.GetPassage(*passageID)
-		if err == nil {
-			dp := passage.ToDrillPassage()
-			dq.Passage = &dp
+		if len(ids) > 0 {
+			break
 		}
 	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
 
-	return dq, choiceRows.Err()
+	questions, err := s.GetDrillQuestionsByIDs(ids)
+	if err != nil || len(questions) == 0 {
+		return nil, err
+	}
+	return &questions[0], nil
 }
 
-func (s *Store) GetAdaptiveQuestions(userID int64, section string, subtype *string, minDiff, maxDiff, count int, excludeIDs []int64) ([]models.DrillQuestion, error) {
+func (s *Store) GetAdaptiveQuestions(userID int64, section string, subtype *string, minDiff, maxDiff, count int, excludeIDs []int64, includeFlagged bool) ([]models.DrillQuestion, error) {
 	args := []interface{}{userID, section, minDiff, maxDiff}
 	paramIdx := 5
 
@@ -648,8 +1705,10 @@ func (s *Store) GetAdaptiveQuestions(userID int64, section string, subtype *stri
 
 	extra := strings.Join(filterClauses, " ")
 
-	// First, pick the question IDs
-	pickQuery := fmt.Sprintf(`
+	// First, pick the question IDs, outside the repeat window if there is one
+	var questionIDs []int64
+	for _, window := range s.repeatWindowPasses() {
+		pickQuery := fmt.Sprintf(`
 		SELECT q.id
 		FROM questions q
 		LEFT JOIN user_question_history h ON h.question_id = q.id AND h.user_id = $1
@@ -657,29 +1716,18 @@ func (s *Store) GetAdaptiveQuestions(userID int64, section string, subtype *stri
 		  AND q.difficulty_score >= $3
 		  AND q.difficulty_score <= $4
 		  %s
-		  AND q.validation_status IN ('passed', 'unvalidated')
-		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)
-		ORDER BY
-		    CASE WHEN h.id IS NULL THEN 0 ELSE 1 END,
-		    RANDOM()
-		LIMIT %d`, extra, count)
-
-	idRows, err := s.db.Query(pickQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("get adaptive questions: %w", err)
-	}
-	defer idRows.Close()
+		  `+notRecentlyMastered+`
+		  %s
+		  %s`, extra, window, servingFilter(includeFlagged))
 
-	var questionIDs []int64
-	for idRows.Next() {
-		var id int64
-		if err := idRows.Scan(&id); err != nil {
-			return nil, err
+		var err error
+		questionIDs, err = s.pickServing(pickQuery, args, count)
+		if err != nil {
+			return nil, fmt.Errorf("get adaptive questions: %w", err)
+		}
+		if len(questionIDs) > 0 {
+			break
 		}
-		questionIDs = append(questionIDs, id)
-	}
-	if err := idRows.Err(); err != nil {
-		return nil, err
 	}
 	if len(questionIDs) == 0 {
 		return nil, nil
@@ -696,7 +1744,7 @@ func (s *Store) GetAdaptiveQuestions(userID int64, section string, subtype *stri
 	fullQuery := fmt.Sprintf(`
 		SELECT q.id, q.section, q.lr_subtype, q.rc_subtype, q.difficulty, q.difficulty_score,
 		       q.stimulus, q.question_stem, q.passage_id,
-		       ac.choice_id, ac.choice_text
+		       ac.choice_id, ac.choice_text, ac.is_correct
 		FROM questions q
 		JOIN answer_choices ac ON ac.question_id = q.id
 		WHERE q.id IN (%s)
@@ -711,11 +1759,96 @@ func (s *Store) GetAdaptiveQuestions(userID int64, section string, subtype *stri
 	return s.scanDrillQuestions(rows, count)
 }
 
+// GetDrillQuestionsByIDs returns the given questions as DrillQuestions, with
+// the answer key stripped, ordered by ID.
+func (s *Store) GetDrillQuestionsByIDs(questionIDs []int64) ([]models.DrillQuestion, error) {
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(questionIDs))
+	args := make([]interface{}, len(questionIDs))
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT q.id, q.section, q.lr_subtype, q.rc_subtype, q.difficulty, q.difficulty_score,
+		       q.stimulus, q.question_stem, q.passage_id,
+		       ac.choice_id, ac.choice_text, ac.is_correct
+		FROM questions q
+		JOIN answer_choices ac ON ac.question_id = q.id
+		WHERE q.id IN (%s)
+		ORDER BY q.id, ac.choice_id`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("get drill questions by id: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanDrillQuestions(rows, len(questionIDs))
+}
+
+// GetDrillQuestionsByBatch returns a batch's questions as DrillQuestions,
+// with the answer key stripped, in generation order.
+func (s *Store) GetDrillQuestionsByBatch(batchID int64) ([]models.DrillQuestion, error) {
+	rows, err := s.db.Query(
+		`SELECT id, section, lr_subtype, rc_subtype, difficulty, difficulty_score,
+		        stimulus, question_stem, passage_id
+		 FROM questions WHERE batch_id = $1 ORDER BY id`,
+		batchID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get batch questions: %w", err)
+	}
+	var full []models.Question
+	for rows.Next() {
+		var q models.Question
+		if err := rows.Scan(&q.ID, &q.Section, &q.LRSubtype, &q.RCSubtype, &q.Difficulty, &q.DifficultyScore,
+			&q.Stimulus, &q.QuestionStem, &q.PassageID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan batch question: %w", err)
+		}
+		full = append(full, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	questions := make([]models.DrillQuestion, 0, len(full))
+	passages := make(map[int64]*models.DrillPassage)
+	for i := range full {
+		q := &full[i]
+		choices, err := s.getChoicesForQuestion(q.ID)
+		if err != nil {
+			return nil, err
+		}
+		q.Choices = choices
+		dq := q.ToDrillQuestion()
+
+		if q.PassageID != nil {
+			dp, ok := passages[*q.PassageID]
+			if !ok {
+				if p, err := s.GetPassage(*q.PassageID); err == nil {
+					d := p.ToDrillPassage()
+					dp = &d
+				}
+				passages[*q.PassageID] = dp
+			}
+			dq.Passage = dp
+		}
+		questions = append(questions, dq)
+	}
+	return questions, nil
+}
+
 func (s *Store) scanDrillQuestions(rows *sql.Rows, maxQuestions int) ([]models.DrillQuestion, error) {
 	questionMap := make(map[int64]*models.DrillQuestion)
 	var questionOrder []int64
 	passageIDs := make(map[int64]bool)
 	questionPassages := make(map[int64]int64) // questionID -> passageID
+	correct := make(map[int64]int)
 
 	for rows.Next() {
 		var id int64
@@ -725,11 +1858,15 @@ func (s *Store) scanDrillQuestions(rows *sql.Rows, maxQuestions int) ([]models.D
 		var stimulus, stem string
 		var passageID *int64
 		var choiceID, choiceText string
+		var isCorrect bool
 
 		if err := rows.Scan(&id, &sect, &lrSubtype, &rcSubtype, &difficulty, &diffScore,
-			&stimulus, &stem, &passageID, &choiceID, &choiceText); err != nil {
+			&stimulus, &stem, &passageID, &choiceID, &choiceText, &isCorrect); err != nil {
 			return nil, fmt.Errorf("scan drill question: %w", err)
 		}
+		if isCorrect {
+			correct[id]++
+		}
 
 		if existing, ok := questionMap[id]; ok {
 			existing.Choices = append(existing.Choices, models.DrillChoice{
@@ -791,6 +1928,9 @@ func (s *Store) scanDrillQuestions(rows *sql.Rows, maxQuestions int) ([]models.D
 
 	questions := make([]models.DrillQuestion, 0, len(questionOrder))
 	for _, id := range questionOrder {
+		if correct[id] > 1 {
+			questionMap[id].SelectCount = correct[id]
+		}
 		questions = append(questions, *questionMap[id])
 	}
 	return questions, nil
@@ -837,7 +1977,10 @@ func (s *Store) CountQuestionsInBucket(section string, subtype *string, minDiff,
 
 // ── Generation Queue ────────────────────────────────────
 
-func (s *Store) UpsertGenerationQueue(section string, subtype *string, minDiff, maxDiff int, targetDiff string, needed int) error {
+// UpsertGenerationQueue queues a bucket top-up unless one is already pending.
+// A high-priority request promotes an already pending item so it isn't held
+// for the off-peak window.
+func (s *Store) UpsertGenerationQueue(section string, subtype *string, minDiff, maxDiff int, targetDiff string, needed int, highPriority bool) error {
 	var lrSubtype, rcSubtype *string
 	if subtype != nil {
 		if strings.HasPrefix(*subtype, "rc_") {
@@ -848,8 +1991,8 @@ func (s *Store) UpsertGenerationQueue(section string, subtype *string, minDiff,
 	}
 
 	_, err := s.db.Exec(
-		`INSERT INTO generation_queue (section, lr_subtype, rc_subtype, difficulty_bucket_min, difficulty_bucket_max, target_difficulty, questions_needed)
-		 SELECT $1, $2, $3, $4, $5, $6, $7
+		`INSERT INTO generation_queue (section, lr_subtype, rc_subtype, difficulty_bucket_min, difficulty_bucket_max, target_difficulty, questions_needed, high_priority)
+		 SELECT $1, $2, $3, $4, $5, $6, $7, $8
 		 WHERE NOT EXISTS (
 		     SELECT 1 FROM generation_queue
 		     WHERE section = $1
@@ -859,37 +2002,51 @@ func (s *Store) UpsertGenerationQueue(section string, subtype *string, minDiff,
 		     AND difficulty_bucket_max = $5
 		     AND status IN ('pending', 'generating')
 		 )`,
-		section, lrSubtype, rcSubtype, minDiff, maxDiff, targetDiff, needed,
+		section, lrSubtype, rcSubtype, minDiff, maxDiff, targetDiff, needed, highPriority,
+	)
+	if err != nil || !highPriority {
+		return err
+	}
+	_, err = s.db.Exec(
+		`UPDATE generation_queue SET high_priority = TRUE
+		 WHERE section = $1
+		 AND lr_subtype IS NOT DISTINCT FROM $2
+		 AND rc_subtype IS NOT DISTINCT FROM $3
+		 AND difficulty_bucket_min = $4
+		 AND difficulty_bucket_max = $5
+		 AND status = 'pending'
+		 AND NOT high_priority`,
+		section, lrSubtype, rcSubtype, minDiff, maxDiff,
 	)
 	return err
 }
 
-func (s *Store) GetPendingGenerations(limit int) ([]models.GenerationQueueItem, error) {
-	rows, err := s.db.Query(
-		`SELECT id, section, lr_subtype, rc_subtype,
+const queueItemCols = `id, section, lr_subtype, rc_subtype,
 		        difficulty_bucket_min, difficulty_bucket_max,
 		        target_difficulty, status, questions_needed,
-		        subject_area, COALESCE(is_comparative, FALSE),
-		        error_message, created_at, completed_at
-		 FROM generation_queue
-		 WHERE status = 'pending'
-		 ORDER BY created_at ASC
-		 LIMIT $1`,
-		limit,
-	)
+		        subject_area, COALESCE(is_comparative, FALSE), high_priority,
+		        error_message, created_at, started_at, completed_at`
+
+func scanQueueItem(row interface{ Scan(...interface{}) error }, item *models.GenerationQueueItem) error {
+	return row.Scan(&item.ID, &item.Section, &item.LRSubtype, &item.RCSubtype,
+		&item.DifficultyBucketMin, &item.DifficultyBucketMax,
+		&item.TargetDifficulty, &item.Status, &item.QuestionsNeeded,
+		&item.SubjectArea, &item.IsComparative, &item.HighPriority,
+		&item.ErrorMessage, &item.CreatedAt, &item.StartedAt, &item.CompletedAt)
+}
+
+// queryQueueItems runs a generation_queue query selecting queueItemCols.
+func (s *Store) queryQueueItems(query string, args ...interface{}) ([]models.GenerationQueueItem, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("get pending generations: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
 	var items []models.GenerationQueueItem
 	for rows.Next() {
 		var item models.GenerationQueueItem
-		if err := rows.Scan(&item.ID, &item.Section, &item.LRSubtype, &item.RCSubtype,
-			&item.DifficultyBucketMin, &item.DifficultyBucketMax,
-			&item.TargetDifficulty, &item.Status, &item.QuestionsNeeded,
-			&item.SubjectArea, &item.IsComparative,
-			&item.ErrorMessage, &item.CreatedAt, &item.CompletedAt); err != nil {
+		if err := scanQueueItem(rows, &item); err != nil {
 			return nil, fmt.Errorf("scan generation queue item: %w", err)
 		}
 		items = append(items, item)
@@ -897,6 +2054,105 @@ func (s *Store) GetPendingGenerations(limit int) ([]models.GenerationQueueItem,
 	return items, rows.Err()
 }
 
+func (s *Store) GetPendingGenerations(limit int) ([]models.GenerationQueueItem, error) {
+	items, err := s.queryQueueItems(
+		`SELECT `+queueItemCols+`
+		 FROM generation_queue
+		 WHERE status = 'pending'
+		 ORDER BY high_priority DESC, created_at ASC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get pending generations: %w", err)
+	}
+	return items, nil
+}
+
+// GetGeneratingItems returns the items currently marked as generating.
+func (s *Store) GetGeneratingItems() ([]models.GenerationQueueItem, error) {
+	items, err := s.queryQueueItems(
+		`SELECT ` + queueItemCols + `
+		 FROM generation_queue
+		 WHERE status = 'generating'
+		 ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get generating items: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Store) GetGenerationQueueItem(id int64) (*models.GenerationQueueItem, error) {
+	var item models.GenerationQueueItem
+	err := scanQueueItem(s.db.QueryRow(
+		`SELECT `+queueItemCols+` FROM generation_queue WHERE id = $1`, id), &item)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ResetGenerationItem puts a generating or failed item back to pending so
+// the worker retries it. It reports false, changing nothing, if the item has
+// since moved on, or if a newer item for the same bucket is already pending
+// or generating and would duplicate it.
+func (s *Store) ResetGenerationItem(id int64) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE generation_queue q
+		 SET status = 'pending', error_message = NULL, started_at = NULL, completed_at = NULL
+		 WHERE q.id = $1
+		 AND q.status IN ('generating', 'failed')
+		 AND NOT EXISTS (
+		     SELECT 1 FROM generation_queue n
+		     WHERE n.id > q.id
+		     AND n.section = q.section
+		     AND n.lr_subtype IS NOT DISTINCT FROM q.lr_subtype
+		     AND n.rc_subtype IS NOT DISTINCT FROM q.rc_subtype
+		     AND n.difficulty_bucket_min = q.difficulty_bucket_min
+		     AND n.difficulty_bucket_max = q.difficulty_bucket_max
+		     AND n.status IN ('pending', 'generating')
+		 )`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// ListGenerationQueue pages through queue items, newest first, optionally
+// only those with status. It also returns the total number matching.
+func (s *Store) ListGenerationQueue(status string, page, pageSize int) ([]models.GenerationQueueItem, int, error) {
+	where := ""
+	args := []interface{}{}
+	if status != "" {
+		where = "WHERE status = $1"
+		args = append(args, status)
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM generation_queue `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count generation queue: %w", err)
+	}
+
+	paramIdx := len(args) + 1
+	args = append(args, pageSize, (page-1)*pageSize)
+	items, err := s.queryQueueItems(fmt.Sprintf(
+		`SELECT `+queueItemCols+`
+		 FROM generation_queue
+		 %s
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $%d OFFSET $%d`, where, paramIdx, paramIdx+1),
+		args...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list generation queue: %w", err)
+	}
+	return items, total, nil
+}
+
 func (s *Store) UpdateGenerationStatus(id int64, status string, errMsg *string) error {
 	if status == "completed" || status == "failed" {
 		_, err := s.db.Exec(
@@ -905,6 +2161,13 @@ func (s *Store) UpdateGenerationStatus(id int64, status string, errMsg *string)
 		)
 		return err
 	}
+	if status == "generating" {
+		_, err := s.db.Exec(
+			`UPDATE generation_queue SET status = $1, started_at = NOW() WHERE id = $2`,
+			status, id,
+		)
+		return err
+	}
 	_, err := s.db.Exec(
 		`UPDATE generation_queue SET status = $1 WHERE id = $2`,
 		status, id,
@@ -936,6 +2199,7 @@ func (s *Store) SetDifficultySlider(userID int64, value int) error {
 func (s *Store) GetQualityStats() (*models.QualityStats, error) {
 	stats := &models.QualityStats{
 		QualityDistribution: make(map[string]int),
+		ByPromptVersion:     make(map[string]models.PromptVersionStats),
 	}
 
 	err := s.db.QueryRow(
@@ -980,8 +2244,57 @@ func (s *Store) GetQualityStats() (*models.QualityStats, error) {
 		}
 		stats.QualityDistribution[bucket] = count
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Per prompt version, so regressions after a prompt change are attributable
+	versionRows, err := s.db.Query(
+		`SELECT COALESCE(prompt_version, 'unknown'),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE validation_status = 'passed'),
+			COUNT(*) FILTER (WHERE validation_status = 'flagged'),
+			COUNT(*) FILTER (WHERE validation_status = 'rejected'),
+			COALESCE(AVG(quality_score), 0)
+		 FROM questions
+		 GROUP BY 1`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("quality by prompt version: %w", err)
+	}
+	defer versionRows.Close()
+
+	for versionRows.Next() {
+		var version string
+		var vs models.PromptVersionStats
+		if err := versionRows.Scan(&version, &vs.Total, &vs.Passed, &vs.Flagged, &vs.Rejected, &vs.AvgQuality); err != nil {
+			return nil, err
+		}
+		if vs.Total > 0 {
+			vs.PassRate = float64(vs.Passed) / float64(vs.Total)
+		}
+		stats.ByPromptVersion[version] = vs
+	}
+
+	return stats, versionRows.Err()
+}
 
-	return stats, rows.Err()
+func (s *Store) GetStructuralStats() (*models.StructuralStats, error) {
+	stats := &models.StructuralStats{}
+	err := s.db.QueryRow(
+		`SELECT
+			COUNT(*),
+			COALESCE(AVG(choice_length_balance), 0),
+			COUNT(*) FILTER (WHERE correct_length_outlier)
+		 FROM questions WHERE choice_length_balance IS NOT NULL`,
+	).Scan(&stats.TotalScored, &stats.AvgLengthBalance, &stats.CorrectLengthOutliers)
+	if err != nil {
+		return nil, fmt.Errorf("structural stats: %w", err)
+	}
+	if stats.TotalScored > 0 {
+		stats.CorrectLengthOutlierRate = float64(stats.CorrectLengthOutliers) / float64(stats.TotalScored)
+	}
+	return stats, nil
 }
 
 func (s *Store) GetGenerationStats() (*models.GenerationStats, error) {
@@ -1015,25 +2328,171 @@ func (s *Store) GetGenerationStats() (*models.GenerationStats, error) {
 		`SELECT
 			COALESCE(SUM(total_cost_cents) FILTER (WHERE created_at >= CURRENT_DATE), 0),
 			COALESCE(SUM(total_cost_cents) FILTER (WHERE created_at >= date_trunc('week', CURRENT_DATE)), 0),
-			COALESCE(SUM(total_cost_cents) FILTER (WHERE created_at >= date_trunc('month', CURRENT_DATE)), 0)
+			COALESCE(SUM(total_cost_cents) FILTER (WHERE created_at >= date_trunc('month', CURRENT_DATE)), 0),
+			COALESCE(SUM(validation_cost_cents) FILTER (WHERE created_at >= date_trunc('month', CURRENT_DATE)), 0)
 		 FROM question_batches WHERE status = 'completed'`,
-	).Scan(&stats.Cost.TodayCents, &stats.Cost.ThisWeekCents, &stats.Cost.ThisMonthCents)
+	).Scan(&stats.Cost.TodayCents, &stats.Cost.ThisWeekCents, &stats.Cost.ThisMonthCents, &stats.Cost.ValidationThisMonthCents)
+	if err != nil {
+		return nil, fmt.Errorf("generation stats cost: %w", err)
+	}
+
+	stats.Cost.DailyLimitCents = 1000 // default $10
+
+	return stats, nil
+}
+
+func (s *Store) GetFlaggedQuestions(limit, offset int) ([]models.Question, int, error) {
+	var total int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM questions WHERE flagged = true OR validation_status = 'flagged'`,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count flagged: %w", err)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, batch_id, section, lr_subtype, rc_subtype, difficulty, difficulty_score,
+		        stimulus, question_stem, correct_answer_id, explanation,
+		        passage_id, quality_score,
+		        validation_status, validation_reasoning, adversarial_score,
+		        flagged, times_served, times_correct, created_at
+		 FROM questions
+		 WHERE flagged = true OR validation_status = 'flagged'
+		 ORDER BY created_at DESC LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get flagged: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		var q models.Question
+		if err := rows.Scan(&q.ID, &q.BatchID, &q.Section, &q.LRSubtype, &q.RCSubtype,
+			&q.Difficulty, &q.DifficultyScore,
+			&q.Stimulus, &q.QuestionStem, &q.CorrectAnswerID, &q.Explanation,
+			&q.PassageID, &q.QualityScore,
+			&q.ValidationStatus, &q.ValidationReasoning, &q.AdversarialScore,
+			&q.Flagged, &q.TimesServed, &q.TimesCorrect, &q.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan flagged: %w", err)
+		}
+		choices, err := s.getChoicesForQuestion(q.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		q.Choices = choices
+		questions = append(questions, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]int64, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	flags, err := s.getUserFlags(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	attachUserFlags(questions, flags)
+
+	return questions, total, nil
+}
+
+// FlagQuestion records a user's dispute of a question and marks the question
+// flagged so it shows up in the admin flagged queue. A user re-flagging the
+// same question replaces their reason.
+func (s *Store) FlagQuestion(userID, questionID int64, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE questions SET flagged = true WHERE id = $1`, questionID)
+	if err != nil {
+		return fmt.Errorf("flag question: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("question not found")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO question_flags (question_id, user_id, reason)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (question_id, user_id)
+		 DO UPDATE SET reason = $3, created_at = NOW()`,
+		questionID, userID, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("record question flag: %w", err)
+	}
+	return tx.Commit()
+}
+
+// getUserFlags returns the user flags on each of questionIDs, oldest first.
+func (s *Store) getUserFlags(questionIDs []int64) (map[int64][]models.QuestionFlag, error) {
+	flags := make(map[int64][]models.QuestionFlag)
+	if len(questionIDs) == 0 {
+		return flags, nil
+	}
+	placeholders := make([]string, len(questionIDs))
+	args := make([]interface{}, len(questionIDs))
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT question_id, user_id, reason, created_at
+		 FROM question_flags WHERE question_id IN (%s)
+		 ORDER BY created_at`, strings.Join(placeholders, ",")),
+		args...,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("generation stats cost: %w", err)
+		return nil, fmt.Errorf("get user flags: %w", err)
 	}
+	defer rows.Close()
 
-	stats.Cost.DailyLimitCents = 1000 // default $10
+	for rows.Next() {
+		var questionID int64
+		var f models.QuestionFlag
+		if err := rows.Scan(&questionID, &f.UserID, &f.Reason, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		flags[questionID] = append(flags[questionID], f)
+	}
+	return flags, rows.Err()
+}
 
-	return stats, nil
+// attachUserFlags sets each question's UserFlags from flags by question ID.
+func attachUserFlags(questions []models.Question, flags map[int64][]models.QuestionFlag) {
+	for i := range questions {
+		questions[i].UserFlags = flags[questions[i].ID]
+	}
 }
 
-func (s *Store) GetFlaggedQuestions(limit, offset int) ([]models.Question, int, error) {
+// searchPattern builds a case-insensitive ILIKE substring pattern, escaping
+// LIKE wildcards in the user's query so they match literally.
+func searchPattern(query string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.TrimSpace(query))
+	return "%" + escaped + "%"
+}
+
+// SearchQuestions returns questions whose stimulus or stem contains query,
+// newest first.
+func (s *Store) SearchQuestions(query string, page, pageSize int) ([]models.Question, int, error) {
+	pattern := searchPattern(query)
+
 	var total int
 	err := s.db.QueryRow(
-		`SELECT COUNT(*) FROM questions WHERE flagged = true OR validation_status = 'flagged'`,
+		`SELECT COUNT(*) FROM questions WHERE stimulus ILIKE $1 OR question_stem ILIKE $1`,
+		pattern,
 	).Scan(&total)
 	if err != nil {
-		return nil, 0, fmt.Errorf("count flagged: %w", err)
+		return nil, 0, fmt.Errorf("count search results: %w", err)
 	}
 
 	rows, err := s.db.Query(
@@ -1043,16 +2502,17 @@ func (s *Store) GetFlaggedQuestions(limit, offset int) ([]models.Question, int,
 		        validation_status, validation_reasoning, adversarial_score,
 		        flagged, times_served, times_correct, created_at
 		 FROM questions
-		 WHERE flagged = true OR validation_status = 'flagged'
-		 ORDER BY created_at DESC LIMIT $1 OFFSET $2`,
-		limit, offset,
+		 WHERE stimulus ILIKE $1 OR question_stem ILIKE $1
+		 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
+		pattern, pageSize, (page-1)*pageSize,
 	)
 	if err != nil {
-		return nil, 0, fmt.Errorf("get flagged: %w", err)
+		return nil, 0, fmt.Errorf("search questions: %w", err)
 	}
 	defer rows.Close()
 
 	var questions []models.Question
+	var ids []int64
 	for rows.Next() {
 		var q models.Question
 		if err := rows.Scan(&q.ID, &q.BatchID, &q.Section, &q.LRSubtype, &q.RCSubtype,
@@ -1061,27 +2521,50 @@ func (s *Store) GetFlaggedQuestions(limit, offset int) ([]models.Question, int,
 			&q.PassageID, &q.QualityScore,
 			&q.ValidationStatus, &q.ValidationReasoning, &q.AdversarialScore,
 			&q.Flagged, &q.TimesServed, &q.TimesCorrect, &q.CreatedAt); err != nil {
-			return nil, 0, fmt.Errorf("scan flagged: %w", err)
-		}
-		choices, err := s.getChoicesForQuestion(q.ID)
-		if err != nil {
-			return nil, 0, err
+			return nil, 0, fmt.Errorf("scan search result: %w", err)
 		}
-		q.Choices = choices
 		questions = append(questions, q)
+		ids = append(ids, q.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	choiceMap, err := s.loadChoicesForQuestions(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range questions {
+		questions[i].Choices = choiceMap[questions[i].ID]
 	}
+	return questions, total, nil
+}
+
+// distinctRespondersExpr counts the users who have answered question q.
+// History keeps one row per user and question, with repeat attempts in
+// attempt_count, so each user counts once however often they answered.
+const distinctRespondersExpr = `(SELECT COUNT(DISTINCT h.user_id) FROM user_question_history h WHERE h.question_id = q.id)`
 
-	return questions, total, rows.Err()
+// GetDistinctResponders returns how many users have answered the question.
+func (s *Store) GetDistinctResponders(questionID int64) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT `+distinctRespondersExpr+` FROM questions q WHERE q.id = $1`, questionID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("distinct responders: %w", err)
+	}
+	return n, nil
 }
 
+// recalibrationCandidatesQuery lists questions served at least $1 times.
+// Questions whose difficulty an admin set by hand are left out.
+const recalibrationCandidatesQuery = `SELECT q.id, q.difficulty, q.times_served, q.times_correct, ` + distinctRespondersExpr + `
+		 FROM questions q
+		 WHERE q.times_served >= $1
+		   AND NOT q.difficulty_manual
+		 ORDER BY q.times_served DESC`
+
 func (s *Store) GetRecalibrationCandidates(minResponses int) ([]models.RecalibrationCandidate, error) {
-	rows, err := s.db.Query(
-		`SELECT id, difficulty, times_served, times_correct
-		 FROM questions
-		 WHERE times_served >= $1
-		 ORDER BY times_served DESC`,
-		minResponses,
-	)
+	rows, err := s.db.Query(recalibrationCandidatesQuery, minResponses)
 	if err != nil {
 		return nil, fmt.Errorf("recalibration candidates: %w", err)
 	}
@@ -1091,7 +2574,7 @@ func (s *Store) GetRecalibrationCandidates(minResponses int) ([]models.Recalibra
 	for rows.Next() {
 		var c models.RecalibrationCandidate
 		var difficulty string
-		if err := rows.Scan(&c.QuestionID, &difficulty, &c.TimesServed, &c.TimesCorrect); err != nil {
+		if err := rows.Scan(&c.QuestionID, &difficulty, &c.TimesServed, &c.TimesCorrect, &c.DistinctResponders); err != nil {
 			return nil, err
 		}
 		c.LabeledDifficulty = difficulty
@@ -1120,6 +2603,23 @@ func (s *Store) UpdateQuestionDifficulty(questionID int64, difficulty string) er
 	return err
 }
 
+// SetQuestionDifficulty sets both the difficulty enum and score and marks
+// them as a manual override, which recalibration won't change.
+func (s *Store) SetQuestionDifficulty(questionID int64, difficulty models.Difficulty, score int) error {
+	result, err := s.db.Exec(
+		`UPDATE questions SET difficulty = $2, difficulty_score = $3, difficulty_manual = TRUE WHERE id = $1`,
+		questionID, difficulty, score,
+	)
+	if err != nil {
+		return fmt.Errorf("set question difficulty: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("question not found")
+	}
+	return nil
+}
+
 // ── RC Passage Serving ──────────────────────────────────
 
 func (s *Store) GetPassage(passageID int64) (*models.RCPassage, error) {
@@ -1140,6 +2640,84 @@ func (s *Store) GetPassage(passageID int64) (*models.RCPassage, error) {
 	return &p, nil
 }
 
+// buildPassageFilters turns the passage library filters into a WHERE fragment
+// (prefixed with "WHERE" when non-empty) and its positional args.
+func buildPassageFilters(f models.PassageListFilters) (string, []interface{}) {
+	var args []interface{}
+	var filters []string
+	paramIdx := 1
+
+	if f.SubjectArea != nil {
+		filters = append(filters, fmt.Sprintf("p.subject_area = $%d", paramIdx))
+		args = append(args, *f.SubjectArea)
+		paramIdx++
+	}
+	if f.Comparative != nil {
+		filters = append(filters, fmt.Sprintf("p.is_comparative = $%d", paramIdx))
+		args = append(args, *f.Comparative)
+		paramIdx++
+	}
+
+	if len(filters) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(filters, " AND "), args
+}
+
+// ListPassages returns a page of passage metadata with the number of
+// questions attached to each passage.
+func (s *Store) ListPassages(f models.PassageListFilters, page, pageSize int) ([]models.PassageSummary, int, error) {
+	filterSQL, args := buildPassageFilters(f)
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM rc_passages p %s`, filterSQL)
+	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count passages: %w", err)
+	}
+
+	paramIdx := len(args) + 1
+	offset := (page - 1) * pageSize
+	dataArgs := append(args, pageSize, offset)
+
+	dataQuery := fmt.Sprintf(`SELECT p.id, p.batch_id, p.title, p.subject_area, p.is_comparative,
+		       COALESCE(p.word_count, 0), p.reading_grade, COUNT(q.id), p.created_at
+		FROM rc_passages p
+		LEFT JOIN questions q ON q.passage_id = p.id
+		%s
+		GROUP BY p.id
+		ORDER BY p.created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		filterSQL, paramIdx, paramIdx+1)
+
+	rows, err := s.db.Query(dataQuery, dataArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query passages: %w", err)
+	}
+	defer rows.Close()
+
+	var passages []models.PassageSummary
+	for rows.Next() {
+		var p models.PassageSummary
+		if err := rows.Scan(&p.ID, &p.BatchID, &p.Title, &p.SubjectArea, &p.IsComparative,
+			&p.WordCount, &p.ReadingGrade, &p.QuestionCount, &p.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan passage row: %w", err)
+		}
+		passages = append(passages, p)
+	}
+	return passages, total, rows.Err()
+}
+
+// passageQuestionBudget is how many more of a passage's questions a user who
+// has answered seen of them may be served, given a drill asking for limit
+// and the per-user userCap (0 for none). A budget of 0 means the passage is
+// used up.
+func passageQuestionBudget(limit, userCap, seen int) int {
+	if userCap <= 0 {
+		return limit
+	}
+	return max(0, min(limit, userCap-seen))
+}
+
 func (s *Store) GetRCPassageWithQuestions(
 	userID int64,
 	minDiff, maxDiff int,
@@ -1164,10 +2742,22 @@ func (s *Store) GetRCPassageWithQuestions(
 		comparativeFilter = "AND p.is_comparative = TRUE"
 	}
 
+	// Answered questions from the passage, outside the difficulty window too
+	seenQuery := `(SELECT COUNT(*) FROM user_question_history uh
+		  JOIN questions uq ON uq.id = uh.question_id
+		  WHERE uh.user_id = $1 AND uq.passage_id = p.id)`
+	capFilter := ""
+	if s.passageUserCap > 0 {
+		capFilter = fmt.Sprintf("AND %s < $%d", seenQuery, paramIdx)
+		args = append(args, s.passageUserCap)
+		paramIdx++
+	}
+
 	candidateQuery := fmt.Sprintf(`
 		SELECT p.id, p.title, p.subject_area, p.content, p.is_comparative,
 		       COALESCE(p.passage_b, ''), COALESCE(p.word_count, 0),
-		       COUNT(q.id) FILTER (WHERE h.id IS NULL) AS unseen_count
+		       COUNT(q.id) FILTER (WHERE h.id IS NULL) AS unseen_count,
+		       %s AS seen_count
 		FROM rc_passages p
 		JOIN questions q ON q.passage_id = p.id
 		LEFT JOIN user_question_history h ON h.question_id = q.id AND h.user_id = $1
@@ -1180,15 +2770,16 @@ func (s *Store) GetRCPassageWithQuestions(
 		  %s
 		GROUP BY p.id
 		HAVING COUNT(q.id) FILTER (WHERE h.id IS NULL) >= 3
+		  %s
 		ORDER BY unseen_count DESC, RANDOM()
-		LIMIT 1`, subtypeFilter, comparativeFilter)
+		LIMIT 1`, seenQuery, subtypeFilter, comparativeFilter, capFilter)
 
 	var passage models.RCPassage
-	var unseenCount int
+	var unseenCount, seenCount int
 	err := s.db.QueryRow(candidateQuery, args...).Scan(
 		&passage.ID, &passage.Title, &passage.SubjectArea, &passage.Content,
 		&passage.IsComparative, &passage.PassageB, &passage.WordCount,
-		&unseenCount,
+		&unseenCount, &seenCount,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1198,51 +2789,35 @@ func (s *Store) GetRCPassageWithQuestions(
 	}
 
 	// Step 2: Fetch questions for this passage (unseen first)
-	limit := maxQuestions
-	if limit <= 0 {
-		limit = 8
-	}
+	limit := rcDrillCount(maxQuestions, s.rcDrillMax)
+	limit = passageQuestionBudget(limit, s.passageUserCap, seenCount)
 	questionQuery := `
-		SELECT q.id, q.section, q.lr_subtype, q.rc_subtype, q.difficulty,
-		       q.difficulty_score, q.stimulus, q.question_stem, q.correct_answer_id,
-		       q.explanation, q.passage_id
+		SELECT q.id
 		FROM questions q
 		LEFT JOIN user_question_history h ON h.question_id = q.id AND h.user_id = $1
 		WHERE q.passage_id = $2
 		  AND q.validation_status IN ('passed', 'unvalidated')
-		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)
-		ORDER BY
-		    CASE WHEN h.id IS NULL THEN 0 ELSE 1 END,
-		    RANDOM()
-		LIMIT $3`
+		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)`
 
-	rows, err := s.db.Query(questionQuery, userID, passage.ID, limit)
+	ids, err := s.pickServing(questionQuery, []interface{}{userID, passage.ID}, limit)
 	if err != nil {
 		return nil, nil, fmt.Errorf("fetch passage questions: %w", err)
 	}
-	defer rows.Close()
+	loaded, err := s.GetQuestionsByIDs(ids)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// Keep the serving order, which loading by ID doesn't
+	byID := make(map[int64]models.Question, len(loaded))
+	for _, q := range loaded {
+		byID[q.ID] = q
+	}
 	var questions []models.Question
-	for rows.Next() {
-		var q models.Question
-		err := rows.Scan(
-			&q.ID, &q.Section, &q.LRSubtype, &q.RCSubtype, &q.Difficulty,
-			&q.DifficultyScore, &q.Stimulus, &q.QuestionStem, &q.CorrectAnswerID,
-			&q.Explanation, &q.PassageID,
-		)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		choices, err := s.getChoicesForQuestion(q.ID)
-		if err != nil {
-			return nil, nil, err
+	for _, id := range ids {
+		if q, ok := byID[id]; ok {
+			questions = append(questions, q)
 		}
-		q.Choices = choices
-		questions = append(questions, q)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, nil, err
 	}
 
 	return &passage, questions, nil
@@ -1251,81 +2826,38 @@ func (s *Store) GetRCPassageWithQuestions(
 func (s *Store) GetOneAdaptiveQuestionFromPassage(
 	userID int64, subtype string, passageID int64, minDiff, maxDiff int,
 ) (*models.DrillQuestion, error) {
-	query := `
-		SELECT q.id, q.section, q.lr_subtype, q.rc_subtype, q.difficulty,
-		       q.difficulty_score, q.stimulus, q.question_stem
+	var ids []int64
+	for _, window := range s.repeatWindowPasses() {
+		query := `
+		SELECT q.id
 		FROM questions q
 		LEFT JOIN user_question_history h ON h.question_id = q.id AND h.user_id = $1
 		WHERE q.passage_id = $2
 		  AND q.rc_subtype = $3
 		  AND q.difficulty_score >= $4
 		  AND q.difficulty_score <= $5
+		  ` + window + `
 		  AND q.validation_status IN ('passed', 'unvalidated')
-		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)
-		ORDER BY
-		    CASE WHEN h.id IS NULL THEN 0 ELSE 1 END,
-		    RANDOM()
-		LIMIT 1`
-
-	var id int64
-	var sect, difficulty string
-	var lrSubtype, rcSubtype *string
-	var diffScore int
-	var stimulus, stem string
+		  AND (q.quality_score >= 0.50 OR q.quality_score IS NULL)`
 
-	err := s.db.QueryRow(query, userID, passageID, subtype, minDiff, maxDiff).Scan(
-		&id, &sect, &lrSubtype, &rcSubtype, &difficulty, &diffScore, &stimulus, &stem)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		var err error
+		ids, err = s.pickServing(query, []interface{}{userID, passageID, subtype, minDiff, maxDiff}, 1)
+		if err != nil {
+			return nil, fmt.Errorf("get adaptive question from passage: %w", err)
 		}
-		return nil, fmt.Errorf("get adaptive question from passage: %w", err)
-	}
-
-	dq := &models.DrillQuestion{
-		ID:              id,
-		Section:         models.Section(sect),
-		Difficulty:      models.Difficulty(difficulty),
-		DifficultyScore: diffScore,
-		Stimulus:        stimulus,
-		QuestionStem:    stem,
-	}
-	if lrSubtype != nil {
-		ls := models.LRSubtype(*lrSubtype)
-		dq.LRSubtype = &ls
-	}
-	if rcSubtype != nil {
-		rs := models.RCSubtype(*rcSubtype)
-		dq.RCSubtype = &rs
-	}
-
-	// Fetch choices
-	choiceRows, err := s.db.Query(
-		`SELECT choice_id, choice_text FROM answer_choices WHERE question_id = $1 ORDER BY choice_id`, id)
-	if err != nil {
-		return nil, fmt.Errorf("get choices: %w", err)
-	}
-	defer choiceRows.Close()
-
-	for choiceRows.Next() {
-		var choiceID, choiceText string
-		if err := choiceRows.Scan(&choiceID, &choiceText); err != nil {
-			return nil, err
+		if len(ids) > 0 {
+			break
 		}
-		dq.Choices = append(dq.Choices, models.DrillChoice{
-			ChoiceID:   choiceID,
-			ChoiceText: choiceText,
-		})
 	}
-
-	// Attach passage
-	passage, err := s.GetPassage(passageID)
-	if err == nil {
-		dp := passage.ToDrillPassage()
-		dq.Passage = &dp
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	return dq, choiceRows.Err()
+	questions, err := s.GetDrillQuestionsByIDs(ids)
+	if err != nil || len(questions) == 0 {
+		return nil, err
+	}
+	return &questions[0], nil
 }
 
 func (s *Store) CountRCPassagesInBucket(minDiff, maxDiff int) int {
@@ -1344,7 +2876,7 @@ func (s *Store) CountRCPassagesInBucket(minDiff, maxDiff int) int {
 	return count
 }
 
-func (s *Store) UpsertRCGenerationQueue(minDiff, maxDiff int, targetDiff string, subjectArea string, isComparative bool) error {
+func (s *Store) UpsertRCGenerationQueue(minDiff, maxDiff int, targetDiff string, subjectArea string, isComparative bool, questionsNeeded int) error {
 	_, err := s.db.Exec(
 		`INSERT INTO generation_queue (section, difficulty_bucket_min, difficulty_bucket_max, target_difficulty, questions_needed, subject_area, is_comparative)
 		 SELECT $1, $2, $3, $4, $5, $6, $7
@@ -1355,20 +2887,40 @@ func (s *Store) UpsertRCGenerationQueue(minDiff, maxDiff int, targetDiff string,
 		     AND difficulty_bucket_max = $3
 		     AND status IN ('pending', 'generating')
 		 )`,
-		"reading_comprehension", minDiff, maxDiff, targetDiff, 6, subjectArea, isComparative,
+		"reading_comprehension", minDiff, maxDiff, targetDiff, questionsNeeded, subjectArea, isComparative,
 	)
 	return err
 }
 
-func (s *Store) GetLastRCSubjectArea() string {
-	var subjectArea string
-	err := s.db.QueryRow(
-		`SELECT subject_area FROM rc_passages ORDER BY created_at DESC LIMIT 1`,
-	).Scan(&subjectArea)
+// GetSubjectAreaCounts returns how many RC passages each subject area has,
+// counting passages still queued for generation so that concurrent top-ups
+// spread across areas.
+func (s *Store) GetSubjectAreaCounts() (map[string]int, error) {
+	rows, err := s.db.Query(
+		`SELECT subject_area, COUNT(*) FROM (
+		     SELECT subject_area FROM rc_passages
+		     UNION ALL
+		     SELECT subject_area FROM generation_queue
+		     WHERE section = 'reading_comprehension'
+		     AND subject_area IS NOT NULL
+		     AND status IN ('pending', 'generating')
+		 ) areas
+		 GROUP BY subject_area`)
 	if err != nil {
-		return ""
+		return nil, fmt.Errorf("count subject areas: %w", err)
 	}
-	return subjectArea
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var area string
+		var n int
+		if err := rows.Scan(&area, &n); err != nil {
+			return nil, fmt.Errorf("scan subject area count: %w", err)
+		}
+		counts[area] = n
+	}
+	return counts, rows.Err()
 }
 
 func (s *Store) GetComparativeRatio() (int, int) {
@@ -1378,6 +2930,46 @@ func (s *Store) GetComparativeRatio() (int, int) {
 	return comparative, total
 }
 
+// passageText joins both halves of a comparative passage for text analysis.
+func passageText(content, passageB string) string {
+	if passageB == "" {
+		return content
+	}
+	return content + "\n\n" + passageB
+}
+
+// BackfillPassageReadability computes reading_grade for passages saved
+// before the column existed.
+func (s *Store) BackfillPassageReadability() (int, error) {
+	rows, err := s.db.Query(
+		`SELECT id, content, COALESCE(passage_b, '') FROM rc_passages WHERE reading_grade IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("query passages for readability: %w", err)
+	}
+
+	grades := make(map[int64]float64)
+	for rows.Next() {
+		var id int64
+		var content, passageB string
+		if err := rows.Scan(&id, &content, &passageB); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan passage: %w", err)
+		}
+		grades[id] = generator.FleschKincaidGrade(passageText(content, passageB))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for id, grade := range grades {
+		if _, err := s.db.Exec(`UPDATE rc_passages SET reading_grade = $1 WHERE id = $2`, grade, id); err != nil {
+			return 0, fmt.Errorf("update passage readability: %w", err)
+		}
+	}
+	return len(grades), nil
+}
+
 func nullString(s string) *string {
 	if s == "" {
 		return nil
@@ -1635,6 +3227,7 @@ func (s *Store) ImportQuestions(ctx context.Context, groups []ImportBatchGroup)
 		}
 
 		// Insert questions and choices
+		saved := 0
 		for _, q := range group.Questions {
 			var questionID int64
 			err := tx.QueryRow(
@@ -1643,14 +3236,21 @@ func (s *Store) ImportQuestions(ctx context.Context, groups []ImportBatchGroup)
 				  stimulus, question_stem, correct_answer_id, explanation, passage_id,
 				  quality_score, validation_status, flagged)
 				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+				 `+questionContentConflict+`
 				 RETURNING id`,
 				batchID, q.Section, q.LRSubtype, q.RCSubtype, q.Difficulty, q.DifficultyScore,
 				q.Stimulus, q.QuestionStem, q.CorrectAnswerID, q.Explanation,
 				passageID, q.QualityScore, q.ValidationStatus, false,
 			).Scan(&questionID)
+			inserted, err := insertedQuestion(err)
 			if err != nil {
 				return nil, fmt.Errorf("insert import question: %w", err)
 			}
+			// Saved since CheckExistingQuestions ran
+			if !inserted {
+				result.Skipped++
+				continue
+			}
 
 			for _, c := range q.Choices {
 				_, err := tx.Exec(
@@ -1664,6 +3264,10 @@ func (s *Store) ImportQuestions(ctx context.Context, groups []ImportBatchGroup)
 			}
 
 			result.Imported++
+			saved++
+		}
+		if err := dropUnusedPassage(tx, passageID, saved); err != nil {
+			return nil, err
 		}
 
 		result.BatchesCreated++
@@ -1787,10 +3391,12 @@ func (s *Store) attachPassages(questions []models.HistoryQuestion, questionIDs [
 	return nil
 }
 
-func (s *Store) GetUserHistory(userID int64, req models.HistoryListRequest) ([]models.HistoryQuestion, int, error) {
+// buildHistoryFilters builds the WHERE clause selecting userID's history
+// rows that match req, aliasing history as h and questions as q.
+func buildHistoryFilters(userID int64, req models.HistoryListRequest) (string, []interface{}) {
 	args := []interface{}{userID}
 	paramIdx := 2
-	var filters []string
+	filters := []string{"h.user_id = $1"}
 
 	if req.Section != nil {
 		filters = append(filters, fmt.Sprintf("q.section = $%d", paramIdx))
@@ -1821,12 +3427,19 @@ func (s *Store) GetUserHistory(userID int64, req models.HistoryListRequest) ([]m
 		args = append(args, *req.DateTo)
 		paramIdx++
 	}
-
-	filterSQL := ""
-	if len(filters) > 0 {
-		filterSQL = "AND " + strings.Join(filters, " AND ")
+	if req.Search != nil {
+		filters = append(filters, fmt.Sprintf("(q.stimulus ILIKE $%d OR q.question_stem ILIKE $%d)", paramIdx, paramIdx))
+		args = append(args, searchPattern(*req.Search))
+		paramIdx++
 	}
 
+	return "WHERE " + strings.Join(filters, " AND "), args
+}
+
+func (s *Store) GetUserHistory(userID int64, req models.HistoryListRequest) ([]models.HistoryQuestion, int, error) {
+	filterSQL, args := buildHistoryFilters(userID, req)
+	paramIdx := len(args) + 1
+
 	// Whitelist sort columns
 	sortCol := "h.answered_at"
 	switch req.SortBy {
@@ -1844,7 +3457,7 @@ func (s *Store) GetUserHistory(userID int64, req models.HistoryListRequest) ([]m
 	var total int
 	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM user_question_history h
 		JOIN questions q ON q.id = h.question_id
-		WHERE h.user_id = $1 %s`, filterSQL)
+		%s`, filterSQL)
 	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("count history: %w", err)
 	}
@@ -1860,7 +3473,7 @@ func (s *Store) GetUserHistory(userID int64, req models.HistoryListRequest) ([]m
 		       h.correct, h.selected_choice_id, h.time_spent_seconds, h.attempt_count, h.answered_at
 		FROM user_question_history h
 		JOIN questions q ON q.id = h.question_id
-		WHERE h.user_id = $1 %s
+		%s
 		ORDER BY %s %s
 		LIMIT $%d OFFSET $%d`,
 		filterSQL, sortCol, sortDir, paramIdx, paramIdx+1)
@@ -1946,10 +3559,49 @@ func (s *Store) GetUserMistakes(userID int64, page, pageSize int) ([]models.Hist
 	return s.GetUserHistory(userID, req)
 }
 
+// Improvement compares accuracy over the last improvementWindowDays with the
+// window before, once each has at least improvementMinAnswered answers.
+const (
+	improvementWindowDays  = 7
+	improvementMinAnswered = 5
+)
+
+// improvementStat compares the recent window's accuracy with the prior one's,
+// or returns nil if either window has too few answers to say.
+func improvementStat(recentAnswered, recentCorrect, priorAnswered, priorCorrect int) *models.ImprovementStat {
+	if recentAnswered < improvementMinAnswered || priorAnswered < improvementMinAnswered {
+		return nil
+	}
+	recent := float64(recentCorrect) / float64(recentAnswered)
+	prior := float64(priorCorrect) / float64(priorAnswered)
+	return &models.ImprovementStat{
+		WindowDays:     improvementWindowDays,
+		RecentAnswered: recentAnswered,
+		RecentAccuracy: recent,
+		PriorAnswered:  priorAnswered,
+		PriorAccuracy:  prior,
+		Change:         recent - prior,
+	}
+}
+
+// sourceStatsQuery breaks the user's answers down by the practice mode
+// they were given in. Answers without a source are left out, and a question
+// answered in several modes counts only under the latest.
+const sourceStatsQuery = `
+		SELECT h.source,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE h.correct = true),
+		       COALESCE(AVG(h.time_spent_seconds), 0)
+		FROM user_question_history h
+		WHERE h.user_id = $1
+		  AND h.source IS NOT NULL
+		GROUP BY h.source`
+
 func (s *Store) GetUserHistoryStats(userID int64) (*models.HistoryStatsResponse, error) {
 	stats := &models.HistoryStatsResponse{
 		SectionStats: make(map[string]models.SectionStat),
 		SubtypeStats: make(map[string]models.SubtypeStat),
+		SourceStats:  make(map[string]models.SectionStat),
 	}
 
 	// Overall totals + avg time
@@ -1993,6 +3645,24 @@ func (s *Store) GetUserHistoryStats(userID int64) (*models.HistoryStatsResponse,
 		stats.SectionStats[section] = ss
 	}
 
+	// Per-source stats
+	sourceRows, err := s.db.Query(sourceStatsQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("source stats: %w", err)
+	}
+	defer sourceRows.Close()
+	for sourceRows.Next() {
+		var source string
+		var ss models.SectionStat
+		if err := sourceRows.Scan(&source, &ss.Answered, &ss.Correct, &ss.AvgTime); err != nil {
+			return nil, fmt.Errorf("scan source stat: %w", err)
+		}
+		if ss.Answered > 0 {
+			ss.Accuracy = float64(ss.Correct) / float64(ss.Answered)
+		}
+		stats.SourceStats[source] = ss
+	}
+
 	// Per-subtype stats
 	subtypeRows, err := s.db.Query(`
 		SELECT q.section,
@@ -2058,6 +3728,22 @@ func (s *Store) GetUserHistoryStats(userID int64) (*models.HistoryStatsResponse,
 		}
 	}
 
+	// Improvement: the last window against the one before it
+	var recentAnswered, recentCorrect, priorAnswered, priorCorrect int
+	err = s.db.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*) FILTER (WHERE h.answered_at >= NOW() - INTERVAL '%[1]d days'),
+		       COUNT(*) FILTER (WHERE h.answered_at >= NOW() - INTERVAL '%[1]d days' AND h.correct = true),
+		       COUNT(*) FILTER (WHERE h.answered_at < NOW() - INTERVAL '%[1]d days'),
+		       COUNT(*) FILTER (WHERE h.answered_at < NOW() - INTERVAL '%[1]d days' AND h.correct = true)
+		FROM user_question_history h
+		WHERE h.user_id = $1
+		  AND h.answered_at >= NOW() - INTERVAL '%[2]d days'`, improvementWindowDays, 2*improvementWindowDays), userID,
+	).Scan(&recentAnswered, &recentCorrect, &priorAnswered, &priorCorrect)
+	if err != nil {
+		return nil, fmt.Errorf("improvement stats: %w", err)
+	}
+	stats.Improvement = improvementStat(recentAnswered, recentCorrect, priorAnswered, priorCorrect)
+
 	// Recent trend (last 30 days)
 	trendRows, err := s.db.Query(`
 		SELECT h.answered_at::date as day,
@@ -2091,6 +3777,46 @@ func (s *Store) GetUserHistoryStats(userID int64) (*models.HistoryStatsResponse,
 	return stats, nil
 }
 
+// studyPeriodStart returns when period began as of now, in UTC, matching
+// date_trunc: "today" from midnight, "week" from Monday.
+func studyPeriodStart(period string, now time.Time) (time.Time, error) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	switch period {
+	case "today":
+		return day, nil
+	case "week":
+		sinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -sinceMonday), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid study period %q", period)
+}
+
+// studyTimeQuery sums a user's answer time since $2. Rows from before the
+// timing fix have a NULL time_spent_seconds and add nothing to the sum.
+const studyTimeQuery = `
+	SELECT COALESCE(SUM(h.time_spent_seconds), 0),
+	       COUNT(*),
+	       COUNT(h.time_spent_seconds)
+	FROM user_question_history h
+	WHERE h.user_id = $1
+	  AND h.answered_at >= $2`
+
+// GetStudyTime totals the user's answer time for period ("today" or "week").
+// History keeps one row per question and re-answering overwrites its
+// time_spent_seconds, so only the latest attempt's time counts and the total
+// undercounts time spent on repeated questions.
+func (s *Store) GetStudyTime(userID int64, period string) (*models.StudyTime, error) {
+	since, err := studyPeriodStart(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	st := &models.StudyTime{Period: period}
+	if err := s.db.QueryRow(studyTimeQuery, userID, since).Scan(&st.Seconds, &st.Answered, &st.Timed); err != nil {
+		return nil, fmt.Errorf("study time: %w", err)
+	}
+	return st, nil
+}
+
 func (s *Store) GetDrillReview(userID int64, questionIDs []int64) ([]models.HistoryQuestion, error) {
 	if len(questionIDs) == 0 {
 		return nil, nil
@@ -2220,6 +3946,73 @@ func (s *Store) DeleteBookmark(userID, questionID int64) error {
 	return nil
 }
 
+// FilterBookmarked returns the IDs among questionIDs that the user has
+// bookmarked, in no particular order.
+func (s *Store) FilterBookmarked(userID int64, questionIDs []int64) ([]int64, error) {
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(questionIDs))
+	args := []interface{}{userID}
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT question_id FROM user_bookmarks WHERE user_id = $1 AND question_id IN (%s)`,
+		strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query bookmarked: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan bookmarked: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetMissedQuestionIDs returns the IDs among questionIDs whose latest answer
+// by the user was wrong. Questions the user never answered are left out.
+func (s *Store) GetMissedQuestionIDs(userID int64, questionIDs []int64) ([]int64, error) {
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(questionIDs))
+	args := []interface{}{userID}
+	for i, id := range questionIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT question_id FROM user_question_history
+		 WHERE user_id = $1 AND correct = false AND question_id IN (%s)`,
+		strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query missed questions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan missed question: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (s *Store) GetBookmarks(userID int64, page, pageSize int) ([]models.BookmarkEntry, int, error) {
 	var total int
 	if err := s.db.QueryRow(`SELECT COUNT(*) FROM user_bookmarks WHERE user_id = $1`, userID).Scan(&total); err != nil {
@@ -0,0 +1,785 @@
+package questions
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsat-prep/backend/internal/models"
+)
+
+func TestBuildPassageFilters(t *testing.T) {
+	law := "law"
+	yes := true
+	no := false
+
+	tests := []struct {
+		name     string
+		filters  models.PassageListFilters
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"no filters", models.PassageListFilters{}, "", nil},
+		{"subject only", models.PassageListFilters{SubjectArea: &law},
+			"WHERE p.subject_area = $1", []interface{}{"law"}},
+		{"comparative only", models.PassageListFilters{Comparative: &no},
+			"WHERE p.is_comparative = $1", []interface{}{false}},
+		{"both", models.PassageListFilters{SubjectArea: &law, Comparative: &yes},
+			"WHERE p.subject_area = $1 AND p.is_comparative = $2", []interface{}{"law", true}},
+	}
+
+	for _, tt := range tests {
+		gotSQL, gotArgs := buildPassageFilters(tt.filters)
+		if gotSQL != tt.wantSQL {
+			t.Errorf("%s: sql = %q, want %q", tt.name, gotSQL, tt.wantSQL)
+		}
+		if len(gotArgs) != len(tt.wantArgs) {
+			t.Errorf("%s: got %d args, want %d", tt.name, len(gotArgs), len(tt.wantArgs))
+			continue
+		}
+		for i := range gotArgs {
+			if gotArgs[i] != tt.wantArgs[i] {
+				t.Errorf("%s: arg[%d] = %v, want %v", tt.name, i, gotArgs[i], tt.wantArgs[i])
+			}
+		}
+	}
+}
+
+func TestBuildBatchFilters(t *testing.T) {
+	tag := "prompt-v2"
+	completed := models.BatchCompleted
+
+	sql, args := buildBatchFilters(models.BatchListFilters{})
+	if sql != "" || len(args) != 0 {
+		t.Errorf("empty filters: got %q %v, want no filter", sql, args)
+	}
+
+	sql, args = buildBatchFilters(models.BatchListFilters{ExperimentTag: &tag})
+	if sql != "WHERE experiment_tag = $1" {
+		t.Errorf("tag filter sql = %q", sql)
+	}
+	if len(args) != 1 || args[0] != "prompt-v2" {
+		t.Errorf("tag filter args = %v, want [prompt-v2]", args)
+	}
+
+	sql, args = buildBatchFilters(models.BatchListFilters{Status: &completed, ExperimentTag: &tag})
+	if sql != "WHERE status = $1 AND experiment_tag = $2" {
+		t.Errorf("combined filter sql = %q", sql)
+	}
+	if len(args) != 2 || args[0] != models.BatchCompleted || args[1] != "prompt-v2" {
+		t.Errorf("combined filter args = %v", args)
+	}
+}
+
+// ilike mimics Postgres ILIKE with the default backslash escape.
+func TestOffsetPageInfo_HasMoreFromReturnedRows(t *testing.T) {
+	cases := []struct {
+		name                    string
+		total, limit, offset, n int
+		page, totalPages        int
+		hasMore                 bool
+	}{
+		{"first page", 45, 20, 0, 20, 1, 3, true},
+		{"last page", 45, 20, 40, 5, 3, 3, false},
+		// Offset 30 falls on page 2, but the 15 rows returned reach the end
+		{"unaligned offset", 45, 20, 30, 15, 2, 3, false},
+		{"unaligned offset, more left", 45, 20, 10, 20, 1, 3, true},
+		{"past the end", 45, 20, 60, 0, 4, 3, false},
+		{"zero limit", 45, 0, 0, 0, 0, 0, false},
+	}
+	for _, c := range cases {
+		page, totalPages, hasMore := models.OffsetPageInfo(c.total, c.limit, c.offset, c.n)
+		if page != c.page || totalPages != c.totalPages || hasMore != c.hasMore {
+			t.Errorf("%s: got page %d of %d, has_more %t; want page %d of %d, has_more %t",
+				c.name, page, totalPages, hasMore, c.page, c.totalPages, c.hasMore)
+		}
+	}
+}
+
+func ilike(text, pattern string) bool {
+	var re strings.Builder
+	re.WriteString("(?is)^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			i++
+			re.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String()).MatchString(text)
+}
+
+func TestSearchPattern(t *testing.T) {
+	stimuli := map[int64]string{
+		1: "The city council argues that tidal turbines will lower energy costs.",
+		2: "A recent survey of 100% of residents found widespread support.",
+		3: "Critics claim the new_policy was never evaluated.",
+	}
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"TIDAL turbines", []int64{1}},
+		{"  energy costs ", []int64{1}},
+		{"100%", []int64{2}},
+		{"new_policy", []int64{3}},
+		{"100% of", []int64{2}},
+		{"newXpolicy", nil},
+		{"zoning board", nil},
+	}
+
+	for _, tt := range tests {
+		pattern := searchPattern(tt.query)
+		var got []int64
+		for id := int64(1); id <= 3; id++ {
+			if ilike(stimuli[id], pattern) {
+				got = append(got, id)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("query %q matched %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("query %q matched %v, want %v", tt.query, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestBuildHistoryFilters_SearchOnlyMatchesUsersAnswers(t *testing.T) {
+	type row struct {
+		id, userID     int64
+		stimulus, stem string
+	}
+	history := []row{
+		{1, 7, "Tidal turbines will lower energy costs.", "Which weakens the argument?"},
+		{2, 7, "A survey of residents found support.", "Which assumption about TIDAL power is required?"},
+		{3, 7, "Critics claim the policy was never evaluated.", "Which is the flaw?"},
+		{4, 8, "Tidal barrages harm estuaries.", "Which strengthens the argument?"},
+	}
+
+	query := " tidal "
+	sql, args := buildHistoryFilters(7, models.HistoryListRequest{Search: &query})
+	if sql != "WHERE h.user_id = $1 AND (q.stimulus ILIKE $2 OR q.question_stem ILIKE $2)" {
+		t.Fatalf("sql = %q", sql)
+	}
+	if len(args) != 2 {
+		t.Fatalf("args = %v, want user and pattern", args)
+	}
+
+	// Evaluate the clause the way Postgres would
+	var got []int64
+	for _, r := range history {
+		pattern := args[1].(string)
+		if r.userID == args[0] && (ilike(r.stimulus, pattern) || ilike(r.stem, pattern)) {
+			got = append(got, r.id)
+		}
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("search matched %v, want user 7's questions [1 2]", got)
+	}
+
+	if sql, args := buildHistoryFilters(7, models.HistoryListRequest{}); sql != "WHERE h.user_id = $1" || len(args) != 1 {
+		t.Errorf("no filters = %q %v, want only the user", sql, args)
+	}
+}
+
+func TestAttachUserFlags(t *testing.T) {
+	reason, err := validateFlagReason("  Choice C is also supported by the last sentence.  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validateFlagReason("   "); err == nil {
+		t.Error("blank reason should be rejected")
+	}
+
+	questions := []models.Question{{ID: 1, Flagged: true}, {ID: 2, Flagged: true}}
+	attachUserFlags(questions, map[int64][]models.QuestionFlag{
+		2: {{UserID: 7, Reason: reason}},
+	})
+
+	if questions[0].UserFlags != nil {
+		t.Errorf("question 1 has user flags %v, want none", questions[0].UserFlags)
+	}
+	if len(questions[1].UserFlags) != 1 {
+		t.Fatalf("question 2 has %d user flags, want 1", len(questions[1].UserFlags))
+	}
+	f := questions[1].UserFlags[0]
+	if f.UserID != 7 || f.Reason != "Choice C is also supported by the last sentence." {
+		t.Errorf("flag = %+v, want user 7 with trimmed reason", f)
+	}
+}
+
+func TestToDrillQuestion_HidesAnswers(t *testing.T) {
+	flaw := models.SubtypeFlaw
+	q := models.Question{
+		ID: 3, Section: models.SectionLR, LRSubtype: &flaw, Difficulty: models.DifficultyMedium,
+		Stimulus: "Stimulus", QuestionStem: "The argument is flawed because it",
+		CorrectAnswerID: "B", Explanation: "B names the flaw.",
+	}
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		q.Choices = append(q.Choices, models.AnswerChoice{
+			ChoiceID: id, ChoiceText: "Choice " + id, Explanation: "Why " + id,
+			IsCorrect: id == "B", WrongAnswerType: "irrelevant",
+		})
+	}
+
+	dq := q.ToDrillQuestion()
+	if len(dq.Choices) != 5 || dq.Choices[1].ChoiceID != "B" || dq.Choices[1].ChoiceText != "Choice B" {
+		t.Fatalf("choices = %+v, want A-E with text", dq.Choices)
+	}
+
+	b, err := json.Marshal(dq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"correct_answer_id", "explanation", "is_correct", "wrong_answer_type", "B names the flaw", "Why B"} {
+		if strings.Contains(string(b), leaked) {
+			t.Errorf("drill question JSON contains %q: %s", leaked, b)
+		}
+	}
+}
+
+func TestBucketDifficultyCounts(t *testing.T) {
+	// difficulty_score -> servable questions
+	seeded := map[int]int{0: 1, 9: 2, 10: 3, 35: 1, 39: 4, 90: 2, 99: 1, 100: 5}
+
+	buckets, total := bucketDifficultyCounts(seeded)
+	if total != 19 {
+		t.Errorf("total = %d, want 19", total)
+	}
+	if len(buckets) != 10 {
+		t.Fatalf("got %d buckets, want 10", len(buckets))
+	}
+	want := []int{3, 3, 0, 5, 0, 0, 0, 0, 0, 8}
+	for i, b := range buckets {
+		if b.Min != i*10 {
+			t.Errorf("bucket %d min = %d, want %d", i, b.Min, i*10)
+		}
+		if b.Count != want[i] {
+			t.Errorf("bucket %d-%d count = %d, want %d", b.Min, b.Max, b.Count, want[i])
+		}
+	}
+	if buckets[9].Max != 100 {
+		t.Errorf("last bucket max = %d, want 100", buckets[9].Max)
+	}
+}
+
+func TestServingTier_Precedence(t *testing.T) {
+	// CASE takes the first branch that matches, so the branches must run
+	// unseen, missed, then mastered, with every other seen question between
+	branch := regexp.MustCompile(`WHEN (.+?) THEN (\d)`)
+	var got []string
+	for _, m := range branch.FindAllStringSubmatch(servingTier, -1) {
+		got = append(got, m[2]+": "+m[1])
+	}
+	want := []string{"0: h.id IS NULL", "1: NOT h.correct", "3: " + recentlyMastered}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("servingTier branches = %q, want %q", got, want)
+	}
+	if !strings.HasSuffix(servingTier, "ELSE 2 END") {
+		t.Errorf("servingTier = %q, want other seen questions in tier 2", servingTier)
+	}
+	for _, cond := range []string{"h.correct", "h.attempt_count = 1", "INTERVAL '30 days'"} {
+		if !strings.Contains(recentlyMastered, cond) {
+			t.Errorf("recentlyMastered = %q, missing %q", recentlyMastered, cond)
+		}
+	}
+
+	// Featured questions come first within a tier, never across tiers
+	tier := strings.Index(adaptiveOrder, servingTier)
+	featured := strings.Index(adaptiveOrder, "q.featured")
+	random := strings.Index(adaptiveOrder, "RANDOM()")
+	if tier != 0 || featured < tier || random < featured {
+		t.Errorf("adaptiveOrder = %q, want tier, then featured, then random", adaptiveOrder)
+	}
+}
+
+func TestServingPickQuery_OrdersAndLimitsInSQL(t *testing.T) {
+	args := make([]interface{}, 4, 8)
+	query, got := servingPickQuery("SELECT q.id FROM questions q", args, 20)
+
+	// The pool is ranked and cut in SQL, not read whole into Go
+	if !strings.HasSuffix(query, "ORDER BY "+adaptiveOrder+"\n\t\tLIMIT $5") {
+		t.Errorf("query = %q, want adaptiveOrder and LIMIT $5", query)
+	}
+	if len(got) != 5 || got[4] != 20 {
+		t.Errorf("args = %v, want the limit bound as $5", got)
+	}
+
+	// The caller's args are reused across repeat window passes
+	again, againArgs := servingPickQuery("SELECT q.id FROM questions q", args, 1)
+	if len(args) != 4 || got[4] != 20 || againArgs[4] != 1 || !strings.HasSuffix(again, "LIMIT $5") {
+		t.Errorf("second pass: args %v, first %v, second %v; want each pass its own limit", args, got, againArgs)
+	}
+}
+
+func TestRepeatWindowPasses_FallsBackToNoWindow(t *testing.T) {
+	// The window is tried first; if it leaves nothing, e.g. the only
+	// candidate was answered 5 minutes ago, the pick runs again without it
+	passes := repeatWindowPasses(24, 0)
+	if len(passes) != 2 || passes[0] != repeatWindowFilter(24, 0) || passes[1] != "" {
+		t.Errorf("passes = %q, want the 24h window then no window", passes)
+	}
+	if !strings.Contains(passes[0], "INTERVAL '24 hours'") {
+		t.Errorf("first pass = %q, want the 24 hour window", passes[0])
+	}
+
+	passes = repeatWindowPasses(0, 10)
+	if len(passes) != 2 || !strings.Contains(passes[0], "user_id = $1") || !strings.Contains(passes[0], "LIMIT 10") || passes[1] != "" {
+		t.Errorf("passes = %q, want the user's last 10 answers excluded, then no window", passes)
+	}
+
+	// With no window there's only the one pass
+	if passes := repeatWindowPasses(0, 0); len(passes) != 1 || passes[0] != "" {
+		t.Errorf("passes = %q, want a single unfiltered pass", passes)
+	}
+}
+
+func TestFairServeOrder_FavorsLessServedWithoutStarving(t *testing.T) {
+	if fairServeOrder != "-LN(1 - RANDOM()) * (1 + q.times_served)" {
+		t.Fatalf("fairServeOrder = %q; update the key below to match", fairServeOrder)
+	}
+	// The key fairServeOrder computes, with RANDOM() drawn from rng
+	rng := rand.New(rand.NewSource(1))
+	key := func(timesServed int) float64 { return -math.Log(1-rng.Float64()) * float64(1+timesServed) }
+
+	served := map[int64]int{1: 0, 2: 5, 3: 40}
+	firsts := map[int64]int{}
+	for call := 0; call < 5000; call++ {
+		first, best := int64(0), math.Inf(1)
+		for id, n := range served {
+			if k := key(n); k < best {
+				first, best = id, k
+			}
+		}
+		firsts[first]++
+	}
+
+	if !(firsts[1] > firsts[2] && firsts[2] > firsts[3]) {
+		t.Errorf("first picks by ID (served 0, 5, 40 times) = %v, want fewer picks as times_served grows", firsts)
+	}
+	if firsts[2] == 0 || firsts[3] == 0 {
+		t.Errorf("first picks = %v, want more-served questions still picked sometimes", firsts)
+	}
+}
+
+func TestPurgeableBatchIDs(t *testing.T) {
+	candidates := []batchPurgeCandidate{
+		{ID: 1, Status: models.BatchFailed},
+		{ID: 2, Status: models.BatchCompleted},
+		{ID: 3, Status: models.BatchCompleted, Questions: 6},
+		{ID: 4, Status: models.BatchFailed, Questions: 2},
+		{ID: 5, Status: models.BatchGenerating},
+		{ID: 6, Status: models.BatchFailed},
+	}
+
+	got := purgeableBatchIDs(candidates)
+	want := []int64{1, 2, 6}
+	if len(got) != len(want) {
+		t.Fatalf("purgeable = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("purgeable = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestStudyPeriodStart_TodayFromMidnightWeekFromMonday(t *testing.T) {
+	// Thursday afternoon
+	now := time.Date(2026, 10, 15, 15, 0, 0, 0, time.UTC)
+
+	// GetStudyTime sums answers at or after the period start
+	today, err := studyPeriodStart("today", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC); !today.Equal(want) {
+		t.Errorf("today starts %v, want midnight %v", today, want)
+	}
+	if early := now.Add(-14 * time.Hour); early.Before(today) {
+		t.Errorf("an answer at %v should count toward today", early)
+	}
+	if lastNight := now.Add(-16 * time.Hour); !lastNight.Before(today) {
+		t.Errorf("an answer at %v should not count toward today", lastNight)
+	}
+
+	week, err := studyPeriodStart("week", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC); !week.Equal(want) {
+		t.Errorf("week starts %v, want Monday %v", week, want)
+	}
+	// A Monday is the start of its own week
+	if monday, _ := studyPeriodStart("week", time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)); !monday.Equal(week) {
+		t.Errorf("Monday's week starts %v, want %v", monday, week)
+	}
+
+	if _, err := studyPeriodStart("month", now); err == nil {
+		t.Error("unknown period should be an error")
+	}
+}
+
+func TestAnswerSource_RecordedWithEachAnswer(t *testing.T) {
+	flaw := models.SubtypeFlaw
+	q := &models.Question{ID: 9, Section: models.SectionLR, LRSubtype: &flaw, DifficultyScore: 50, CorrectAnswerID: "B"}
+	for _, source := range []models.AnswerSource{models.SourceQuickDrill, models.SourceRCDrill, models.SourceReview, ""} {
+		state := &fakeAnswerState{}
+		choice := "B"
+		if _, err := recordAnswerCore(&fakeAnswerTx{state: state}, 1, q, true, &choice, nil, source); err != nil {
+			t.Fatal(err)
+		}
+		if len(state.sources) != 1 || state.sources[0] != source {
+			t.Errorf("recorded sources %v, want [%q]", state.sources, source)
+		}
+	}
+
+	// Nothing is recorded if the answer's transaction fails
+	state := &fakeAnswerState{}
+	choice := "B"
+	if _, err := recordAnswerCore(&fakeAnswerTx{state: state, failOn: "history"}, 1, q, true, &choice, nil, models.SourceQuickDrill); err == nil {
+		t.Fatal("expected the history write to fail")
+	}
+	if len(state.sources) != 0 {
+		t.Errorf("recorded sources %v after a failed answer, want none", state.sources)
+	}
+
+	for _, s := range []models.AnswerSource{models.SourceQuickDrill, models.SourceSubtypeDrill, models.SourceRCDrill, models.SourceReview} {
+		if !models.ClientAnswerSources[s] {
+			t.Errorf("drill source %q should be accepted on answer submit", s)
+		}
+	}
+	for _, s := range []models.AnswerSource{models.SourcePractice, models.SourceDailyChallenge} {
+		if models.ClientAnswerSources[s] {
+			t.Errorf("server-assigned source %q should not be accepted from clients", s)
+		}
+	}
+}
+
+// sourcedAnswer is one user_question_history row as sourceStatsQuery sees
+// it; source is "" for NULL.
+type sourcedAnswer struct {
+	source  models.AnswerSource
+	correct bool
+}
+
+// sourceStatsFor mimics sourceStatsQuery over one user's rows, keyed by
+// question as the history table is.
+func sourceStatsFor(rows map[int64]sourcedAnswer) map[string]models.SectionStat {
+	stats := map[string]models.SectionStat{}
+	for _, r := range rows {
+		if r.source == "" {
+			continue
+		}
+		ss := stats[string(r.source)]
+		ss.Answered++
+		if r.correct {
+			ss.Correct++
+		}
+		ss.Accuracy = float64(ss.Correct) / float64(ss.Answered)
+		stats[string(r.source)] = ss
+	}
+	return stats
+}
+
+func TestSourceStats_GroupsLatestAnswerPerQuestion(t *testing.T) {
+	if !strings.Contains(sourceStatsQuery, "h.source IS NOT NULL") || !strings.Contains(sourceStatsQuery, "GROUP BY h.source") {
+		t.Fatalf("sourceStatsQuery changed; update sourceStatsFor to match:%s", sourceStatsQuery)
+	}
+
+	answers := []struct {
+		questionID int64
+		source     models.AnswerSource
+		correct    bool
+	}{
+		{1, models.SourceQuickDrill, false},
+		{2, models.SourceQuickDrill, true},
+		{3, models.SourceRCDrill, true},
+		{4, "", false},
+		{1, models.SourceReview, true}, // re-answered in review
+	}
+
+	// Recording upserts the question's row, source included
+	rows := map[int64]sourcedAnswer{}
+	for _, a := range answers {
+		state := &fakeAnswerState{}
+		q := &models.Question{ID: a.questionID, Section: models.SectionLR, DifficultyScore: 50, CorrectAnswerID: "B"}
+		choice := "B"
+		if _, err := recordAnswerCore(&fakeAnswerTx{state: state}, 1, q, a.correct, &choice, nil, a.source); err != nil {
+			t.Fatal(err)
+		}
+		rows[a.questionID] = sourcedAnswer{source: state.sources[0], correct: a.correct}
+	}
+
+	stats := sourceStatsFor(rows)
+	want := map[string]models.SectionStat{
+		"quick_drill": {Answered: 1, Correct: 1, Accuracy: 1},
+		"rc_drill":    {Answered: 1, Correct: 1, Accuracy: 1},
+		"review":      {Answered: 1, Correct: 1, Accuracy: 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("stats = %+v, want sources %v and none for the unsourced answer", stats, want)
+	}
+	for source, ws := range want {
+		if stats[source] != ws {
+			t.Errorf("%s = %+v, want %+v", source, stats[source], ws)
+		}
+	}
+}
+
+func TestPassageQuestionBudget_CapsQuestionsPerPassage(t *testing.T) {
+	const passageQuestions, drillSize, userCap = 12, 4, 6
+
+	// Repeated drills on one passage, the user answering everything served
+	seen, drills := 0, 0
+	for drills < 10 {
+		budget := passageQuestionBudget(drillSize, userCap, seen)
+		if budget == 0 {
+			break
+		}
+		seen += min(budget, passageQuestions-seen)
+		drills++
+	}
+	if seen != userCap {
+		t.Errorf("user was served %d of the passage's questions, want the cap of %d", seen, userCap)
+	}
+	if drills != 2 {
+		t.Errorf("passage lasted %d drills, want 2 (4 then the remaining 2)", drills)
+	}
+
+	if got := passageQuestionBudget(drillSize, 0, 100); got != drillSize {
+		t.Errorf("no cap: budget = %d, want the full drill of %d", got, drillSize)
+	}
+}
+
+func TestRCDrillCount_ClampsOverMaxConsistently(t *testing.T) {
+	t.Setenv("RC_DRILL_MAX", "5")
+	st := NewStore(nil)
+	svc := &Service{store: st}
+	if got := svc.RCDrillMax(); got != 5 {
+		t.Fatalf("RCDrillMax = %d, want RC_DRILL_MAX of 5", got)
+	}
+
+	// The handler clamps, then the service and the store clamp again; an
+	// over-max request comes out at the max at every step
+	handlerCount := rcDrillCount(20, svc.RCDrillMax())
+	serviceCount := rcDrillCount(handlerCount, svc.RCDrillMax())
+	storeLimit := rcDrillCount(serviceCount, st.rcDrillMax)
+	if handlerCount != 5 || serviceCount != 5 || storeLimit != 5 {
+		t.Errorf("over-max request: handler %d, service %d, store %d; want 5 throughout", handlerCount, serviceCount, storeLimit)
+	}
+
+	// Unset counts default to the same max, and smaller counts pass through
+	if got := rcDrillCount(0, st.rcDrillMax); got != 5 {
+		t.Errorf("unset count = %d, want 5", got)
+	}
+	if got := rcDrillCount(3, st.rcDrillMax); got != 3 {
+		t.Errorf("count 3 = %d, want 3", got)
+	}
+
+	t.Setenv("RC_DRILL_MAX", "")
+	if got := NewStore(nil).rcDrillMax; got != defaultRCDrillMax {
+		t.Errorf("unset RC_DRILL_MAX = %d, want %d", got, defaultRCDrillMax)
+	}
+}
+
+// recordingExecer records the statements run through it.
+type recordingExecer struct {
+	execs []string
+	args  [][]interface{}
+}
+
+func (r *recordingExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	r.execs = append(r.execs, query)
+	r.args = append(r.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (r *recordingExecer) QueryRow(query string, args ...interface{}) *sql.Row {
+	panic("unexpected QueryRow: " + query)
+}
+
+func TestQuestionContentConflict_KeyedByPassage(t *testing.T) {
+	migration, err := os.ReadFile("../database/migrations/029_question_content_unique.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The conflict target must match the index for ON CONFLICT to use it,
+	// and the backfill must retire duplicates under the same key
+	if !strings.Contains(string(migration), "ON questions "+questionContentKey) {
+		t.Errorf("idx_questions_content_unique isn't on %s", questionContentKey)
+	}
+	partition := "PARTITION BY " + strings.Trim(questionContentKey, "()")
+	if !strings.Contains(string(migration), partition) {
+		t.Errorf("duplicate backfill doesn't use %q", partition)
+	}
+	if !strings.Contains(questionContentConflict, questionContentKey) {
+		t.Errorf("questionContentConflict = %q, want the %s target", questionContentConflict, questionContentKey)
+	}
+	// RC questions have an empty stimulus and share stock stems
+	if !strings.Contains(questionContentKey, "passage_id") {
+		t.Errorf("content key %s would merge RC questions across passages", questionContentKey)
+	}
+
+	// No row from the guarded insert is a skip; real failures still fail
+	if inserted, err := insertedQuestion(sql.ErrNoRows); inserted || err != nil {
+		t.Errorf("conflict: inserted=%v err=%v, want a skip", inserted, err)
+	}
+	if inserted, err := insertedQuestion(nil); !inserted || err != nil {
+		t.Errorf("insert: inserted=%v err=%v, want saved", inserted, err)
+	}
+	if _, err := insertedQuestion(sql.ErrConnDone); err == nil {
+		t.Error("a failed insert should be an error, not a skip")
+	}
+}
+
+func TestDropUnusedPassage_OnlyWhenEveryQuestionSkipped(t *testing.T) {
+	pid := int64(7)
+
+	rec := &recordingExecer{}
+	if err := dropUnusedPassage(rec, &pid, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.execs) != 1 || !strings.Contains(rec.execs[0], "DELETE FROM rc_passages") || rec.args[0][0] != pid {
+		t.Errorf("all questions skipped: ran %v %v, want the passage deleted", rec.execs, rec.args)
+	}
+
+	for _, c := range []struct {
+		passageID *int64
+		saved     int
+	}{{&pid, 2}, {nil, 0}} {
+		rec := &recordingExecer{}
+		if err := dropUnusedPassage(rec, c.passageID, c.saved); err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.execs) != 0 {
+			t.Errorf("passage %v with %d saved: ran %v, want nothing", c.passageID, c.saved, rec.execs)
+		}
+	}
+}
+
+func TestImprovementStat_ReportsDirection(t *testing.T) {
+	// 8/10 this week against 5/10 the week before
+	up := improvementStat(10, 8, 10, 5)
+	if up == nil || math.Abs(up.Change-0.3) > 1e-9 {
+		t.Errorf("improving user = %+v, want change +0.3", up)
+	}
+
+	down := improvementStat(10, 4, 20, 15)
+	if down == nil || math.Abs(down.Change-(-0.35)) > 1e-9 {
+		t.Errorf("slipping user = %+v, want change -0.35", down)
+	}
+	if down.RecentAccuracy != 0.4 || down.PriorAccuracy != 0.75 || down.WindowDays != improvementWindowDays {
+		t.Errorf("slipping user = %+v, want accuracies 0.4 and 0.75", down)
+	}
+
+	// Too little data in either window says nothing
+	if got := improvementStat(improvementMinAnswered-1, 4, 10, 5); got != nil {
+		t.Errorf("sparse recent window = %+v, want nil", got)
+	}
+	if got := improvementStat(10, 8, 0, 0); got != nil {
+		t.Errorf("no prior window = %+v, want nil", got)
+	}
+}
+
+// respondersDB is a database/sql driver over a user_question_history table
+// of (user_id, question_id) rows. It answers the count subquery the way
+// Postgres would, counting each user once only under COUNT(DISTINCT ...).
+type respondersDB struct {
+	history [][2]int64
+}
+
+type respondersConn struct{ db *respondersDB }
+
+type respondersStmt struct {
+	db    *respondersDB
+	query string
+}
+
+type respondersRows struct{ counts []int64 }
+
+func (d *respondersDB) Open(string) (driver.Conn, error) { return respondersConn{d}, nil }
+
+func (c respondersConn) Prepare(query string) (driver.Stmt, error) {
+	return &respondersStmt{db: c.db, query: query}, nil
+}
+func (c respondersConn) Close() error              { return nil }
+func (c respondersConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("no transactions") }
+
+func (s *respondersStmt) Close() error  { return nil }
+func (s *respondersStmt) NumInput() int { return -1 }
+func (s *respondersStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("unexpected exec: %s", s.query)
+}
+
+func (s *respondersStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.Contains(s.query, "FROM user_question_history h WHERE h.question_id = q.id") {
+		return nil, fmt.Errorf("unexpected query: %s", s.query)
+	}
+	questionID := args[0].(int64)
+	distinct := strings.Contains(s.query, "COUNT(DISTINCT h.user_id)")
+	seen := map[int64]bool{}
+	var n int64
+	for _, row := range s.db.history {
+		if row[1] != questionID || (distinct && seen[row[0]]) {
+			continue
+		}
+		seen[row[0]] = true
+		n++
+	}
+	return &respondersRows{counts: []int64{n}}, nil
+}
+
+func (r *respondersRows) Columns() []string { return []string{"count"} }
+func (r *respondersRows) Close() error      { return nil }
+func (r *respondersRows) Next(dest []driver.Value) error {
+	if len(r.counts) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.counts[0]
+	r.counts = r.counts[1:]
+	return nil
+}
+
+func TestGetDistinctResponders_IgnoresRepeatAttempts(t *testing.T) {
+	fake := &respondersDB{history: [][2]int64{
+		{1, 5}, {1, 5}, {1, 5}, // user 1 answered question 5 three times
+		{2, 5},
+		{3, 6}, // another question
+	}}
+	sql.Register("distinct-responders-fake", fake)
+	db, err := sql.Open("distinct-responders-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	n, err := NewStore(db).GetDistinctResponders(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("distinct responders = %d, want 2 (users 1 and 2, each once)", n)
+	}
+}